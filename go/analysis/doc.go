@@ -190,6 +190,21 @@ diagnostics belong in different groups, or could be tagged differently
 before being shown to the end user. Analyzers should document their severity
 level to help downstream tools surface diagnostics properly.
 
+Users suppress the diagnostics of an Analyzer with directives in comments,
+which the drivers in this repository (singlechecker, multichecker,
+unitchecker) and gopls apply after the analysis:
+
+	//lint:ignore printf the format is checked at run time
+	fmt.Printf(format, args...)
+
+A //lint:ignore directive names a comma-separated list of Analyzers and
+gives a reason; it applies to the line that follows its comment, or to its
+own line if it follows code. A //lint:file-ignore directive with the same
+syntax applies to its whole file. The drivers report malformed directives,
+and directives that suppress no diagnostic of an Analyzer that ran, as
+diagnostics of the "lint" pseudo-analyzer. With the -require-ignore-reason
+flag, they also report directives without a reason.
+
 Most Analyzers inspect typed Go syntax trees, but a few, such as asmdecl
 and buildtag, inspect the raw text of Go source files or even non-Go
 files such as assembly. To report a diagnostic against a line of a
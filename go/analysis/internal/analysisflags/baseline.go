@@ -0,0 +1,228 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysisflags
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"os"
+	"sort"
+	"strings"
+)
+
+// A BaselineEntry is the number of diagnostics with the same message that an
+// analyzer reports in a declaration of a package. Diagnostics are matched
+// without their positions, so that a baseline survives the edits that move
+// them.
+type BaselineEntry struct {
+	Analyzer string `json:"analyzer"`
+	Package  string `json:"package"`
+	Decl     string `json:"decl,omitempty"` // see EnclosingDecl
+	Message  string `json:"message"`
+	Count    int    `json:"count"`
+
+	// File is the base name of the file of the diagnostics of an entry
+	// added by AddIn. ReadBaseline counts once the identical entries of a
+	// file that several compilation units share, such as a package and its
+	// test variant, which each append them.
+	File string `json:"file,omitempty"`
+}
+
+type baselineKey struct {
+	analyzer, pkg, decl, message string
+}
+
+// A Baseline is a set of diagnostics, as written by the -baseline flag and
+// read with the -new-only flag.
+type Baseline struct {
+	counts map[baselineKey]int
+	files  map[baselineKey]map[string]int // counts per file of the diagnostics added by AddIn
+}
+
+// NewBaseline returns an empty baseline.
+func NewBaseline() *Baseline {
+	return &Baseline{
+		counts: make(map[baselineKey]int),
+		files:  make(map[baselineKey]map[string]int),
+	}
+}
+
+// ReadBaseline reads the baseline file filename, which holds a JSON
+// BaselineEntry per line. The counts of identical entries are summed, except
+// that the identical entries of a file count once.
+func ReadBaseline(filename string) (*Baseline, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	b := NewBaseline()
+	type fileKey struct {
+		baselineKey
+		file string
+	}
+	fileCounts := make(map[fileKey]int)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<20)
+	for line := 1; scanner.Scan(); line++ {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		var e BaselineEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid baseline entry: %v", filename, line, err)
+		}
+		k := baselineKey{e.Analyzer, e.Package, e.Decl, e.Message}
+		if e.File == "" {
+			b.counts[k] += e.Count
+		} else if fk := (fileKey{k, e.File}); e.Count > fileCounts[fk] {
+			fileCounts[fk] = e.Count
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading baseline %s: %v", filename, err)
+	}
+	for fk, count := range fileCounts {
+		b.counts[fk.baselineKey] += count
+	}
+	return b, nil
+}
+
+// Add adds a diagnostic to b.
+func (b *Baseline) Add(analyzer, pkgPath, decl, message string) {
+	b.counts[baselineKey{analyzer, pkgPath, decl, message}]++
+}
+
+// AddIn adds a diagnostic in the file with base name file to b. Its entry
+// records the file, so that ReadBaseline counts it once if several
+// compilation units add the diagnostics of the file.
+func (b *Baseline) AddIn(analyzer, pkgPath, decl, message, file string) {
+	k := baselineKey{analyzer, pkgPath, decl, message}
+	b.counts[k]++
+	if b.files[k] == nil {
+		b.files[k] = make(map[string]int)
+	}
+	b.files[k][file]++
+}
+
+// Remove reports whether b holds a diagnostic, and removes it from b, so
+// that the diagnostics in excess of those of the baseline are new.
+func (b *Baseline) Remove(analyzer, pkgPath, decl, message string) bool {
+	k := baselineKey{analyzer, pkgPath, decl, message}
+	if b.counts[k] == 0 {
+		return false
+	}
+	b.counts[k]--
+	return true
+}
+
+// Entries returns the entries of b, in order. The diagnostics added by AddIn
+// have an entry per file.
+func (b *Baseline) Entries() []BaselineEntry {
+	var entries []BaselineEntry
+	for k, count := range b.counts {
+		if files := b.files[k]; len(files) > 0 {
+			for file, count := range files {
+				entries = append(entries, BaselineEntry{k.analyzer, k.pkg, k.decl, k.message, count, file})
+			}
+		} else if count > 0 {
+			entries = append(entries, BaselineEntry{k.analyzer, k.pkg, k.decl, k.message, count, ""})
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		ei, ej := entries[i], entries[j]
+		if ei.Package != ej.Package {
+			return ei.Package < ej.Package
+		}
+		if ei.Analyzer != ej.Analyzer {
+			return ei.Analyzer < ej.Analyzer
+		}
+		if ei.Decl != ej.Decl {
+			return ei.Decl < ej.Decl
+		}
+		if ei.Message != ej.Message {
+			return ei.Message < ej.Message
+		}
+		return ei.File < ej.File
+	})
+	return entries
+}
+
+// Write writes the entries of b to the file filename, replacing its
+// contents, or appending to them if append is set. Appending entries is a
+// single write, so that the processes that analyze the packages of a build
+// may append to the same file.
+func (b *Baseline) Write(filename string, append bool) error {
+	var buf strings.Builder
+	for _, e := range b.Entries() {
+		data, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if append {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	}
+	f, err := os.OpenFile(filename, flags, 0666)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(buf.String()); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// EnclosingDecl returns the name of the top-level declaration of files that
+// encloses pos: "F" for a function, "(T).M" or "(*T).M" for a method, and
+// the names of the specification of a type, variable or constant
+// declaration, separated by commas. It returns "" outside declarations.
+func EnclosingDecl(fset *token.FileSet, files []*ast.File, pos token.Pos) string {
+	for _, f := range files {
+		tok := fset.File(f.Pos())
+		if tok == nil || int(pos) < tok.Base() || int(pos) > tok.Base()+tok.Size() {
+			continue
+		}
+		for _, decl := range f.Decls {
+			if pos < decl.Pos() || pos >= decl.End() {
+				continue
+			}
+			switch decl := decl.(type) {
+			case *ast.FuncDecl:
+				if decl.Recv != nil && len(decl.Recv.List) > 0 {
+					return fmt.Sprintf("(%s).%s", types.ExprString(decl.Recv.List[0].Type), decl.Name.Name)
+				}
+				return decl.Name.Name
+			case *ast.GenDecl:
+				for _, spec := range decl.Specs {
+					if pos < spec.Pos() || pos >= spec.End() {
+						continue
+					}
+					switch spec := spec.(type) {
+					case *ast.TypeSpec:
+						return spec.Name.Name
+					case *ast.ValueSpec:
+						var names []string
+						for _, name := range spec.Names {
+							names = append(names, name.Name)
+						}
+						return strings.Join(names, ",")
+					}
+				}
+			}
+			return ""
+		}
+		return ""
+	}
+	return ""
+}
@@ -0,0 +1,144 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysisflags_test
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/analysis/internal/analysisflags"
+)
+
+func TestEnclosingDecl(t *testing.T) {
+	const src = `package p
+
+import "fmt"
+
+type T struct{ x int }
+
+var a, b = 1, 2
+
+const (
+	c = iota
+	d
+)
+
+func F() { fmt.Println() }
+
+func (T) M() {}
+
+func (t *T) N() {}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		at   string
+		want string
+	}{
+		{"fmt", ""},
+		{"x int", "T"},
+		{"1, 2", "a,b"},
+		{"d\n", "d"},
+		{"Println", "F"},
+		{"M()", "(T).M"},
+		{"N()", "(*T).N"},
+	} {
+		pos := f.Pos() + token.Pos(strings.Index(src, test.at))
+		if got := analysisflags.EnclosingDecl(fset, []*ast.File{f}, pos); got != test.want {
+			t.Errorf("EnclosingDecl(%q) = %q, want %q", test.at, got, test.want)
+		}
+	}
+}
+
+func TestBaseline(t *testing.T) {
+	dir, err := ioutil.TempDir("", "baseline")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "baseline.json")
+
+	b := analysisflags.NewBaseline()
+	b.Add("printf", "example.com/p", "F", "bad format")
+	b.Add("printf", "example.com/p", "F", "bad format")
+	b.Add("shadow", "example.com/p", "", "shadowed")
+	if err := b.Write(filename, false); err != nil {
+		t.Fatal(err)
+	}
+	// Appending adds to the counts of the entries.
+	b = analysisflags.NewBaseline()
+	b.Add("shadow", "example.com/p", "", "shadowed")
+	if err := b.Write(filename, true); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err = analysisflags.ReadBaseline(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []analysisflags.BaselineEntry{
+		{Analyzer: "printf", Package: "example.com/p", Decl: "F", Message: "bad format", Count: 2},
+		{Analyzer: "shadow", Package: "example.com/p", Message: "shadowed", Count: 2},
+	}
+	if got := b.Entries(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Entries() = %+v, want %+v", got, want)
+	}
+
+	for i, test := range []struct {
+		decl string
+		want bool
+	}{
+		{"G", false},
+		{"F", true},
+		{"F", true},
+		{"F", false}, // in excess of the baseline
+	} {
+		if got := b.Remove("printf", "example.com/p", test.decl, "bad format"); got != test.want {
+			t.Errorf("%d: Remove(%s) = %t, want %t", i, test.decl, got, test.want)
+		}
+	}
+}
+
+func TestBaselineAppendFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "baseline")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "baseline.json")
+
+	// A package and its test variant both append the diagnostics of p.go.
+	for _, files := range [][]string{{"p.go"}, {"p.go", "p_test.go"}} {
+		b := analysisflags.NewBaseline()
+		for _, name := range files {
+			b.AddIn("printf", "example.com/p", "F", "bad format", name)
+			b.AddIn("printf", "example.com/p", "F", "bad format", name)
+		}
+		if err := b.Write(filename, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	b, err := analysisflags.ReadBaseline(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []analysisflags.BaselineEntry{
+		{Analyzer: "printf", Package: "example.com/p", Decl: "F", Message: "bad format", Count: 4},
+	}
+	if got := b.Entries(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Entries() = %+v, want %+v", got, want)
+	}
+}
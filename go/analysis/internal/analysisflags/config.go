@@ -0,0 +1,242 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysisflags
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// A Config is the configuration of the analyzers of a multichecker, read
+// from the JSON file named by its -config flag. Its format is documented in
+// package multichecker.
+type Config struct {
+	// Default reports whether the analyzers that the configuration does not
+	// enable or disable are enabled. If nil, they are.
+	Default *bool `json:"default,omitempty"`
+
+	// Analyzers maps the name of an analyzer to its configuration.
+	Analyzers map[string]*AnalyzerConfig `json:"analyzers,omitempty"`
+}
+
+// An AnalyzerConfig is the configuration of an analyzer.
+type AnalyzerConfig struct {
+	// Enabled reports whether the analyzer is enabled. If nil, the default
+	// of the configuration applies.
+	Enabled *bool `json:"enabled,omitempty"`
+
+	// Flags maps the name of a flag of the analyzer, without the prefix of
+	// its name, to its value: a string, number or boolean.
+	Flags map[string]interface{} `json:"flags,omitempty"`
+
+	// Packages and Exclude are patterns of package paths. If Packages is
+	// not empty, the diagnostics of the analyzer are reported only for the
+	// packages that match one of its patterns, and never for the packages
+	// that match one of the patterns of Exclude. A pattern is matched as by
+	// path.Match, except that a trailing "/..." matches any path below it.
+	Packages []string `json:"packages,omitempty"`
+	Exclude  []string `json:"exclude,omitempty"`
+}
+
+// config is the configuration read by Parse, if any.
+var config *Config
+
+// readConfig reads the configuration file filename, and validates it for
+// analyzers.
+func readConfig(filename string, analyzers []*analysis.Analyzer) (*Config, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	cfg := new(Config)
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("cannot decode configuration file %s: %v", filename, err)
+	}
+	if errs := cfg.validate(analyzers); len(errs) > 0 {
+		return nil, fmt.Errorf("invalid configuration file %s:\n\t%s", filename, strings.Join(errs, "\n\t"))
+	}
+	return cfg, nil
+}
+
+// validate returns the errors of cfg for analyzers, in a deterministic order.
+func (cfg *Config) validate(analyzers []*analysis.Analyzer) []string {
+	byName := make(map[string]*analysis.Analyzer)
+	for _, a := range analyzers {
+		byName[a.Name] = a
+	}
+	var names []string
+	for name := range cfg.Analyzers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var errs []string
+	for _, name := range names {
+		a := byName[name]
+		if a == nil {
+			errs = append(errs, fmt.Sprintf("unknown analyzer %q", name))
+			continue
+		}
+		ac := cfg.Analyzers[name]
+		if ac == nil {
+			continue
+		}
+		var flags []string
+		for name := range ac.Flags {
+			flags = append(flags, name)
+		}
+		sort.Strings(flags)
+		for _, f := range flags {
+			if a.Flags.Lookup(f) == nil {
+				errs = append(errs, fmt.Sprintf("analyzer %s has no flag %q", a.Name, f))
+				continue
+			}
+			if _, err := flagValue(ac.Flags[f]); err != nil {
+				errs = append(errs, fmt.Sprintf("flag %s of analyzer %s: %v", f, a.Name, err))
+			}
+		}
+		for _, pattern := range append(ac.Packages, ac.Exclude...) {
+			if _, err := path.Match(strings.TrimSuffix(pattern, "/..."), ""); err != nil {
+				errs = append(errs, fmt.Sprintf("analyzer %s: invalid package pattern %q", a.Name, pattern))
+			}
+		}
+	}
+	return errs
+}
+
+// flagValue returns the value of a flag given by a JSON value.
+func flagValue(v interface{}) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	}
+	return "", fmt.Errorf("value must be a string, number or boolean")
+}
+
+// enabled reports whether cfg enables analyzer a.
+func (cfg *Config) enabled(a *analysis.Analyzer) bool {
+	if ac := cfg.Analyzers[a.Name]; ac != nil && ac.Enabled != nil {
+		return *ac.Enabled
+	}
+	return cfg.Default == nil || *cfg.Default
+}
+
+// setFlags sets the flags of analyzers to their values in cfg, except the
+// flags named in set, which were set on the command line.
+func (cfg *Config) setFlags(analyzers []*analysis.Analyzer, set map[string]bool) error {
+	for _, a := range analyzers {
+		ac := cfg.Analyzers[a.Name]
+		if ac == nil {
+			continue
+		}
+		for name, v := range ac.Flags {
+			if set[a.Name+"."+name] {
+				continue
+			}
+			value, err := flagValue(v)
+			if err == nil {
+				err = a.Flags.Set(name, value)
+			}
+			if err != nil {
+				return fmt.Errorf("flag %s of analyzer %s: %v", name, a.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// EnabledFor reports whether the configuration enables the diagnostics of
+// analyzer a for the package of path pkgPath. Without a configuration, it
+// does.
+func EnabledFor(a *analysis.Analyzer, pkgPath string) bool {
+	if config == nil {
+		return true
+	}
+	ac := config.Analyzers[a.Name]
+	if ac == nil {
+		return true
+	}
+	if len(ac.Packages) > 0 && !matchAny(ac.Packages, pkgPath) {
+		return false
+	}
+	return !matchAny(ac.Exclude, pkgPath)
+}
+
+func matchAny(patterns []string, pkgPath string) bool {
+	for _, pattern := range patterns {
+		if matchPackage(pattern, pkgPath) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPackage reports whether pkgPath matches pattern, as documented for
+// AnalyzerConfig.
+func matchPackage(pattern, pkgPath string) bool {
+	if pattern == "..." {
+		return true
+	}
+	if prefix := strings.TrimSuffix(pattern, "/..."); prefix != pattern {
+		// Match the prefix against pkgPath and each of its parents.
+		for p := pkgPath; ; {
+			if ok, _ := path.Match(prefix, p); ok {
+				return true
+			}
+			i := strings.LastIndexByte(p, '/')
+			if i < 0 {
+				return false
+			}
+			p = p[:i]
+		}
+	}
+	ok, _ := path.Match(pattern, pkgPath)
+	return ok
+}
+
+// printConfig prints the effective configuration of analyzers, of which
+// those in enabled are enabled.
+func printConfig(analyzers []*analysis.Analyzer, enabled []*analysis.Analyzer) error {
+	on := make(map[*analysis.Analyzer]bool)
+	for _, a := range enabled {
+		on[a] = true
+	}
+	effective := &Config{Analyzers: make(map[string]*AnalyzerConfig)}
+	for _, a := range analyzers {
+		enabled := on[a]
+		ac := &AnalyzerConfig{Enabled: &enabled}
+		a.Flags.VisitAll(func(f *flag.Flag) {
+			if ac.Flags == nil {
+				ac.Flags = make(map[string]interface{})
+			}
+			ac.Flags[f.Name] = f.Value.String()
+		})
+		if config != nil {
+			if c := config.Analyzers[a.Name]; c != nil {
+				ac.Packages = c.Packages
+				ac.Exclude = c.Exclude
+			}
+		}
+		effective.Analyzers[a.Name] = ac
+	}
+	data, err := json.MarshalIndent(effective, "", "\t")
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s\n", data)
+	return nil
+}
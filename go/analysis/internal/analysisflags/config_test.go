@@ -0,0 +1,132 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysisflags_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/internal/analysisflags"
+)
+
+func configMain() {
+	a1 := &analysis.Analyzer{Name: "a1", Doc: "a1"}
+	name := a1.Flags.String("name", "none", "a name")
+	a2 := &analysis.Analyzer{Name: "a2", Doc: "a2"}
+	a3 := &analysis.Analyzer{Name: "a3", Doc: "a3"}
+	analyzers := analysisflags.Parse([]*analysis.Analyzer{a1, a2, a3}, true)
+	fmt.Println(analyzers, *name, analysisflags.EnabledFor(a2, "example.com/p"), analysisflags.EnabledFor(a2, "example.com/gen/p"))
+	os.Exit(0)
+}
+
+// This test fork/execs the configMain function above.
+func TestConfig(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skipf("skipping fork/exec test on this platform")
+	}
+
+	progname := os.Args[0]
+
+	if os.Getenv("ANALYSISFLAGS_CONFIG_CHILD") == "1" {
+		// child process
+		os.Args = strings.Fields(progname + " " + os.Getenv("FLAGS"))
+		configMain()
+		panic("unreachable")
+	}
+
+	dir, err := ioutil.TempDir("", "analysisflags")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	write := func(name, content string) string {
+		filename := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(filename, []byte(content), 0666); err != nil {
+			t.Fatal(err)
+		}
+		return filename
+	}
+	config := write("config.json", `{
+	"analyzers": {
+		"a1": {"flags": {"name": "configured"}},
+		"a2": {"exclude": ["example.com/gen/..."]},
+		"a3": {"enabled": false}
+	}
+}`)
+	onlyA2 := write("only.json", `{"default": false, "analyzers": {"a2": {"enabled": true}}}`)
+	invalid := write("invalid.json", `{
+	"analyzers": {
+		"a1": {"flags": {"nosuchflag": 1, "name": {}}},
+		"nosuchanalyzer": {},
+		"a2": {"packages": ["[a-"]}
+	}
+}`)
+
+	for _, test := range []struct {
+		flags string
+		want  string
+	}{
+		{"", "[a1 a2 a3] none true true"},
+		{"-config=" + config, "[a1 a2] configured true false"},
+		{"-config=" + config + " -a1.name=flag", "[a1 a2] flag true false"},
+		{"-config=" + config + " -a2=0", "[a1] configured true false"},
+		{"-config=" + config + " -a3", "[a3] configured true false"},
+		{"-config=" + onlyA2, "[a2] none true true"},
+		{"-config=" + onlyA2 + " -print-config", `{
+	"analyzers": {
+		"a1": {
+			"enabled": false,
+			"flags": {
+				"name": "none"
+			}
+		},
+		"a2": {
+			"enabled": true
+		},
+		"a3": {
+			"enabled": false
+		}
+	}
+}`},
+	} {
+		cmd := exec.Command(progname, "-test.run=TestConfig")
+		cmd.Env = append(os.Environ(), "ANALYSISFLAGS_CONFIG_CHILD=1", "FLAGS="+test.flags)
+
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("exec failed: %v; output=<<%s>>", err, output)
+		}
+
+		got := strings.TrimSpace(string(output))
+		if got != test.want {
+			t.Errorf("%s: got %s, want %s", test.flags, got, test.want)
+		}
+	}
+
+	// Invalid configurations are reported.
+	cmd := exec.Command(progname, "-test.run=TestConfig")
+	cmd.Env = append(os.Environ(), "ANALYSISFLAGS_CONFIG_CHILD=1", "FLAGS=-config="+invalid)
+	output, err := cmd.CombinedOutput()
+	if err == nil {
+		t.Fatalf("exec succeeded with an invalid configuration; output=<<%s>>", output)
+	}
+	for _, want := range []string{
+		`analyzer a1 has no flag "nosuchflag"`,
+		`flag name of analyzer a1: value must be a string, number or boolean`,
+		`analyzer a2: invalid package pattern "[a-"`,
+		`unknown analyzer "nosuchanalyzer"`,
+	} {
+		if !strings.Contains(string(output), want) {
+			t.Errorf("invalid configuration: got <<%s>>, want %q", output, want)
+		}
+	}
+}
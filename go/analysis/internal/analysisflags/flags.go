@@ -21,12 +21,16 @@ import (
 	"strings"
 
 	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/internal/lintignore"
 )
 
 // flags common to all {single,multi,unit}checkers.
 var (
-	JSON    = false // -json
-	Context = -1    // -c=N: if N>0, display offending line plus N lines of context
+	JSON                = false // -json
+	Context             = -1    // -c=N: if N>0, display offending line plus N lines of context
+	RequireIgnoreReason = false // -require-ignore-reason
+	BaselineFile        = ""    // -baseline=file: write diagnostics to file, or read them with -new-only
+	NewOnly             = false // -new-only: report only diagnostics not in the -baseline file
 )
 
 // Parse creates a flag for each of the analyzer's flags,
@@ -72,9 +76,20 @@ func Parse(analyzers []*analysis.Analyzer, multi bool) []*analysis.Analyzer {
 	printflags := flag.Bool("flags", false, "print analyzer flags in JSON")
 	addVersionFlag()
 
+	// -config, -print-config: configure the analyzers from a file.
+	var configFile string
+	var printconfig bool
+	if multi {
+		flag.StringVar(&configFile, "config", "", "read the configuration of the analyzers from this JSON file")
+		flag.BoolVar(&printconfig, "print-config", false, "print the effective configuration of the analyzers in JSON and exit")
+	}
+
 	// flags common to all checkers
 	flag.BoolVar(&JSON, "json", JSON, "emit JSON output")
 	flag.IntVar(&Context, "c", Context, `display offending line with this many lines of context`)
+	flag.BoolVar(&RequireIgnoreReason, "require-ignore-reason", RequireIgnoreReason, "report //lint:ignore directives without a reason")
+	flag.StringVar(&BaselineFile, "baseline", BaselineFile, "write the diagnostics to this baseline `file`, or with -new-only, omit the diagnostics it holds")
+	flag.BoolVar(&NewOnly, "new-only", NewOnly, "report only the diagnostics not in the -baseline file")
 
 	// Add shims for legacy vet flags to enable existing
 	// scripts that run vet to continue to work.
@@ -97,10 +112,35 @@ func Parse(analyzers []*analysis.Analyzer, multi bool) []*analysis.Analyzer {
 		os.Exit(0)
 	}
 
+	if NewOnly && BaselineFile == "" {
+		log.Fatal("-new-only requires a -baseline file")
+	}
+
 	everything := expand(analyzers)
 
+	all := analyzers
+	if configFile != "" {
+		cfg, err := readConfig(configFile, analyzers)
+		if err != nil {
+			log.Fatal(err)
+		}
+		// The flags on the command line take precedence.
+		set := make(map[string]bool)
+		flag.Visit(func(f *flag.Flag) {
+			set[f.Name] = true
+			if name, ok := vetLegacyFlags[f.Name]; ok {
+				set[name] = true
+			}
+		})
+		if err := cfg.setFlags(analyzers, set); err != nil {
+			log.Fatal(err)
+		}
+		config = cfg
+	}
+
 	// If any -NAME flag is true,  run only those analyzers. Otherwise,
-	// if any -NAME flag is false, run all but those analyzers.
+	// if any -NAME flag is false, run all but those analyzers, and those
+	// the configuration disables.
 	if multi {
 		var hasTrue, hasFalse bool
 		for _, ts := range enabled {
@@ -120,9 +160,9 @@ func Parse(analyzers []*analysis.Analyzer, multi bool) []*analysis.Analyzer {
 				}
 			}
 			analyzers = keep
-		} else if hasFalse {
+		} else if hasFalse || config != nil {
 			for _, a := range analyzers {
-				if *enabled[a] != setFalse {
+				if *enabled[a] != setFalse && (config == nil || config.enabled(a)) {
 					keep = append(keep, a)
 				}
 			}
@@ -130,6 +170,13 @@ func Parse(analyzers []*analysis.Analyzer, multi bool) []*analysis.Analyzer {
 		}
 	}
 
+	if printconfig {
+		if err := printConfig(all, analyzers); err != nil {
+			log.Fatal(err)
+		}
+		os.Exit(0)
+	}
+
 	// Register fact types of skipped analyzers
 	// in case we encounter them in imported files.
 	kept := expand(analyzers)
@@ -171,7 +218,7 @@ func printFlags() {
 		// flags or fix as these have no effect on unitchecker
 		// (as invoked by 'go vet').
 		switch f.Name {
-		case "debug", "cpuprofile", "memprofile", "trace", "fix":
+		case "debug", "cpuprofile", "memprofile", "trace", "fix", "fix-dry-run", "cache":
 			return
 		}
 
@@ -315,6 +362,14 @@ var vetLegacyFlags = map[string]string{
 
 // ---- output helpers common to all drivers ----
 
+// Lint is the pseudo-analyzer under which drivers report the malformed and
+// unused //lint:ignore directives. It is never run.
+var Lint = &analysis.Analyzer{
+	Name: lintignore.Name,
+	Doc:  "report malformed and unused //lint:ignore directives",
+	Run:  func(*analysis.Pass) (interface{}, error) { return nil, nil },
+}
+
 // PrintPlain prints a diagnostic in plain text form,
 // with context specified by the -c flag.
 func PrintPlain(fset *token.FileSet, diag analysis.Diagnostic) {
@@ -0,0 +1,612 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package checker
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/internal/analysisflags"
+	"golang.org/x/tools/go/analysis/internal/unitcheckerinternal"
+	"golang.org/x/tools/go/analysis/unitchecker"
+	"golang.org/x/tools/go/packages"
+)
+
+// The -cache flag caches the facts of each package, and the diagnostics of
+// each initial package, in files named by a key that hashes the contents of
+// the files of the package and the keys of its imports, along with the
+// analyzers, their flags, and the executable that runs them. The packages
+// whose entries are all cached are neither parsed nor analyzed: the others
+// are analyzed as by unitchecker, from the export data and the cached facts
+// of their imports. The entries of packages that could not be analyzed, or
+// in which an analyzer failed, are not stored. The entries are never
+// removed: the cache directory may be removed at any time.
+//
+// Of the cached initial packages, only the files that hold //lint:
+// directives, or diagnostics that -baseline attributes to declarations, are
+// parsed.
+
+// A cache is a directory of cached facts and diagnostics.
+type cache struct {
+	dir  string
+	salt []byte // hash of the analyzers, their flags and the executable
+}
+
+// newCache returns the cache of analyzers in dir, which it creates if needed.
+func newCache(dir string, analyzers []*analysis.Analyzer) (*cache, error) {
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return nil, err
+	}
+	h := sha256.New()
+	fmt.Fprintf(h, "go/analysis checker cache v1 %s %s\n", runtime.Version(), build.Default.GOARCH)
+
+	// The executable holds the code of the analyzers.
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(exe)
+	if err != nil {
+		return nil, err
+	}
+	_, err = io.Copy(h, f)
+	f.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, a := range analyzers {
+		fmt.Fprintf(h, "analyzer %s\n", a.Name)
+	}
+	// The flags of required analyzers matter too.
+	var all []*analysis.Analyzer
+	seen := make(map[*analysis.Analyzer]bool)
+	var visit func(a *analysis.Analyzer)
+	visit = func(a *analysis.Analyzer) {
+		if !seen[a] {
+			seen[a] = true
+			all = append(all, a)
+			for _, req := range a.Requires {
+				visit(req)
+			}
+		}
+	}
+	for _, a := range analyzers {
+		visit(a)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Name < all[j].Name })
+	for _, a := range all {
+		a.Flags.VisitAll(func(f *flag.Flag) {
+			fmt.Fprintf(h, "flag %s.%s=%s\n", a.Name, f.Name, f.Value)
+		})
+	}
+	return &cache{dir: dir, salt: h.Sum(nil)}, nil
+}
+
+// keys returns the keys of pkgs and their dependencies.
+func (c *cache) keys(pkgs []*packages.Package) (map[*packages.Package]string, error) {
+	keys := make(map[*packages.Package]string)
+	var key func(pkg *packages.Package) (string, error)
+	key = func(pkg *packages.Package) (string, error) {
+		if k, ok := keys[pkg]; ok {
+			return k, nil
+		}
+		h := sha256.New()
+		h.Write(c.salt)
+		fmt.Fprintf(h, "package %s %s\n", pkg.ID, pkg.PkgPath)
+		for _, files := range []struct {
+			kind  string
+			names []string
+		}{
+			{"go", pkg.CompiledGoFiles},
+			{"other", pkg.OtherFiles},
+			{"ignored", pkg.IgnoredFiles},
+		} {
+			for _, name := range files.names {
+				data, err := ioutil.ReadFile(name)
+				if err != nil {
+					return "", err
+				}
+				fmt.Fprintf(h, "%s %s %x\n", files.kind, name, sha256.Sum256(data))
+			}
+		}
+		paths := make([]string, 0, len(pkg.Imports))
+		for path := range pkg.Imports {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+		for _, path := range paths {
+			k, err := key(pkg.Imports[path])
+			if err != nil {
+				return "", err
+			}
+			fmt.Fprintf(h, "import %s %s\n", path, k)
+		}
+		k := hex.EncodeToString(h.Sum(nil))
+		keys[pkg] = k
+		return k, nil
+	}
+	for _, pkg := range pkgs {
+		if _, err := key(pkg); err != nil {
+			return nil, err
+		}
+	}
+	return keys, nil
+}
+
+// The files of an entry.
+const (
+	factsExt       = ".vetx" // facts, as encoded by unitchecker
+	diagnosticsExt = ".json" // []cachedResult
+)
+
+func (c *cache) file(key, ext string) string {
+	return filepath.Join(c.dir, key[:2], key+ext)
+}
+
+func (c *cache) has(key, ext string) bool {
+	_, err := os.Stat(c.file(key, ext))
+	return err == nil
+}
+
+// A cachedResult holds the diagnostics of an analyzer for a package, with
+// positions that do not depend on a token.FileSet.
+type cachedResult struct {
+	Analyzer    string
+	Diagnostics []cachedDiagnostic
+	Err         string `json:",omitempty"` // never cached
+}
+
+type cachedDiagnostic struct {
+	Pos, End       cachedPos
+	Category       string `json:",omitempty"`
+	Message        string
+	SuggestedFixes []cachedFix     `json:",omitempty"`
+	Related        []cachedRelated `json:",omitempty"`
+}
+
+type cachedFix struct {
+	Message   string
+	TextEdits []cachedEdit
+}
+
+type cachedEdit struct {
+	Pos, End cachedPos
+	NewText  []byte
+}
+
+type cachedRelated struct {
+	Pos, End cachedPos
+	Message  string
+}
+
+// A cachedPos is a byte offset in a file. Its zero value is token.NoPos.
+type cachedPos struct {
+	File   string `json:",omitempty"`
+	Offset int    `json:",omitempty"`
+}
+
+// An entry is the analysis of a package that is not cached.
+type entry struct {
+	once    sync.Once
+	results []cachedResult
+	facts   string              // file of the facts of the package, if any
+	failed  map[string][]string // failed actions, by the analyzers whose facts they lack
+	err     error
+}
+
+// analyzeCached applies analyzers to the packages specified by patterns,
+// as analyze does, but caches the facts and diagnostics of the packages in
+// CacheDir. It returns the root actions.
+func analyzeCached(patterns []string, analyzers []*analysis.Analyzer) ([]*action, error) {
+	c, err := newCache(CacheDir, analyzers)
+	if err != nil {
+		return nil, fmt.Errorf("opening the cache: %v", err)
+	}
+	mode := packages.NeedName | packages.NeedFiles | packages.NeedCompiledGoFiles | packages.NeedImports | packages.NeedDeps
+	initial, err := load(patterns, mode, nil)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := c.keys(initial)
+	if err != nil {
+		return nil, err
+	}
+
+	// Find the packages whose diagnostics or needed facts are missing.
+	isroot := make(map[*packages.Package]bool)
+	for _, pkg := range initial {
+		isroot[pkg] = true
+	}
+	facts := needFacts(analyzers)
+	entries := make(map[*packages.Package]*entry)
+	var missing []*packages.Package
+	packages.Visit(initial, nil, func(pkg *packages.Package) {
+		k := keys[pkg]
+		if isroot[pkg] && !c.has(k, diagnosticsExt) || facts && !c.has(k, factsExt) {
+			entries[pkg] = new(entry)
+			missing = append(missing, pkg)
+		}
+	})
+	if dbg('v') {
+		log.Printf("cache: analyzing %d packages", len(missing))
+	}
+
+	if len(missing) > 0 {
+		// Analyze the missing packages from their syntax and the
+		// export data of their imports.
+		exported, err := load(patterns, mode|packages.NeedExportsFile, nil)
+		if err != nil {
+			return nil, err
+		}
+		byID := make(map[string]*packages.Package)
+		packages.Visit(exported, nil, func(pkg *packages.Package) {
+			byID[pkg.ID] = pkg
+		})
+
+		// The facts of failed analyses are not cached, but their files
+		// are used by the analyses of the importers in this run.
+		defer func() {
+			for pkg, e := range entries {
+				if e.facts != "" && e.facts != c.file(keys[pkg], factsExt) {
+					os.Remove(e.facts)
+				}
+			}
+		}()
+		usesFacts := make(map[string]bool)
+		for _, a := range analyzers {
+			usesFacts[a.Name] = needFacts([]*analysis.Analyzer{a})
+		}
+
+		// Each missing package is analyzed by its own goroutine, after its
+		// imports, and at most GOMAXPROCS at a time.
+		sema := make(chan struct{}, runtime.GOMAXPROCS(0))
+		var exec func(pkg *packages.Package)
+		exec = func(pkg *packages.Package) {
+			e := entries[pkg]
+			if e == nil {
+				return // cached
+			}
+			e.once.Do(func() {
+				// An analyzer that failed in an import lacks its facts, so it
+				// fails in pkg too.
+				failed := make(map[string][]string)
+				facts := make(map[string]string)
+				for _, imp := range pkg.Imports {
+					exec(imp)
+					ie := entries[imp]
+					if ie == nil {
+						if c.has(keys[imp], factsExt) {
+							facts[imp.PkgPath] = c.file(keys[imp], factsExt)
+						}
+						continue
+					}
+					if ie.err != nil {
+						e.err = ie.err
+						return
+					}
+					if ie.facts != "" {
+						facts[imp.PkgPath] = ie.facts
+					}
+					for name, acts := range ie.failed {
+						failed[name] = append(failed[name], acts...)
+					}
+				}
+				for name, acts := range failed {
+					sort.Strings(acts)
+					uniq := acts[:0]
+					for i, act := range acts {
+						if i == 0 || act != acts[i-1] {
+							uniq = append(uniq, act)
+						}
+					}
+					failed[name] = uniq
+				}
+				sema <- struct{}{}
+				e.results, e.facts, e.err = c.analyze(pkg, byID[pkg.ID], keys[pkg], isroot[pkg], analyzers, facts, failed)
+				<-sema
+				e.failed = make(map[string][]string)
+				for _, r := range e.results {
+					if r.Err == "" || !usesFacts[r.Analyzer] {
+						continue
+					}
+					if acts := failed[r.Analyzer]; len(acts) > 0 {
+						e.failed[r.Analyzer] = acts
+					} else {
+						e.failed[r.Analyzer] = []string{r.Analyzer + "@" + pkg.PkgPath}
+					}
+				}
+			})
+		}
+		var wg sync.WaitGroup
+		for _, pkg := range missing {
+			wg.Add(1)
+			go func(pkg *packages.Package) {
+				exec(pkg)
+				wg.Done()
+			}(pkg)
+		}
+		wg.Wait()
+		for _, pkg := range missing {
+			if err := entries[pkg].err; err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Read the diagnostics of the initial packages.
+	results := make(map[*packages.Package]map[string][]cachedDiagnostic)
+	errs := make(map[*packages.Package]map[string]string)
+	diagnosed := make(map[string]bool) // files with diagnostics
+	for _, pkg := range initial {
+		var res []cachedResult
+		if e := entries[pkg]; e != nil {
+			res = e.results
+		} else {
+			data, err := ioutil.ReadFile(c.file(keys[pkg], diagnosticsExt))
+			if err != nil {
+				return nil, err
+			}
+			if err := json.Unmarshal(data, &res); err != nil {
+				return nil, fmt.Errorf("reading the cached diagnostics of %s: %v", pkg.ID, err)
+			}
+		}
+		results[pkg] = make(map[string][]cachedDiagnostic)
+		errs[pkg] = make(map[string]string)
+		for _, r := range res {
+			results[pkg][r.Analyzer] = r.Diagnostics
+			errs[pkg][r.Analyzer] = r.Err
+			for _, diag := range r.Diagnostics {
+				diagnosed[diag.Pos.File] = true
+			}
+		}
+	}
+
+	// Build the root actions, whose packages share a token.FileSet, and
+	// hold the syntax of the files needed by ignore and baseline.
+	fset := token.NewFileSet()
+	tokFiles := make(map[string]*token.File)
+	syntax := make(map[string]*ast.File)
+	for _, pkg := range initial {
+		pkg.Fset = fset
+		for _, name := range pkg.CompiledGoFiles {
+			if _, ok := tokFiles[name]; ok {
+				if f := syntax[name]; f != nil {
+					pkg.Syntax = append(pkg.Syntax, f)
+				}
+				continue
+			}
+			data, err := ioutil.ReadFile(name)
+			if err != nil {
+				return nil, err
+			}
+			if bytes.Contains(data, []byte("//lint:")) || analysisflags.BaselineFile != "" && diagnosed[name] {
+				f, err := parser.ParseFile(fset, name, data, parser.ParseComments)
+				if err != nil {
+					return nil, err
+				}
+				pkg.Syntax = append(pkg.Syntax, f)
+				tokFiles[name] = fset.File(f.Pos())
+				syntax[name] = f
+				continue
+			}
+			tokFiles[name] = fset.AddFile(name, -1, len(data))
+			tokFiles[name].SetLinesForContent(data)
+		}
+	}
+	pos := func(p cachedPos) token.Pos {
+		if p.File == "" {
+			return token.NoPos
+		}
+		tf, ok := tokFiles[p.File]
+		if !ok {
+			// A position in a file of another package.
+			if data, err := ioutil.ReadFile(p.File); err == nil {
+				tf = fset.AddFile(p.File, -1, len(data))
+				tf.SetLinesForContent(data)
+			}
+			tokFiles[p.File] = tf
+		}
+		if tf == nil || p.Offset > tf.Size() {
+			return token.NoPos
+		}
+		return tf.Pos(p.Offset)
+	}
+
+	var roots []*action
+	for _, a := range analyzers {
+		for _, pkg := range initial {
+			if !analysisflags.EnabledFor(a, pkg.PkgPath) {
+				continue
+			}
+			act := &action{a: a, pkg: pkg, isroot: true}
+			if msg := errs[pkg][a.Name]; msg != "" {
+				act.err = errors.New(msg)
+			}
+			for _, cd := range results[pkg][a.Name] {
+				diag := analysis.Diagnostic{
+					Pos:      pos(cd.Pos),
+					End:      pos(cd.End),
+					Category: cd.Category,
+					Message:  cd.Message,
+				}
+				for _, cf := range cd.SuggestedFixes {
+					sf := analysis.SuggestedFix{Message: cf.Message}
+					for _, ce := range cf.TextEdits {
+						sf.TextEdits = append(sf.TextEdits, analysis.TextEdit{Pos: pos(ce.Pos), End: pos(ce.End), NewText: ce.NewText})
+					}
+					diag.SuggestedFixes = append(diag.SuggestedFixes, sf)
+				}
+				for _, cr := range cd.Related {
+					diag.Related = append(diag.Related, analysis.RelatedInformation{Pos: pos(cr.Pos), End: pos(cr.End), Message: cr.Message})
+				}
+				act.diagnostics = append(act.diagnostics, diag)
+			}
+			roots = append(roots, act)
+		}
+	}
+	return roots, nil
+}
+
+// analyze analyzes pkg, whose key is k, from the syntax of exported, the
+// same package loaded with export data, and the files of the facts of its
+// imports, by package path. It stores its facts and, if isroot, its
+// diagnostics, in c, and returns its results and the file of its facts.
+//
+// The analyzers named in failed, which failed in the imports of pkg, fail in
+// pkg too. The errors of the analyzers, and those of the package itself, are
+// recorded in the results, which are then not stored: the facts are
+// returned in a temporary file, which the caller removes.
+func (c *cache) analyze(pkg, exported *packages.Package, k string, isroot bool, analyzers []*analysis.Analyzer, facts map[string]string, failed map[string][]string) ([]cachedResult, string, error) {
+	if exported == nil {
+		return nil, "", fmt.Errorf("package %s changed during the analysis", pkg.ID)
+	}
+	if err := os.MkdirAll(filepath.Dir(c.file(k, "")), 0777); err != nil {
+		return nil, "", err
+	}
+	vetx, err := ioutil.TempFile(filepath.Dir(c.file(k, "")), k+factsExt+".*")
+	if err != nil {
+		return nil, "", err
+	}
+	vetx.Close()
+
+	cfg := &unitchecker.Config{
+		ID:           pkg.ID,
+		Compiler:     "gc",
+		ImportPath:   pkg.PkgPath,
+		GoFiles:      exported.CompiledGoFiles,
+		NonGoFiles:   exported.OtherFiles,
+		IgnoredFiles: exported.IgnoredFiles,
+		ImportMap:    make(map[string]string),
+		PackageFile:  make(map[string]string),
+		PackageVetx:  facts,
+		VetxOnly:     !isroot,
+		VetxOutput:   vetx.Name(),
+	}
+	for path, imp := range exported.Imports {
+		cfg.ImportMap[path] = imp.PkgPath
+		if imp.ExportFile != "" {
+			cfg.PackageFile[imp.PkgPath] = imp.ExportFile
+		}
+	}
+	fset := token.NewFileSet()
+	res, err := unitcheckerinternal.Analyze(fset, cfg, analyzers)
+	if err != nil {
+		// The package could not be analyzed at all.
+		os.Remove(vetx.Name())
+		var results []cachedResult
+		for _, a := range analyzers {
+			results = append(results, cachedResult{
+				Analyzer: a.Name,
+				Err:      fmt.Sprintf("analysis skipped due to errors in package: %v", err),
+			})
+		}
+		return results, "", nil
+	}
+	cpos := func(pos token.Pos) cachedPos {
+		if tf := fset.File(pos); tf != nil {
+			return cachedPos{File: tf.Name(), Offset: tf.Offset(pos)}
+		}
+		return cachedPos{}
+	}
+	var results []cachedResult
+	ok := true
+	for _, r := range res {
+		cr := cachedResult{Analyzer: r.Analyzer.Name}
+		if acts := failed[r.Analyzer.Name]; len(acts) > 0 {
+			cr.Err = fmt.Sprintf("failed prerequisites: %s", strings.Join(acts, ", "))
+		} else if r.Err != nil {
+			cr.Err = r.Err.Error()
+		}
+		if cr.Err != "" {
+			ok = false
+			results = append(results, cr)
+			continue
+		}
+		for _, diag := range r.Diagnostics {
+			cd := cachedDiagnostic{
+				Pos:      cpos(diag.Pos),
+				End:      cpos(diag.End),
+				Category: diag.Category,
+				Message:  diag.Message,
+			}
+			for _, sf := range diag.SuggestedFixes {
+				cf := cachedFix{Message: sf.Message}
+				for _, edit := range sf.TextEdits {
+					cf.TextEdits = append(cf.TextEdits, cachedEdit{cpos(edit.Pos), cpos(edit.End), edit.NewText})
+				}
+				cd.SuggestedFixes = append(cd.SuggestedFixes, cf)
+			}
+			for _, rel := range diag.Related {
+				cd.Related = append(cd.Related, cachedRelated{cpos(rel.Pos), cpos(rel.End), rel.Message})
+			}
+			cr.Diagnostics = append(cr.Diagnostics, cd)
+		}
+		results = append(results, cr)
+	}
+
+	// Don't cache failures, so that they are retried.
+	if !ok {
+		return results, vetx.Name(), nil
+	}
+
+	// Move the files into place, so that concurrent runs never read
+	// partial entries.
+	if isroot {
+		data, err := json.Marshal(results)
+		if err != nil {
+			os.Remove(vetx.Name())
+			return nil, "", err
+		}
+		if err := writeFileAtomic(c.file(k, diagnosticsExt), data); err != nil {
+			os.Remove(vetx.Name())
+			return nil, "", err
+		}
+	}
+	if err := os.Rename(vetx.Name(), c.file(k, factsExt)); err != nil {
+		os.Remove(vetx.Name())
+		return nil, "", err
+	}
+	return results, c.file(k, factsExt), nil
+}
+
+// writeFileAtomic writes data to the file filename, by renaming a temporary
+// file.
+func writeFileAtomic(filename string, data []byte) error {
+	f, err := ioutil.TempFile(filepath.Dir(filename), filepath.Base(filename)+".*")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), filename)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
@@ -33,6 +33,9 @@ import (
 	"golang.org/x/tools/go/analysis/internal/analysisflags"
 	"golang.org/x/tools/go/packages"
 	"golang.org/x/tools/internal/analysisinternal"
+	"golang.org/x/tools/internal/lintignore"
+	"golang.org/x/tools/internal/lsp/diff"
+	"golang.org/x/tools/internal/lsp/diff/myers"
 	"golang.org/x/tools/internal/span"
 )
 
@@ -52,6 +55,14 @@ var (
 
 	// Fix determines whether to apply all suggested fixes.
 	Fix bool
+
+	// FixDryRun determines whether to print all suggested fixes as unified
+	// diffs, instead of applying them.
+	FixDryRun bool
+
+	// CacheDir is the directory of the persistent cache of the facts and
+	// diagnostics of packages, if any.
+	CacheDir string
 )
 
 // RegisterFlags registers command-line flags used by the analysis driver.
@@ -66,6 +77,8 @@ func RegisterFlags() {
 	flag.StringVar(&Trace, "trace", "", "write trace log to this file")
 
 	flag.BoolVar(&Fix, "fix", false, "apply all suggested fixes")
+	flag.BoolVar(&FixDryRun, "fix-dry-run", false, "print all suggested fixes as unified diffs instead of applying them")
+	flag.StringVar(&CacheDir, "cache", "", "cache the facts and diagnostics of unchanged packages in this directory")
 }
 
 // Run loads the packages specified by args using go/packages,
@@ -126,32 +139,59 @@ func Run(args []string, analyzers []*analysis.Analyzer) (exitcode int) {
 
 	// Optimization: if the selected analyzers don't produce/consume
 	// facts, we need source only for the initial packages.
-	allSyntax := needFacts(analyzers)
-	initial, err := load(args, allSyntax)
-	if err != nil {
-		log.Print(err)
-		return 1 // load errors
+	mode := packages.LoadSyntax
+	if needFacts(analyzers) {
+		mode = packages.LoadAllSyntax
+	}
+	var roots []*action
+	if CacheDir != "" {
+		var err error
+		if roots, err = analyzeCached(args, analyzers); err != nil {
+			log.Print(err)
+			return 1
+		}
+	} else {
+		initial, err := load(args, mode, nil)
+		if err != nil {
+			log.Print(err)
+			return 1 // load errors
+		}
+		roots = analyze(initial, analyzers)
 	}
 
 	// Print the results.
-	roots := analyze(initial, analyzers)
+	roots = ignore(roots)
+	if analysisflags.BaselineFile != "" {
+		if err := baseline(roots); err != nil {
+			log.Print(err)
+			return 1
+		}
+	}
 
-	if Fix {
-		applyFixes(roots)
+	if Fix || FixDryRun {
+		applyFixes(roots, func(overlay map[string][]byte) ([]*action, error) {
+			initial, err := load(args, mode, overlay)
+			if err != nil {
+				return nil, err
+			}
+			roots := ignore(analyze(initial, analyzers))
+			if analysisflags.NewOnly {
+				err = baseline(roots)
+			}
+			return roots, err
+		})
 	}
 
 	return printDiagnostics(roots)
 }
 
-// load loads the initial packages.
-func load(patterns []string, allSyntax bool) ([]*packages.Package, error) {
-	mode := packages.LoadSyntax
-	if allSyntax {
-		mode = packages.LoadAllSyntax
-	}
+// load loads the initial packages in the given mode, with the contents of
+// the files in overlay, if any, replacing those on disk.
+func load(patterns []string, mode packages.LoadMode, overlay map[string][]byte) ([]*packages.Package, error) {
 	conf := packages.Config{
-		Mode:  mode,
-		Tests: true,
+		Mode:    mode,
+		Tests:   true,
+		Overlay: overlay,
 	}
 	initial, err := packages.Load(&conf, patterns...)
 	if err == nil {
@@ -248,10 +288,14 @@ func analyze(pkgs []*packages.Package, analyzers []*analysis.Analyzer) []*action
 		return act
 	}
 
-	// Build nodes for initial packages.
+	// Build nodes for initial packages, except the packages for which the
+	// configuration disables an analyzer.
 	var roots []*action
 	for _, a := range analyzers {
 		for _, pkg := range pkgs {
+			if !analysisflags.EnabledFor(a, pkg.PkgPath) {
+				continue
+			}
 			root := mkAction(a, pkg)
 			root.isroot = true
 			roots = append(roots, root)
@@ -264,133 +308,309 @@ func analyze(pkgs []*packages.Package, analyzers []*analysis.Analyzer) []*action
 	return roots
 }
 
-func applyFixes(roots []*action) {
-	visited := make(map[*action]bool)
-	var apply func(*action) error
-	var visitAll func(actions []*action) error
-	visitAll = func(actions []*action) error {
-		for _, act := range actions {
-			if !visited[act] {
-				visited[act] = true
-				visitAll(act.deps)
-				if err := apply(act); err != nil {
-					return err
-				}
-			}
-		}
-		return nil
-	}
-
-	// TODO(matloob): Is this tree business too complicated? (After all this is Go!)
-	// Just create a set (map) of edits, sort by pos and call it a day?
-	type offsetedit struct {
-		start, end int
-		newText    []byte
-	} // TextEdit using byteOffsets instead of pos
-	type node struct {
-		edit        offsetedit
-		left, right *node
-	}
-
-	var insert func(tree **node, edit offsetedit) error
-	insert = func(treeptr **node, edit offsetedit) error {
-		if *treeptr == nil {
-			*treeptr = &node{edit, nil, nil}
-			return nil
-		}
-		tree := *treeptr
-		if edit.end <= tree.edit.start {
-			return insert(&tree.left, edit)
-		} else if edit.start >= tree.edit.end {
-			return insert(&tree.right, edit)
-		}
+// maxFixRounds bounds the rounds of analysis of applyFixes.
+const maxFixRounds = 10
 
-		// Overlapping text edit.
-		return fmt.Errorf("analyses applying overlapping text edits affecting pos range (%v, %v) and (%v, %v)",
-			edit.start, edit.end, tree.edit.start, tree.edit.end)
+// A fileEdit is a TextEdit of a file, using byte offsets instead of positions.
+type fileEdit struct {
+	start, end int
+	newText    string
+}
 
+// overlaps reports whether e and other cannot both be applied, as they
+// replace the same text, or insert text at the same offset.
+func (e fileEdit) overlaps(other fileEdit) bool {
+	if e.start < other.end && other.start < e.end {
+		return true
 	}
+	return e.start == other.start && (e.start == e.end || other.start == other.end)
+}
 
-	editsForFile := make(map[*token.File]*node)
+// A suggestedFix is a suggested fix of a diagnostic, with its edits to each
+// file.
+type suggestedFix struct {
+	analyzer string
+	message  string
+	posn     token.Position
+	edits    map[string][]fileEdit
+}
 
-	apply = func(act *action) error {
+// suggestedFixes returns the suggested fixes of the diagnostics of the root
+// actions.
+func suggestedFixes(roots []*action) []*suggestedFix {
+	var fixes []*suggestedFix
+	for _, act := range roots {
 		for _, diag := range act.diagnostics {
+		fixes:
 			for _, sf := range diag.SuggestedFixes {
+				fix := &suggestedFix{
+					analyzer: act.a.Name,
+					message:  sf.Message,
+					posn:     act.pkg.Fset.Position(diag.Pos),
+					edits:    make(map[string][]fileEdit),
+				}
 				for _, edit := range sf.TextEdits {
 					// Validate the edit.
 					if edit.Pos > edit.End {
-						return fmt.Errorf(
-							"diagnostic for analysis %v contains Suggested Fix with malformed edit: pos (%v) > end (%v)",
+						log.Printf("diagnostic for analysis %v contains Suggested Fix with malformed edit: pos (%v) > end (%v)",
 							act.a.Name, edit.Pos, edit.End)
+						continue fixes
 					}
 					file, endfile := act.pkg.Fset.File(edit.Pos), act.pkg.Fset.File(edit.End)
 					if file == nil || endfile == nil || file != endfile {
-						return (fmt.Errorf(
-							"diagnostic for analysis %v contains Suggested Fix with malformed spanning files %v and %v",
-							act.a.Name, file.Name(), endfile.Name()))
+						log.Printf("diagnostic for analysis %v contains Suggested Fix with malformed spanning files %v and %v",
+							act.a.Name, file.Name(), endfile.Name())
+						continue fixes
 					}
-					start, end := file.Offset(edit.Pos), file.Offset(edit.End)
-
 					// TODO(matloob): Validate that edits do not affect other packages.
-					root := editsForFile[file]
-					if err := insert(&root, offsetedit{start, end, edit.NewText}); err != nil {
-						return err
-					}
-					editsForFile[file] = root // In case the root changed
+					fix.edits[file.Name()] = append(fix.edits[file.Name()], fileEdit{
+						start:   file.Offset(edit.Pos),
+						end:     file.Offset(edit.End),
+						newText: string(edit.NewText),
+					})
 				}
+				fixes = append(fixes, fix)
 			}
 		}
-		return nil
 	}
+	return fixes
+}
 
-	visitAll(roots)
-
-	fset := token.NewFileSet() // Shared by parse calls below
-	// Now we've got a set of valid edits for each file. Get the new file contents.
-	for f, tree := range editsForFile {
-		contents, err := ioutil.ReadFile(f.Name())
-		if err != nil {
-			log.Fatal(err)
+// applyFixes applies the suggested fixes of the root actions to the files of
+// their packages or, with -fix-dry-run, prints the changes as unified diffs.
+//
+// Each fix is applied as a whole, without the edits made by the fixes already
+// applied, unless its edits overlap theirs. If fixes were skipped, reanalyze
+// analyzes the packages again with the edited files, and the fixes of the new
+// diagnostics are applied, until no fix is skipped or no fix applies.
+func applyFixes(roots []*action, reanalyze func(overlay map[string][]byte) ([]*action, error)) {
+	original := make(map[string][]byte)
+	contents := make(map[string][]byte) // contents of the edited files
+	fset := token.NewFileSet()          // Shared by parse calls below
+	var skipped []*suggestedFix
+	for round := 1; ; round++ {
+		applied := make(map[string][]fileEdit)
+		skipped = nil
+		progress := false
+		for _, fix := range suggestedFixes(roots) {
+			// Drop the edits already made, and skip the fix if the
+			// others overlap the edits of the fixes already applied.
+			add := make(map[string][]fileEdit)
+			conflict := false
+		files:
+			for name, edits := range fix.edits {
+			edits:
+				for _, edit := range edits {
+					for _, prev := range append(applied[name], add[name]...) {
+						if edit == prev {
+							continue edits
+						}
+						if edit.overlaps(prev) {
+							conflict = true
+							break files
+						}
+					}
+					add[name] = append(add[name], edit)
+				}
+			}
+			if conflict {
+				skipped = append(skipped, fix)
+				continue
+			}
+			for name, edits := range add {
+				applied[name] = append(applied[name], edits...)
+				progress = true
+			}
 		}
 
-		cur := 0 // current position in the file
+		for name, edits := range applied {
+			src, ok := contents[name]
+			if !ok {
+				var err error
+				if src, err = ioutil.ReadFile(name); err != nil {
+					log.Fatal(err)
+				}
+				original[name] = src
+			}
+			out := applyEdits(src, edits)
+
+			// Try to format the file.
+			ff, err := parser.ParseFile(fset, name, out, parser.ParseComments)
+			if err == nil {
+				var buf bytes.Buffer
+				if err = format.Node(&buf, fset, ff); err == nil {
+					out = buf.Bytes()
+				}
+			}
+			contents[name] = out
+		}
 
-		var out bytes.Buffer
+		if len(skipped) == 0 || !progress || round == maxFixRounds {
+			break
+		}
+		var err error
+		if roots, err = reanalyze(contents); err != nil {
+			log.Printf("analyzing the fixed packages: %v", err)
+			break
+		}
+	}
+	for _, fix := range skipped {
+		log.Printf("%s: skipped the fix %q of %s, which conflicts with other fixes", fix.posn, fix.message, fix.analyzer)
+	}
 
-		var recurse func(*node)
-		recurse = func(node *node) {
-			if node.left != nil {
-				recurse(node.left)
+	var names []string
+	for name := range contents {
+		if !bytes.Equal(contents[name], original[name]) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if FixDryRun {
+			before, after := string(original[name]), string(contents[name])
+			edits, err := myers.ComputeEdits(span.URIFromPath(name), before, after)
+			if err != nil {
+				log.Fatal(err)
 			}
+			fmt.Print(diff.ToUnified(name+".orig", name, before, edits))
+			continue
+		}
+		ioutil.WriteFile(name, contents[name], 0644)
+	}
+}
+
+// applyEdits returns src with edits, which do not overlap, applied.
+func applyEdits(src []byte, edits []fileEdit) []byte {
+	sort.SliceStable(edits, func(i, j int) bool {
+		if edits[i].start != edits[j].start {
+			return edits[i].start < edits[j].start
+		}
+		return edits[i].end < edits[j].end
+	})
+	var out bytes.Buffer
+	cur := 0 // current position in src
+	for _, edit := range edits {
+		out.Write(src[cur:edit.start])
+		out.WriteString(edit.newText)
+		cur = edit.end
+	}
+	// Write out the rest of the file.
+	out.Write(src[cur:])
+	return out.Bytes()
+}
 
-			edit := node.edit
-			if edit.start > cur {
-				out.Write(contents[cur:edit.start])
-				out.Write(edit.newText)
+// ignore removes the diagnostics of the root actions suppressed by the
+// //lint:ignore directives of the root packages, before their fixes are
+// applied or they are printed. It returns the root actions, followed by an
+// action of the analysisflags.Lint pseudo-analyzer for each root package
+// whose directives are malformed or unused.
+func ignore(roots []*action) []*action {
+	set := lintignore.NewSet()
+	// ran records the analyzers that ran on each file, in any of the packages
+	// that contain it.
+	ran := make(map[string]map[string]bool)
+	// Each file is attributed to the first package that contains it.
+	filePkgs := make(map[string]*packages.Package)
+	var pkgs []*packages.Package
+	for _, act := range roots {
+		enabled := act.err == nil && analysisflags.EnabledFor(act.a, act.pkg.PkgPath)
+		for _, f := range act.pkg.Syntax {
+			name := act.pkg.Fset.File(f.Pos()).Name()
+			if enabled {
+				if ran[name] == nil {
+					ran[name] = make(map[string]bool)
+				}
+				ran[name][act.a.Name] = true
 			}
-			cur = edit.end
+			if _, ok := filePkgs[name]; ok {
+				continue
+			}
+			if len(pkgs) == 0 || pkgs[len(pkgs)-1] != act.pkg {
+				pkgs = append(pkgs, act.pkg)
+			}
+			filePkgs[name] = act.pkg
+			set.Add(act.pkg.Fset, f)
+		}
+	}
 
-			if node.right != nil {
-				recurse(node.right)
+	for _, act := range roots {
+		var kept []analysis.Diagnostic
+		for _, diag := range act.diagnostics {
+			if !set.Suppressed(act.a.Name, act.pkg.Fset.Position(diag.Pos)) {
+				kept = append(kept, diag)
 			}
 		}
-		recurse(tree)
-		// Write out the rest of the file.
-		if cur < len(contents) {
-			out.Write(contents[cur:])
+		act.diagnostics = kept
+	}
+
+	problems := make(map[*packages.Package][]analysis.Diagnostic)
+	for _, p := range set.Problems(func(name, filename string) bool { return ran[filename][name] }, analysisflags.RequireIgnoreReason) {
+		pkg := filePkgs[p.Position.Filename]
+		problems[pkg] = append(problems[pkg], analysis.Diagnostic{
+			Pos:      p.Pos,
+			End:      p.End,
+			Category: lintignore.Name,
+			Message:  p.Message,
+		})
+	}
+	for _, pkg := range pkgs {
+		if diags := problems[pkg]; len(diags) > 0 {
+			roots = append(roots, &action{
+				a:           analysisflags.Lint,
+				pkg:         pkg,
+				isroot:      true,
+				diagnostics: diags,
+			})
 		}
+	}
+	return roots
+}
 
-		// Try to format the file.
-		ff, err := parser.ParseFile(fset, f.Name(), out.Bytes(), parser.ParseComments)
-		if err == nil {
-			var buf bytes.Buffer
-			if err = format.Node(&buf, fset, ff); err == nil {
-				out = buf
+// baseline writes the diagnostics of the root actions to the -baseline file
+// or, with -new-only, removes the diagnostics that the file holds from the
+// root actions.
+func baseline(roots []*action) error {
+	b := analysisflags.NewBaseline()
+	if analysisflags.NewOnly {
+		var err error
+		if b, err = analysisflags.ReadBaseline(analysisflags.BaselineFile); err != nil {
+			return err
+		}
+	}
+
+	// Count once the diagnostics in source files that belong to
+	// multiple packages, such as foo and foo.test.
+	type key struct {
+		pos      token.Position
+		analyzer string
+		message  string
+	}
+	inBaseline := make(map[key]bool)
+	for _, act := range roots {
+		var kept []analysis.Diagnostic
+		for _, diag := range act.diagnostics {
+			k := key{act.pkg.Fset.Position(diag.Pos), act.a.Name, diag.Message}
+			old, ok := inBaseline[k]
+			if !ok {
+				decl := analysisflags.EnclosingDecl(act.pkg.Fset, act.pkg.Syntax, diag.Pos)
+				if analysisflags.NewOnly {
+					old = b.Remove(act.a.Name, act.pkg.PkgPath, decl, diag.Message)
+				} else {
+					b.Add(act.a.Name, act.pkg.PkgPath, decl, diag.Message)
+				}
+				inBaseline[k] = old
+			}
+			if !old {
+				kept = append(kept, diag)
 			}
 		}
+		act.diagnostics = kept
+	}
 
-		ioutil.WriteFile(f.Name(), out.Bytes(), 0644)
+	if analysisflags.NewOnly {
+		return nil
 	}
+	return b.Write(analysisflags.BaselineFile, false)
 }
 
 // printDiagnostics prints the diagnostics for the root packages in either
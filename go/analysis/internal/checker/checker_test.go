@@ -8,11 +8,15 @@ import (
 	"fmt"
 	"go/ast"
 	"io/ioutil"
+	"os"
 	"path/filepath"
+	"strings"
+	"sync/atomic"
 	"testing"
 
 	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/analysis/analysistest"
+	"golang.org/x/tools/go/analysis/internal/analysisflags"
 	"golang.org/x/tools/go/analysis/internal/checker"
 	"golang.org/x/tools/go/analysis/passes/inspect"
 	"golang.org/x/tools/go/ast/inspector"
@@ -68,6 +72,450 @@ func Foo() {
 	defer cleanup()
 }
 
+func TestIgnore(t *testing.T) {
+	testenv.NeedsGoPackages(t)
+
+	from = "bar"
+	to = "baz"
+
+	for _, test := range []struct {
+		name, src, want string
+		exitcode        int
+	}{
+		{
+			name: "line",
+			src: `package ignore
+
+func Foo() {
+	//lint:ignore rename bar is fine
+	bar := ""
+	_ = bar //lint:ignore rename bar is fine
+}
+`,
+			want: `package ignore
+
+func Foo() {
+	//lint:ignore rename bar is fine
+	bar := ""
+	_ = bar //lint:ignore rename bar is fine
+}
+`,
+		},
+		{
+			name: "file",
+			src: `package ignore
+
+//lint:file-ignore rename bar is fine
+
+func Foo() {
+	bar := ""
+	_ = bar
+}
+`,
+			want: `package ignore
+
+//lint:file-ignore rename bar is fine
+
+func Foo() {
+	bar := ""
+	_ = bar
+}
+`,
+		},
+		{
+			name: "unused",
+			src: `package ignore
+
+func Foo() {
+	//lint:ignore rename bar is fine
+	foo := ""
+	bar := foo
+	_ = bar
+}
+`,
+			want: `package ignore
+
+func Foo() {
+	//lint:ignore rename bar is fine
+	foo := ""
+	baz := foo
+	_ = baz
+}
+`,
+			exitcode: 3,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			testdata, cleanup, err := analysistest.WriteFiles(map[string]string{"ignore/test.go": test.src})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer cleanup()
+			path := filepath.Join(testdata, "src/ignore/test.go")
+			checker.Fix = true
+			defer func() { checker.Fix = false }()
+			if exitcode := checker.Run([]string{"file=" + path}, []*analysis.Analyzer{analyzer}); exitcode != test.exitcode {
+				t.Errorf("exit code: got %d, want %d", exitcode, test.exitcode)
+			}
+
+			contents, err := ioutil.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := string(contents); got != test.want {
+				t.Errorf("contents of rewritten file\ngot: %s\nwant: %s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestBaseline(t *testing.T) {
+	testenv.NeedsGoPackages(t)
+
+	from = "bar"
+	to = "baz"
+
+	const before = `package baseline
+
+func Foo() {
+	bar := ""
+	_ = bar
+}
+`
+	// The diagnostics of Foo moved, and Qux has new ones.
+	const after = `package baseline
+
+// Foo is documented.
+func Foo() {
+	bar := ""
+	_ = bar
+}
+
+func Qux() {
+	bar := ""
+	_ = bar
+}
+`
+	const want = `package baseline
+
+// Foo is documented.
+func Foo() {
+	bar := ""
+	_ = bar
+}
+
+func Qux() {
+	baz := ""
+	_ = baz
+}
+`
+
+	testdata, cleanup, err := analysistest.WriteFiles(map[string]string{"baseline/test.go": before})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+	path := filepath.Join(testdata, "src/baseline/test.go")
+	analysisflags.BaselineFile = filepath.Join(testdata, "baseline.json")
+	defer func() { analysisflags.BaselineFile = "" }()
+
+	// Write the baseline.
+	if exitcode := checker.Run([]string{"file=" + path}, []*analysis.Analyzer{analyzer}); exitcode != 3 {
+		t.Errorf("writing the baseline: got exit code %d, want 3", exitcode)
+	}
+
+	// Fix only the new diagnostics.
+	if err := ioutil.WriteFile(path, []byte(after), 0666); err != nil {
+		t.Fatal(err)
+	}
+	analysisflags.NewOnly = true
+	checker.Fix = true
+	defer func() {
+		analysisflags.NewOnly = false
+		checker.Fix = false
+	}()
+	if exitcode := checker.Run([]string{"file=" + path}, []*analysis.Analyzer{analyzer}); exitcode != 3 {
+		t.Errorf("with -new-only: got exit code %d, want 3", exitcode)
+	}
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(contents); got != want {
+		t.Errorf("contents of rewritten file\ngot: %s\nwant: %s", got, want)
+	}
+
+	// Without new diagnostics, there is nothing to report.
+	if err := ioutil.WriteFile(path, []byte(before), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if exitcode := checker.Run([]string{"file=" + path}, []*analysis.Analyzer{analyzer}); exitcode != 0 {
+		t.Errorf("with -new-only and no new diagnostics: got exit code %d, want 0", exitcode)
+	}
+}
+
+func TestApplyFixesRounds(t *testing.T) {
+	testenv.NeedsGoPackages(t)
+
+	from = "bar"
+	to = "baz"
+
+	// The fix of blank overlaps the renaming of bar in the same statement,
+	// and applies once bar is renamed. The test variant of the package
+	// makes the same edits.
+	files := map[string]string{
+		"rounds/test.go": `package rounds
+
+func Foo() {
+	bar := ""
+	_ = bar
+}
+`,
+		"rounds/test_test.go": `package rounds
+`,
+	}
+	const want = `package rounds
+
+func Foo() {
+	baz := ""
+	println(baz)
+}
+`
+
+	testdata, cleanup, err := analysistest.WriteFiles(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+	path := filepath.Join(testdata, "src/rounds/test.go")
+
+	// With -fix-dry-run, the file is not changed, and the diff is printed.
+	checker.FixDryRun = true
+	out, err := captureStdout(func() {
+		checker.Run([]string{"file=" + path}, []*analysis.Analyzer{analyzer, blank})
+	})
+	checker.FixDryRun = false
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"--- " + path + ".orig", "+++ " + path, "-\t_ = bar", "+\tprintln(baz)"} {
+		if !strings.Contains(out, line+"\n") {
+			t.Errorf("-fix-dry-run output does not contain %q:\n%s", line, out)
+		}
+	}
+	if contents, err := ioutil.ReadFile(path); err != nil {
+		t.Fatal(err)
+	} else if got := string(contents); got != files["rounds/test.go"] {
+		t.Errorf("-fix-dry-run changed the file:\n%s", got)
+	}
+
+	checker.Fix = true
+	defer func() { checker.Fix = false }()
+	checker.Run([]string{"file=" + path}, []*analysis.Analyzer{analyzer, blank})
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(contents); got != want {
+		t.Errorf("contents of rewritten file\ngot: %s\nwant: %s", got, want)
+	}
+}
+
+func TestCache(t *testing.T) {
+	testenv.NeedsGoPackages(t)
+
+	files := map[string]string{
+		"a/a.go": `package a
+
+func A() string { return "" }
+`,
+		"b/b.go": `package b
+
+import "a"
+
+var B = a.A()
+`,
+	}
+	testdata, cleanup, err := analysistest.WriteFiles(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+	for name, value := range map[string]string{"GOPATH": testdata, "GO111MODULE": "off", "GOFLAGS": ""} {
+		defer os.Setenv(name, os.Getenv(name))
+		os.Setenv(name, value)
+	}
+	checker.CacheDir = filepath.Join(testdata, "cache")
+	analysisflags.JSON = true
+	defer func() {
+		checker.CacheDir = ""
+		analysisflags.JSON = false
+	}()
+
+	analyzeB := func() string {
+		atomic.StoreInt32(&counterRuns, 0)
+		out, err := captureStdout(func() {
+			checker.Run([]string{"b"}, []*analysis.Analyzer{counter})
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+
+	out := analyzeB()
+	if !strings.Contains(out, "imports a") {
+		t.Fatalf("got %s, want the diagnostic \"imports a\"", out)
+	}
+	if runs := atomic.LoadInt32(&counterRuns); runs != 2 {
+		t.Errorf("with an empty cache, the analyzer ran %d times, want 2", runs)
+	}
+
+	// The unchanged packages are not analyzed again.
+	if cached := analyzeB(); cached != out {
+		t.Errorf("with a full cache, got %s, want %s", cached, out)
+	}
+	if runs := atomic.LoadInt32(&counterRuns); runs != 0 {
+		t.Errorf("with a full cache, the analyzer ran %d times, want 0", runs)
+	}
+
+	// A change to a invalidates the entries of a and b.
+	if err := ioutil.WriteFile(filepath.Join(testdata, "src/a/a.go"), []byte(files["a/a.go"]+"\nfunc C() {}\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	analyzeB()
+	if runs := atomic.LoadInt32(&counterRuns); runs != 2 {
+		t.Errorf("after a change to a dependency, the analyzer ran %d times, want 2", runs)
+	}
+}
+
+// The failure of an analyzer in a dependency is reported for it alone, and
+// is not cached.
+func TestCacheError(t *testing.T) {
+	testenv.NeedsGoPackages(t)
+
+	files := map[string]string{
+		"a/a.go": `package a
+
+func A() string { return "" }
+`,
+		"b/b.go": `package b
+
+import "a"
+
+var B = a.A()
+`,
+	}
+	testdata, cleanup, err := analysistest.WriteFiles(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+	for name, value := range map[string]string{"GOPATH": testdata, "GO111MODULE": "off", "GOFLAGS": ""} {
+		defer os.Setenv(name, os.Getenv(name))
+		os.Setenv(name, value)
+	}
+	checker.CacheDir = filepath.Join(testdata, "cache")
+	analysisflags.JSON = true
+	defer func() {
+		checker.CacheDir = ""
+		analysisflags.JSON = false
+	}()
+
+	for i := 0; i < 2; i++ {
+		out, err := captureStdout(func() {
+			checker.Run([]string{"b"}, []*analysis.Analyzer{counter, failer})
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(out, "imports a") {
+			t.Errorf("run %d: got %s, want the diagnostic \"imports a\"", i, out)
+		}
+		if !strings.Contains(out, "failed prerequisites: failer@a") {
+			t.Errorf("run %d: got %s, want the error of failer in a", i, out)
+		}
+	}
+}
+
+// captureStdout returns the output of f to os.Stdout.
+func captureStdout(f func()) (string, error) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return "", err
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	out := make(chan []byte)
+	go func() {
+		data, _ := ioutil.ReadAll(r)
+		out <- data
+	}()
+	f()
+	os.Stdout = stdout
+	w.Close()
+	return string(<-out), nil
+}
+
+// blank replaces the assignments of an identifier to the blank identifier
+// with a call to println.
+var blank = &analysis.Analyzer{
+	Name:     "blank",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run: func(pass *analysis.Pass) (interface{}, error) {
+		inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+		inspect.Preorder([]ast.Node{(*ast.AssignStmt)(nil)}, func(n ast.Node) {
+			assign := n.(*ast.AssignStmt)
+			if len(assign.Lhs) != 1 || len(assign.Rhs) != 1 {
+				return
+			}
+			lhs, _ := assign.Lhs[0].(*ast.Ident)
+			rhs, _ := assign.Rhs[0].(*ast.Ident)
+			if lhs == nil || lhs.Name != "_" || rhs == nil {
+				return
+			}
+			pass.Report(analysis.Diagnostic{
+				Pos:     assign.Pos(),
+				End:     assign.End(),
+				Message: "blank assignment",
+				SuggestedFixes: []analysis.SuggestedFix{{
+					Message: "call println",
+					TextEdits: []analysis.TextEdit{{
+						Pos:     assign.Pos(),
+						End:     assign.End(),
+						NewText: []byte("println(" + rhs.Name + ")"),
+					}},
+				}},
+			})
+		})
+		return nil, nil
+	},
+}
+
+// counterRuns is the number of runs of counter.
+var counterRuns int32
+
+type seenFact struct{ Path string }
+
+func (*seenFact) AFact() {}
+
+// counter reports the imports of the packages for which it exported a fact,
+// and counts its runs.
+var counter = &analysis.Analyzer{
+	Name:      "counter",
+	FactTypes: []analysis.Fact{new(seenFact)},
+	Run: func(pass *analysis.Pass) (interface{}, error) {
+		atomic.AddInt32(&counterRuns, 1)
+		pass.ExportPackageFact(&seenFact{pass.Pkg.Path()})
+		for _, imp := range pass.Pkg.Imports() {
+			if pass.ImportPackageFact(imp, new(seenFact)) {
+				pass.Reportf(pass.Files[0].Package, "imports %s", imp.Path())
+			}
+		}
+		return nil, nil
+	},
+}
+
 var analyzer = &analysis.Analyzer{
 	Name:     "rename",
 	Requires: []*analysis.Analyzer{inspect.Analyzer},
@@ -99,3 +547,16 @@ func run(pass *analysis.Pass) (interface{}, error) {
 
 	return nil, nil
 }
+
+// failer fails in package a, after exporting a fact.
+var failer = &analysis.Analyzer{
+	Name:      "failer",
+	FactTypes: []analysis.Fact{new(seenFact)},
+	Run: func(pass *analysis.Pass) (interface{}, error) {
+		pass.ExportPackageFact(&seenFact{pass.Pkg.Path()})
+		if pass.Pkg.Path() == "a" {
+			return nil, fmt.Errorf("failing in a")
+		}
+		return nil, nil
+	},
+}
@@ -0,0 +1,31 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package unitcheckerinternal exposes internal-only functions of
+// go/analysis/unitchecker to the other analysis drivers.
+package unitcheckerinternal
+
+import (
+	"fmt"
+	"go/token"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// A Result holds the diagnostics of an analyzer for a compilation unit, or
+// its error.
+type Result struct {
+	Analyzer    *analysis.Analyzer
+	Diagnostics []analysis.Diagnostic
+	Err         error
+}
+
+// Analyze analyzes the compilation unit described by config, a
+// *unitchecker.Config, and writes its facts to its VetxOutput file. It
+// returns the results of analyzers, which the configuration of the analyzers,
+// //lint:ignore directives and baselines do not filter. It is set by package
+// unitchecker.
+var Analyze = func(fset *token.FileSet, config interface{}, analyzers []*analysis.Analyzer) ([]Result, error) {
+	return nil, fmt.Errorf("unitchecker is not linked into the program")
+}
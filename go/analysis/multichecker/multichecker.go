@@ -5,6 +5,40 @@
 // Package multichecker defines the main function for an analysis driver
 // with several analyzers. This package makes it easy for anyone to build
 // an analysis tool containing just the analyzers they need.
+//
+// Besides the flags of each analyzer, the driver reads the configuration of
+// its analyzers from the JSON file named by the -config flag, such as a file
+// checked in at the root of a repository:
+//
+//	{
+//		"default": true,
+//		"analyzers": {
+//			"shadow": {"enabled": false},
+//			"printf": {
+//				"flags": {"funcs": "Logf,Warnf"},
+//				"exclude": ["example.com/internal/gen/..."]
+//			},
+//			"findcall": {
+//				"flags": {"name": "panic"},
+//				"packages": ["example.com/cmd/*"]
+//			}
+//		}
+//	}
+//
+// The configuration enables or disables each analyzer, with "default"
+// applying to the analyzers it does not list, and sets the flags of the
+// analyzers, without the prefix of their name. The "packages" and "exclude"
+// lists restrict the diagnostics of an analyzer to the packages whose paths
+// match a pattern of "packages", if any, and none of "exclude". Patterns
+// are matched as by path.Match, except that a trailing "/..." matches any
+// path below the prefix. The flags on the command line take precedence over
+// the configuration. Unknown analyzers or flags in the configuration are
+// errors, and the -print-config flag prints the effective configuration.
+//
+// The -cache flag names a directory in which the driver keeps the facts and
+// diagnostics of the packages it analyzes, so that later runs analyze only
+// the packages that changed, or whose dependencies changed, unless the
+// analyzers or their flags changed too.
 package multichecker
 
 import (
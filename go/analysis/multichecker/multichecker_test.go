@@ -9,8 +9,10 @@ package multichecker_test
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"runtime"
 	"testing"
 
@@ -54,6 +56,20 @@ func TestExitCode(t *testing.T) {
 
 	testenv.NeedsTool(t, "go")
 
+	dir, err := ioutil.TempDir("", "multichecker")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	excludeIO := filepath.Join(dir, "exclude.json")
+	if err := ioutil.WriteFile(excludeIO, []byte(`{"analyzers": {"findcall": {"exclude": ["io"]}}}`), 0666); err != nil {
+		t.Fatal(err)
+	}
+	unknown := filepath.Join(dir, "unknown.json")
+	if err := ioutil.WriteFile(unknown, []byte(`{"analyzers": {"nosuchanalyzer": {}}}`), 0666); err != nil {
+		t.Fatal(err)
+	}
+
 	for _, test := range []struct {
 		args []string
 		want int
@@ -66,6 +82,10 @@ func TestExitCode(t *testing.T) {
 		{[]string{"-findcall.name=nosuchfunc", "io"}, 0},    // no diagnostics
 		{[]string{"-findcall.name=panic", "sort", "io"}, 1}, // 'fail' failed on 'sort'
 
+		// -config: configures the analyzers.
+		{[]string{"-findcall.name=panic", "-config=" + excludeIO, "io"}, 0}, // io excluded
+		{[]string{"-config=" + unknown, "io"}, 1},                           // invalid configuration
+
 		// -json: exits zero even in face of diagnostics or package errors.
 		{[]string{"-findcall.name=panic", "-json", "io"}, 0},
 		{[]string{"-findcall.name=panic", "-json", "io"}, 0},
@@ -18,6 +18,11 @@
 // If you need a standalone tool, use multichecker,
 // which supports this mode but can also load packages
 // from source using go/packages.
+//
+// Without the -new-only flag, the -baseline flag makes each compilation
+// unit append its diagnostics to the baseline file, which should be removed
+// before the build. The diagnostics of a file that several units analyze,
+// such as a package and its test variant, count once.
 package unitchecker
 
 // TODO(adonovan):
@@ -51,6 +56,8 @@ import (
 	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/analysis/internal/analysisflags"
 	"golang.org/x/tools/go/analysis/internal/facts"
+	"golang.org/x/tools/go/analysis/internal/unitcheckerinternal"
+	"golang.org/x/tools/internal/lintignore"
 )
 
 // A Config describes a compilation unit to be analyzed.
@@ -188,7 +195,53 @@ var importerForCompiler = func(_ *token.FileSet, compiler string, lookup importe
 	return importer.For(compiler, lookup)
 }
 
+func init() {
+	unitcheckerinternal.Analyze = func(fset *token.FileSet, config interface{}, analyzers []*analysis.Analyzer) ([]unitcheckerinternal.Result, error) {
+		_, results, err := analyze(fset, config.(*Config), analyzers)
+		var res []unitcheckerinternal.Result
+		for _, r := range results {
+			res = append(res, unitcheckerinternal.Result{Analyzer: r.a, Diagnostics: r.diagnostics, Err: r.err})
+		}
+		return res, err
+	}
+}
+
 func run(fset *token.FileSet, cfg *Config, analyzers []*analysis.Analyzer) ([]result, error) {
+	files, results, err := analyze(fset, cfg, analyzers)
+	if err != nil || cfg.VetxOnly {
+		return results, err
+	}
+
+	// Drop the results of the analyzers for which the configuration
+	// disables the diagnostics of this package.
+	var enabled []result
+	for _, res := range results {
+		if analysisflags.EnabledFor(res.a, pkgPath(cfg)) {
+			enabled = append(enabled, res)
+		}
+	}
+	results = ignore(fset, files, enabled)
+	if analysisflags.BaselineFile != "" {
+		if err := baseline(fset, files, pkgPath(cfg), results); err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+// pkgPath returns the import path of the package of cfg, without the
+// bracketed suffix of a test variant, such as " [fmt.test]".
+func pkgPath(cfg *Config) string {
+	if i := strings.Index(cfg.ImportPath, " ["); i >= 0 {
+		return cfg.ImportPath[:i]
+	}
+	return cfg.ImportPath
+}
+
+// analyze parses and type-checks the compilation unit of cfg, runs
+// analyzers, and writes its facts to cfg.VetxOutput. It returns the files of
+// the unit, and the diagnostics and errors of the root analyzers.
+func analyze(fset *token.FileSet, cfg *Config, analyzers []*analysis.Analyzer) ([]*ast.File, []result, error) {
 	// Load, parse, typecheck.
 	var files []*ast.File
 	for _, name := range cfg.GoFiles {
@@ -199,7 +252,7 @@ func run(fset *token.FileSet, cfg *Config, analyzers []*analysis.Analyzer) ([]re
 				// report parse errors.
 				err = nil
 			}
-			return nil, err
+			return nil, nil, err
 		}
 		files = append(files, f)
 	}
@@ -240,7 +293,7 @@ func run(fset *token.FileSet, cfg *Config, analyzers []*analysis.Analyzer) ([]re
 			// report type errors.
 			err = nil
 		}
-		return nil, err
+		return nil, nil, err
 	}
 
 	// Register fact types with gob.
@@ -293,7 +346,7 @@ func run(fset *token.FileSet, cfg *Config, analyzers []*analysis.Analyzer) ([]re
 	}
 	facts, err := facts.Decode(pkg, read)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	// In parallel, execute the DAG of analyzers.
@@ -381,10 +434,10 @@ func run(fset *token.FileSet, cfg *Config, analyzers []*analysis.Analyzer) ([]re
 
 	data := facts.Encode()
 	if err := ioutil.WriteFile(cfg.VetxOutput, data, 0666); err != nil {
-		return nil, fmt.Errorf("failed to write analysis facts: %v", err)
+		return nil, nil, fmt.Errorf("failed to write analysis facts: %v", err)
 	}
 
-	return results, nil
+	return files, results, nil
 }
 
 type result struct {
@@ -393,6 +446,78 @@ type result struct {
 	err         error
 }
 
+// ignore removes the diagnostics suppressed by the //lint:ignore directives
+// of files from results, and appends a result of the analysisflags.Lint
+// pseudo-analyzer if the directives are malformed or unused.
+func ignore(fset *token.FileSet, files []*ast.File, results []result) []result {
+	set := lintignore.NewSet()
+	for _, f := range files {
+		set.Add(fset, f)
+	}
+	ran := make(map[string]bool)
+	for i, res := range results {
+		if res.err == nil {
+			ran[res.a.Name] = true
+		}
+		var kept []analysis.Diagnostic
+		for _, diag := range res.diagnostics {
+			if !set.Suppressed(res.a.Name, fset.Position(diag.Pos)) {
+				kept = append(kept, diag)
+			}
+		}
+		results[i].diagnostics = kept
+	}
+
+	var problems []analysis.Diagnostic
+	for _, p := range set.Problems(func(name, _ string) bool { return ran[name] }, analysisflags.RequireIgnoreReason) {
+		problems = append(problems, analysis.Diagnostic{
+			Pos:      p.Pos,
+			End:      p.End,
+			Category: lintignore.Name,
+			Message:  p.Message,
+		})
+	}
+	if len(problems) > 0 {
+		results = append(results, result{a: analysisflags.Lint, diagnostics: problems})
+	}
+	return results
+}
+
+// baseline appends the diagnostics of results to the -baseline file or, with
+// -new-only, removes the diagnostics that the file holds from results. As
+// the packages of a build are analyzed by separate processes, each appends
+// its diagnostics to the file, which should be removed beforehand. The
+// diagnostics are appended with the names of their files, so that those in
+// the files of both a package and its test variant count once.
+func baseline(fset *token.FileSet, files []*ast.File, pkgPath string, results []result) error {
+	b := analysisflags.NewBaseline()
+	if analysisflags.NewOnly {
+		var err error
+		if b, err = analysisflags.ReadBaseline(analysisflags.BaselineFile); err != nil {
+			return err
+		}
+	}
+	for i, res := range results {
+		var kept []analysis.Diagnostic
+		for _, diag := range res.diagnostics {
+			decl := analysisflags.EnclosingDecl(fset, files, diag.Pos)
+			if !analysisflags.NewOnly {
+				file := filepath.Base(fset.Position(diag.Pos).Filename)
+				b.AddIn(res.a.Name, pkgPath, decl, diag.Message, file)
+				kept = append(kept, diag)
+			} else if !b.Remove(res.a.Name, pkgPath, decl, diag.Message) {
+				kept = append(kept, diag)
+			}
+		}
+		results[i].diagnostics = kept
+	}
+
+	if analysisflags.NewOnly {
+		return nil
+	}
+	return b.Write(analysisflags.BaselineFile, true)
+}
+
 type importerFunc func(path string) (*types.Package, error)
 
 func (f importerFunc) Import(path string) (*types.Package, error) { return f(path) }
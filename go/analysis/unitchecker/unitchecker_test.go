@@ -13,8 +13,10 @@ package unitchecker_test
 
 import (
 	"flag"
+	"io/ioutil"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"regexp"
 	"runtime"
 	"strings"
@@ -73,6 +75,16 @@ func _() {
 	MyFunc123()
 }
 
+func MyFunc123() {}
+`,
+			"c/c.go": `package c
+
+func _() {
+	//lint:ignore findcall the call is fine
+	MyFunc123()
+	MyFunc123() //lint:ignore printf no reason to
+}
+
 func MyFunc123() {}
 `,
 		}}})
@@ -85,6 +97,18 @@ func MyFunc123() {}
 ([/._\-a-zA-Z0-9]+[\\/]fake[\\/])?b/b.go:6:13: call of MyFunc123\(...\)
 ([/._\-a-zA-Z0-9]+[\\/]fake[\\/])?b/b.go:7:11: call of MyFunc123\(...\)
 `
+	const wantC = `# golang.org/fake/c
+([/._\-a-zA-Z0-9]+[\\/]fake[\\/])?c/c.go:6:11: call of MyFunc123\(...\)
+([/._\-a-zA-Z0-9]+[\\/]fake[\\/])?c/c.go:6:14: unused //lint:ignore directive for printf
+`
+	const wantBNew = `# golang.org/fake/b
+([/._\-a-zA-Z0-9]+[\\/]fake[\\/])?b/b.go:7:11: call of MyFunc123\(...\)
+`
+	// The baseline holds one of the two calls in the function of b.
+	baseline := filepath.Join(exported.Temp(), "baseline.json")
+	if err := ioutil.WriteFile(baseline, []byte(`{"analyzer":"findcall","package":"golang.org/fake/b","decl":"_","message":"call of MyFunc123(...)","count":1}`+"\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
 	const wantAJSON = `# golang.org/fake/a
 \{
 	"golang.org/fake/a": \{
@@ -106,6 +130,8 @@ func MyFunc123() {}
 		{args: "golang.org/fake/a", wantOut: wantA, wantExit: 2},
 		{args: "golang.org/fake/b", wantOut: wantB, wantExit: 2},
 		{args: "golang.org/fake/a golang.org/fake/b", wantOut: wantA + wantB, wantExit: 2},
+		{args: "golang.org/fake/c", wantOut: wantC, wantExit: 2},
+		{args: "-baseline=" + baseline + " -new-only golang.org/fake/b", wantOut: wantBNew, wantExit: 2},
 		{args: "-json golang.org/fake/a", wantOut: wantAJSON, wantExit: 0},
 		{args: "-c=0 golang.org/fake/a", wantOut: wantA + "4		MyFunc123\\(\\)\n", wantExit: 2},
 	} {
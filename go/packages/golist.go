@@ -834,6 +834,7 @@ func (state *golistState) cfgInvocation() gocommand.Invocation {
 		Env:        cfg.Env,
 		Logf:       cfg.Logf,
 		WorkingDir: cfg.Dir,
+		GoCmd:      cfg.goCmd,
 	}
 }
 
@@ -150,6 +150,9 @@ type Config struct {
 	// modFlag will be used for -modfile in go command invocations.
 	modFlag string
 
+	// goCmd is the path of the go command to run, if not the one on PATH.
+	goCmd string
+
 	// Fset provides source position information for syntax trees and types.
 	// If Fset is nil, Load will use a new fileset, but preserve Fset's value.
 	Fset *token.FileSet
@@ -384,6 +387,9 @@ func init() {
 	packagesinternal.SetModFlag = func(config interface{}, value string) {
 		config.(*Config).modFlag = value
 	}
+	packagesinternal.SetGoCmd = func(config interface{}, value string) {
+		config.(*Config).goCmd = value
+	}
 	packagesinternal.TypecheckCgo = int(typecheckCgo)
 }
 
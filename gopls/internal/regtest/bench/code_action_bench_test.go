@@ -0,0 +1,99 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bench
+
+import (
+	"flag"
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"testing"
+
+	. "golang.org/x/tools/gopls/internal/regtest"
+
+	"golang.org/x/tools/internal/lsp/protocol"
+)
+
+var codeActionOptions struct {
+	workdir, file string
+}
+
+func init() {
+	flag.StringVar(&codeActionOptions.workdir, "code_action_workdir", "", "if set, run code action benchmark in this directory")
+	flag.StringVar(&codeActionOptions.file, "code_action_file", "", "relative path to the file to request code actions in")
+}
+
+// TestBenchmarkCodeAction measures the latency of a codeAction request for
+// a file whose imports have been removed, with and without support for
+// codeAction/resolve. Without it, the edits of every import fix are
+// computed up front, and the package is analyzed again for the fixes of
+// its diagnostics.
+func TestBenchmarkCodeAction(t *testing.T) {
+	if codeActionOptions.workdir == "" || codeActionOptions.file == "" {
+		t.Skip("-code_action_workdir or -code_action_file not configured")
+	}
+	for _, eager := range []bool{true, false} {
+		name := "lazy"
+		if eager {
+			name = "eager"
+		}
+		t.Run(name, func(t *testing.T) {
+			opts := stressTestOptions(codeActionOptions.workdir)
+			// Don't skip hooks, so that we can wait for IWL.
+			opts = append(opts, SkipHooks(false), EditorConfig{EagerCodeActions: eager})
+			WithOptions(opts...).Run(t, "", func(t *testing.T, env *Env) {
+				benchmarkCodeAction(t, env, codeActionOptions.file)
+			})
+		})
+	}
+}
+
+var importBlockRE = regexp.MustCompile(`(?s)\nimport \(.*?\n\)\n`)
+
+func benchmarkCodeAction(t *testing.T, env *Env, file string) {
+	env.OpenFile(file)
+
+	// Remove the imports of the file, and report each of them as an
+	// undeclared name, as the type checker would.
+	block := importBlockRE.FindString(env.Editor.BufferText(file))
+	if block == "" {
+		t.Fatalf("%s has no import block", file)
+	}
+	var diagnostics []protocol.Diagnostic
+	for _, m := range regexp.MustCompile(`(?m)^\s*(\w+ )?(".*")`).FindAllStringSubmatch(block, -1) {
+		importPath, err := strconv.Unquote(m[2])
+		if err != nil {
+			t.Fatal(err)
+		}
+		diagnostics = append(diagnostics, protocol.Diagnostic{
+			Message: "undeclared name: " + path.Base(importPath),
+		})
+	}
+	env.RegexpReplace(file, importBlockRE.String(), "\n")
+
+	params := &protocol.CodeActionParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: env.Sandbox.Workdir.URI(file)},
+		Context: protocol.CodeActionContext{
+			Only:        []protocol.CodeActionKind{protocol.QuickFix},
+			Diagnostics: diagnostics,
+		},
+	}
+	// Run one request to completion to populate the caches.
+	actions, err := env.Editor.Server.CodeAction(env.Ctx, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Printf("%d code actions for %d removed imports\n", len(actions), len(diagnostics))
+
+	results := testing.Benchmark(func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := env.Editor.Server.CodeAction(env.Ctx, params); err != nil {
+				t.Fatal(err)
+			}
+		}
+	})
+	printBenchmarkResults(results)
+}
@@ -1862,3 +1862,111 @@ package main
 		)
 	})
 }
+
+func TestWorkspaceDiagnosticsScope(t *testing.T) {
+	const files = `
+-- go.mod --
+module mod.com
+
+go 1.12
+-- a/a.go --
+package a
+
+func _() {}
+-- b/b.go --
+package b
+
+import "fmt"
+
+func _() {
+	fmt.Printf("%d", "s")
+}
+`
+	// By default, only the packages of open files are analyzed.
+	Run(t, files, func(t *testing.T, env *Env) {
+		env.OpenFile("a/a.go")
+		env.Await(
+			OnceMet(
+				env.DoneWithOpen(),
+				NoDiagnostics("b/b.go"),
+			),
+		)
+	})
+	WithOptions(
+		EditorConfig{
+			Settings: map[string]interface{}{
+				"diagnosticsScope": "workspace",
+			},
+		},
+	).Run(t, files, func(t *testing.T, env *Env) {
+		env.Await(
+			OnceMet(
+				CompletedWork(lsp.AnalyzingWorkspace, 1),
+				env.DiagnosticAtRegexpWithMessage("b/b.go", "fmt.Printf", "wrong type"),
+			),
+		)
+		// Fixing the unopened file clears its diagnostics.
+		env.WriteWorkspaceFile("b/b.go", "package b\n\nimport \"fmt\"\n\nfunc _() {\n\tfmt.Printf(\"%s\", \"s\")\n}\n")
+		env.Await(
+			EmptyDiagnostics("b/b.go"),
+		)
+	})
+}
+
+func TestIgnoreDirectives(t *testing.T) {
+	const files = `
+-- go.mod --
+module mod.com
+
+go 1.12
+-- a/a.go --
+package a
+
+import "fmt"
+
+func _() {
+	//lint:ignore printf the format is checked elsewhere
+	fmt.Printf("%d", "s")
+	fmt.Printf("%d", "t") //lint:ignore printf the format is checked elsewhere
+	fmt.Printf("%d", "u")
+	//lint:ignore unreachable
+	fmt.Println()
+}
+`
+	Run(t, files, func(t *testing.T, env *Env) {
+		env.OpenFile("a/a.go")
+		env.Await(
+			env.DiagnosticAtRegexpWithMessage("a/a.go", `fmt.Printf\("%d", "u"\)`, "wrong type"),
+			env.DiagnosticAtRegexpWithMessage("a/a.go", "//lint:ignore unreachable", "unused //lint:ignore directive for unreachable"),
+		)
+		env.Await(
+			OnceMet(
+				env.DoneWithOpen(),
+				env.NoDiagnosticAtRegexp("a/a.go", `fmt.Printf\("%d", "s"\)`),
+			),
+			OnceMet(
+				env.DoneWithOpen(),
+				env.NoDiagnosticAtRegexp("a/a.go", `fmt.Printf\("%d", "t"\)`),
+			),
+			OnceMet(
+				env.DoneWithOpen(),
+				NoDiagnosticWithMessage("a/a.go", "has no reason"),
+			),
+		)
+	})
+	WithOptions(
+		EditorConfig{
+			Settings: map[string]interface{}{
+				"requireIgnoreReason": true,
+			},
+		},
+	).Run(t, files, func(t *testing.T, env *Env) {
+		env.OpenFile("a/a.go")
+		env.Await(
+			OnceMet(
+				env.DoneWithOpen(),
+				env.DiagnosticAtRegexpWithMessage("a/a.go", "//lint:ignore unreachable", "//lint:ignore directive for unreachable has no reason"),
+			),
+		)
+	})
+}
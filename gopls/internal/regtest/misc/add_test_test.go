@@ -0,0 +1,296 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package misc
+
+import (
+	"testing"
+
+	. "golang.org/x/tools/gopls/internal/regtest"
+
+	"golang.org/x/tools/internal/lsp/protocol"
+	"golang.org/x/tools/internal/lsp/tests"
+)
+
+// addTest runs the code action that adds a test for the function whose
+// signature matches re in path.
+func addTest(t *testing.T, env *Env, path, re string) {
+	t.Helper()
+	pos := env.RegexpSearch(path, re).ToProtocolPosition()
+	actions, err := env.Editor.Server.CodeAction(env.Ctx, &protocol.CodeActionParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: env.Sandbox.Workdir.URI(path)},
+		Range:        protocol.Range{Start: pos, End: pos},
+		Context: protocol.CodeActionContext{
+			Only: []protocol.CodeActionKind{protocol.RefactorRewrite},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(actions) != 1 {
+		t.Fatalf("got %d code actions, want 1: %v", len(actions), actions)
+	}
+	if _, err := env.Editor.ExecuteCommand(env.Ctx, &protocol.ExecuteCommandParams{
+		Command:   actions[0].Command.Command,
+		Arguments: actions[0].Command.Arguments,
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAddTestNewFile(t *testing.T) {
+	const files = `
+-- go.mod --
+module mod.com
+
+go 1.12
+-- a/a.go --
+package a
+
+import "io"
+
+func Copy(dst io.Writer, src io.Reader, bufs ...[]byte) (n int64, err error) {
+	return 0, nil
+}
+
+type counter struct{ n int }
+
+func (c *counter) add(delta int) {
+	c.n += delta
+}
+`
+	const want = `package a
+
+import (
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestCopy(t *testing.T) {
+	type args struct {
+		dst  io.Writer
+		src  io.Reader
+		bufs [][]byte
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    int64
+		wantErr bool
+	}{
+		// TODO: Add test cases.
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Copy(tt.args.dst, tt.args.src, tt.args.bufs...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Copy() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Copy() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+`
+	const wantMethod = `
+func Test_counter_add(t *testing.T) {
+	type args struct {
+		delta int
+	}
+	tests := []struct {
+		name string
+		recv *counter
+		args args
+	}{
+		// TODO: Add test cases.
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.recv.add(tt.args.delta)
+		})
+	}
+}
+`
+	// Clients that cannot create files get the new file written on disk.
+	for _, noResourceOps := range []bool{false, true} {
+		WithOptions(
+			EditorConfig{NoResourceOperations: noResourceOps},
+		).Run(t, files, func(t *testing.T, env *Env) {
+			env.OpenFile("a/a.go")
+			addTest(t, env, "a/a.go", "func (Copy)")
+			var got string
+			if noResourceOps {
+				got = env.ReadWorkspaceFile("a/a_test.go")
+				env.OpenFile("a/a_test.go")
+			} else {
+				got = env.Editor.BufferText("a/a_test.go")
+			}
+			if got != want {
+				t.Errorf("a/a_test.go:\n%s", tests.Diff(t, want, got))
+			}
+			addTest(t, env, "a/a.go", `\) (add)\(`)
+			if got := env.Editor.BufferText("a/a_test.go"); got != want+wantMethod {
+				t.Errorf("a/a_test.go:\n%s", tests.Diff(t, want+wantMethod, got))
+			}
+		})
+	}
+}
+
+func TestAddTestExternal(t *testing.T) {
+	const files = `
+-- go.mod --
+module mod.com
+
+go 1.12
+-- a/a.go --
+package a
+
+type Point struct{ X, Y int }
+
+func Add(p, q Point) Point {
+	return Point{p.X + q.X, p.Y + q.Y}
+}
+
+func scale(p Point, k int) Point {
+	return Point{p.X * k, p.Y * k}
+}
+-- a/other_test.go --
+package a_test
+`
+	const want = `package a_test
+
+import (
+	"reflect"
+	"testing"
+
+	"mod.com/a"
+)
+
+func TestAdd(t *testing.T) {
+	type args struct {
+		p a.Point
+		q a.Point
+	}
+	tests := []struct {
+		name string
+		args args
+		want a.Point
+	}{
+		// TODO: Add test cases.
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := a.Add(tt.args.p, tt.args.q)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Add() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+`
+	Run(t, files, func(t *testing.T, env *Env) {
+		env.OpenFile("a/a.go")
+		addTest(t, env, "a/a.go", "func (Add)")
+		if got := env.Editor.BufferText("a/a_test.go"); got != want {
+			t.Errorf("a/a_test.go:\n%s", tests.Diff(t, want, got))
+		}
+		// The unexported function can't be tested from package a_test.
+		pos := env.RegexpSearch("a/a.go", "func (scale)").ToProtocolPosition()
+		actions, err := env.Editor.Server.CodeAction(env.Ctx, &protocol.CodeActionParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: env.Sandbox.Workdir.URI("a/a.go")},
+			Range:        protocol.Range{Start: pos, End: pos},
+			Context: protocol.CodeActionContext{
+				Only: []protocol.CodeActionKind{protocol.RefactorRewrite},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := env.Editor.ExecuteCommand(env.Ctx, &protocol.ExecuteCommandParams{
+			Command:   actions[0].Command.Command,
+			Arguments: actions[0].Command.Arguments,
+		}); err == nil {
+			t.Error("AddTest of an unexported function succeeded in package a_test")
+		}
+	})
+}
+
+// A dot import leaves the names of its package unqualified, and a blank import
+// declares no name, so the package is imported again.
+func TestAddTestDotAndBlankImports(t *testing.T) {
+	const files = `
+-- go.mod --
+module mod.com
+
+go 1.12
+-- a/a.go --
+package a
+
+type Point struct{ X, Y int }
+
+func Add(p, q Point) Point {
+	return Point{p.X + q.X, p.Y + q.Y}
+}
+-- a/a_test.go --
+package a_test
+
+import (
+	_ "reflect"
+	. "testing"
+
+	. "mod.com/a"
+)
+
+var _ = Point{}
+
+var _ *T
+`
+	const want = `package a_test
+
+import (
+	"reflect"
+	_ "reflect"
+	. "testing"
+
+	. "mod.com/a"
+)
+
+var _ = Point{}
+
+var _ *T
+
+func TestAdd(t *T) {
+	type args struct {
+		p Point
+		q Point
+	}
+	tests := []struct {
+		name string
+		args args
+		want Point
+	}{
+		// TODO: Add test cases.
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *T) {
+			got := Add(tt.args.p, tt.args.q)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Add() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+`
+	Run(t, files, func(t *testing.T, env *Env) {
+		env.OpenFile("a/a.go")
+		env.OpenFile("a/a_test.go")
+		addTest(t, env, "a/a.go", "func (Add)")
+		if got := env.Editor.BufferText("a/a_test.go"); got != want {
+			t.Errorf("a/a_test.go:\n%s", tests.Diff(t, want, got))
+		}
+	})
+}
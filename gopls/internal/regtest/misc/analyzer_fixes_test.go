@@ -0,0 +1,91 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package misc
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "golang.org/x/tools/gopls/internal/regtest"
+
+	"golang.org/x/tools/internal/lsp/command"
+	"golang.org/x/tools/internal/lsp/fake"
+	"golang.org/x/tools/internal/lsp/protocol"
+)
+
+func TestAnalyzerFixes(t *testing.T) {
+	const files = `
+-- go.mod --
+module mod.com
+
+go 1.12
+-- a/a.go --
+package a
+
+type T struct{ A int }
+
+var _ = []T{T{A: 1}}
+-- a/b.go --
+package a
+
+var _ = [][]T{[]T{T{A: 2}}}
+`
+	Run(t, files, func(t *testing.T, env *Env) {
+		cmd, err := command.NewAnalyzerFixesCommand("", command.AnalyzerFixesArgs{
+			URIs: []protocol.DocumentURI{
+				env.Sandbox.Workdir.URI("a/a.go"),
+				env.Sandbox.Workdir.URI("a/b.go"),
+			},
+			Analyzers: []string{"simplifycompositelit"},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, err := env.Editor.ExecuteCommand(env.Ctx, &protocol.ExecuteCommandParams{
+			Command:   cmd.Command,
+			Arguments: cmd.Arguments,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The result is decoded from JSON when gopls runs remotely.
+		data, err := json.Marshal(res)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var result command.AnalyzerFixesResult
+		if err := json.Unmarshal(data, &result); err != nil {
+			t.Fatal(err)
+		}
+		if len(result.Fixes) != 3 || len(result.Conflicts) != 0 {
+			t.Fatalf("AnalyzerFixes: got %d fixes and %d conflicts, want 3 fixes and no conflicts", len(result.Fixes), len(result.Conflicts))
+		}
+
+		want := map[string]string{
+			"a/a.go": "package a\n\ntype T struct{ A int }\n\nvar _ = []T{{A: 1}}\n",
+			"a/b.go": "package a\n\nvar _ = [][]T{{{A: 2}}}\n",
+		}
+		for _, change := range result.Edits {
+			name := env.Sandbox.Workdir.URIToPath(change.TextDocument.URI)
+			env.OpenFile(name)
+			var edits []fake.Edit
+			for _, edit := range change.Edits {
+				edits = append(edits, fake.NewEdit(
+					int(edit.Range.Start.Line), int(edit.Range.Start.Character),
+					int(edit.Range.End.Line), int(edit.Range.End.Character),
+					edit.NewText,
+				))
+			}
+			env.EditBuffer(name, edits...)
+			if got := env.Editor.BufferText(name); got != want[name] {
+				t.Errorf("%s after the fixes:\n%s\nwant:\n%s", name, got, want[name])
+			}
+			delete(want, name)
+		}
+		for name := range want {
+			t.Errorf("AnalyzerFixes: no edits of %s", name)
+		}
+	})
+}
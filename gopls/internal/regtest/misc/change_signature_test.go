@@ -0,0 +1,260 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package misc
+
+import (
+	"strings"
+	"testing"
+
+	. "golang.org/x/tools/gopls/internal/regtest"
+
+	"golang.org/x/tools/internal/lsp/command"
+	"golang.org/x/tools/internal/lsp/protocol"
+	"golang.org/x/tools/internal/lsp/tests"
+)
+
+func TestChangeSignature(t *testing.T) {
+	const files = `
+-- go.mod --
+module mod.com
+
+go 1.12
+-- a/a.go --
+package a
+
+func Greet(greeting string, name string, n int) string {
+	return greeting + name
+}
+
+var F func(string, string, int) string = Greet
+-- main.go --
+package main
+
+import (
+	"fmt"
+
+	"mod.com/a"
+)
+
+func next() int { return 1 }
+
+func main() {
+	fmt.Println(a.Greet("hello, ", "world", 1))
+	fmt.Println(a.Greet("hi, ", a.Greet("a", "b", 2), next()))
+}
+`
+	const wantA = `package a
+
+func Greet(name string, greeting string, excl bool) string {
+	return greeting + name
+}
+
+var F func(string, string, int) string = func(greeting string, name string, n int) string { return Greet(name, greeting, false) }
+`
+	const wantMain = `package main
+
+import (
+	"fmt"
+
+	"mod.com/a"
+)
+
+func next() int { return 1 }
+
+func main() {
+	fmt.Println(a.Greet("world", "hello, ", false))
+	fmt.Println(a.Greet("hi, ", a.Greet("b", "a", false), next()))
+}
+`
+	Run(t, files, func(t *testing.T, env *Env) {
+		env.OpenFile("a/a.go")
+		env.OpenFile("main.go")
+		cmd, err := command.NewChangeSignatureCommand("", command.ChangeSignatureArgs{
+			URI:      env.Sandbox.Workdir.URI("a/a.go"),
+			Position: env.RegexpSearch("a/a.go", "Greet").ToProtocolPosition(),
+			Params: []command.SignatureParam{
+				{OldIndex: 1},
+				{OldIndex: 0},
+				{OldIndex: -1, Name: "excl", Type: "bool", Value: "false"},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := env.Editor.ExecuteCommand(env.Ctx, &protocol.ExecuteCommandParams{
+			Command:   cmd.Command,
+			Arguments: cmd.Arguments,
+		}); err != nil {
+			t.Fatal(err)
+		}
+		env.Await(ShownMessage("as it would no longer evaluate next()"))
+		if got := env.Editor.BufferText("a/a.go"); got != wantA {
+			t.Errorf("a/a.go:\n%s", tests.Diff(t, wantA, got))
+		}
+		if got := env.Editor.BufferText("main.go"); got != wantMain {
+			t.Errorf("main.go:\n%s", tests.Diff(t, wantMain, got))
+		}
+	})
+}
+
+func TestChangeSignatureInterface(t *testing.T) {
+	const files = `
+-- go.mod --
+module mod.com
+
+go 1.12
+-- main.go --
+package main
+
+type Shape interface {
+	Scale(x, y, z float64)
+}
+
+type Square struct{ size float64 }
+
+func (s *Square) Scale(x, y, z float64) { s.size *= x }
+
+type Circle struct{ r float64 }
+
+func (c *Circle) Scale(x, y, z float64) { c.r *= x }
+
+func main() {
+	var s Shape = &Square{1}
+	s.Scale(2, 3, 4)
+}
+`
+	const want = `package main
+
+type Shape interface {
+	Scale(x float64)
+}
+
+type Square struct{ size float64 }
+
+func (s *Square) Scale(x float64) { s.size *= x }
+
+type Circle struct{ r float64 }
+
+func (c *Circle) Scale(x float64) { c.r *= x }
+
+func main() {
+	var s Shape = &Square{1}
+	s.Scale(2)
+}
+`
+	Run(t, files, func(t *testing.T, env *Env) {
+		env.OpenFile("main.go")
+		cmd, err := command.NewChangeSignatureCommand("", command.ChangeSignatureArgs{
+			URI:      env.Sandbox.Workdir.URI("main.go"),
+			Position: env.RegexpSearch("main.go", `\*Square\) (Scale)`).ToProtocolPosition(),
+			Params:   []command.SignatureParam{{OldIndex: 0}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := env.Editor.ExecuteCommand(env.Ctx, &protocol.ExecuteCommandParams{
+			Command:   cmd.Command,
+			Arguments: cmd.Arguments,
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if got := env.Editor.BufferText("main.go"); got != want {
+			t.Errorf("main.go:\n%s", tests.Diff(t, want, got))
+		}
+	})
+}
+
+func TestChangeSignatureRefused(t *testing.T) {
+	const files = `
+-- go.mod --
+module mod.com
+
+go 1.12
+-- main.go --
+package main
+
+type Shape interface {
+	Scale(x, y float64)
+}
+
+type Square struct{ size float64 }
+
+func (s *Square) Scale(x, y float64) { s.size *= x }
+
+type Rect struct{ w, h float64 }
+
+func (r *Rect) Scale(x, y float64) { r.w *= x; r.h *= y }
+
+func main() {
+	var s Shape = &Square{1}
+	s.Scale(2, 3)
+}
+`
+	Run(t, files, func(t *testing.T, env *Env) {
+		env.OpenFile("main.go")
+		cmd, err := command.NewChangeSignatureCommand("", command.ChangeSignatureArgs{
+			URI:      env.Sandbox.Workdir.URI("main.go"),
+			Position: env.RegexpSearch("main.go", `\*Square\) (Scale)`).ToProtocolPosition(),
+			Params:   []command.SignatureParam{{OldIndex: 0}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Rect uses y, so removing it would break the build.
+		_, err = env.Editor.ExecuteCommand(env.Ctx, &protocol.ExecuteCommandParams{
+			Command:   cmd.Command,
+			Arguments: cmd.Arguments,
+		})
+		if err == nil || !strings.Contains(err.Error(), "parameter y is used") {
+			t.Fatalf("ExecuteCommand: got error %v, want parameter y is used", err)
+		}
+		if got := env.Editor.BufferText("main.go"); got != files[strings.Index(files, "package main"):] {
+			t.Errorf("main.go was changed:\n%s", got)
+		}
+	})
+}
+
+func TestRemoveUnusedParameter(t *testing.T) {
+	const files = `
+-- go.mod --
+module mod.com
+
+go 1.12
+-- main.go --
+package main
+
+func add(x, unused, y int) int {
+	return x + y
+}
+
+func main() {
+	_ = add(1, 2, 3)
+}
+`
+	const want = `package main
+
+func add(x, y int) int {
+	return x + y
+}
+
+func main() {
+	_ = add(1, 3)
+}
+`
+	WithOptions(
+		EditorConfig{AllExperiments: true},
+	).Run(t, files, func(t *testing.T, env *Env) {
+		env.OpenFile("main.go")
+		var d protocol.PublishDiagnosticsParams
+		env.Await(OnceMet(
+			env.DiagnosticAtRegexpWithMessage("main.go", "unused", "potentially unused parameter"),
+			ReadDiagnostics("main.go", &d),
+		))
+		env.ApplyQuickFixes("main.go", d.Diagnostics)
+		if got := env.Editor.BufferText("main.go"); got != want {
+			t.Errorf("main.go:\n%s", tests.Diff(t, want, got))
+		}
+	})
+}
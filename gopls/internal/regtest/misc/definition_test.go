@@ -179,3 +179,83 @@ func main() {}
 		})
 	}
 }
+
+const declarations = `
+-- go.mod --
+module mod.com
+
+go 1.12
+-- a/a.go --
+package a
+
+type Shape interface {
+	Area() int
+}
+
+type Square struct{ size int }
+
+func (s Square) Area() int { return s.size * s.size }
+
+type Named struct {
+	Name string
+}
+
+type Labeled struct {
+	Named
+	Square
+}
+
+func use(l Labeled) (string, int) {
+	return l.Name, l.Area()
+}
+
+func squares() []*Square { return nil }
+
+var s = squares()
+
+func newSquare() (Square, error) { return Square{}, nil }
+
+var (
+	grid  [2]Square
+	queue chan *Square
+	index map[string]Square
+)
+`
+
+func TestGoToDeclaration(t *testing.T) {
+	Run(t, declarations, func(t *testing.T, env *Env) {
+		env.OpenFile("a/a.go")
+		for _, test := range []struct {
+			from, to string
+		}{
+			// A method of a concrete type goes to the interface method.
+			{`\) (Area)\(\) int {`, `(?m)(Area)\(\) int$`},
+			// A promoted field goes to the embedded field.
+			{`l\.(Name)`, `(?m)^\t(Named)$`},
+			{`l\.(Area)`, `(?m)^\t(Square)$`},
+			// Other identifiers go to their definition.
+			{`\(l (Labeled)\)`, `type (Labeled)`},
+		} {
+			_, pos := env.GoToDeclaration("a/a.go", env.RegexpSearch("a/a.go", test.from))
+			if want := env.RegexpSearch("a/a.go", test.to); pos != want {
+				t.Errorf("GoToDeclaration(%q): got position %v, want %v", test.from, pos, want)
+			}
+		}
+	})
+}
+
+func TestGoToTypeDefinition(t *testing.T) {
+	Run(t, declarations, func(t *testing.T, env *Env) {
+		env.OpenFile("a/a.go")
+		for _, from := range []string{`var (s) =`, `func (newSquare)`, `(grid) `, `(queue) `} {
+			_, pos := env.GoToTypeDefinition("a/a.go", env.RegexpSearch("a/a.go", from))
+			if want := env.RegexpSearch("a/a.go", `type (Square)`); pos != want {
+				t.Errorf("GoToTypeDefinition(%q): got position %v, want %v", from, pos, want)
+			}
+		}
+		// A map has two types, and no location.
+		if path, _ := env.GoToTypeDefinition("a/a.go", env.RegexpSearch("a/a.go", `(index) `)); path != "" {
+			t.Errorf("GoToTypeDefinition(index): got a location in %s, want none", path)
+		}
+	})
+}
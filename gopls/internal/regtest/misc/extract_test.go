@@ -0,0 +1,154 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package misc
+
+import (
+	"testing"
+
+	. "golang.org/x/tools/gopls/internal/regtest"
+	"golang.org/x/tools/internal/lsp/protocol"
+)
+
+func TestMoveDeclarations(t *testing.T) {
+	const files = `
+-- go.mod --
+module mod.com
+
+go 1.12
+-- main.go --
+package main
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Point is a point in the plane.
+type Point struct{ X, Y int }
+
+func (p Point) String() string {
+	return strconv.Itoa(p.X) + "," + strconv.Itoa(p.Y)
+}
+
+func main() {
+	fmt.Println(Point{1,2})
+}
+`
+	const wantNew = `package main
+
+import (
+	"strconv"
+)
+
+// Point is a point in the plane.
+type Point struct{ X, Y int }
+
+func (p Point) String() string {
+	return strconv.Itoa(p.X) + "," + strconv.Itoa(p.Y)
+}
+`
+	const wantOld = `package main
+
+import (
+	"fmt"
+)
+
+func main() {
+	fmt.Println(Point{1,2})
+}
+`
+	// Clients that cannot create files get the new file written on disk.
+	for _, noResourceOps := range []bool{false, true} {
+		WithOptions(
+			EditorConfig{NoResourceOperations: noResourceOps},
+		).Run(t, files, func(t *testing.T, env *Env) {
+			env.OpenFile("main.go")
+			start := env.RegexpSearch("main.go", "// Point is").ToProtocolPosition()
+			end := env.RegexpSearch("main.go", "func main").ToProtocolPosition()
+			actions, err := env.Editor.Server.CodeAction(env.Ctx, &protocol.CodeActionParams{
+				TextDocument: protocol.TextDocumentIdentifier{URI: env.Sandbox.Workdir.URI("main.go")},
+				Range:        protocol.Range{Start: start, End: end},
+				Context: protocol.CodeActionContext{
+					Only: []protocol.CodeActionKind{protocol.RefactorExtract},
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var move *protocol.Command
+			for _, action := range actions {
+				if action.Title == "Move declarations to new file" {
+					move = action.Command
+				}
+			}
+			if move == nil {
+				t.Fatalf("no code action to move declarations in %v", actions)
+			}
+			if _, err := env.Editor.ExecuteCommand(env.Ctx, &protocol.ExecuteCommandParams{
+				Command:   move.Command,
+				Arguments: move.Arguments,
+			}); err != nil {
+				t.Fatal(err)
+			}
+			var got string
+			if noResourceOps {
+				got = env.ReadWorkspaceFile("point.go")
+			} else {
+				got = env.Editor.BufferText("point.go")
+			}
+			if got != wantNew {
+				t.Errorf("new file:\n%s\nwant:\n%s", got, wantNew)
+			}
+			if got := env.Editor.BufferText("main.go"); got != wantOld {
+				t.Errorf("old file:\n%s\nwant:\n%s", got, wantOld)
+			}
+		})
+	}
+}
+
+// The receiver is only used if the selection refers to the receiver object
+// itself, not to another variable of the same name.
+func TestExtractMethodShadowedReceiver(t *testing.T) {
+	const files = `
+-- go.mod --
+module mod.com
+
+go 1.12
+-- main.go --
+package main
+
+type A struct{ x int }
+
+func (a *A) F() int {
+	{
+		a := 1
+		b := a + 1
+		return b
+	}
+}
+`
+	Run(t, files, func(t *testing.T, env *Env) {
+		env.OpenFile("main.go")
+		start := env.RegexpSearch("main.go", "a := 1").ToProtocolPosition()
+		end := env.RegexpSearch("main.go", "return b").ToProtocolPosition()
+		actions, err := env.Editor.Server.CodeAction(env.Ctx, &protocol.CodeActionParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: env.Sandbox.Workdir.URI("main.go")},
+			Range:        protocol.Range{Start: start, End: end},
+			Context: protocol.CodeActionContext{
+				Only: []protocol.CodeActionKind{protocol.RefactorExtract},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var titles []string
+		for _, action := range actions {
+			titles = append(titles, action.Title)
+		}
+		if len(titles) != 1 || titles[0] != "Extract to function" {
+			t.Errorf("got code actions %q, want only Extract to function", titles)
+		}
+	})
+}
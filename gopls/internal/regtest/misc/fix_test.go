@@ -61,6 +61,110 @@ func Foo() {
 	})
 }
 
+// Code actions for fixes are resolved lazily for clients that support it.
+func TestFillStructResolve(t *testing.T) {
+	const basic = `
+-- go.mod --
+module mod.com
+
+go 1.14
+-- main.go --
+package main
+
+type Info struct {
+	Words []string
+}
+
+func Foo() {
+	_ = Info{}
+}
+`
+	Run(t, basic, func(t *testing.T, env *Env) {
+		env.OpenFile("main.go")
+		pos := env.RegexpSearch("main.go", "Info{}").ToProtocolPosition()
+		actions, err := env.Editor.Server.CodeAction(env.Ctx, &protocol.CodeActionParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: env.Sandbox.Workdir.URI("main.go")},
+			Range:        protocol.Range{Start: pos, End: pos},
+			Context: protocol.CodeActionContext{
+				Only: []protocol.CodeActionKind{protocol.RefactorRewrite},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(actions) != 1 {
+			t.Fatalf("got %d code actions, want 1", len(actions))
+		}
+		action := actions[0]
+		if action.Data == nil || action.Command != nil || len(action.Edit.DocumentChanges) > 0 {
+			t.Fatalf("code action %q was not deferred: %+v", action.Title, action)
+		}
+		resolved, err := env.Editor.Server.ResolveCodeAction(env.Ctx, &action)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(resolved.Edit.DocumentChanges) == 0 {
+			t.Fatalf("resolved code action %q has no edits", resolved.Title)
+		}
+	})
+}
+
+// The edits of import fixes are computed only when the fix is resolved.
+func TestImportFixResolve(t *testing.T) {
+	const files = `
+-- go.mod --
+module mod.com
+
+go 1.14
+-- main.go --
+package main
+
+func main() {
+	fmt.Println("hello")
+}
+`
+	const want = `package main
+
+import "fmt"
+
+func main() {
+	fmt.Println("hello")
+}
+`
+	for _, eager := range []bool{true, false} {
+		WithOptions(
+			EditorConfig{EagerCodeActions: eager},
+		).Run(t, files, func(t *testing.T, env *Env) {
+			env.OpenFile("main.go")
+			var d protocol.PublishDiagnosticsParams
+			env.Await(OnceMet(
+				env.DiagnosticAtRegexpWithMessage("main.go", `fmt`, "undeclared name"),
+				ReadDiagnostics("main.go", &d),
+			))
+			actions, err := env.Editor.Server.CodeAction(env.Ctx, &protocol.CodeActionParams{
+				TextDocument: protocol.TextDocumentIdentifier{URI: env.Sandbox.Workdir.URI("main.go")},
+				Context: protocol.CodeActionContext{
+					Only:        []protocol.CodeActionKind{protocol.QuickFix},
+					Diagnostics: d.Diagnostics,
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(actions) != 1 {
+				t.Fatalf("got %d code actions, want 1", len(actions))
+			}
+			if deferred := actions[0].Data != nil && len(actions[0].Edit.DocumentChanges) == 0; deferred == eager {
+				t.Fatalf("eager = %t, but code action %q has data %v and %d changes", eager, actions[0].Title, actions[0].Data, len(actions[0].Edit.DocumentChanges))
+			}
+			env.ApplyQuickFixes("main.go", d.Diagnostics)
+			if got := env.Editor.BufferText("main.go"); got != want {
+				t.Errorf("main.go:\n%s", tests.Diff(t, want, got))
+			}
+		})
+	}
+}
+
 func TestFillReturns(t *testing.T) {
 	const files = `
 -- go.mod --
@@ -10,6 +10,7 @@ import (
 
 	. "golang.org/x/tools/gopls/internal/regtest"
 
+	"golang.org/x/tools/internal/lsp/fake"
 	"golang.org/x/tools/internal/lsp/tests"
 )
 
@@ -240,3 +241,82 @@ type Tree struct {
 		})
 	}
 }
+
+const legacyProgram = `
+-- go.mod --
+module mod.com
+
+go 1.12
+-- main.go --
+package main
+
+import "fmt"
+
+func   untouched( ) {
+	fmt.Println(  "a")
+}
+
+func main() {
+	x :=   1
+	if x>0 {
+	fmt.Println( x )
+	}
+	y :=   2
+	_ = y
+}
+`
+
+func TestFormatRange(t *testing.T) {
+	const want = `package main
+
+import "fmt"
+
+func   untouched( ) {
+	fmt.Println(  "a")
+}
+
+func main() {
+	x :=   1
+	if x > 0 {
+		fmt.Println(x)
+	}
+	y :=   2
+	_ = y
+}
+`
+	Run(t, legacyProgram, func(t *testing.T, env *Env) {
+		env.OpenFile("main.go")
+		// Select from within the if condition to within the call: only the
+		// enclosing if statement should be reformatted.
+		env.FormatRange("main.go", fake.Range{
+			Start: fake.Pos{Line: 10, Column: 5},
+			End:   fake.Pos{Line: 11, Column: 5},
+		})
+		got := env.Editor.BufferText("main.go")
+		if got != want {
+			t.Errorf("unexpected formatting result:\n%s", tests.Diff(t, want, got))
+		}
+	})
+}
+
+func TestFormatOnType(t *testing.T) {
+	Run(t, legacyProgram, func(t *testing.T, env *Env) {
+		env.OpenFile("main.go")
+
+		// Typing the closing brace of the if statement reformats it.
+		env.FormatOnType("main.go", fake.Pos{Line: 12, Column: 2}, "}")
+		got := env.Editor.BufferText("main.go")
+		want := strings.Replace(env.ReadWorkspaceFile("main.go"), "if x>0 {\n\tfmt.Println( x )\n", "if x > 0 {\n\t\tfmt.Println(x)\n", 1)
+		if got != want {
+			t.Fatalf("unexpected formatting after '}':\n%s", tests.Diff(t, want, got))
+		}
+
+		// Typing a newline reformats the line that was just ended.
+		env.FormatOnType("main.go", fake.Pos{Line: 14, Column: 0}, "\n")
+		got = env.Editor.BufferText("main.go")
+		want = strings.Replace(want, "y :=   2", "y := 2", 1)
+		if got != want {
+			t.Errorf("unexpected formatting after newline:\n%s", tests.Diff(t, want, got))
+		}
+	})
+}
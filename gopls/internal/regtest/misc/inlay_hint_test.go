@@ -0,0 +1,137 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package misc
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	. "golang.org/x/tools/gopls/internal/regtest"
+
+	"golang.org/x/tools/internal/lsp/command"
+	"golang.org/x/tools/internal/lsp/protocol"
+)
+
+func TestInlayHints(t *testing.T) {
+	const files = `
+-- go.mod --
+module mod.com
+
+go 1.12
+-- a.go --
+package a
+
+type Kind int
+
+const (
+	KindNone Kind = iota
+	KindPrint
+	_
+	KindLog
+)
+
+const Limit = 10
+
+type pair struct {
+	in, want string
+}
+
+func join(sep string, parts ...string) string { return "" }
+
+func _() {
+	sep := ","
+	n, s := Limit, join(sep, "a", "b")
+	for i, p := range []pair{{"a", "b"}, {in: "c", want: "d"}} {
+		_, _, _, _ = n, s, i, p
+	}
+	_ = Kind(1)
+}
+`
+	tests := []struct {
+		setting string
+		want    []string
+	}{
+		{"parameterNameHints", []string{`join(sep, <parts...: >"a", "b")`}},
+		{"assignVariableTypeHints", []string{`sep< string> :=`, `n< int>, s< string> :=`}},
+		{"rangeVariableTypeHints", []string{`for i< int>, p< pair> :=`}},
+		{"constantValueHints", []string{`KindNone Kind = iota< = 0>`, `KindPrint< = 1>`, `KindLog< = 3>`}},
+		{"compositeLiteralFieldHints", []string{`{{<in: >"a", <want: >"b"}`}},
+	}
+	for _, test := range tests {
+		t.Run(test.setting, func(t *testing.T) {
+			WithOptions(
+				EditorConfig{
+					Settings: map[string]interface{}{
+						test.setting: true,
+					},
+				},
+			).Run(t, files, func(t *testing.T, env *Env) {
+				env.OpenFile("a.go")
+				got := inlayHints(env, "a.go")
+				for _, want := range test.want {
+					if !strings.Contains(got, want) {
+						t.Errorf("inlay hints of a.go do not contain %q:\n%s", want, got)
+					}
+				}
+				if n, want := strings.Count(got, "<"), strings.Count(strings.Join(test.want, ""), "<"); n != want {
+					t.Errorf("got %d hints, want %d:\n%s", n, want, got)
+				}
+			})
+		})
+	}
+}
+
+// inlayHints returns the content of the file name with its inlay hints
+// inserted between angle brackets, and padding rendered as spaces.
+func inlayHints(env *Env, name string) string {
+	env.T.Helper()
+	cmd, err := command.NewInlayHintCommand("", protocol.InlayHintParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: env.Sandbox.Workdir.URI(name)},
+	})
+	if err != nil {
+		env.T.Fatal(err)
+	}
+	res, err := env.Editor.ExecuteCommand(env.Ctx, &protocol.ExecuteCommandParams{
+		Command:   cmd.Command,
+		Arguments: cmd.Arguments,
+	})
+	if err != nil {
+		env.T.Fatal(err)
+	}
+	// The result is decoded from JSON when gopls runs remotely.
+	data, err := json.Marshal(res)
+	if err != nil {
+		env.T.Fatal(err)
+	}
+	var hints []protocol.InlayHint
+	if err := json.Unmarshal(data, &hints); err != nil {
+		env.T.Fatal(err)
+	}
+	// Insert the hints from the end of the file, so that the positions of
+	// the remaining ones stay valid.
+	sort.SliceStable(hints, func(i, j int) bool {
+		return protocol.ComparePosition(hints[i].Position, hints[j].Position) > 0
+	})
+	lines := strings.Split(env.Editor.BufferText(name), "\n")
+	for _, h := range hints {
+		var label string
+		for _, part := range h.Label {
+			label += part.Value
+		}
+		if h.PaddingLeft {
+			label = " " + label
+		}
+		if h.PaddingRight {
+			label += " "
+		}
+		line := lines[h.Position.Line]
+		col := int(h.Position.Character)
+		lines[h.Position.Line] = fmt.Sprintf("%s<%s>%s", line[:col], label, line[col:])
+	}
+	return strings.Join(lines, "\n")
+}
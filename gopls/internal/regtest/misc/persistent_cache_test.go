@@ -0,0 +1,93 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package misc
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	. "golang.org/x/tools/gopls/internal/regtest"
+
+	"golang.org/x/tools/internal/lsp/filecache"
+)
+
+func TestPersistentCache(t *testing.T) {
+	const files = `
+-- go.mod --
+module mod.com
+
+go 1.12
+-- main.go --
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+func main() {
+	var b strings.Builder
+	b.WriteString("hello")
+	fmt.Printf("%d", b.String())
+}
+`
+	dir, err := ioutil.TempDir("", "TestPersistentCache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	// The in-process server reads the location of the cache from the
+	// environment of the test.
+	defer os.Setenv("GOPLSCACHE", os.Getenv("GOPLSCACHE"))
+	os.Setenv("GOPLSCACHE", dir)
+
+	opts := []RunOption{
+		Modes(Singleton),
+		EditorConfig{
+			Settings: map[string]interface{}{
+				"experimentalPersistentCache": true,
+			},
+		},
+	}
+	// The second run reuses the entries written by the first one.
+	for i := 0; i < 2; i++ {
+		WithOptions(opts...).Run(t, files, func(t *testing.T, env *Env) {
+			env.OpenFile("main.go")
+			env.Await(env.DiagnosticAtRegexpWithMessage("main.go", `fmt.Printf`, "wrong type"))
+
+			name, pos := env.GoToDefinition("main.go", env.RegexpSearch("main.go", `b.(WriteString)`))
+			if got, want := path.Base(name), "builder.go"; got != want {
+				t.Fatalf("GoToDefinition: got file %q, want %q", name, want)
+			}
+			env.OpenFile(name)
+			if want := env.RegexpSearch(name, `\) (WriteString)\(`); pos != want {
+				t.Errorf("GoToDefinition: got position %v, want %v", pos, want)
+			}
+			content, _ := env.Hover("main.go", env.RegexpSearch("main.go", `strings.(Builder)`))
+			if !strings.Contains(content.Value, "A Builder is used") {
+				t.Errorf("Hover: got %q, want the documentation of strings.Builder", content.Value)
+			}
+		})
+	}
+
+	fc, err := filecache.Open(dir, filecache.DefaultMaxSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	usage, err := fc.Usage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	kinds := make(map[string]bool)
+	for _, u := range usage {
+		kinds[u.Kind] = u.Entries > 0
+	}
+	if !kinds["export"] || !kinds["analysis"] {
+		t.Errorf("got cache usage %+v, want export data and analysis entries", usage)
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package misc
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "golang.org/x/tools/gopls/internal/regtest"
+
+	"golang.org/x/tools/internal/lsp/command"
+	"golang.org/x/tools/internal/lsp/protocol"
+)
+
+func TestPullDiagnostics(t *testing.T) {
+	const files = `
+-- go.mod --
+module mod.com
+
+go 1.12
+-- a/a.go --
+package a
+
+var x int = "hello"
+-- b/b.go --
+package b
+
+var y string = 1
+-- c/c.go --
+package c
+
+func f(x int) {
+	x = x
+}
+`
+	Run(t, files, func(t *testing.T, env *Env) {
+		env.OpenFile("a/a.go")
+		uri := env.Sandbox.Workdir.URI("a/a.go")
+		pull := func(previousResultID string) protocol.DocumentDiagnosticReport {
+			var report protocol.DocumentDiagnosticReport
+			pullDiagnostics(env, command.DocumentDiagnostic, protocol.DocumentDiagnosticParams{
+				TextDocument:     protocol.TextDocumentIdentifier{URI: uri},
+				PreviousResultID: previousResultID,
+			}, &report)
+			return report
+		}
+
+		report := pull("")
+		if report.Kind != protocol.DiagnosticFull || len(report.Items) != 1 || report.ResultID == "" {
+			t.Fatalf("DocumentDiagnostic: got %+v, want a full report with 1 diagnostic", report)
+		}
+		if unchanged := pull(report.ResultID); unchanged.Kind != protocol.DiagnosticUnchanged || unchanged.ResultID != report.ResultID {
+			t.Errorf("DocumentDiagnostic(%s): got %+v, want an unchanged report", report.ResultID, unchanged)
+		}
+
+		env.RegexpReplace("a/a.go", `"hello"`, "1")
+		fixed := pull(report.ResultID)
+		if fixed.Kind != protocol.DiagnosticFull || len(fixed.Items) != 0 || fixed.ResultID == report.ResultID {
+			t.Errorf("DocumentDiagnostic after edit: got %+v, want a new full report without diagnostics", fixed)
+		}
+
+		// The workspace report includes the files that are not open.
+		var workspace protocol.WorkspaceDiagnosticReport
+		pullDiagnostics(env, command.WorkspaceDiagnostic, protocol.WorkspaceDiagnosticParams{
+			PreviousResultIds: []protocol.PreviousResultID{{URI: uri, Value: fixed.ResultID}},
+		}, &workspace)
+		byURI := make(map[protocol.DocumentURI]protocol.WorkspaceDocumentDiagnosticReport)
+		for _, item := range workspace.Items {
+			byURI[item.URI] = item
+		}
+		if item := byURI[uri]; item.Kind != protocol.DiagnosticUnchanged || item.Version == nil {
+			t.Errorf("WorkspaceDiagnostic: got %+v for a/a.go, want an unchanged report with a version", item)
+		}
+		if item := byURI[env.Sandbox.Workdir.URI("b/b.go")]; item.Kind != protocol.DiagnosticFull || len(item.Items) != 1 || item.Version != nil {
+			t.Errorf("WorkspaceDiagnostic: got %+v for b/b.go, want a full report with 1 diagnostic and no version", item)
+		}
+		// Pulled diagnostics include the analyses of the packages without
+		// open files.
+		if item := byURI[env.Sandbox.Workdir.URI("c/c.go")]; item.Kind != protocol.DiagnosticFull || len(item.Items) != 1 {
+			t.Errorf("WorkspaceDiagnostic: got %+v for c/c.go, want a full report with 1 diagnostic", item)
+		}
+
+		// Pulling diagnostics does not affect the published diagnostics: when
+		// checking for upgrades diagnoses again the snapshot they were pulled
+		// from, the analyses of c are not published, as c has no open file.
+		args, err := command.MarshalArgs(command.CheckUpgradesArgs{
+			URI: env.Sandbox.Workdir.URI("go.mod"),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := env.Editor.ExecuteCommand(env.Ctx, &protocol.ExecuteCommandParams{
+			Command:   command.CheckUpgrades.ID(),
+			Arguments: args,
+		}); err != nil {
+			t.Fatal(err)
+		}
+		env.Await(
+			OnceMet(
+				CompletedWork("Checking for upgrades", 1),
+				NoDiagnostics("c/c.go"),
+			),
+			env.DiagnosticAtRegexp("b/b.go", "1"),
+		)
+	})
+}
+
+// pullDiagnostics executes the pull diagnostics command cmd with the given
+// argument, and decodes its result into report.
+func pullDiagnostics(env *Env, cmd command.Command, arg, report interface{}) {
+	env.T.Helper()
+	args, err := command.MarshalArgs(arg)
+	if err != nil {
+		env.T.Fatal(err)
+	}
+	res, err := env.Editor.ExecuteCommand(env.Ctx, &protocol.ExecuteCommandParams{
+		Command:   cmd.ID(),
+		Arguments: args,
+	})
+	if err != nil {
+		env.T.Fatal(err)
+	}
+	// The result is decoded from JSON when gopls runs remotely.
+	data, err := json.Marshal(res)
+	if err != nil {
+		env.T.Fatal(err)
+	}
+	if err := json.Unmarshal(data, report); err != nil {
+		env.T.Fatal(err)
+	}
+}
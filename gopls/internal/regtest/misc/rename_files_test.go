@@ -0,0 +1,173 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package misc
+
+import (
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	. "golang.org/x/tools/gopls/internal/regtest"
+	"golang.org/x/tools/internal/lsp/protocol"
+)
+
+const renameFilesProgram = `
+-- go.mod --
+module mod.com
+
+go 1.12
+-- main.go --
+package main
+
+import (
+	"mod.com/greet"
+	"mod.com/greet/sub"
+)
+
+func main() {
+	greet.Hello()
+	sub.Hi()
+}
+-- greet/greet.go --
+package greet
+
+func Hello() {}
+-- greet/greet_test.go --
+package greet_test
+
+import (
+	"testing"
+
+	"mod.com/greet"
+)
+
+func TestHello(t *testing.T) {
+	greet.Hello()
+}
+-- greet/sub/sub.go --
+package sub
+
+func Hi() {}
+-- other/other.go --
+package other
+-- other/helper_test.go --
+package other_test
+`
+
+// renameFilesEdits requests the edits for renaming from to to, and returns
+// them as "path: new text" strings in a stable order.
+func renameFilesEdits(t *testing.T, env *Env, from, to string) []string {
+	t.Helper()
+	edit, err := env.Editor.Server.WillRenameFiles(env.Ctx, &protocol.RenameFilesParams{
+		Files: []protocol.FileRename{{
+			OldURI: string(env.Sandbox.Workdir.URI(from)),
+			NewURI: string(env.Sandbox.Workdir.URI(to)),
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	if edit == nil {
+		return got
+	}
+	for _, change := range edit.DocumentChanges {
+		path := env.Sandbox.Workdir.URIToPath(change.TextDocumentEdit.TextDocument.URI)
+		for _, e := range change.TextDocumentEdit.Edits {
+			got = append(got, path+": "+e.NewText)
+		}
+	}
+	sort.Strings(got)
+	return got
+}
+
+func TestWillRenameDirectory(t *testing.T) {
+	Run(t, renameFilesProgram, func(t *testing.T, env *Env) {
+		env.Await(InitialWorkspaceLoad)
+		got := renameFilesEdits(t, env, "greet", "salute")
+		want := []string{
+			`greet/greet.go: salute`,
+			`greet/greet_test.go: greet "mod.com/salute"`,
+			`greet/greet_test.go: salute_test`,
+			`main.go: "mod.com/salute/sub"`,
+			`main.go: greet "mod.com/salute"`,
+		}
+		if !reflect.DeepEqual(want, got) {
+			t.Errorf("renaming directory: got edits\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+		}
+	})
+}
+
+func TestWillRenameTestFile(t *testing.T) {
+	Run(t, renameFilesProgram, func(t *testing.T, env *Env) {
+		env.Await(InitialWorkspaceLoad)
+
+		// Leaving the external test package.
+		got := renameFilesEdits(t, env, "other/helper_test.go", "other/helper.go")
+		if want := []string{"other/helper_test.go: other"}; !reflect.DeepEqual(want, got) {
+			t.Errorf("renaming test file: got %v, want %v", got, want)
+		}
+
+		// Joining the external test package used by the directory's tests.
+		got = renameFilesEdits(t, env, "other/other.go", "other/other_test.go")
+		if want := []string{"other/other.go: other_test"}; !reflect.DeepEqual(want, got) {
+			t.Errorf("renaming to test file: got %v, want %v", got, want)
+		}
+
+		// Moving into another package.
+		got = renameFilesEdits(t, env, "other/other.go", "greet/other.go")
+		if want := []string{"other/other.go: greet"}; !reflect.DeepEqual(want, got) {
+			t.Errorf("moving file: got %v, want %v", got, want)
+		}
+	})
+}
+
+func TestWillRenameOutsideModule(t *testing.T) {
+	Run(t, renameFilesProgram, func(t *testing.T, env *Env) {
+		env.Await(InitialWorkspaceLoad)
+		got := renameFilesEdits(t, env, "greet", "../greet")
+		if len(got) > 0 {
+			t.Errorf("moving directory out of the module: got edits %v, want none", got)
+		}
+		env.Await(ShownMessage("outside the module"))
+	})
+}
+
+func TestRenameFilesRegistration(t *testing.T) {
+	Run(t, renameFilesProgram, func(t *testing.T, env *Env) {
+		env.Await(
+			RegistrationMatching("workspace/willRenameFiles"),
+			RegistrationMatching("workspace/didRenameFiles"),
+		)
+	})
+}
+
+func TestDidRenameDirectory(t *testing.T) {
+	Run(t, renameFilesProgram, func(t *testing.T, env *Env) {
+		env.OpenFile("main.go")
+		env.Await(env.DoneWithOpen(), NoDiagnostics("main.go"))
+
+		// Move the directory behind the editor's back, and only report the
+		// rename.
+		from, to := env.Sandbox.Workdir.AbsPath("greet/sub"), env.Sandbox.Workdir.AbsPath("greet/sub2")
+		if err := os.Rename(from, to); err != nil {
+			t.Fatal(err)
+		}
+		if err := env.Editor.Server.DidRenameFiles(env.Ctx, &protocol.RenameFilesParams{
+			Files: []protocol.FileRename{{
+				OldURI: string(env.Sandbox.Workdir.URI("greet/sub")),
+				NewURI: string(env.Sandbox.Workdir.URI("greet/sub2")),
+			}},
+		}); err != nil {
+			t.Fatal(err)
+		}
+		env.Await(env.DiagnosticAtRegexp("main.go", `"mod.com/greet/sub"`))
+
+		env.RegexpReplace("main.go", `greet/sub"`, `greet/sub2"`)
+		env.Await(env.DoneWithChange(), EmptyDiagnostics("main.go"))
+	})
+}
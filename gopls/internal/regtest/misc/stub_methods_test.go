@@ -0,0 +1,235 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package misc
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "golang.org/x/tools/gopls/internal/regtest"
+
+	"golang.org/x/tools/internal/lsp/command"
+	"golang.org/x/tools/internal/lsp/protocol"
+	"golang.org/x/tools/internal/lsp/tests"
+)
+
+func TestStubMethods(t *testing.T) {
+	const files = `
+-- go.mod --
+module mod.com
+
+go 1.12
+-- a/a.go --
+package a
+
+type Reader interface {
+	Read(p []byte) (n int, err error)
+}
+-- main.go --
+package main
+
+import "mod.com/a"
+
+type T struct{}
+
+func (t *T) Close() error { return nil }
+
+type ReadCloser interface {
+	a.Reader
+	Close() error
+}
+
+var _ ReadCloser = &T{}
+`
+	const want = `package main
+
+import "mod.com/a"
+
+type T struct{}
+
+func (t *T) Read(p []byte) (n int, err error) {
+	panic("unimplemented")
+}
+
+func (t *T) Close() error { return nil }
+
+type ReadCloser interface {
+	a.Reader
+	Close() error
+}
+
+var _ ReadCloser = &T{}
+`
+	Run(t, files, func(t *testing.T, env *Env) {
+		env.OpenFile("main.go")
+		var d protocol.PublishDiagnosticsParams
+		env.Await(OnceMet(
+			env.DiagnosticAtRegexpWithMessage("main.go", `&T{}`, "missing method Read"),
+			ReadDiagnostics("main.go", &d),
+		))
+		env.ApplyQuickFixes("main.go", d.Diagnostics)
+		if got := env.Editor.BufferText("main.go"); got != want {
+			t.Errorf("main.go:\n%s", tests.Diff(t, want, got))
+		}
+	})
+}
+
+func TestImplementInterface(t *testing.T) {
+	const files = `
+-- go.mod --
+module mod.com
+
+go 1.12
+-- a/a.go --
+package a
+
+import "io"
+
+type Writer interface {
+	Write(b []byte, w io.Writer, opts ...string) error
+	Flush()
+}
+-- b/b.go --
+package b
+
+import "mod.com/a"
+
+var W a.Writer
+-- b/t.go --
+package b
+
+type T struct {
+	n int
+}
+`
+	const want = `package b
+
+import "io"
+
+type T struct {
+	n int
+}
+
+func (t T) Write(b []byte, w io.Writer, opts ...string) error {
+	panic("unimplemented")
+}
+
+func (t T) Flush() {
+	panic("unimplemented")
+}
+`
+	Run(t, files, func(t *testing.T, env *Env) {
+		env.OpenFile("b/t.go")
+		cmd, err := command.NewListInterfacesCommand("", command.URIArg{
+			URI: env.Sandbox.Workdir.URI("b/t.go"),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, err := env.Editor.ExecuteCommand(env.Ctx, &protocol.ExecuteCommandParams{
+			Command:   cmd.Command,
+			Arguments: cmd.Arguments,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The result is decoded from JSON when gopls runs remotely.
+		data, err := json.Marshal(res)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var result command.ListInterfacesResult
+		if err := json.Unmarshal(data, &result); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := len(result.Interfaces), 2; got != want || result.Interfaces[1] != "mod.com/a.Writer" {
+			t.Fatalf("ListInterfaces: got %v, want [error mod.com/a.Writer]", result.Interfaces)
+		}
+		cmd, err = command.NewImplementInterfaceCommand("", command.ImplementInterfaceArgs{
+			URI:       env.Sandbox.Workdir.URI("b/t.go"),
+			Position:  env.RegexpSearch("b/t.go", "T").ToProtocolPosition(),
+			Interface: result.Interfaces[1],
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := env.Editor.ExecuteCommand(env.Ctx, &protocol.ExecuteCommandParams{
+			Command:   cmd.Command,
+			Arguments: cmd.Arguments,
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if got := env.Editor.BufferText("b/t.go"); got != want {
+			t.Errorf("b/t.go:\n%s", tests.Diff(t, want, got))
+		}
+	})
+}
+
+// The stubs use the names of the imports of the file, and added imports are
+// renamed if their names are already declared.
+func TestStubMethodsImportNames(t *testing.T) {
+	const files = `
+-- go.mod --
+module mod.com
+
+go 1.12
+-- a/a.go --
+package a
+
+import (
+	"bytes"
+	"io"
+)
+
+type Reader interface {
+	Read(r io.Reader, buf *bytes.Buffer) error
+}
+-- main.go --
+package main
+
+import (
+	myio "io"
+
+	"mod.com/a"
+)
+
+var bytes myio.Reader
+
+type T struct{}
+
+var _ a.Reader = T{}
+`
+	const want = `package main
+
+import (
+	bytes2 "bytes"
+	myio "io"
+
+	"mod.com/a"
+)
+
+var bytes myio.Reader
+
+type T struct{}
+
+func (t T) Read(r myio.Reader, buf *bytes2.Buffer) error {
+	panic("unimplemented")
+}
+
+var _ a.Reader = T{}
+`
+	Run(t, files, func(t *testing.T, env *Env) {
+		env.OpenFile("main.go")
+		var d protocol.PublishDiagnosticsParams
+		env.Await(OnceMet(
+			env.DiagnosticAtRegexpWithMessage("main.go", `T{}`, "missing method Read"),
+			ReadDiagnostics("main.go", &d),
+		))
+		env.ApplyQuickFixes("main.go", d.Diagnostics)
+		if got := env.Editor.BufferText("main.go"); got != want {
+			t.Errorf("main.go:\n%s", tests.Diff(t, want, got))
+		}
+	})
+}
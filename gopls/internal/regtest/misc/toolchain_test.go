@@ -0,0 +1,78 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package misc
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	. "golang.org/x/tools/gopls/internal/regtest"
+)
+
+func TestToolchainSelection(t *testing.T) {
+	const files = `
+-- go.mod --
+module mod.com
+
+go 1.12
+-- a/a.go --
+package a
+
+import "fmt"
+
+func _() {
+	fmt.Println()
+}
+`
+	sdk, err := ioutil.TempDir("", "sdk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(sdk)
+	if err := os.Symlink(runtime.GOROOT(), filepath.Join(sdk, "go1.12.1")); err != nil {
+		t.Skipf("cannot link the toolchain: %v", err)
+	}
+
+	// The links to the standard library are those of the version of the
+	// selected toolchain.
+	want := "pkg.go.dev/fmt@" + runtime.Version()
+	for _, test := range []struct {
+		name     string
+		settings map[string]interface{}
+	}{
+		{"goroot", map[string]interface{}{"goroot": runtime.GOROOT()}},
+		{"toolchainsDir", map[string]interface{}{"toolchainsDir": sdk}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			WithOptions(
+				EditorConfig{Settings: test.settings},
+				Modes(Singleton),
+			).Run(t, files, func(t *testing.T, env *Env) {
+				env.OpenFile("a/a.go")
+				content, _ := env.Hover("a/a.go", env.RegexpSearch("a/a.go", "Println"))
+				if !strings.Contains(content.Value, want) {
+					t.Errorf("hover: got %q, want a link to %s", content.Value, want)
+				}
+			})
+		})
+	}
+
+	// Without a toolchain for the version of the go directive, the go command
+	// on PATH is run.
+	WithOptions(
+		EditorConfig{Settings: map[string]interface{}{"toolchainsDir": filepath.Join(sdk, "missing")}},
+		Modes(Singleton),
+	).Run(t, files, func(t *testing.T, env *Env) {
+		env.OpenFile("a/a.go")
+		content, _ := env.Hover("a/a.go", env.RegexpSearch("a/a.go", "Println"))
+		if strings.Contains(content.Value, "@") {
+			t.Errorf("hover: got %q, want a link without version", content.Value)
+		}
+	})
+}
@@ -0,0 +1,137 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package misc
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	. "golang.org/x/tools/gopls/internal/regtest"
+
+	"golang.org/x/tools/internal/lsp/command"
+	"golang.org/x/tools/internal/lsp/protocol"
+)
+
+func TestTypeHierarchy(t *testing.T) {
+	const files = `
+-- go.mod --
+module mod.com
+
+go 1.12
+-- a/a.go --
+package a
+
+type Shape interface {
+	Area() int
+}
+
+type Solid interface {
+	Shape
+	Volume() int
+}
+
+type Square struct{ size int }
+
+func (s Square) Area() int { return s.size * s.size }
+
+type Cube struct{ Square }
+
+func (c *Cube) Volume() int { return c.Area() * c.size }
+-- b/b.go --
+package b
+
+import "mod.com/a"
+
+type Circle struct{}
+
+func (Circle) Area() int { return 3 }
+
+var _ a.Shape = Circle{}
+`
+	Run(t, files, func(t *testing.T, env *Env) {
+		env.OpenFile("b/b.go")
+		items := typeHierarchy(env, command.PrepareTypeHierarchy, protocol.TypeHierarchyPrepareParams{
+			TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+				TextDocument: protocol.TextDocumentIdentifier{URI: env.Sandbox.Workdir.URI("b/b.go")},
+				Position:     env.RegexpSearch("b/b.go", `a.(Shape)`).ToProtocolPosition(),
+			},
+		})
+		if got, want := itemNames(items), "[Shape]"; got != want {
+			t.Fatalf("PrepareTypeHierarchy: got %s, want %s", got, want)
+		}
+		shape := items[0]
+		if shape.Kind != protocol.Interface || shape.URI != env.Sandbox.Workdir.URI("a/a.go") {
+			t.Errorf("PrepareTypeHierarchy: got item %+v, want interface in a/a.go", shape)
+		}
+
+		subtypes := typeHierarchy(env, command.TypeHierarchySubtypes, protocol.TypeHierarchySubtypesParams{Item: shape})
+		if got, want := itemNames(subtypes), "[Solid Square Cube Circle]"; got != want {
+			t.Errorf("TypeHierarchySubtypes(Shape): got %s, want %s", got, want)
+		}
+		supertypes := typeHierarchy(env, command.TypeHierarchySupertypes, protocol.TypeHierarchySupertypesParams{Item: shape})
+		if got, want := itemNames(supertypes), "[]"; got != want {
+			t.Errorf("TypeHierarchySupertypes(Shape): got %s, want %s", got, want)
+		}
+
+		// Expand the hierarchy from the items that it returned.
+		for _, item := range subtypes {
+			var want string
+			switch item.Name {
+			case "Solid", "Square", "Circle":
+				want = "[Shape]"
+			case "Cube":
+				want = "[Shape Solid]"
+			}
+			supertypes := typeHierarchy(env, command.TypeHierarchySupertypes, protocol.TypeHierarchySupertypesParams{Item: item})
+			if got := itemNames(supertypes); got != want {
+				t.Errorf("TypeHierarchySupertypes(%s): got %s, want %s", item.Name, got, want)
+			}
+			want = "[]"
+			if item.Name == "Solid" {
+				want = "[Cube]"
+			}
+			subtypes := typeHierarchy(env, command.TypeHierarchySubtypes, protocol.TypeHierarchySubtypesParams{Item: item})
+			if got := itemNames(subtypes); got != want {
+				t.Errorf("TypeHierarchySubtypes(%s): got %s, want %s", item.Name, got, want)
+			}
+		}
+	})
+}
+
+// typeHierarchy executes the type hierarchy command cmd with the given
+// argument.
+func typeHierarchy(env *Env, cmd command.Command, arg interface{}) []protocol.TypeHierarchyItem {
+	env.T.Helper()
+	args, err := command.MarshalArgs(arg)
+	if err != nil {
+		env.T.Fatal(err)
+	}
+	res, err := env.Editor.ExecuteCommand(env.Ctx, &protocol.ExecuteCommandParams{
+		Command:   cmd.ID(),
+		Arguments: args,
+	})
+	if err != nil {
+		env.T.Fatal(err)
+	}
+	// The result is decoded from JSON when gopls runs remotely.
+	data, err := json.Marshal(res)
+	if err != nil {
+		env.T.Fatal(err)
+	}
+	var items []protocol.TypeHierarchyItem
+	if err := json.Unmarshal(data, &items); err != nil {
+		env.T.Fatal(err)
+	}
+	return items
+}
+
+func itemNames(items []protocol.TypeHierarchyItem) string {
+	var names []string
+	for _, item := range items {
+		names = append(names, item.Name)
+	}
+	return fmt.Sprint(names)
+}
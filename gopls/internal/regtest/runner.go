@@ -243,7 +243,7 @@ func (r *Runner) Run(t *testing.T, files string, test TestFunc, opts ...RunOptio
 		t.Run(tc.name, func(t *testing.T) {
 			ctx, cancel := context.WithTimeout(context.Background(), config.timeout)
 			defer cancel()
-			ctx = debug.WithInstance(ctx, "", "off")
+			ctx = debug.WithInstance(ctx, "", "off", "off")
 			if config.debugAddr != "" {
 				di := debug.GetInstance(ctx)
 				di.DebugAddress = config.debugAddr
@@ -391,7 +391,7 @@ func (r *Runner) getTestServer() *servertest.TCPServer {
 	defer r.mu.Unlock()
 	if r.ts == nil {
 		ctx := context.Background()
-		ctx = debug.WithInstance(ctx, "", "off")
+		ctx = debug.WithInstance(ctx, "", "off", "off")
 		ss := lsprpc.NewStreamServer(cache.New(ctx, hooks.Options), false)
 		r.ts = servertest.NewTCPServer(ctx, ss, nil)
 	}
@@ -165,6 +165,28 @@ func (e *Env) GoToDefinition(name string, pos fake.Pos) (string, fake.Pos) {
 	return n, p
 }
 
+// GoToDeclaration goes to declaration in the editor, calling t.Fatal on any
+// error.
+func (e *Env) GoToDeclaration(name string, pos fake.Pos) (string, fake.Pos) {
+	e.T.Helper()
+	n, p, err := e.Editor.GoToDeclaration(e.Ctx, name, pos)
+	if err != nil {
+		e.T.Fatal(err)
+	}
+	return n, p
+}
+
+// GoToTypeDefinition goes to type definition in the editor, calling t.Fatal
+// on any error.
+func (e *Env) GoToTypeDefinition(name string, pos fake.Pos) (string, fake.Pos) {
+	e.T.Helper()
+	n, p, err := e.Editor.GoToTypeDefinition(e.Ctx, name, pos)
+	if err != nil {
+		e.T.Fatal(err)
+	}
+	return n, p
+}
+
 // Symbol returns symbols matching query
 func (e *Env) Symbol(query string) []fake.SymbolInformation {
 	e.T.Helper()
@@ -183,6 +205,24 @@ func (e *Env) FormatBuffer(name string) {
 	}
 }
 
+// FormatRange formats the given range of the editor buffer, calling
+// t.Fatal on any error.
+func (e *Env) FormatRange(name string, rng fake.Range) {
+	e.T.Helper()
+	if err := e.Editor.FormatRange(e.Ctx, name, rng); err != nil {
+		e.T.Fatal(err)
+	}
+}
+
+// FormatOnType applies the formatting edits for typing ch at pos in the
+// editor buffer, calling t.Fatal on any error.
+func (e *Env) FormatOnType(name string, pos fake.Pos, ch string) {
+	e.T.Helper()
+	if err := e.Editor.FormatOnType(e.Ctx, name, pos, ch); err != nil {
+		e.T.Fatal(err)
+	}
+}
+
 // OrganizeImports processes the source.organizeImports codeAction, calling
 // t.Fatal on any error.
 func (e *Env) OrganizeImports(name string) {
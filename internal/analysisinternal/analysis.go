@@ -212,6 +212,7 @@ type TypeErrorPass string
 const (
 	NoNewVars      TypeErrorPass = "nonewvars"
 	NoResultValues TypeErrorPass = "noresultvalues"
+	StubMethods    TypeErrorPass = "stubmethods"
 	UndeclaredName TypeErrorPass = "undeclaredname"
 )
 
@@ -0,0 +1,363 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package otlp adds the ability to export metrics to an OpenTelemetry
+// collector, using the JSON encoding of the OTLP/HTTP protocol.
+// Like the ocagent exporter, it has no compile time dependencies on the
+// OpenTelemetry libraries.
+package otlp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"golang.org/x/tools/internal/event"
+	"golang.org/x/tools/internal/event/core"
+	"golang.org/x/tools/internal/event/export/metric"
+	"golang.org/x/tools/internal/event/keys"
+	"golang.org/x/tools/internal/event/label"
+)
+
+type Config struct {
+	Start   time.Time
+	Host    string
+	Process uint32
+	Client  *http.Client
+	Service string
+	// Address is the base URL of the collector, such as
+	// http://localhost:4318. Metrics are posted to its /v1/metrics path.
+	Address string
+	Rate    time.Duration
+}
+
+var (
+	connectMu sync.Mutex
+	exporters = make(map[Config]*Exporter)
+)
+
+// An Exporter sends the metrics it processes to a collector.
+//
+// Metrics are sent cumulatively: each flush sends the latest value of the
+// metrics that changed since the previous flush.
+type Exporter struct {
+	mu      sync.Mutex
+	config  Config
+	metrics map[string]metric.Data // by handle, the metrics not yet sent
+}
+
+// Connect creates a process specific exporter that uploads its metrics to
+// the collector at the address of config. It returns nil if the address is
+// empty or "off".
+func Connect(config *Config) *Exporter {
+	if config == nil || config.Address == "" || config.Address == "off" {
+		return nil
+	}
+	resolved := *config
+	if resolved.Host == "" {
+		hostname, _ := os.Hostname()
+		resolved.Host = hostname
+	}
+	if resolved.Process == 0 {
+		resolved.Process = uint32(os.Getpid())
+	}
+	if resolved.Client == nil {
+		resolved.Client = http.DefaultClient
+	}
+	if resolved.Service == "" {
+		resolved.Service = filepath.Base(os.Args[0])
+	}
+	if resolved.Rate == 0 {
+		resolved.Rate = 10 * time.Second
+	}
+
+	connectMu.Lock()
+	defer connectMu.Unlock()
+	if exporter, found := exporters[resolved]; found {
+		return exporter
+	}
+	exporter := &Exporter{
+		config:  resolved,
+		metrics: make(map[string]metric.Data),
+	}
+	exporters[resolved] = exporter
+	if exporter.config.Start.IsZero() {
+		exporter.config.Start = time.Now()
+	}
+	go func() {
+		for range time.Tick(exporter.config.Rate) {
+			exporter.Flush()
+		}
+	}()
+	return exporter
+}
+
+func (e *Exporter) ProcessEvent(ctx context.Context, ev core.Event, lm label.Map) context.Context {
+	if !event.IsMetric(ev) {
+		return ctx
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, data := range metric.Entries.Get(lm).([]metric.Data) {
+		e.metrics[data.Handle()] = data
+	}
+	return ctx
+}
+
+// Flush sends the metrics that changed since the last flush to the
+// collector.
+func (e *Exporter) Flush() {
+	e.mu.Lock()
+	pending := e.metrics
+	e.metrics = make(map[string]metric.Data)
+	e.mu.Unlock()
+	if len(pending) == 0 {
+		return
+	}
+
+	handles := make([]string, 0, len(pending))
+	for handle := range pending {
+		handles = append(handles, handle)
+	}
+	sort.Strings(handles)
+	var metrics []*Metric
+	for _, handle := range handles {
+		if m := convertMetric(pending[handle], e.config.Start); m != nil {
+			metrics = append(metrics, m)
+		}
+	}
+	e.send("/v1/metrics", &ExportMetricsServiceRequest{
+		ResourceMetrics: []*ResourceMetrics{{
+			Resource: e.config.buildResource(),
+			ScopeMetrics: []*ScopeMetrics{{
+				Scope:   &InstrumentationScope{Name: "golang.org/x/tools"},
+				Metrics: metrics,
+			}},
+		}},
+	})
+}
+
+func (cfg *Config) buildResource() *Resource {
+	return &Resource{
+		Attributes: []*KeyValue{
+			stringAttribute("service.name", cfg.Service),
+			stringAttribute("host.name", cfg.Host),
+			intAttribute("process.pid", int64(cfg.Process)),
+		},
+	}
+}
+
+func (e *Exporter) send(endpoint string, message interface{}) {
+	blob, err := json.Marshal(message)
+	if err != nil {
+		errorInExport("otlp failed to marshal message for %v: %v", endpoint, err)
+		return
+	}
+	uri := e.config.Address + endpoint
+	req, err := http.NewRequest("POST", uri, bytes.NewReader(blob))
+	if err != nil {
+		errorInExport("otlp failed to build request for %v: %v", uri, err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	res, err := e.config.Client.Do(req)
+	if err != nil {
+		errorInExport("otlp failed to send message: %v \n", err)
+		return
+	}
+	if res.Body != nil {
+		res.Body.Close()
+	}
+}
+
+func errorInExport(message string, args ...interface{}) {
+	// This function is useful when debugging the exporter, but in general we
+	// want to just drop any export
+}
+
+func convertTimestamp(t time.Time) uint64 {
+	if t.IsZero() {
+		return 0
+	}
+	return uint64(t.UnixNano())
+}
+
+func convertMetric(data metric.Data, start time.Time) *Metric {
+	switch data := data.(type) {
+	case *metric.Int64Data:
+		points := make([]*NumberDataPoint, len(data.Rows))
+		for i, row := range data.Rows {
+			row := row
+			points[i] = &NumberDataPoint{
+				Attributes:        convertAttributes(data.Groups()[i]),
+				StartTimeUnixNano: convertTimestamp(start),
+				TimeUnixNano:      convertTimestamp(data.EndTime),
+				AsInt:             &row,
+			}
+		}
+		return numberMetric(data.Info, data.IsGauge, points)
+	case *metric.Float64Data:
+		points := make([]*NumberDataPoint, len(data.Rows))
+		for i, row := range data.Rows {
+			row := row
+			points[i] = &NumberDataPoint{
+				Attributes:        convertAttributes(data.Groups()[i]),
+				StartTimeUnixNano: convertTimestamp(start),
+				TimeUnixNano:      convertTimestamp(data.EndTime),
+				AsDouble:          &row,
+			}
+		}
+		return numberMetric(data.Info, data.IsGauge, points)
+	case *metric.HistogramInt64Data:
+		bounds := make([]float64, len(data.Info.Buckets))
+		for i, b := range data.Info.Buckets {
+			bounds[i] = float64(b)
+		}
+		points := make([]*HistogramDataPoint, len(data.Rows))
+		for i, row := range data.Rows {
+			sum, min, max := float64(row.Sum), float64(row.Min), float64(row.Max)
+			points[i] = &HistogramDataPoint{
+				Attributes:        convertAttributes(data.Groups()[i]),
+				StartTimeUnixNano: convertTimestamp(start),
+				TimeUnixNano:      convertTimestamp(data.EndTime),
+				Count:             uint64(row.Count),
+				Sum:               &sum,
+				BucketCounts:      convertBuckets(row.Values, row.Count),
+				ExplicitBounds:    bounds,
+				Min:               &min,
+				Max:               &max,
+			}
+		}
+		return histogramMetric(data.Info.Name, data.Info.Description, points)
+	case *metric.HistogramFloat64Data:
+		points := make([]*HistogramDataPoint, len(data.Rows))
+		for i, row := range data.Rows {
+			sum, min, max := row.Sum, row.Min, row.Max
+			points[i] = &HistogramDataPoint{
+				Attributes:        convertAttributes(data.Groups()[i]),
+				StartTimeUnixNano: convertTimestamp(start),
+				TimeUnixNano:      convertTimestamp(data.EndTime),
+				Count:             uint64(row.Count),
+				Sum:               &sum,
+				BucketCounts:      convertBuckets(row.Values, row.Count),
+				ExplicitBounds:    data.Info.Buckets,
+				Min:               &min,
+				Max:               &max,
+			}
+		}
+		return histogramMetric(data.Info.Name, data.Info.Description, points)
+	}
+	return nil
+}
+
+func numberMetric(info *metric.Scalar, isGauge bool, points []*NumberDataPoint) *Metric {
+	m := &Metric{
+		Name:        info.Name,
+		Description: info.Description,
+	}
+	if isGauge {
+		m.Gauge = &Gauge{DataPoints: points}
+	} else {
+		m.Sum = &Sum{
+			DataPoints:             points,
+			AggregationTemporality: CumulativeTemporality,
+			IsMonotonic:            true,
+		}
+	}
+	return m
+}
+
+func histogramMetric(name, description string, points []*HistogramDataPoint) *Metric {
+	return &Metric{
+		Name:        name,
+		Description: description,
+		Histogram: &Histogram{
+			DataPoints:             points,
+			AggregationTemporality: CumulativeTemporality,
+		},
+	}
+}
+
+// convertBuckets converts the cumulative bucket counts of a metric histogram
+// row to the per bucket counts of OTLP, which include the count of the
+// values above the last bound.
+func convertBuckets(values []int64, count int64) []Uint64 {
+	counts := make([]Uint64, len(values)+1)
+	var prev int64
+	for i, v := range values {
+		counts[i] = Uint64(v - prev)
+		prev = v
+	}
+	counts[len(values)] = Uint64(count - prev)
+	return counts
+}
+
+func convertAttributes(group []label.Label) []*KeyValue {
+	var attributes []*KeyValue
+	for _, l := range group {
+		if !l.Valid() {
+			continue
+		}
+		attributes = append(attributes, convertAttribute(l))
+	}
+	return attributes
+}
+
+func stringAttribute(key, value string) *KeyValue {
+	return &KeyValue{Key: key, Value: &AnyValue{StringValue: &value}}
+}
+
+func intAttribute(key string, value int64) *KeyValue {
+	return &KeyValue{Key: key, Value: &AnyValue{IntValue: &value}}
+}
+
+func convertAttribute(l label.Label) *KeyValue {
+	name := l.Key().Name()
+	switch key := l.Key().(type) {
+	case *keys.Int:
+		return intAttribute(name, int64(key.From(l)))
+	case *keys.Int8:
+		return intAttribute(name, int64(key.From(l)))
+	case *keys.Int16:
+		return intAttribute(name, int64(key.From(l)))
+	case *keys.Int32:
+		return intAttribute(name, int64(key.From(l)))
+	case *keys.Int64:
+		return intAttribute(name, key.From(l))
+	case *keys.UInt:
+		return intAttribute(name, int64(key.From(l)))
+	case *keys.UInt8:
+		return intAttribute(name, int64(key.From(l)))
+	case *keys.UInt16:
+		return intAttribute(name, int64(key.From(l)))
+	case *keys.UInt32:
+		return intAttribute(name, int64(key.From(l)))
+	case *keys.UInt64:
+		return intAttribute(name, int64(key.From(l)))
+	case *keys.Float32:
+		v := float64(key.From(l))
+		return &KeyValue{Key: name, Value: &AnyValue{DoubleValue: &v}}
+	case *keys.Float64:
+		v := key.From(l)
+		return &KeyValue{Key: name, Value: &AnyValue{DoubleValue: &v}}
+	case *keys.Boolean:
+		v := key.From(l)
+		return &KeyValue{Key: name, Value: &AnyValue{BoolValue: &v}}
+	case *keys.String:
+		return stringAttribute(name, key.From(l))
+	case *keys.Error:
+		return stringAttribute(name, key.From(l).Error())
+	case *keys.Value:
+		return stringAttribute(name, fmt.Sprint(key.From(l)))
+	default:
+		return stringAttribute(name, fmt.Sprintf("%T", key))
+	}
+}
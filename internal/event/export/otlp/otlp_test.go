@@ -0,0 +1,155 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package otlp_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"golang.org/x/tools/internal/event"
+	"golang.org/x/tools/internal/event/export"
+	"golang.org/x/tools/internal/event/export/metric"
+	"golang.org/x/tools/internal/event/export/otlp"
+	"golang.org/x/tools/internal/event/keys"
+	"golang.org/x/tools/internal/event/label"
+)
+
+var (
+	keyMethod = keys.NewString("method", "a metric grouping key")
+	latencyMs = keys.NewFloat64("latency", "The latency in milliseconds")
+	loaded    = keys.NewInt64("loaded", "Number of packages loaded")
+	started   = keys.NewInt64("started", "Count of started calls")
+
+	metricLatency = metric.HistogramFloat64{
+		Name:        "latency_ms",
+		Description: "The latency of calls in milliseconds",
+		Keys:        []label.Key{keyMethod},
+		Buckets:     []float64{10, 100},
+	}
+
+	metricLoaded = metric.HistogramInt64{
+		Name:        "loaded",
+		Description: "The number of packages loaded",
+		Buckets:     []int64{1, 10},
+	}
+
+	metricStarted = metric.Scalar{
+		Name:        "started",
+		Description: "The number of started calls",
+		Keys:        []label.Key{keyMethod},
+	}
+)
+
+func TestExport(t *testing.T) {
+	requests := make(chan *otlp.ExportMetricsServiceRequest, 1)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/metrics" {
+			t.Errorf("got request to %s, want /v1/metrics", r.URL.Path)
+		}
+		req := &otlp.ExportMetricsServiceRequest{}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			t.Error(err)
+		}
+		requests <- req
+	}))
+	defer collector.Close()
+
+	start := time.Unix(100, 0)
+	exporter := otlp.Connect(&otlp.Config{
+		Start:   start,
+		Host:    "tester",
+		Process: 1,
+		Service: "otlp-tests",
+		Address: collector.URL,
+		Rate:    time.Hour, // flushed explicitly
+	})
+	metrics := metric.Config{}
+	metricLatency.Record(&metrics, latencyMs)
+	metricLoaded.Record(&metrics, loaded)
+	metricStarted.Count(&metrics, started)
+	event.SetExporter(export.Labels(metrics.Exporter(exporter.ProcessEvent)))
+	defer event.SetExporter(nil)
+
+	ctx := context.Background()
+	for _, v := range []float64{5, 50, 500} {
+		event.Metric(ctx, latencyMs.Of(v), keyMethod.Of("hover"), started.Of(1))
+	}
+	event.Metric(ctx, latencyMs.Of(20), keyMethod.Of("definition"))
+	event.Metric(ctx, loaded.Of(3))
+	exporter.Flush()
+
+	got := <-requests
+	if len(got.ResourceMetrics) != 1 || len(got.ResourceMetrics[0].ScopeMetrics) != 1 {
+		t.Fatalf("got %d resource metrics, want 1 with 1 scope", len(got.ResourceMetrics))
+	}
+	var service string
+	for _, a := range got.ResourceMetrics[0].Resource.Attributes {
+		if a.Key == "service.name" && a.Value.StringValue != nil {
+			service = *a.Value.StringValue
+		}
+	}
+	if service != "otlp-tests" {
+		t.Errorf("got service.name %q, want %q", service, "otlp-tests")
+	}
+	byName := make(map[string]*otlp.Metric)
+	for _, m := range got.ResourceMetrics[0].ScopeMetrics[0].Metrics {
+		byName[m.Name] = m
+	}
+
+	latency := byName["latency_ms"]
+	if latency == nil || latency.Histogram == nil || len(latency.Histogram.DataPoints) != 2 {
+		t.Fatalf("got latency metric %+v, want a histogram with 2 data points", latency)
+	}
+	for _, p := range latency.Histogram.DataPoints {
+		if len(p.Attributes) != 1 || p.Attributes[0].Key != "method" {
+			t.Fatalf("got attributes %+v, want method", p.Attributes)
+		}
+		if p.StartTimeUnixNano != uint64(start.UnixNano()) {
+			t.Errorf("got start time %d, want %d", p.StartTimeUnixNano, start.UnixNano())
+		}
+		var wantCount uint64
+		var wantBuckets []otlp.Uint64
+		switch method := *p.Attributes[0].Value.StringValue; method {
+		case "hover":
+			wantCount, wantBuckets = 3, []otlp.Uint64{1, 1, 1}
+		case "definition":
+			wantCount, wantBuckets = 1, []otlp.Uint64{0, 1, 0}
+		default:
+			t.Fatalf("unexpected method %q", method)
+		}
+		if p.Count != wantCount || !reflect.DeepEqual(p.BucketCounts, wantBuckets) {
+			t.Errorf("got count %d and buckets %v, want %d and %v", p.Count, p.BucketCounts, wantCount, wantBuckets)
+		}
+	}
+
+	loadedMetric := byName["loaded"]
+	if loadedMetric == nil || loadedMetric.Histogram == nil || len(loadedMetric.Histogram.DataPoints) != 1 {
+		t.Fatalf("got loaded metric %+v, want a histogram with 1 data point", loadedMetric)
+	}
+	if p := loadedMetric.Histogram.DataPoints[0]; *p.Sum != 3 || !reflect.DeepEqual(p.BucketCounts, []otlp.Uint64{0, 1, 0}) {
+		t.Errorf("got loaded sum %v and buckets %v, want 3 and [0 1 0]", *p.Sum, p.BucketCounts)
+	}
+
+	startedMetric := byName["started"]
+	if startedMetric == nil || startedMetric.Sum == nil || len(startedMetric.Sum.DataPoints) != 1 {
+		t.Fatalf("got started metric %+v, want a sum with 1 data point", startedMetric)
+	}
+	if sum := startedMetric.Sum; !sum.IsMonotonic || sum.AggregationTemporality != otlp.CumulativeTemporality || *sum.DataPoints[0].AsInt != 3 {
+		t.Errorf("got started sum %+v, want a cumulative monotonic sum of 3", sum)
+	}
+
+	// Only the metrics that changed are sent again.
+	event.Metric(ctx, loaded.Of(30))
+	exporter.Flush()
+	got = <-requests
+	if metrics := got.ResourceMetrics[0].ScopeMetrics[0].Metrics; len(metrics) != 1 || metrics[0].Name != "loaded" {
+		t.Errorf("got %d metrics after second flush, want only loaded", len(metrics))
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package otlp
+
+import (
+	"strconv"
+	"strings"
+)
+
+// This file holds the subset of the OTLP/HTTP JSON encoding of metrics that
+// is produced by the exporter. It follows the JSON mapping of the protocol
+// buffers of opentelemetry-proto/opentelemetry/proto/metrics/v1, in which
+// 64-bit integers are encoded as strings.
+
+type ExportMetricsServiceRequest struct {
+	ResourceMetrics []*ResourceMetrics `json:"resourceMetrics,omitempty"`
+}
+
+type ResourceMetrics struct {
+	Resource     *Resource       `json:"resource,omitempty"`
+	ScopeMetrics []*ScopeMetrics `json:"scopeMetrics,omitempty"`
+}
+
+type Resource struct {
+	Attributes []*KeyValue `json:"attributes,omitempty"`
+}
+
+type ScopeMetrics struct {
+	Scope   *InstrumentationScope `json:"scope,omitempty"`
+	Metrics []*Metric             `json:"metrics,omitempty"`
+}
+
+type InstrumentationScope struct {
+	Name    string `json:"name,omitempty"`
+	Version string `json:"version,omitempty"`
+}
+
+// A Metric holds exactly one of Gauge, Sum and Histogram.
+type Metric struct {
+	Name        string     `json:"name,omitempty"`
+	Description string     `json:"description,omitempty"`
+	Unit        string     `json:"unit,omitempty"`
+	Gauge       *Gauge     `json:"gauge,omitempty"`
+	Sum         *Sum       `json:"sum,omitempty"`
+	Histogram   *Histogram `json:"histogram,omitempty"`
+}
+
+type Gauge struct {
+	DataPoints []*NumberDataPoint `json:"dataPoints,omitempty"`
+}
+
+type Sum struct {
+	DataPoints             []*NumberDataPoint     `json:"dataPoints,omitempty"`
+	AggregationTemporality AggregationTemporality `json:"aggregationTemporality,omitempty"`
+	IsMonotonic            bool                   `json:"isMonotonic,omitempty"`
+}
+
+type Histogram struct {
+	DataPoints             []*HistogramDataPoint  `json:"dataPoints,omitempty"`
+	AggregationTemporality AggregationTemporality `json:"aggregationTemporality,omitempty"`
+}
+
+type AggregationTemporality int32
+
+const (
+	UnspecifiedTemporality AggregationTemporality = 0
+	DeltaTemporality       AggregationTemporality = 1
+	CumulativeTemporality  AggregationTemporality = 2
+)
+
+// A NumberDataPoint holds exactly one of AsInt and AsDouble.
+type NumberDataPoint struct {
+	Attributes        []*KeyValue `json:"attributes,omitempty"`
+	StartTimeUnixNano uint64      `json:"startTimeUnixNano,string,omitempty"`
+	TimeUnixNano      uint64      `json:"timeUnixNano,string,omitempty"`
+	AsInt             *int64      `json:"asInt,string,omitempty"`
+	AsDouble          *float64    `json:"asDouble,omitempty"`
+}
+
+// A HistogramDataPoint holds the counts of the values in each bucket of a
+// histogram. BucketCounts has one more element than ExplicitBounds, for the
+// values above the last bound, and its counts are not cumulative.
+type HistogramDataPoint struct {
+	Attributes        []*KeyValue `json:"attributes,omitempty"`
+	StartTimeUnixNano uint64      `json:"startTimeUnixNano,string,omitempty"`
+	TimeUnixNano      uint64      `json:"timeUnixNano,string,omitempty"`
+	Count             uint64      `json:"count,string"`
+	Sum               *float64    `json:"sum,omitempty"`
+	BucketCounts      []Uint64    `json:"bucketCounts,omitempty"`
+	ExplicitBounds    []float64   `json:"explicitBounds,omitempty"`
+	Min               *float64    `json:"min,omitempty"`
+	Max               *float64    `json:"max,omitempty"`
+}
+
+// A Uint64 is an element of a repeated 64-bit integer field, which is
+// encoded as a string.
+type Uint64 uint64
+
+func (u Uint64) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + strconv.FormatUint(uint64(u), 10) + `"`), nil
+}
+
+func (u *Uint64) UnmarshalJSON(data []byte) error {
+	v, err := strconv.ParseUint(strings.Trim(string(data), `"`), 10, 64)
+	*u = Uint64(v)
+	return err
+}
+
+type KeyValue struct {
+	Key   string    `json:"key"`
+	Value *AnyValue `json:"value"`
+}
+
+// An AnyValue holds exactly one of its fields.
+type AnyValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	IntValue    *int64   `json:"intValue,string,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
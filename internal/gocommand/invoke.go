@@ -140,6 +140,9 @@ type Invocation struct {
 	Env        []string
 	WorkingDir string
 	Logf       func(format string, args ...interface{})
+	// GoCmd is the path of the go command to run. If it is empty, the go
+	// command on PATH is run.
+	GoCmd string
 }
 
 func (i *Invocation) runWithFriendlyError(ctx context.Context, stdout, stderr io.Writer) (friendlyError error, rawError error) {
@@ -202,7 +205,11 @@ func (i *Invocation) run(ctx context.Context, stdout, stderr io.Writer) error {
 		appendOverlayFlag()
 		goArgs = append(goArgs, i.Args...)
 	}
-	cmd := exec.Command("go", goArgs...)
+	goCmd := i.GoCmd
+	if goCmd == "" {
+		goCmd = "go"
+	}
+	cmd := exec.Command(goCmd, goArgs...)
 	cmd.Stdout = stdout
 	cmd.Stderr = stderr
 	// On darwin the cwd gets resolved to the real path, which breaks anything that
@@ -607,6 +607,10 @@ func getCandidatePkgs(ctx context.Context, wrappedCallback *scanCallback, filena
 	if err != nil {
 		return err
 	}
+	stdlib, err := env.stdlibPackages()
+	if err != nil {
+		return err
+	}
 
 	var mu sync.Mutex // to guard asynchronous access to dupCheck
 	dupCheck := map[string]struct{}{}
@@ -815,12 +819,21 @@ type ProcessEnv struct {
 
 	WorkingDir string
 
+	// GoCmd is the path of the go command to run. If it is empty, the go
+	// command on PATH is run.
+	GoCmd string
+
 	// If Logf is non-nil, debug logging is enabled through this function.
 	Logf func(format string, args ...interface{})
 
 	initialized bool
 
 	resolver Resolver
+
+	// stdlib is the subset of the standard library index whose packages are
+	// in stdlibGOROOT, computed on first use.
+	stdlib       map[string][]string
+	stdlibGOROOT string
 }
 
 func (e *ProcessEnv) goEnv() (map[string]string, error) {
@@ -846,6 +859,7 @@ func (e *ProcessEnv) CopyConfig() *ProcessEnv {
 		BuildFlags:  e.BuildFlags,
 		Logf:        e.Logf,
 		WorkingDir:  e.WorkingDir,
+		GoCmd:       e.GoCmd,
 		resolver:    nil,
 		Env:         map[string]string{},
 	}
@@ -954,16 +968,50 @@ func (e *ProcessEnv) invokeGo(ctx context.Context, verb string, args ...string)
 		Env:        e.env(),
 		Logf:       e.Logf,
 		WorkingDir: e.WorkingDir,
+		GoCmd:      e.GoCmd,
 	}
 	return e.GocmdRunner.Run(ctx, inv)
 }
 
+// stdlibPackages returns the packages of the standard library index that
+// are in GOROOT, which may be that of a Go version older than the index.
+func (e *ProcessEnv) stdlibPackages() (map[string][]string, error) {
+	goenv, err := e.goEnv()
+	if err != nil {
+		return nil, err
+	}
+	goroot := goenv["GOROOT"]
+	if e.stdlib != nil && e.stdlibGOROOT == goroot {
+		return e.stdlib, nil
+	}
+	e.stdlibGOROOT = goroot
+	if fi, err := os.Stat(filepath.Join(goroot, "src")); goroot == "" || err != nil || !fi.IsDir() {
+		// Without a GOROOT to check, assume that it is that of the index.
+		e.stdlib = stdlib
+		return e.stdlib, nil
+	}
+	e.stdlib = make(map[string][]string)
+	for importPath, exports := range stdlib {
+		if _, err := os.Stat(filepath.Join(goroot, "src", importPath)); err == nil {
+			e.stdlib[importPath] = exports
+		}
+	}
+	return e.stdlib, nil
+}
+
 func addStdlibCandidates(pass *pass, refs references) error {
 	goenv, err := pass.env.goEnv()
 	if err != nil {
 		return err
 	}
+	stdlib, err := pass.env.stdlibPackages()
+	if err != nil {
+		return err
+	}
 	add := func(pkg string) {
+		if _, ok := stdlib[pkg]; !ok {
+			return
+		}
 		// Prevent self-imports.
 		if path.Base(pkg) == pass.f.Name.Name && filepath.Join(goenv["GOROOT"], "src", pkg) == pass.srcDir {
 			return
@@ -11,6 +11,7 @@ import (
 	"go/build"
 	"io/ioutil"
 	"log"
+	"os"
 	"path/filepath"
 	"reflect"
 	"runtime"
@@ -2793,3 +2794,36 @@ var _, _ = fmt.Sprintf, dot.Dot
 		gopathOnly: true, // our modules testing setup doesn't allow modules without dots.
 	}.processTest(t, "golang.org/fake", "x.go", nil, nil, want)
 }
+
+func TestStdlibPackagesInGOROOT(t *testing.T) {
+	goroot, err := ioutil.TempDir("", "goroot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(goroot)
+	if err := os.MkdirAll(filepath.Join(goroot, "src", "fmt"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	env := &ProcessEnv{Env: map[string]string{}}
+	for _, k := range RequiredGoEnvVars {
+		env.Env[k] = ""
+	}
+	env.Env["GOROOT"] = goroot
+	pkgs, err := env.stdlibPackages()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := pkgs["fmt"]; !ok || len(pkgs) != 1 {
+		t.Errorf("stdlibPackages() returned %d packages, want only fmt", len(pkgs))
+	}
+
+	// Without GOROOT, the whole index is used.
+	env.Env["GOROOT"] = ""
+	if pkgs, err = env.stdlibPackages(); err != nil {
+		t.Fatal(err)
+	}
+	if len(pkgs) != len(stdlib) {
+		t.Errorf("stdlibPackages() returned %d packages without GOROOT, want %d", len(pkgs), len(stdlib))
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"golang.org/x/tools/internal/event"
 	"golang.org/x/tools/internal/event/label"
@@ -82,12 +83,13 @@ func (c *conn) Notify(ctx context.Context, method string, params interface{}) (e
 	if err != nil {
 		return fmt.Errorf("marshaling notify parameters: %v", err)
 	}
+	start := time.Now()
 	ctx, done := event.Start(ctx, method,
 		tag.Method.Of(method),
 		tag.RPCDirection.Of(tag.Outbound),
 	)
 	defer func() {
-		recordStatus(ctx, err)
+		recordStatus(ctx, start, err)
 		done()
 	}()
 
@@ -104,13 +106,14 @@ func (c *conn) Call(ctx context.Context, method string, params, result interface
 	if err != nil {
 		return id, fmt.Errorf("marshaling call parameters: %v", err)
 	}
+	start := time.Now()
 	ctx, done := event.Start(ctx, method,
 		tag.Method.Of(method),
 		tag.RPCDirection.Of(tag.Outbound),
 		tag.RPCID.Of(fmt.Sprintf("%q", id)),
 	)
 	defer func() {
-		recordStatus(ctx, err)
+		recordStatus(ctx, start, err)
 		done()
 	}()
 	event.Metric(ctx, tag.Started.Of(1))
@@ -153,10 +156,10 @@ func (c *conn) Call(ctx context.Context, method string, params, result interface
 	}
 }
 
-func (c *conn) replier(req Request, spanDone func()) Replier {
+func (c *conn) replier(req Request, start time.Time, spanDone func()) Replier {
 	return func(ctx context.Context, result interface{}, err error) error {
 		defer func() {
-			recordStatus(ctx, err)
+			recordStatus(ctx, start, err)
 			spanDone()
 		}()
 		call, ok := req.(*Call)
@@ -211,11 +214,12 @@ func (c *conn) run(ctx context.Context, handler Handler) {
 			} else {
 				labels = labels[:len(labels)-1]
 			}
+			start := time.Now()
 			reqCtx, spanDone := event.Start(ctx, msg.Method(), labels...)
 			event.Metric(reqCtx,
 				tag.Started.Of(1),
 				tag.ReceivedBytes.Of(n))
-			if err := handler(reqCtx, c.replier(msg, spanDone), msg); err != nil {
+			if err := handler(reqCtx, c.replier(msg, start, spanDone), msg); err != nil {
 				// delivery failed, not much we can do
 				event.Error(reqCtx, "jsonrpc2 message delivery failed", err)
 			}
@@ -253,10 +257,14 @@ func (c *conn) fail(err error) {
 	c.stream.Close()
 }
 
-func recordStatus(ctx context.Context, err error) {
+// recordStatus records the status of a completed RPC, and its latency since
+// start.
+func recordStatus(ctx context.Context, start time.Time, err error) {
+	status := "OK"
 	if err != nil {
-		event.Label(ctx, tag.StatusCode.Of("ERROR"))
-	} else {
-		event.Label(ctx, tag.StatusCode.Of("OK"))
+		status = "ERROR"
 	}
+	event.Label(ctx, tag.StatusCode.Of(status))
+	elapsed := float64(time.Since(start)) / float64(time.Millisecond)
+	event.Metric(ctx, tag.Latency.Of(elapsed), tag.StatusCode.Of(status))
 }
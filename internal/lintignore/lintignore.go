@@ -0,0 +1,239 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package lintignore implements the directives that suppress the
+// diagnostics of analyzers, for the analysis drivers.
+//
+// A line directive
+//
+//	//lint:ignore analyzer[,analyzer...] reason
+//
+// suppresses the diagnostics of the named analyzers that start on the line of
+// the directive, if it follows code on that line, and otherwise on the line
+// that follows the comment group of the directive. A file directive
+//
+//	//lint:file-ignore analyzer[,analyzer...] reason
+//
+// suppresses the diagnostics of the named analyzers in the whole file.
+//
+// Drivers report the malformed directives, and the directives that suppress
+// nothing, as diagnostics of the pseudo-analyzer Name.
+package lintignore
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"sort"
+	"strings"
+)
+
+// Name is the name of the pseudo-analyzer under which drivers report the
+// problems of directives.
+const Name = "lint"
+
+const (
+	lineDirective = "//lint:ignore"
+	fileDirective = "//lint:file-ignore"
+)
+
+// A directive is a line or file directive.
+type directive struct {
+	pos, end token.Pos
+	posn     token.Position
+
+	file      bool
+	analyzers []string
+	reason    string
+	// line is the line suppressed by a line directive.
+	line int
+	// err is the problem of a malformed directive.
+	err string
+
+	// used records the analyzers whose diagnostics were suppressed.
+	used map[string]bool
+}
+
+// A Set holds the directives of a set of files. It is not safe for
+// concurrent use.
+type Set struct {
+	files map[string][]*directive
+}
+
+// NewSet returns an empty set of directives.
+func NewSet() *Set {
+	return &Set{files: make(map[string][]*directive)}
+}
+
+// Add adds the directives in the comments of f to s, unless the directives of
+// a file of the same name were already added.
+func (s *Set) Add(fset *token.FileSet, f *ast.File) {
+	tok := fset.File(f.Pos())
+	if tok == nil {
+		return
+	}
+	if _, ok := s.files[tok.Name()]; ok {
+		return
+	}
+	var directives []*directive
+	var code map[int]token.Pos
+	for _, group := range f.Comments {
+		for _, c := range group.List {
+			d := parse(c)
+			if d == nil {
+				continue
+			}
+			d.posn = fset.Position(c.Pos())
+			if !d.file {
+				if code == nil {
+					code = codeLines(tok, f)
+				}
+				if first, ok := code[d.posn.Line]; ok && first < c.Pos() {
+					d.line = d.posn.Line
+				} else {
+					d.line = tok.Line(group.End()) + 1
+				}
+			}
+			directives = append(directives, d)
+		}
+	}
+	s.files[tok.Name()] = directives
+}
+
+// parse returns the directive of the comment c, or nil if c is not a
+// directive.
+func parse(c *ast.Comment) *directive {
+	if !strings.HasPrefix(c.Text, "//lint:") {
+		return nil
+	}
+	d := &directive{
+		pos:  c.Pos(),
+		end:  c.End(),
+		used: make(map[string]bool),
+	}
+	fields := strings.Fields(c.Text)
+	switch fields[0] {
+	case lineDirective:
+	case fileDirective:
+		d.file = true
+	default:
+		d.err = fmt.Sprintf("unknown directive %s", fields[0])
+		return d
+	}
+	if len(fields) < 2 {
+		d.err = fmt.Sprintf("malformed %s directive: missing analyzer names", fields[0])
+		return d
+	}
+	for _, name := range strings.Split(fields[1], ",") {
+		if name == "" {
+			d.err = fmt.Sprintf("malformed %s directive: empty analyzer name in %q", fields[0], fields[1])
+			return d
+		}
+		d.analyzers = append(d.analyzers, name)
+	}
+	d.reason = strings.Join(fields[2:], " ")
+	return d
+}
+
+// codeLines returns the position of the first token of code of each line of
+// f that has code.
+func codeLines(tok *token.File, f *ast.File) map[int]token.Pos {
+	lines := make(map[int]token.Pos)
+	record := func(pos token.Pos) {
+		if !pos.IsValid() {
+			return
+		}
+		line := tok.Line(pos)
+		if first, ok := lines[line]; !ok || pos < first {
+			lines[line] = pos
+		}
+	}
+	ast.Inspect(f, func(n ast.Node) bool {
+		switch n.(type) {
+		case nil, *ast.CommentGroup, *ast.Comment:
+			return false
+		}
+		record(n.Pos())
+		// The end of a node is after its last token.
+		if end := n.End(); end.IsValid() && end > n.Pos() {
+			record(end - 1)
+		}
+		return true
+	})
+	return lines
+}
+
+// Suppressed reports whether a directive of s suppresses the diagnostics of
+// the named analyzer at posn, and records that the directives that do are
+// used.
+func (s *Set) Suppressed(analyzer string, posn token.Position) bool {
+	suppressed := false
+	for _, d := range s.files[posn.Filename] {
+		if d.err != "" || (!d.file && d.line != posn.Line) {
+			continue
+		}
+		for _, name := range d.analyzers {
+			if name == analyzer {
+				d.used[name] = true
+				suppressed = true
+			}
+		}
+	}
+	return suppressed
+}
+
+// A Problem is a malformed directive, a directive without a reason, or a
+// directive that suppressed no diagnostic.
+type Problem struct {
+	Pos, End token.Pos
+	Position token.Position
+	Message  string
+}
+
+// Problems returns the problems of the directives of s, in order of position.
+// The directives of an analyzer are reported as unused only if ran reports
+// that the analyzer ran on their file, as other tools may use them. If
+// requireReason is set, the directives without a reason are reported too.
+func (s *Set) Problems(ran func(analyzer, filename string) bool, requireReason bool) []Problem {
+	var problems []Problem
+	for filename, directives := range s.files {
+		for _, d := range directives {
+			report := func(format string, args ...interface{}) {
+				problems = append(problems, Problem{
+					Pos:      d.pos,
+					End:      d.end,
+					Position: d.posn,
+					Message:  fmt.Sprintf(format, args...),
+				})
+			}
+			if d.err != "" {
+				report("%s", d.err)
+				continue
+			}
+			if requireReason && d.reason == "" {
+				report("%s directive for %s has no reason", d.kind(), strings.Join(d.analyzers, ","))
+			}
+			for _, name := range d.analyzers {
+				if ran(name, filename) && !d.used[name] {
+					report("unused %s directive for %s", d.kind(), name)
+				}
+			}
+		}
+	}
+	sort.SliceStable(problems, func(i, j int) bool {
+		pi, pj := problems[i].Position, problems[j].Position
+		if pi.Filename != pj.Filename {
+			return pi.Filename < pj.Filename
+		}
+		return pi.Offset < pj.Offset
+	})
+	return problems
+}
+
+func (d *directive) kind() string {
+	if d.file {
+		return fileDirective
+	}
+	return lineDirective
+}
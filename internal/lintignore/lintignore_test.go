@@ -0,0 +1,104 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lintignore_test
+
+import (
+	"fmt"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"testing"
+
+	"golang.org/x/tools/internal/lintignore"
+)
+
+const src = `package p
+
+//lint:file-ignore filed because
+
+func f() {
+	//lint:ignore above,other because
+	_ = 1
+
+	_ = 2 //lint:ignore trailing because
+
+	//lint:ignore unused because
+	_ = 3
+
+	//lint:ignore noreason
+	_ = 4
+
+	//lint:ignore
+	//lint:ignore a,,b because
+	//lint:frobnicate
+	_ = 5
+}
+`
+
+func TestSet(t *testing.T) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	set := lintignore.NewSet()
+	set.Add(fset, f)
+	// Adding the file twice does not duplicate its directives.
+	set.Add(fset, f)
+
+	for _, test := range []struct {
+		analyzer string
+		line     int
+		want     bool
+	}{
+		{"above", 7, true},
+		{"other", 7, true},
+		{"above", 6, false},
+		{"above", 8, false},
+		{"trailing", 9, true},
+		{"trailing", 10, false},
+		{"filed", 1, true},
+		{"filed", 20, true},
+		{"noreason", 15, true},
+		{"b", 20, false},
+		{"unknown", 7, false},
+	} {
+		posn := token.Position{Filename: "p.go", Line: test.line}
+		if got := set.Suppressed(test.analyzer, posn); got != test.want {
+			t.Errorf("Suppressed(%s, %d) = %t, want %t", test.analyzer, test.line, got, test.want)
+		}
+	}
+	if set.Suppressed("above", token.Position{Filename: "q.go", Line: 7}) {
+		t.Errorf("Suppressed(above, q.go:7) = true, want false")
+	}
+
+	ran := func(analyzer, _ string) bool { return analyzer != "other" }
+	for _, test := range []struct {
+		requireReason bool
+		want          []string
+	}{
+		{false, []string{
+			"p.go:11: unused //lint:ignore directive for unused",
+			"p.go:17: malformed //lint:ignore directive: missing analyzer names",
+			"p.go:18: malformed //lint:ignore directive: empty analyzer name in \"a,,b\"",
+			"p.go:19: unknown directive //lint:frobnicate",
+		}},
+		{true, []string{
+			"p.go:11: unused //lint:ignore directive for unused",
+			"p.go:14: //lint:ignore directive for noreason has no reason",
+			"p.go:17: malformed //lint:ignore directive: missing analyzer names",
+			"p.go:18: malformed //lint:ignore directive: empty analyzer name in \"a,,b\"",
+			"p.go:19: unknown directive //lint:frobnicate",
+		}},
+	} {
+		var got []string
+		for _, p := range set.Problems(ran, test.requireReason) {
+			got = append(got, fmt.Sprintf("%s:%d: %s", p.Position.Filename, p.Position.Line, p.Message))
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Problems(requireReason=%t) = %q, want %q", test.requireReason, got, test.want)
+		}
+	}
+}
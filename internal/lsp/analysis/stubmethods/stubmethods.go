@@ -0,0 +1,331 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package stubmethods defines an Analyzer that applies suggested fixes
+// to errors of the type "missing method %s".
+package stubmethods
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/internal/analysisinternal"
+	"golang.org/x/tools/internal/typesinternal"
+)
+
+const Doc = `suggested fixes for "missing method <>"
+
+This checker provides suggested fixes for type errors of the
+type "missing method <>", when a value of a type declared in the
+current package is used as an interface. It will declare stubs of the
+missing methods after the declaration of the type. For example:
+	var _ io.Reader = T{}
+will declare
+	func (t T) Read(p []byte) (n int, err error) {
+		panic("unimplemented")
+	}
+`
+
+var Analyzer = &analysis.Analyzer{
+	Name:             string(analysisinternal.StubMethods),
+	Doc:              Doc,
+	Requires:         []*analysis.Analyzer{},
+	Run:              run,
+	RunDespiteErrors: true,
+}
+
+const missingMethodMsg = "missing method "
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	for _, err := range analysisinternal.GetTypeErrors(pass) {
+		if !FixesError(err.Msg) {
+			continue
+		}
+		var file *ast.File
+		for _, f := range pass.Files {
+			if f.Pos() <= err.Pos && err.Pos < f.End() {
+				file = f
+				break
+			}
+		}
+		if file == nil {
+			continue
+		}
+		// Report the diagnostic at the range of the type error, so that
+		// its fixes are attached to it.
+		_, start, end, ok := typesinternal.ReadGo116ErrorData(err)
+		if !ok || !end.IsValid() || end == start {
+			start = err.Pos
+			end = err.Pos
+		}
+		path, _ := astutil.PathEnclosingInterval(file, start, end)
+		si := GetStubInfo(pass.Pkg, pass.TypesInfo, path, start)
+		if si == nil {
+			continue
+		}
+		if missing, err := si.MissingMethods(); err != nil || len(missing) == 0 {
+			continue
+		}
+		if end == start {
+			end = si.Expr.End()
+		}
+		pass.Report(analysis.Diagnostic{
+			Pos:     start,
+			End:     end,
+			Message: err.Msg,
+		})
+	}
+	return nil, nil
+}
+
+// FixesError reports whether the type error msg may be fixed by stubbing
+// the missing methods of a type.
+func FixesError(msg string) bool {
+	return strings.Contains(msg, missingMethodMsg)
+}
+
+// StubInfo describes a concrete type used as an interface that it does
+// not implement.
+type StubInfo struct {
+	// Expr is the expression of the concrete type.
+	Expr ast.Expr
+	// Concrete is the concrete type, which is declared in the current
+	// package.
+	Concrete *types.Named
+	// Pointer reports whether Expr is a pointer to Concrete, in which case
+	// the stubs have pointer receivers.
+	Pointer bool
+	// Interface is the interface type that Expr is used as.
+	Interface types.Type
+}
+
+// GetStubInfo returns information about the concrete type used as an
+// interface at pos, or nil if there is none. The path is the path to pos
+// in the file, innermost first. The concrete type must be declared in pkg.
+//
+// The interface is deduced from the context of the expression, which may
+// be the value of a typed variable declaration, the right hand side of an
+// assignment, a return value, an argument of a call or a conversion.
+func GetStubInfo(pkg *types.Package, info *types.Info, path []ast.Node, pos token.Pos) *StubInfo {
+	for i, n := range path {
+		switch n := n.(type) {
+		case *ast.ValueSpec:
+			if n.Type == nil {
+				return nil
+			}
+			j := exprAt(n.Values, pos)
+			if j < 0 {
+				return nil
+			}
+			return newStubInfo(pkg, info, n.Values[j], info.TypeOf(n.Type))
+		case *ast.AssignStmt:
+			j := exprAt(n.Rhs, pos)
+			if j < 0 || len(n.Lhs) != len(n.Rhs) {
+				return nil
+			}
+			return newStubInfo(pkg, info, n.Rhs[j], info.TypeOf(n.Lhs[j]))
+		case *ast.ReturnStmt:
+			j := exprAt(n.Results, pos)
+			if j < 0 {
+				return nil
+			}
+			sig := enclosingSignature(info, path[i+1:])
+			if sig == nil || sig.Results().Len() != len(n.Results) {
+				return nil
+			}
+			return newStubInfo(pkg, info, n.Results[j], sig.Results().At(j).Type())
+		case *ast.CallExpr:
+			j := exprAt(n.Args, pos)
+			if j < 0 {
+				// The expression may be the callee, or a larger
+				// expression that is an argument of an enclosing call.
+				continue
+			}
+			if tv, ok := info.Types[n.Fun]; ok && tv.IsType() {
+				return newStubInfo(pkg, info, n.Args[j], tv.Type)
+			}
+			sig, ok := info.TypeOf(n.Fun).(*types.Signature)
+			if !ok {
+				return nil
+			}
+			params := sig.Params()
+			var typ types.Type
+			switch {
+			case j < params.Len()-1 || (j == params.Len()-1 && !sig.Variadic()):
+				typ = params.At(j).Type()
+			case sig.Variadic() && n.Ellipsis == token.NoPos:
+				typ = params.At(params.Len() - 1).Type().(*types.Slice).Elem()
+			default:
+				return nil
+			}
+			return newStubInfo(pkg, info, n.Args[j], typ)
+		case ast.Stmt, ast.Decl:
+			return nil
+		}
+	}
+	return nil
+}
+
+// exprAt returns the index of the expression in exprs that contains pos,
+// or -1.
+func exprAt(exprs []ast.Expr, pos token.Pos) int {
+	for i, e := range exprs {
+		if e.Pos() <= pos && pos < e.End() {
+			return i
+		}
+	}
+	return -1
+}
+
+// enclosingSignature returns the signature of the innermost function in
+// path.
+func enclosingSignature(info *types.Info, path []ast.Node) *types.Signature {
+	for _, n := range path {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			sig, _ := info.TypeOf(n).(*types.Signature)
+			return sig
+		case *ast.FuncDecl:
+			if fn, ok := info.Defs[n.Name].(*types.Func); ok {
+				return fn.Type().(*types.Signature)
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+func newStubInfo(pkg *types.Package, info *types.Info, expr ast.Expr, iface types.Type) *StubInfo {
+	if iface == nil || !types.IsInterface(iface) {
+		return nil
+	}
+	typ := info.TypeOf(expr)
+	ptr, pointer := typ.(*types.Pointer)
+	if pointer {
+		typ = ptr.Elem()
+	}
+	named, ok := typ.(*types.Named)
+	if !ok || named.Obj().Pkg() != pkg || types.IsInterface(named) {
+		return nil
+	}
+	return &StubInfo{
+		Expr:      expr,
+		Concrete:  named,
+		Pointer:   pointer,
+		Interface: iface,
+	}
+}
+
+// MissingMethods returns the methods of the interface that the concrete
+// type lacks, in the order of their declarations. It returns an error if the
+// concrete type has a field or method of the same name as one of them,
+// or if one of them cannot be declared outside of its package.
+func (si *StubInfo) MissingMethods() ([]*types.Func, error) {
+	var recv types.Type = si.Concrete
+	if si.Pointer {
+		recv = types.NewPointer(recv)
+	}
+	iface := si.Interface.Underlying().(*types.Interface)
+	var missing []*types.Func
+	for i := 0; i < iface.NumMethods(); i++ {
+		m := iface.Method(i)
+		obj, _, _ := types.LookupFieldOrMethod(recv, false, m.Pkg(), m.Name())
+		switch obj := obj.(type) {
+		case nil:
+			if obj, _, _ := types.LookupFieldOrMethod(recv, true, m.Pkg(), m.Name()); obj != nil {
+				return nil, fmt.Errorf("%s.%s has a pointer receiver", si.Concrete.Obj().Name(), m.Name())
+			}
+			if !m.Exported() && m.Pkg() != si.Concrete.Obj().Pkg() {
+				return nil, fmt.Errorf("cannot declare unexported method %s of package %s", m.Name(), m.Pkg().Name())
+			}
+			missing = append(missing, m)
+		case *types.Var:
+			return nil, fmt.Errorf("%s has a field %s", si.Concrete.Obj().Name(), m.Name())
+		case *types.Func:
+			if !types.Identical(obj.Type(), m.Type()) {
+				return nil, fmt.Errorf("%s.%s has the wrong type", si.Concrete.Obj().Name(), m.Name())
+			}
+		}
+	}
+	sort.SliceStable(missing, func(i, j int) bool {
+		return missing[i].Pos() < missing[j].Pos()
+	})
+	return missing, nil
+}
+
+// Stubs returns the declarations of stubs for the given methods, qualifying
+// the types of their signatures with qf. Each stub is preceded by a blank
+// line.
+func (si *StubInfo) Stubs(methods []*types.Func, qf types.Qualifier) []byte {
+	var buf bytes.Buffer
+	recvName := si.receiverName()
+	recvType := si.Concrete.Obj().Name()
+	if si.Pointer {
+		recvType = "*" + recvType
+	}
+	for _, m := range methods {
+		sig := m.Type().(*types.Signature)
+		// Don't name the receiver if its name is taken by a parameter.
+		recv := recvName + " " + recvType
+		if tupleHasName(sig.Params(), recvName) || tupleHasName(sig.Results(), recvName) {
+			recv = recvType
+		}
+		fmt.Fprintf(&buf, "\nfunc (%s) %s(%s)", recv, m.Name(), formatTuple(sig.Params(), sig.Variadic(), qf))
+		switch res := sig.Results(); {
+		case res.Len() == 1 && res.At(0).Name() == "":
+			fmt.Fprintf(&buf, " %s", types.TypeString(res.At(0).Type(), qf))
+		case res.Len() > 0:
+			fmt.Fprintf(&buf, " (%s)", formatTuple(res, false, qf))
+		}
+		buf.WriteString(" {\n\tpanic(\"unimplemented\")\n}\n")
+	}
+	return buf.Bytes()
+}
+
+// receiverName returns the name of the receiver of the existing methods of
+// the concrete type, or the lower-cased first letter of its name.
+func (si *StubInfo) receiverName() string {
+	for i := 0; i < si.Concrete.NumMethods(); i++ {
+		recv := si.Concrete.Method(i).Type().(*types.Signature).Recv()
+		if name := recv.Name(); name != "" && name != "_" {
+			return name
+		}
+	}
+	r, _ := utf8.DecodeRuneInString(si.Concrete.Obj().Name())
+	return string(unicode.ToLower(r))
+}
+
+func tupleHasName(tuple *types.Tuple, name string) bool {
+	for i := 0; i < tuple.Len(); i++ {
+		if tuple.At(i).Name() == name {
+			return true
+		}
+	}
+	return false
+}
+
+func formatTuple(tuple *types.Tuple, variadic bool, qf types.Qualifier) string {
+	var params []string
+	for i := 0; i < tuple.Len(); i++ {
+		v := tuple.At(i)
+		typ := types.TypeString(v.Type(), qf)
+		if variadic && i == tuple.Len()-1 {
+			typ = "..." + types.TypeString(v.Type().(*types.Slice).Elem(), qf)
+		}
+		if v.Name() != "" {
+			typ = v.Name() + " " + typ
+		}
+		params = append(params, typ)
+	}
+	return strings.Join(params, ", ")
+}
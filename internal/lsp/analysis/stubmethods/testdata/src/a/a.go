@@ -0,0 +1,47 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package stubmethods
+
+import "b"
+
+type Reader interface {
+	Read(p []byte) (n int, err error)
+}
+
+type Closer interface {
+	Close() error
+}
+
+type ReadCloser interface {
+	Reader
+	Closer
+}
+
+type T struct{}
+
+func (T) Close() error { return nil }
+
+type P struct{}
+
+var _ ReadCloser = T{}  // want "missing method Read"
+var _ ReadCloser = &P{} // want "missing method"
+
+func ret() Reader {
+	return T{} // want "missing method Read"
+}
+
+func use(string, ...Reader) {}
+
+func f() {
+	var r Reader
+	r = &P{}           // want "missing method Read"
+	use("", T{}, &P{}) // want "missing method Read" "missing method Read"
+	_ = Closer(P{})    // want "missing method Close"
+	_ = b.Writer(T{})  // want "missing method Write"
+	_ = r
+}
+
+// There is no fix for a type declared in another package.
+var _ Reader = b.T{}
@@ -0,0 +1,7 @@
+package b
+
+type Writer interface {
+	Write(p []byte) (n int, err error)
+}
+
+type T struct{}
@@ -74,6 +74,10 @@ type actionData struct {
 	objectFacts  map[objectFactKey]analysis.Fact
 	packageFacts map[packageFactKey]analysis.Fact
 	err          error
+
+	// analysisDiagnostics are the diagnostics reported by the analyzer,
+	// from which diagnostics are computed.
+	analysisDiagnostics []*analysis.Diagnostic
 }
 
 type objectFactKey struct {
@@ -138,8 +142,18 @@ func (s *snapshot) actionHandle(ctx context.Context, id packageID, a *analysis.A
 		}
 	}
 
+	var persistentKey string
+	if persistable(a) {
+		persistentKey = analysisKey(a, ph.key)
+	}
 	h := s.generation.Bind(buildActionKey(a, ph), func(ctx context.Context, arg memoize.Arg) interface{} {
 		snapshot := arg.(*snapshot)
+		fc := snapshot.fileCache(ctx)
+		if fc != nil && persistentKey != "" {
+			if data := readAnalysis(ctx, snapshot, fc, persistentKey, a, pkg); data != nil {
+				return data
+			}
+		}
 		// Analyze dependencies first.
 		results, err := execAll(ctx, snapshot, deps)
 		if err != nil {
@@ -147,7 +161,11 @@ func (s *snapshot) actionHandle(ctx context.Context, id packageID, a *analysis.A
 				err: err,
 			}
 		}
-		return runAnalysis(ctx, snapshot, a, pkg, results)
+		data := runAnalysis(ctx, snapshot, a, pkg, results)
+		if fc != nil && persistentKey != "" && data.err == nil {
+			writeAnalysis(ctx, fc, persistentKey, pkg, data)
+		}
+		return data
 	}, nil)
 	act.handle = h
 
@@ -351,6 +369,15 @@ func runAnalysis(ctx context.Context, snapshot *snapshot, analyzer *analysis.Ana
 		panic(fmt.Sprintf("%s:%s: Pass.ExportPackageFact(%T) called after Run", analyzer.Name, pkg.PkgPath(), fact))
 	}
 
+	data.analysisDiagnostics = diagnostics
+	data.diagnostics, data.err = toSourceDiagnostics(ctx, snapshot, pkg, analyzer, diagnostics)
+	return data
+}
+
+// toSourceDiagnostics computes the diagnostics of the diagnostics reported by
+// analyzer on pkg.
+func toSourceDiagnostics(ctx context.Context, snapshot *snapshot, pkg *pkg, analyzer *analysis.Analyzer, diagnostics []*analysis.Diagnostic) ([]*source.Diagnostic, error) {
+	var result []*source.Diagnostic
 	for _, diag := range diagnostics {
 		srcDiags, err := analysisDiagnosticDiagnostics(ctx, snapshot, pkg, analyzer, diag)
 		if err != nil {
@@ -358,12 +385,11 @@ func runAnalysis(ctx context.Context, snapshot *snapshot, analyzer *analysis.Ana
 			continue
 		}
 		if ctx.Err() != nil {
-			data.err = ctx.Err()
-			return data
+			return nil, ctx.Err()
 		}
-		data.diagnostics = append(data.diagnostics, srcDiags...)
+		result = append(result, srcDiags...)
 	}
-	return data
+	return result, nil
 }
 
 // exportedFrom reports whether obj may be visible to a package that imports pkg.
@@ -24,6 +24,7 @@ import (
 	"golang.org/x/tools/internal/event"
 	"golang.org/x/tools/internal/gocommand"
 	"golang.org/x/tools/internal/lsp/debug/tag"
+	"golang.org/x/tools/internal/lsp/filecache"
 	"golang.org/x/tools/internal/lsp/source"
 	"golang.org/x/tools/internal/memoize"
 	"golang.org/x/tools/internal/span"
@@ -36,6 +37,7 @@ func New(ctx context.Context, options func(*source.Options)) *Cache {
 		fset:        token.NewFileSet(),
 		options:     options,
 		fileContent: map[span.URI]*fileHandle{},
+		sessions:    map[*Session]struct{}{},
 	}
 	return c
 }
@@ -49,6 +51,20 @@ type Cache struct {
 
 	fileMu      sync.Mutex
 	fileContent map[span.URI]*fileHandle
+
+	// fileCache is the persistent cache, opened on first use.
+	fileCacheOnce sync.Once
+	fileCache     *filecache.Cache
+
+	// memoryMu guards the sessions of the cache and their memory budget.
+	memoryMu sync.Mutex
+	sessions map[*Session]struct{}
+	budget   MemoryBudget
+	// refused counts the sessions refused by Admit.
+	refused int
+
+	// enforceMu serializes the enforcement of the memory budget.
+	enforceMu sync.Mutex
 }
 
 type fileHandle struct {
@@ -156,6 +172,9 @@ func (c *Cache) NewSession(ctx context.Context) *Session {
 		overlays:    make(map[span.URI]*overlay),
 		gocmdRunner: &gocommand.Runner{},
 	}
+	c.memoryMu.Lock()
+	c.sessions[s] = struct{}{}
+	c.memoryMu.Unlock()
 	event.Log(ctx, "New session", KeyCreateSession.Of(s))
 	return s
 }
@@ -197,6 +216,10 @@ var cacheIndex, sessionIndex, viewIndex int64
 func (c *Cache) ID() string                     { return c.id }
 func (c *Cache) MemStats() map[reflect.Type]int { return c.store.Stats() }
 
+// RecordHits records the metrics of the hits and misses of the cache since
+// the last call.
+func (c *Cache) RecordHits(ctx context.Context) { c.store.RecordHits(ctx) }
+
 type packageStat struct {
 	id        packageID
 	mode      source.ParseMode
@@ -216,25 +239,7 @@ func (c *Cache) PackageStats(withNames bool) template.HTML {
 			if v.pkg == nil {
 				break
 			}
-			var typsCost, typInfoCost int64
-			if v.pkg.types != nil {
-				typsCost = typesCost(v.pkg.types.Scope())
-			}
-			if v.pkg.typesInfo != nil {
-				typInfoCost = typesInfoCost(v.pkg.typesInfo)
-			}
-			stat := packageStat{
-				id:        v.pkg.m.id,
-				mode:      v.pkg.mode,
-				types:     typsCost,
-				typesInfo: typInfoCost,
-			}
-			for _, f := range v.pkg.compiledGoFiles {
-				stat.file += int64(len(f.Src))
-				stat.ast += astCost(f.File)
-			}
-			stat.total = stat.file + stat.ast + stat.types + stat.typesInfo
-			packageStats = append(packageStats, stat)
+			packageStats = append(packageStats, v.memoryStat())
 		}
 	})
 	var totalCost int64
@@ -265,6 +270,26 @@ func (c *Cache) PackageStats(withNames bool) template.HTML {
 	return template.HTML(html)
 }
 
+// newPackageStat estimates the memory used by pkg.
+func newPackageStat(pkg *pkg) packageStat {
+	stat := packageStat{
+		id:   pkg.m.id,
+		mode: pkg.mode,
+	}
+	if pkg.types != nil {
+		stat.types = typesCost(pkg.types.Scope())
+	}
+	if pkg.typesInfo != nil {
+		stat.typesInfo = typesInfoCost(pkg.typesInfo)
+	}
+	for _, f := range pkg.compiledGoFiles {
+		stat.file += int64(len(f.Src))
+		stat.ast += astCost(f.File)
+	}
+	stat.total = stat.file + stat.ast + stat.types + stat.typesInfo
+	return stat
+}
+
 func astCost(f *ast.File) int64 {
 	if f == nil {
 		return 0
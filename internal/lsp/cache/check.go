@@ -16,12 +16,14 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"golang.org/x/mod/module"
 	"golang.org/x/tools/go/ast/astutil"
 	"golang.org/x/tools/go/packages"
 	"golang.org/x/tools/internal/event"
 	"golang.org/x/tools/internal/lsp/debug/tag"
+	"golang.org/x/tools/internal/lsp/filecache"
 	"golang.org/x/tools/internal/lsp/protocol"
 	"golang.org/x/tools/internal/lsp/source"
 	"golang.org/x/tools/internal/memoize"
@@ -59,6 +61,18 @@ func (ph *packageHandle) packageKey() packageKey {
 type packageData struct {
 	pkg *pkg
 	err error
+
+	statOnce sync.Once
+	stat     packageStat // estimated memory use of pkg, computed once
+}
+
+// memoryStat returns the estimated memory used by the package of d, which
+// must not be nil.
+func (d *packageData) memoryStat() packageStat {
+	d.statOnce.Do(func() {
+		d.stat = newPackageStat(d.pkg)
+	})
+	return d.stat
 }
 
 // buildPackageHandle returns a packageHandle for a given package and mode.
@@ -98,7 +112,7 @@ func (s *snapshot) buildPackageHandle(ctx context.Context, id packageID, mode so
 		}
 
 		data := &packageData{}
-		data.pkg, data.err = typeCheck(ctx, snapshot, m, mode, deps)
+		data.pkg, data.err = typeCheck(ctx, snapshot, m, mode, deps, key)
 		// Make sure that the workers above have finished before we return,
 		// especially in case of cancellation.
 		wg.Wait()
@@ -266,10 +280,16 @@ func (s *snapshot) parseGoHandles(ctx context.Context, files []span.URI, mode so
 	return pghs, nil
 }
 
-func typeCheck(ctx context.Context, snapshot *snapshot, m *metadata, mode source.ParseMode, deps map[packagePath]*packageHandle) (*pkg, error) {
+func typeCheck(ctx context.Context, snapshot *snapshot, m *metadata, mode source.ParseMode, deps map[packagePath]*packageHandle, key packageHandleKey) (*pkg, error) {
 	ctx, done := event.Start(ctx, "cache.importer.typeCheck", tag.Package.Of(string(m.id)))
 	defer done()
 
+	start := time.Now()
+	defer func() {
+		elapsed := float64(time.Since(start)) / float64(time.Millisecond)
+		event.Metric(ctx, tag.TypeCheckLatency.Of(elapsed), tag.ParseMode.Of(mode.String()))
+	}()
+
 	fset := snapshot.view.session.cache.fset
 	pkg := &pkg{
 		m:               m,
@@ -396,6 +416,22 @@ func typeCheck(ctx context.Context, snapshot *snapshot, m *metadata, mode source
 		pkg.types = types.NewPackage(string(m.pkgPath), string(m.name))
 	}
 
+	// The types of dependencies may be imported from the export data stored
+	// in the persistent cache by a previous type check.
+	var (
+		fc        *filecache.Cache
+		exportKey string
+	)
+	if mode == source.ParseExported {
+		fc = snapshot.fileCache(ctx)
+	}
+	if fc != nil && !haveFixedFiles {
+		exportKey = exportDataKey(m, key)
+		if importExportData(ctx, snapshot, fc, exportKey, pkg, deps) {
+			return pkg, nil
+		}
+	}
+
 	var typeErrors []types.Error
 	cfg := &types.Config{
 		Error: func(e error) {
@@ -437,6 +473,9 @@ func typeCheck(ctx context.Context, snapshot *snapshot, m *metadata, mode source
 
 	// We don't care about a package's errors unless we have parsed it in full.
 	if mode != source.ParseFull {
+		if exportKey != "" && len(typeErrors) == 0 {
+			writeExportData(ctx, fc, fset, exportKey, pkg)
+		}
 		return pkg, nil
 	}
 
@@ -150,6 +150,7 @@ func (s *importsState) populateProcessEnv(ctx context.Context, snapshot *snapsho
 	pe.WorkingDir = inv.WorkingDir
 	pe.ModFile = inv.ModFile
 	pe.ModFlag = inv.ModFlag
+	pe.GoCmd = inv.GoCmd
 	pe.Env = map[string]string{}
 	for _, kv := range inv.Env {
 		split := strings.SplitN(kv, "=", 2)
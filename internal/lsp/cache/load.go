@@ -114,8 +114,11 @@ func (s *snapshot) load(ctx context.Context, allowNetwork bool, scopes ...interf
 	defer cancel()
 
 	cfg := s.config(ctx, inv)
+	start := time.Now()
 	pkgs, err := packages.Load(cfg, query...)
 	cleanup()
+	elapsed := float64(time.Since(start)) / float64(time.Millisecond)
+	event.Metric(ctx, tag.LoadLatency.Of(elapsed), tag.LoadedPackages.Of(int64(len(pkgs))))
 
 	// If the context was canceled, return early. Otherwise, we might be
 	// type-checking an incomplete result. Check the context directly,
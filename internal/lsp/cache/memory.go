@@ -0,0 +1,266 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sort"
+	"time"
+
+	"golang.org/x/tools/internal/event"
+	"golang.org/x/tools/internal/span"
+	errors "golang.org/x/xerrors"
+)
+
+// A MemoryBudget limits the memory used by the sessions of a cache, such as
+// the sessions of a gopls daemon shared by several editors. The package data
+// of a view, that is its parsed files, type-checked packages and analysis
+// results, is released to stay within the budget, and recomputed by the next
+// requests to the view.
+type MemoryBudget struct {
+	// Heap is the size in bytes of the live heap above which the package data
+	// of the least recently used views is released, and new sessions are
+	// refused. If zero, the heap is not limited.
+	Heap uint64
+
+	// Session is the estimated size in bytes of the package data of a
+	// session above which the package data of its least recently used views
+	// is released. If zero, sessions are not limited.
+	Session int64
+
+	// Idle is the duration without requests after which the package data of
+	// a view is released. If zero, views are never idle.
+	Idle time.Duration
+}
+
+// ViewMemory is the estimated memory used by the package data of a view.
+type ViewMemory struct {
+	ID     string
+	Name   string
+	Folder span.URI
+
+	// Packages is the number of type-checked packages of the view, and Bytes
+	// their estimated size. Packages shared with other views are counted in
+	// each of them.
+	Packages int
+	Bytes    int64
+
+	// LastUsed is the time of the last request to the view or change to its
+	// files.
+	LastUsed time.Time
+
+	// Released counts the times the package data of the view was released.
+	Released int
+}
+
+// memoryCheckInterval is the interval at which ManageMemory enforces the
+// memory budget.
+const memoryCheckInterval = 10 * time.Second
+
+// ManageMemory sets the memory budget of the sessions of c, and enforces it
+// periodically until ctx is done.
+func (c *Cache) ManageMemory(ctx context.Context, budget MemoryBudget) {
+	c.memoryMu.Lock()
+	c.budget = budget
+	c.memoryMu.Unlock()
+	go func() {
+		tick := time.NewTicker(memoryCheckInterval)
+		defer tick.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-tick.C:
+			}
+			c.enforceMemoryBudget(ctx)
+		}
+	}()
+}
+
+// MemoryBudget returns the memory budget of c, and the number of sessions
+// that Admit refused.
+func (c *Cache) MemoryBudget() (MemoryBudget, int) {
+	c.memoryMu.Lock()
+	defer c.memoryMu.Unlock()
+	return c.budget, c.refused
+}
+
+// Admit is called before a new session is created. It enforces the memory
+// budget of c, and returns an error if the heap is still over budget, in which
+// case the new session should be refused rather than risk running out of
+// memory.
+func (c *Cache) Admit(ctx context.Context) error {
+	budget, _ := c.MemoryBudget()
+	if budget.Heap == 0 {
+		return nil
+	}
+	heap := c.enforceMemoryBudget(ctx)
+	if heap <= budget.Heap {
+		return nil
+	}
+	c.memoryMu.Lock()
+	c.refused++
+	c.memoryMu.Unlock()
+	return errors.Errorf("gopls daemon is over its memory budget: heap of %d MiB, budget of %d MiB", heap>>20, budget.Heap>>20)
+}
+
+// enforceMemoryBudget releases the package data of the views of c as
+// required by its budget, and returns the size of the heap afterwards.
+func (c *Cache) enforceMemoryBudget(ctx context.Context) uint64 {
+	c.enforceMu.Lock()
+	defer c.enforceMu.Unlock()
+
+	budget, _ := c.MemoryBudget()
+	var usages []viewUsage
+	for _, s := range c.sessionList() {
+		s.viewMu.Lock()
+		for _, v := range s.views {
+			usages = append(usages, viewUsage{session: s, view: v, ViewMemory: v.memoryUsage()})
+		}
+		s.viewMu.Unlock()
+	}
+	release, rest := planRelease(usages, budget, time.Now())
+	for _, u := range release {
+		if u.session.releaseView(ctx, u.view) {
+			event.Log(ctx, fmt.Sprintf("released the package data of view %s of session %s: %d packages, %d MiB", u.Name, u.session.ID(), u.Packages, u.Bytes>>20))
+		}
+	}
+	heap := heapAlloc(len(release) > 0)
+	for budget.Heap > 0 && heap > budget.Heap {
+		// The heap may hold garbage, so collect it before releasing anything.
+		if heap = heapAlloc(true); heap <= budget.Heap || len(rest) == 0 {
+			break
+		}
+		u := rest[0]
+		rest = rest[1:]
+		if u.session.releaseView(ctx, u.view) {
+			event.Log(ctx, fmt.Sprintf("heap of %d MiB over budget: released the package data of view %s of session %s", heap>>20, u.Name, u.session.ID()))
+		}
+	}
+	return heap
+}
+
+// heapAlloc returns the size of the heap, after collecting garbage if gc is
+// set.
+func heapAlloc(gc bool) uint64 {
+	if gc {
+		runtime.GC()
+	}
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	return mem.HeapAlloc
+}
+
+// viewUsage is the memory used by a view of a session.
+type viewUsage struct {
+	session *Session
+	view    *View
+	ViewMemory
+}
+
+// planRelease returns the views whose package data must be released because
+// they are idle or their session is over budget, and the other views that
+// hold package data, least recently used first.
+func planRelease(usages []viewUsage, budget MemoryBudget, now time.Time) (release, rest []viewUsage) {
+	sort.SliceStable(usages, func(i, j int) bool {
+		return usages[i].LastUsed.Before(usages[j].LastUsed)
+	})
+	sessionBytes := map[*Session]int64{}
+	for _, u := range usages {
+		sessionBytes[u.session] += u.Bytes
+	}
+	for _, u := range usages {
+		if u.Packages == 0 {
+			continue
+		}
+		switch {
+		case budget.Idle > 0 && now.Sub(u.LastUsed) >= budget.Idle:
+		case budget.Session > 0 && sessionBytes[u.session] > budget.Session:
+		default:
+			rest = append(rest, u)
+			continue
+		}
+		release = append(release, u)
+		sessionBytes[u.session] -= u.Bytes
+	}
+	return release, rest
+}
+
+func (c *Cache) sessionList() []*Session {
+	c.memoryMu.Lock()
+	defer c.memoryMu.Unlock()
+	sessions := make([]*Session, 0, len(c.sessions))
+	for s := range c.sessions {
+		sessions = append(sessions, s)
+	}
+	return sessions
+}
+
+func (c *Cache) removeSession(s *Session) {
+	c.memoryMu.Lock()
+	defer c.memoryMu.Unlock()
+	delete(c.sessions, s)
+}
+
+// MemoryUsage returns the estimated memory used by the package data of the
+// views of s.
+func (s *Session) MemoryUsage() []ViewMemory {
+	s.viewMu.Lock()
+	defer s.viewMu.Unlock()
+	var usage []ViewMemory
+	for _, v := range s.views {
+		usage = append(usage, v.memoryUsage())
+	}
+	return usage
+}
+
+// releaseView releases the package data of v, if v is still a view of s.
+func (s *Session) releaseView(ctx context.Context, v *View) bool {
+	s.viewMu.Lock()
+	defer s.viewMu.Unlock()
+	for _, view := range s.views {
+		if view == v {
+			return v.releasePackages(ctx)
+		}
+	}
+	return false
+}
+
+// memoryUsage returns the estimated memory used by the package data of the
+// current snapshot of v.
+func (v *View) memoryUsage() ViewMemory {
+	v.usageMu.Lock()
+	usage := ViewMemory{
+		ID:       v.id,
+		Name:     v.name,
+		Folder:   v.folder,
+		LastUsed: v.lastUsed,
+		Released: v.released,
+	}
+	v.usageMu.Unlock()
+
+	v.snapshotMu.Lock()
+	s := v.snapshot
+	v.snapshotMu.Unlock()
+
+	// Estimate the usage outside of the lock of the snapshot, which
+	// requests need.
+	s.mu.Lock()
+	var packages []*packageData
+	for _, ph := range s.packages {
+		if data, _ := ph.handle.Cached(s.generation).(*packageData); data != nil && data.pkg != nil {
+			packages = append(packages, data)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, data := range packages {
+		usage.Packages++
+		usage.Bytes += data.memoryStat().total
+	}
+	return usage
+}
@@ -0,0 +1,126 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"golang.org/x/tools/internal/lsp/fake"
+	"golang.org/x/tools/internal/lsp/source"
+	"golang.org/x/tools/internal/span"
+	"golang.org/x/tools/internal/testenv"
+)
+
+func TestPlanRelease(t *testing.T) {
+	now := time.Now()
+	s1, s2 := &Session{id: "1"}, &Session{id: "2"}
+	usage := func(s *Session, name string, age time.Duration, packages int, bytes int64) viewUsage {
+		return viewUsage{
+			session: s,
+			ViewMemory: ViewMemory{
+				Name:     name,
+				LastUsed: now.Add(-age),
+				Packages: packages,
+				Bytes:    bytes,
+			},
+		}
+	}
+	usages := []viewUsage{
+		usage(s1, "a", 1*time.Minute, 10, 100),
+		usage(s1, "b", 20*time.Minute, 10, 300),
+		usage(s1, "c", 3*time.Minute, 10, 200),
+		usage(s2, "d", 2*time.Minute, 10, 400),
+		usage(s2, "e", 30*time.Minute, 0, 0),
+		usage(s2, "f", 5*time.Minute, 10, 100),
+	}
+	names := func(usages []viewUsage) []string {
+		var names []string
+		for _, u := range usages {
+			names = append(names, u.Name)
+		}
+		return names
+	}
+	for _, test := range []struct {
+		name    string
+		budget  MemoryBudget
+		release []string
+		rest    []string
+	}{
+		{"no budget", MemoryBudget{}, nil, []string{"b", "f", "c", "d", "a"}},
+		{"idle", MemoryBudget{Idle: 4 * time.Minute}, []string{"b", "f"}, []string{"c", "d", "a"}},
+		{"session", MemoryBudget{Session: 250}, []string{"b", "f", "c", "d"}, []string{"a"}},
+		{"idle and session", MemoryBudget{Idle: 10 * time.Minute, Session: 350}, []string{"b", "f", "d"}, []string{"c", "a"}},
+	} {
+		release, rest := planRelease(append([]viewUsage(nil), usages...), test.budget, now)
+		if got := names(release); !reflect.DeepEqual(got, test.release) {
+			t.Errorf("%s: released %v, want %v", test.name, got, test.release)
+		}
+		if got := names(rest); !reflect.DeepEqual(got, test.rest) {
+			t.Errorf("%s: kept %v, want %v", test.name, got, test.rest)
+		}
+	}
+}
+
+func TestReleasePackages(t *testing.T) {
+	testenv.NeedsGoPackages(t)
+	dir, err := fake.Tempdir(`
+-- go.mod --
+module mod.com
+
+go 1.12
+-- a/a.go --
+package a
+
+func A() int { return 1 }
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx := context.Background()
+	c := New(ctx, nil)
+	session := c.NewSession(ctx)
+	defer session.Shutdown(ctx)
+	options := source.DefaultOptions().Clone()
+	view, snapshot, release, err := session.NewView(ctx, "a", span.URIFromPath(dir), "", options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	release()
+	uri := span.URIFromPath(filepath.Join(dir, "a", "a.go"))
+	check := func(snapshot source.Snapshot) {
+		t.Helper()
+		if _, err := snapshot.PackagesForFile(ctx, uri, source.TypecheckFull); err != nil {
+			t.Fatal(err)
+		}
+	}
+	snapshot.AwaitInitialized(ctx)
+	check(snapshot)
+
+	v := view.(*View)
+	if usage := v.memoryUsage(); usage.Packages == 0 || usage.Bytes == 0 {
+		t.Fatalf("got %d packages of %d bytes before the release, want some", usage.Packages, usage.Bytes)
+	}
+	if !session.releaseView(ctx, v) {
+		t.Fatal("the package data of the view was not released")
+	}
+	if usage := v.memoryUsage(); usage.Packages != 0 || usage.Released != 1 {
+		t.Errorf("got %d packages and %d releases after the release, want 0 and 1", usage.Packages, usage.Released)
+	}
+
+	// The packages are type-checked again on demand.
+	snapshot, release = view.Snapshot(ctx)
+	defer release()
+	check(snapshot)
+	if usage := v.memoryUsage(); usage.Packages == 0 {
+		t.Errorf("got no packages after a request to the released view")
+	}
+}
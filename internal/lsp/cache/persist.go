@@ -0,0 +1,476 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"fmt"
+	"go/token"
+	"go/types"
+	"os"
+	"reflect"
+	"sort"
+	"sync"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/gcexportdata"
+	"golang.org/x/tools/go/types/objectpath"
+	"golang.org/x/tools/internal/event"
+	"golang.org/x/tools/internal/lsp/debug/tag"
+	"golang.org/x/tools/internal/lsp/filecache"
+	errors "golang.org/x/xerrors"
+)
+
+// This file implements the persistent cache of the results of type checking
+// and analysis, which lets gopls reuse them after it restarts. The export
+// data of dependencies is stored, so that they need not be type-checked
+// again, as well as the diagnostics and facts of analyses.
+//
+// The entries are keyed by the keys of the package handles that they were
+// computed from, which are hashes of the contents of the files of the
+// packages and of the keys of their dependencies.
+
+const (
+	exportDataKind = "export"
+	analysisKind   = "analysis"
+)
+
+// persistentCacheVersion is incremented whenever the encoding of the entries
+// of the persistent cache changes.
+const persistentCacheVersion = "1"
+
+var (
+	executableIDOnce sync.Once
+	executableIDErr  error
+	executableIDStr  string
+)
+
+// executableID identifies the gopls executable, so that entries written by
+// other versions of gopls, whose type checker or analyzers may differ, are
+// not reused.
+func executableID() (string, error) {
+	executableIDOnce.Do(func() {
+		exe, err := os.Executable()
+		if err != nil {
+			executableIDErr = err
+			return
+		}
+		fi, err := os.Stat(exe)
+		if err != nil {
+			executableIDErr = err
+			return
+		}
+		executableIDStr = fmt.Sprintf("%s %d %d", exe, fi.Size(), fi.ModTime().UnixNano())
+	})
+	return executableIDStr, executableIDErr
+}
+
+// fileCache returns the persistent cache, or nil if it is disabled or cannot
+// be opened.
+func (s *snapshot) fileCache(ctx context.Context) *filecache.Cache {
+	if !s.View().Options().ExperimentalPersistentCache {
+		return nil
+	}
+	c := s.view.session.cache
+	c.fileCacheOnce.Do(func() {
+		var dir string
+		_, err := executableID()
+		if err == nil {
+			dir, err = filecache.DefaultDir()
+		}
+		if err == nil {
+			c.fileCache, err = filecache.Open(dir, filecache.DefaultMaxSize)
+		}
+		if err != nil {
+			event.Error(ctx, "disabling the persistent cache", err)
+		}
+	})
+	return c.fileCache
+}
+
+// persistentKey returns the key in the persistent cache of the entry
+// computed from the given inputs.
+func persistentKey(inputs ...string) string {
+	id, _ := executableID()
+	return filecache.Key(append([]string{persistentCacheVersion, id}, inputs...)...)
+}
+
+// exportDataKey returns the key in the persistent cache of the export data
+// of the package with metadata m and the given handle key.
+func exportDataKey(m *metadata, key packageHandleKey) string {
+	// The handle key does not include the sizes of types, which determine
+	// the values of some constants.
+	return persistentKey(string(key), fmt.Sprint(m.typesSizes))
+}
+
+// importExportData sets the type information of p from its export data in
+// the persistent cache, and reports whether it was found. The dependencies of
+// p are type-checked first, so that the export data refers to their
+// objects.
+//
+// Objects imported from export data have positions in files of their own,
+// which record the lines of the declarations but not their columns. The
+// typesInfo of p is left empty.
+func importExportData(ctx context.Context, snapshot *snapshot, fc *filecache.Cache, key string, p *pkg, deps map[packagePath]*packageHandle) bool {
+	data, err := fc.Get(exportDataKind, key)
+	if err != nil {
+		if !errors.Is(err, filecache.ErrNotFound) {
+			event.Error(ctx, "reading export data", err, tag.Package.Of(string(p.m.id)))
+		}
+		return false
+	}
+	imports := make(map[packagePath]*pkg)
+	for _, dep := range deps {
+		depPkg, err := dep.check(ctx, snapshot)
+		if err != nil {
+			return false
+		}
+		imports[depPkg.m.pkgPath] = depPkg
+	}
+
+	// The export data may refer to any transitive dependency, which must
+	// be unambiguously identified by its path.
+	typesByPath := make(map[string]*types.Package)
+	var addDeps func(deps map[packagePath]*pkg) bool
+	addDeps = func(deps map[packagePath]*pkg) bool {
+		for _, dep := range deps {
+			path := dep.types.Path()
+			if prev, ok := typesByPath[path]; ok {
+				if prev != dep.types {
+					return false
+				}
+				continue
+			}
+			typesByPath[path] = dep.types
+			if !addDeps(dep.imports) {
+				return false
+			}
+		}
+		return true
+	}
+	if !addDeps(imports) {
+		return false
+	}
+	known := len(typesByPath)
+	tpkg, err := gcexportdata.Read(bytes.NewReader(data), snapshot.FileSet(), typesByPath, string(p.m.pkgPath))
+	if err != nil {
+		event.Error(ctx, "importing export data", err, tag.Package.Of(string(p.m.id)))
+		return false
+	}
+	// Read adds the package itself to typesByPath, and would add any other
+	// package that the export data refers to but was missing.
+	if len(typesByPath) != known+1 {
+		return false
+	}
+	p.types = tpkg
+	p.imports = imports
+	return true
+}
+
+// writeExportData stores the export data of pkg in the persistent cache.
+func writeExportData(ctx context.Context, fc *filecache.Cache, fset *token.FileSet, key string, pkg *pkg) {
+	var buf bytes.Buffer
+	if err := gcexportdata.Write(&buf, fset, pkg.types); err != nil {
+		event.Error(ctx, "writing export data", err, tag.Package.Of(string(pkg.m.id)))
+		return
+	}
+	if err := fc.Set(exportDataKind, key, buf.Bytes()); err != nil {
+		event.Error(ctx, "writing export data", err, tag.Package.Of(string(pkg.m.id)))
+	}
+}
+
+// analysisKey returns the key in the persistent cache of the results of
+// analyzer a on the package with the given handle key.
+func analysisKey(a *analysis.Analyzer, key packageHandleKey) string {
+	return persistentKey(a.Name, string(key))
+}
+
+// persistable reports whether the results of a can be stored in the
+// persistent cache. The result of an analyzer is an arbitrary value that
+// cannot be stored, so only the analyzers that do not produce results for
+// other analyzers are.
+func persistable(a *analysis.Analyzer) bool {
+	return a.ResultType == nil
+}
+
+// An analysisSummary is the encoding of the results of an analysis of a
+// package in the persistent cache.
+type analysisSummary struct {
+	Diagnostics  []diagnosticSummary
+	ObjectFacts  []factSummary
+	PackageFacts []factSummary
+}
+
+type diagnosticSummary struct {
+	Pos, End       positionSummary
+	Category       string
+	Message        string
+	SuggestedFixes []suggestedFixSummary
+	Related        []relatedSummary
+}
+
+type suggestedFixSummary struct {
+	Message   string
+	TextEdits []textEditSummary
+}
+
+type textEditSummary struct {
+	Pos, End positionSummary
+	NewText  []byte
+}
+
+type relatedSummary struct {
+	Pos, End positionSummary
+	Message  string
+}
+
+// A positionSummary is a position in one of the compiled Go files of a
+// package: the index of the file, or -1 for token.NoPos, and an offset.
+type positionSummary struct {
+	File, Offset int
+}
+
+// A factSummary is a fact about an object, identified by its path, or about
+// the package.
+type factSummary struct {
+	Path string
+	Type string
+	Data []byte
+}
+
+// positionCodec converts the positions in the compiled Go files of a package
+// to and from positionSummaries.
+type positionCodec struct {
+	pkg *pkg
+	err error
+}
+
+func (c *positionCodec) encode(pos token.Pos) positionSummary {
+	if !pos.IsValid() {
+		return positionSummary{File: -1}
+	}
+	for i, pgf := range c.pkg.compiledGoFiles {
+		if tok := pgf.Tok; tok.Base() <= int(pos) && int(pos) <= tok.Base()+tok.Size() {
+			return positionSummary{File: i, Offset: int(pos) - tok.Base()}
+		}
+	}
+	c.err = errors.Errorf("position %d is not in package %s", pos, c.pkg.m.id)
+	return positionSummary{File: -1}
+}
+
+func (c *positionCodec) decode(p positionSummary) token.Pos {
+	if p.File < 0 {
+		return token.NoPos
+	}
+	if p.File >= len(c.pkg.compiledGoFiles) || p.Offset > c.pkg.compiledGoFiles[p.File].Tok.Size() {
+		c.err = errors.Errorf("invalid position %v in package %s", p, c.pkg.m.id)
+		return token.NoPos
+	}
+	return token.Pos(c.pkg.compiledGoFiles[p.File].Tok.Base() + p.Offset)
+}
+
+// factTypeName returns the name of the type of a fact in the persistent
+// cache.
+func factTypeName(t reflect.Type) string {
+	return t.Elem().PkgPath() + "." + t.Elem().Name()
+}
+
+// encodeAnalysis encodes the diagnostics and facts of the analysis data of
+// pkg. Facts about objects of other packages are omitted, as are facts
+// about objects that cannot be referred to from other packages.
+func encodeAnalysis(pkg *pkg, data *actionData) ([]byte, error) {
+	c := &positionCodec{pkg: pkg}
+	var summary analysisSummary
+	for _, d := range data.analysisDiagnostics {
+		ds := diagnosticSummary{
+			Pos:      c.encode(d.Pos),
+			End:      c.encode(d.End),
+			Category: d.Category,
+			Message:  d.Message,
+		}
+		for _, fix := range d.SuggestedFixes {
+			fs := suggestedFixSummary{Message: fix.Message}
+			for _, edit := range fix.TextEdits {
+				fs.TextEdits = append(fs.TextEdits, textEditSummary{
+					Pos:     c.encode(edit.Pos),
+					End:     c.encode(edit.End),
+					NewText: edit.NewText,
+				})
+			}
+			ds.SuggestedFixes = append(ds.SuggestedFixes, fs)
+		}
+		for _, rel := range d.Related {
+			ds.Related = append(ds.Related, relatedSummary{
+				Pos:     c.encode(rel.Pos),
+				End:     c.encode(rel.End),
+				Message: rel.Message,
+			})
+		}
+		summary.Diagnostics = append(summary.Diagnostics, ds)
+	}
+	if c.err != nil {
+		return nil, c.err
+	}
+	for key, fact := range data.objectFacts {
+		if key.obj.Pkg() != pkg.types {
+			continue
+		}
+		path, err := objectpath.For(key.obj)
+		if err != nil {
+			continue
+		}
+		fs, err := encodeFact(string(path), key.typ, fact)
+		if err != nil {
+			return nil, err
+		}
+		summary.ObjectFacts = append(summary.ObjectFacts, fs)
+	}
+	for key, fact := range data.packageFacts {
+		if key.pkg != pkg.types {
+			continue
+		}
+		fs, err := encodeFact("", key.typ, fact)
+		if err != nil {
+			return nil, err
+		}
+		summary.PackageFacts = append(summary.PackageFacts, fs)
+	}
+	// Sort the facts, so that identical results are encoded identically.
+	for _, facts := range [][]factSummary{summary.ObjectFacts, summary.PackageFacts} {
+		sort.Slice(facts, func(i, j int) bool {
+			if facts[i].Path != facts[j].Path {
+				return facts[i].Path < facts[j].Path
+			}
+			return facts[i].Type < facts[j].Type
+		})
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(summary); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func encodeFact(path string, t reflect.Type, fact analysis.Fact) (factSummary, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(fact); err != nil {
+		return factSummary{}, errors.Errorf("encoding %T fact: %w", fact, err)
+	}
+	return factSummary{Path: path, Type: factTypeName(t), Data: buf.Bytes()}, nil
+}
+
+// decodeAnalysis decodes the analysis data of analyzer a on pkg encoded by
+// encodeAnalysis. The diagnostics of the data are left for the caller to
+// compute from its analysisDiagnostics.
+func decodeAnalysis(a *analysis.Analyzer, pkg *pkg, b []byte) (*actionData, error) {
+	var summary analysisSummary
+	if err := gob.NewDecoder(bytes.NewReader(b)).Decode(&summary); err != nil {
+		return nil, err
+	}
+	data := &actionData{
+		objectFacts:  make(map[objectFactKey]analysis.Fact),
+		packageFacts: make(map[packageFactKey]analysis.Fact),
+	}
+	c := &positionCodec{pkg: pkg}
+	for _, ds := range summary.Diagnostics {
+		d := &analysis.Diagnostic{
+			Pos:      c.decode(ds.Pos),
+			End:      c.decode(ds.End),
+			Category: ds.Category,
+			Message:  ds.Message,
+		}
+		for _, fs := range ds.SuggestedFixes {
+			fix := analysis.SuggestedFix{Message: fs.Message}
+			for _, es := range fs.TextEdits {
+				fix.TextEdits = append(fix.TextEdits, analysis.TextEdit{
+					Pos:     c.decode(es.Pos),
+					End:     c.decode(es.End),
+					NewText: es.NewText,
+				})
+			}
+			d.SuggestedFixes = append(d.SuggestedFixes, fix)
+		}
+		for _, rs := range ds.Related {
+			d.Related = append(d.Related, analysis.RelatedInformation{
+				Pos:     c.decode(rs.Pos),
+				End:     c.decode(rs.End),
+				Message: rs.Message,
+			})
+		}
+		data.analysisDiagnostics = append(data.analysisDiagnostics, d)
+	}
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	factTypes := make(map[string]reflect.Type)
+	for _, f := range a.FactTypes {
+		t := factType(f)
+		factTypes[factTypeName(t)] = t
+	}
+	decodeFact := func(fs factSummary) (reflect.Type, analysis.Fact, error) {
+		t, ok := factTypes[fs.Type]
+		if !ok {
+			return nil, nil, errors.Errorf("unknown fact type %s for analyzer %s", fs.Type, a.Name)
+		}
+		fact := reflect.New(t.Elem()).Interface().(analysis.Fact)
+		if err := gob.NewDecoder(bytes.NewReader(fs.Data)).Decode(fact); err != nil {
+			return nil, nil, errors.Errorf("decoding %s fact: %w", fs.Type, err)
+		}
+		return t, fact, nil
+	}
+	for _, fs := range summary.ObjectFacts {
+		obj, err := objectpath.Object(pkg.types, objectpath.Path(fs.Path))
+		if err != nil {
+			return nil, err
+		}
+		t, fact, err := decodeFact(fs)
+		if err != nil {
+			return nil, err
+		}
+		data.objectFacts[objectFactKey{obj, t}] = fact
+	}
+	for _, fs := range summary.PackageFacts {
+		t, fact, err := decodeFact(fs)
+		if err != nil {
+			return nil, err
+		}
+		data.packageFacts[packageFactKey{pkg.types, t}] = fact
+	}
+	return data, nil
+}
+
+// readAnalysis returns the analysis data of analyzer a on pkg from the
+// persistent cache, or nil if it is not found.
+func readAnalysis(ctx context.Context, snapshot *snapshot, fc *filecache.Cache, key string, a *analysis.Analyzer, pkg *pkg) *actionData {
+	b, err := fc.Get(analysisKind, key)
+	if err != nil {
+		if !errors.Is(err, filecache.ErrNotFound) {
+			event.Error(ctx, "reading analysis results", err, tag.Package.Of(string(pkg.m.id)))
+		}
+		return nil
+	}
+	data, err := decodeAnalysis(a, pkg, b)
+	if err != nil {
+		event.Error(ctx, "decoding analysis results", err, tag.Package.Of(string(pkg.m.id)))
+		return nil
+	}
+	data.diagnostics, data.err = toSourceDiagnostics(ctx, snapshot, pkg, a, data.analysisDiagnostics)
+	return data
+}
+
+// writeAnalysis stores the analysis data of pkg in the persistent cache.
+func writeAnalysis(ctx context.Context, fc *filecache.Cache, key string, pkg *pkg, data *actionData) {
+	b, err := encodeAnalysis(pkg, data)
+	if err != nil {
+		event.Error(ctx, "encoding analysis results", err, tag.Package.Of(string(pkg.m.id)))
+		return
+	}
+	if err := fc.Set(analysisKind, key, b); err != nil {
+		event.Error(ctx, "writing analysis results", err, tag.Package.Of(string(pkg.m.id)))
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"strconv"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"golang.org/x/tools/internal/event"
 	"golang.org/x/tools/internal/gocommand"
@@ -140,6 +141,7 @@ func (s *Session) Shutdown(ctx context.Context) {
 	}
 	s.views = nil
 	s.viewMap = nil
+	s.cache.removeSession(s)
 	event.Log(ctx, "Shutdown session", KeyShutdownSession.Of(s))
 }
 
@@ -206,6 +208,7 @@ func (s *Session) createView(ctx context.Context, name string, folder, tempWorks
 		rootURI:              root,
 		workspaceInformation: *ws,
 		tempWorkspace:        tempWorkspace,
+		lastUsed:             time.Now(),
 	}
 	v.importsState = &importsState{
 		ctx: backgroundCtx,
@@ -230,6 +230,7 @@ func (s *snapshot) config(ctx context.Context, inv *gocommand.Invocation) *packa
 	}
 	packagesinternal.SetModFile(cfg, inv.ModFile)
 	packagesinternal.SetModFlag(cfg, inv.ModFlag)
+	packagesinternal.SetGoCmd(cfg, inv.GoCmd)
 	// We want to type check cgo code if go/types supports it.
 	if typesinternal.SetUsesCgo(&types.Config{}) {
 		cfg.Mode |= packages.LoadMode(packagesinternal.TypecheckCgo)
@@ -300,7 +301,9 @@ func (s *snapshot) goCommandInvocation(ctx context.Context, flags source.Invocat
 	allowModfileModificationOption := s.view.options.AllowModfileModifications
 	allowNetworkOption := s.view.options.AllowImplicitNetworkAccess
 	inv.Env = append(append(append(os.Environ(), s.view.options.EnvSlice()...), inv.Env...), "GO111MODULE="+s.view.effectiveGo111Module)
+	inv.Env = append(inv.Env, toolchainEnv(s.view.toolchain)...)
 	inv.BuildFlags = append([]string{}, s.view.options.BuildFlags...)
+	inv.GoCmd = s.view.toolchain.GoCmd
 	s.view.optionsMu.Unlock()
 	cleanup = func() {} // fallback
 
@@ -1288,7 +1291,11 @@ func checkSnapshotLocked(ctx context.Context, s *snapshot) {
 	}
 }
 
-func (s *snapshot) clone(ctx, bgCtx context.Context, changes map[span.URI]*fileChange, forceReloadMetadata bool) (*snapshot, bool) {
+// clone returns a copy of s with the given changes applied, and reports
+// whether the workspace changed. If releasePackages is set, the copy drops
+// all parsed files, type-checked packages and analysis results of s, which
+// are then recomputed on demand.
+func (s *snapshot) clone(ctx, bgCtx context.Context, changes map[span.URI]*fileChange, forceReloadMetadata, releasePackages bool) (*snapshot, bool) {
 	var vendorChanged bool
 	newWorkspace, workspaceChanged, workspaceReload := s.workspace.invalidate(ctx, changes)
 
@@ -1347,7 +1354,7 @@ func (s *snapshot) clone(ctx, bgCtx context.Context, changes map[span.URI]*fileC
 	}
 
 	for k, v := range s.goFiles {
-		if _, ok := changes[k.file.URI]; ok {
+		if _, ok := changes[k.file.URI]; ok || releasePackages {
 			continue
 		}
 		newGen.Inherit(v.handle)
@@ -1461,7 +1468,7 @@ func (s *snapshot) clone(ctx, bgCtx context.Context, changes map[span.URI]*fileC
 
 	// Copy the package type information.
 	for k, v := range s.packages {
-		if _, ok := transitiveIDs[k.id]; ok {
+		if _, ok := transitiveIDs[k.id]; ok || releasePackages {
 			continue
 		}
 		newGen.Inherit(v.handle)
@@ -1469,7 +1476,7 @@ func (s *snapshot) clone(ctx, bgCtx context.Context, changes map[span.URI]*fileC
 	}
 	// Copy the package analysis information.
 	for k, v := range s.actions {
-		if _, ok := transitiveIDs[k.pkg.id]; ok {
+		if _, ok := transitiveIDs[k.pkg.id]; ok || releasePackages {
 			continue
 		}
 		newGen.Inherit(v.handle)
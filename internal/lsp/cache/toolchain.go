@@ -0,0 +1,142 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/tools/internal/event"
+	"golang.org/x/tools/internal/lsp/source"
+	"golang.org/x/tools/internal/span"
+	errors "golang.org/x/xerrors"
+)
+
+// selectToolchain returns the toolchain that runs the go command for folder,
+// as selected by the goroot and toolchainsDir settings of options. The
+// GOROOT and version of the go command on PATH are left empty, as they are
+// only known from `go env`.
+func (s *Session) selectToolchain(ctx context.Context, folder span.URI, options *source.Options) source.Toolchain {
+	if options.Goroot != "" {
+		return toolchainAt(options.Goroot, "goroot setting")
+	}
+	if options.ToolchainsDir == "" {
+		return source.Toolchain{GoCmd: "go"}
+	}
+	goroot, modURI, err := s.findToolchain(ctx, folder, options.ToolchainsDir)
+	if err != nil {
+		event.Error(ctx, "selecting the Go toolchain", err)
+		return source.Toolchain{GoCmd: "go"}
+	}
+	if goroot == "" {
+		return source.Toolchain{GoCmd: "go"}
+	}
+	return toolchainAt(goroot, fmt.Sprintf("go directive of %s", modURI.Filename()))
+}
+
+// findToolchain returns the GOROOT of the toolchain in dir for the go
+// directive of the go.mod file of folder, and the URI of the go.mod file.
+// The GOROOT is empty if there is no go.mod file or go directive.
+func (s *Session) findToolchain(ctx context.Context, folder span.URI, dir string) (string, span.URI, error) {
+	root, err := findRootPattern(ctx, folder, "go.mod", s)
+	if err != nil || root == "" {
+		return "", "", err
+	}
+	modURI := span.URIFromPath(filepath.Join(root.Filename(), "go.mod"))
+	fh, err := s.GetFile(ctx, modURI)
+	if err != nil {
+		return "", "", err
+	}
+	content, err := fh.Read()
+	if err != nil {
+		return "", "", err
+	}
+	modFile, err := modfile.ParseLax(modURI.Filename(), content, nil)
+	if err != nil {
+		return "", "", err
+	}
+	if modFile.Go == nil {
+		return "", "", nil
+	}
+	if strings.HasPrefix(dir, "~"+string(filepath.Separator)) || dir == "~" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", "", err
+		}
+		dir = filepath.Join(home, dir[1:])
+	}
+	goroot, err := latestToolchain(dir, modFile.Go.Version)
+	return goroot, modURI, err
+}
+
+// latestToolchain returns the GOROOT of the latest patch release of the
+// toolchains of dir for a Go version, which are named as by golang.org/dl,
+// such as go1.16 and go1.16.5 for version 1.16.
+func latestToolchain(dir, version string) (string, error) {
+	fis, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	prefix := "go" + version
+	latest, latestPatch := "", -1
+	for _, fi := range fis {
+		name := fi.Name()
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		patch := 0
+		if rest := name[len(prefix):]; rest != "" {
+			// Skip the prereleases, such as go1.16beta1.
+			if rest[0] != '.' {
+				continue
+			}
+			if patch, err = strconv.Atoi(rest[1:]); err != nil {
+				continue
+			}
+		}
+		if patch <= latestPatch {
+			continue
+		}
+		// Skip the toolchains that are not fully installed. The toolchains
+		// may be symbolic links, so their go command is the only check.
+		if _, err := os.Stat(toolchainAt(filepath.Join(dir, name), "").GoCmd); err != nil {
+			continue
+		}
+		latest, latestPatch = name, patch
+	}
+	if latest == "" {
+		return "", errors.Errorf("no Go %s toolchain in %s", version, dir)
+	}
+	return filepath.Join(dir, latest), nil
+}
+
+// toolchainAt returns the toolchain of goroot, selected by selection.
+func toolchainAt(goroot, selection string) source.Toolchain {
+	goCmd := filepath.Join(goroot, "bin", "go")
+	if runtime.GOOS == "windows" {
+		goCmd += ".exe"
+	}
+	return source.Toolchain{
+		GoCmd:     goCmd,
+		GOROOT:    goroot,
+		Selection: selection,
+	}
+}
+
+// toolchainEnv returns the environment variables that make the go command
+// of tc run with its own GOROOT, rather than with a GOROOT set by the user.
+func toolchainEnv(tc source.Toolchain) []string {
+	if tc.Selection == "" {
+		return nil
+	}
+	return []string{"GOROOT=" + tc.GOROOT}
+}
@@ -0,0 +1,58 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"golang.org/x/tools/internal/lsp/fake"
+)
+
+func TestLatestToolchain(t *testing.T) {
+	goCmd := "bin/go"
+	if runtime.GOOS == "windows" {
+		goCmd += ".exe"
+	}
+	var sdk string
+	for _, dir := range []string{"go1.15.8", "go1.16", "go1.16.5", "go1.16.12", "go1.160", "go1.17beta1", "go1.17rc2", "go1.18.1"} {
+		sdk += "-- " + dir + "/" + goCmd + " --\n"
+	}
+	// An unfinished download has no go command.
+	sdk += "-- go1.16.13/README.md --\n"
+	dir, err := fake.Tempdir(sdk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, test := range []struct {
+		version, want string
+	}{
+		{"1.15", "go1.15.8"},
+		{"1.16", "go1.16.12"},
+		{"1.16.5", "go1.16.5"},
+		{"1.17", ""},
+		{"1.18", "go1.18.1"},
+		{"1.19", ""},
+	} {
+		got, err := latestToolchain(dir, test.version)
+		if test.want == "" {
+			if err == nil {
+				t.Errorf("latestToolchain(%q) = %q, want an error", test.version, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("latestToolchain(%q): %v", test.version, err)
+			continue
+		}
+		if want := filepath.Join(dir, test.want); got != want {
+			t.Errorf("latestToolchain(%q) = %q, want %q", test.version, got, want)
+		}
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"golang.org/x/mod/modfile"
 	"golang.org/x/mod/semver"
@@ -76,6 +77,15 @@ type View struct {
 	snapshotMu sync.Mutex
 	snapshot   *snapshot
 
+	// usageMu guards the fields below, which the cache uses to keep the
+	// memory of its sessions within their budget.
+	usageMu sync.Mutex
+	// lastUsed is the time of the last request to the view or change to its
+	// files.
+	lastUsed time.Time
+	// released counts the times the package data of the view was released.
+	released int
+
 	// initialWorkspaceLoad is closed when the first workspace initialization has
 	// completed. If we failed to load, we only retry if the go.mod file changes,
 	// to avoid too many go/packages calls.
@@ -103,6 +113,9 @@ type workspaceInformation struct {
 	// The Go version in use: X in Go 1.X.
 	goversion int
 
+	// toolchain is the Go toolchain that runs the go command.
+	toolchain source.Toolchain
+
 	// hasGopackagesDriver is true if the user has a value set for the
 	// GOPACKAGESDRIVER environment variable or a gopackagesdriver binary on
 	// their machine.
@@ -250,6 +263,9 @@ func minorOptionsChange(a, b *source.Options) bool {
 	if !reflect.DeepEqual(a.DirectoryFilters, b.DirectoryFilters) {
 		return false
 	}
+	if a.Goroot != b.Goroot || a.ToolchainsDir != b.ToolchainsDir {
+		return false
+	}
 	aBuildFlags := make([]string, len(a.BuildFlags))
 	bBuildFlags := make([]string, len(b.BuildFlags))
 	copy(aBuildFlags, a.BuildFlags)
@@ -303,8 +319,9 @@ func (s *snapshot) WriteEnv(ctx context.Context, w io.Writer) error {
 	}
 	goVersion, err := s.view.session.gocmdRunner.Run(ctx, gocommand.Invocation{
 		Verb:       "version",
-		Env:        env,
+		Env:        append(env, toolchainEnv(s.view.toolchain)...),
 		WorkingDir: s.view.rootURI.Filename(),
+		GoCmd:      s.view.toolchain.GoCmd,
 	})
 	if err != nil {
 		return err
@@ -312,12 +329,14 @@ func (s *snapshot) WriteEnv(ctx context.Context, w io.Writer) error {
 	fmt.Fprintf(w, `go env for %v
 (root %s)
 (go version %s)
+(go command %s)
 (valid build configuration = %v)
 (build flags: %v)
 `,
 		s.view.folder.Filename(),
 		s.view.rootURI.Filename(),
 		strings.TrimRight(goVersion.String(), "\n"),
+		s.view.toolchain.GoCmd,
 		s.ValidBuildConfiguration(),
 		buildFlags)
 	for k, v := range fullEnv {
@@ -504,9 +523,17 @@ func checkIgnored(suffix string) bool {
 }
 
 func (v *View) Snapshot(ctx context.Context) (source.Snapshot, func()) {
+	v.touch()
 	return v.getSnapshot(ctx)
 }
 
+// touch records that v is in use.
+func (v *View) touch() {
+	v.usageMu.Lock()
+	v.lastUsed = time.Now()
+	v.usageMu.Unlock()
+}
+
 func (v *View) getSnapshot(ctx context.Context) (*snapshot, func()) {
 	v.snapshotMu.Lock()
 	defer v.snapshotMu.Unlock()
@@ -603,6 +630,7 @@ func (v *View) invalidateContent(ctx context.Context, changes map[span.URI]*file
 
 	// Do not clone a snapshot until its view has finished initializing.
 	v.snapshot.AwaitInitialized(ctx)
+	v.touch()
 
 	// This should be the only time we hold the view's snapshot lock for any period of time.
 	v.snapshotMu.Lock()
@@ -611,7 +639,7 @@ func (v *View) invalidateContent(ctx context.Context, changes map[span.URI]*file
 	oldSnapshot := v.snapshot
 
 	var workspaceChanged bool
-	v.snapshot, workspaceChanged = oldSnapshot.clone(ctx, v.baseCtx, changes, forceReloadMetadata)
+	v.snapshot, workspaceChanged = oldSnapshot.clone(ctx, v.baseCtx, changes, forceReloadMetadata, false)
 	if workspaceChanged && v.tempWorkspace != "" {
 		snap := v.snapshot
 		go func() {
@@ -629,6 +657,39 @@ func (v *View) invalidateContent(ctx context.Context, changes map[span.URI]*file
 	return v.snapshot, v.snapshot.generation.Acquire(ctx)
 }
 
+// releasePackages replaces the snapshot of v with a copy that does not hold
+// its parsed files, type-checked packages and analysis results, so that the
+// cache can free them. They are recomputed by the next requests to v. It
+// reports false if v has not finished its initial workspace load.
+//
+// The caller must hold the viewMu of the session of v, so that v cannot be
+// shut down concurrently.
+func (v *View) releasePackages(ctx context.Context) bool {
+	select {
+	case <-v.initialWorkspaceLoad:
+	default:
+		return false
+	}
+	ctx = xcontext.Detach(ctx)
+
+	v.snapshotMu.Lock()
+	defer v.snapshotMu.Unlock()
+
+	// Unlike invalidateContent, let the requests that are running on the old
+	// snapshot complete, as its data is still valid.
+	oldSnapshot := v.snapshot
+	v.snapshot, _ = oldSnapshot.clone(ctx, v.baseCtx, nil, false, true)
+	go func() {
+		oldSnapshot.generation.Destroy()
+		oldSnapshot.cancel()
+	}()
+
+	v.usageMu.Lock()
+	v.released++
+	v.usageMu.Unlock()
+	return true
+}
+
 func copyWorkspace(dst span.URI, src span.URI) error {
 	for _, name := range []string{"go.mod", "go.sum"} {
 		srcname := filepath.Join(src.Filename(), name)
@@ -655,9 +716,11 @@ func (s *Session) getWorkspaceInformation(ctx context.Context, folder span.URI,
 		return nil, errors.Errorf("invalid workspace configuration: %w", err)
 	}
 	var err error
+	toolchain := s.selectToolchain(ctx, folder, options)
 	inv := gocommand.Invocation{
 		WorkingDir: folder.Filename(),
-		Env:        options.EnvSlice(),
+		Env:        append(options.EnvSlice(), toolchainEnv(toolchain)...),
+		GoCmd:      toolchain.GoCmd,
 	}
 	goversion, err := gocommand.GoVersion(ctx, inv, s.gocmdRunner)
 	if err != nil {
@@ -669,10 +732,16 @@ func (s *Session) getWorkspaceInformation(ctx context.Context, folder span.URI,
 		go111module = v
 	}
 	// Make sure to get the `go env` before continuing with initialization.
-	envVars, env, err := s.getGoEnv(ctx, folder.Filename(), goversion, go111module, options.EnvSlice())
+	envVars, env, err := s.getGoEnv(ctx, folder.Filename(), goversion, go111module, toolchain, options.EnvSlice())
 	if err != nil {
 		return nil, err
 	}
+	toolchain.GOROOT = envVars.goroot
+	toolchain.Version = env["GOVERSION"]
+	if toolchain.Version == "" {
+		// GOVERSION does not appear in `go env` output until Go 1.16.
+		toolchain.Version = fmt.Sprintf("go1.%d", goversion)
+	}
 	// If using 1.16, change the default back to auto. The primary effect of
 	// GO111MODULE=on is to break GOPATH, which we aren't too interested in.
 	if goversion >= 16 && go111module == "" {
@@ -697,6 +766,7 @@ func (s *Session) getWorkspaceInformation(ctx context.Context, folder span.URI,
 		effectiveGo111Module: go111module,
 		userGo111Module:      go111moduleForVersion(go111module, goversion),
 		goversion:            goversion,
+		toolchain:            toolchain,
 		environmentVariables: envVars,
 		goEnv:                env,
 	}, nil
@@ -813,7 +883,7 @@ func validBuildConfiguration(folder span.URI, ws *workspaceInformation, modFiles
 }
 
 // getGoEnv gets the view's various GO* values.
-func (s *Session) getGoEnv(ctx context.Context, folder string, goversion int, go111module string, configEnv []string) (environmentVariables, map[string]string, error) {
+func (s *Session) getGoEnv(ctx context.Context, folder string, goversion int, go111module string, toolchain source.Toolchain, configEnv []string) (environmentVariables, map[string]string, error) {
 	envVars := environmentVariables{}
 	vars := map[string]*string{
 		"GOCACHE":     &envVars.gocache,
@@ -825,7 +895,7 @@ func (s *Session) getGoEnv(ctx context.Context, folder string, goversion int, go
 	}
 
 	// We can save ~200 ms by requesting only the variables we care about.
-	args := append([]string{"-json"}, imports.RequiredGoEnvVars...)
+	args := append([]string{"-json", "GOVERSION"}, imports.RequiredGoEnvVars...)
 	for k := range vars {
 		args = append(args, k)
 	}
@@ -833,8 +903,9 @@ func (s *Session) getGoEnv(ctx context.Context, folder string, goversion int, go
 	inv := gocommand.Invocation{
 		Verb:       "env",
 		Args:       args,
-		Env:        configEnv,
+		Env:        append(configEnv, toolchainEnv(toolchain)...),
 		WorkingDir: folder,
+		GoCmd:      toolchain.GoCmd,
 	}
 	// Don't go through runGoCommand, as we don't need a temporary -modfile to
 	// run `go env`.
@@ -862,6 +933,10 @@ func (s *Session) getGoEnv(ctx context.Context, folder string, goversion int, go
 	return envVars, env, err
 }
 
+func (v *View) Toolchain() source.Toolchain {
+	return v.toolchain
+}
+
 func (v *View) IsGoPrivatePath(target string) bool {
 	return globsMatchPath(v.goprivate, target)
 }
@@ -0,0 +1,161 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"golang.org/x/tools/internal/lsp/filecache"
+	"golang.org/x/tools/internal/tool"
+)
+
+// cacheCommand implements the cache verb for gopls, which manages the
+// persistent cache enabled by the experimentalPersistentCache setting.
+type cacheCommand struct {
+	app *Application
+}
+
+func (c *cacheCommand) subCommands() []tool.Application {
+	return []tool.Application{
+		&cacheInfo{},
+		&cacheClean{},
+	}
+}
+
+func (c *cacheCommand) Name() string  { return "cache" }
+func (c *cacheCommand) Usage() string { return "<subcommand> [args...]" }
+func (c *cacheCommand) ShortHelp() string {
+	return "inspect or clean the persistent cache"
+}
+func (c *cacheCommand) DetailedHelp(f *flag.FlagSet) {
+	fmt.Fprint(f.Output(), `
+The persistent cache stores type information and analysis results on disk,
+when the experimentalPersistentCache setting is enabled. It is in the gopls
+subdirectory of the user's cache directory, or in the directory named by
+$GOPLSCACHE.
+
+subcommands:
+`)
+	for _, c := range c.subCommands() {
+		fmt.Fprintf(f.Output(), "  %s: %s\n", c.Name(), c.ShortHelp())
+	}
+	f.PrintDefaults()
+}
+
+func (c *cacheCommand) Run(ctx context.Context, args ...string) error {
+	if len(args) == 0 {
+		return tool.CommandLineErrorf("must provide subcommand to %q", c.Name())
+	}
+	command, args := args[0], args[1:]
+	for _, c := range c.subCommands() {
+		if c.Name() == command {
+			return tool.Run(ctx, c, args)
+		}
+	}
+	return tool.CommandLineErrorf("unknown command %v", command)
+}
+
+func openFileCache() (*filecache.Cache, error) {
+	dir, err := filecache.DefaultDir()
+	if err != nil {
+		return nil, err
+	}
+	return filecache.Open(dir, filecache.DefaultMaxSize)
+}
+
+// cacheInfo is a cache subcommand to print the contents of the cache.
+type cacheInfo struct{}
+
+func (c *cacheInfo) Name() string  { return "info" }
+func (c *cacheInfo) Usage() string { return "" }
+func (c *cacheInfo) ShortHelp() string {
+	return "print the location of the cache and the size of its entries"
+}
+func (c *cacheInfo) DetailedHelp(f *flag.FlagSet) {
+	fmt.Fprint(f.Output(), `
+Example:
+
+  $ gopls cache info
+`)
+	f.PrintDefaults()
+}
+
+func (c *cacheInfo) Run(ctx context.Context, args ...string) error {
+	if len(args) != 0 {
+		return tool.CommandLineErrorf("cache info expects no arguments")
+	}
+	fc, err := openFileCache()
+	if err != nil {
+		return err
+	}
+	usage, err := fc.Usage()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("directory: %s\n", fc.Dir())
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "kind\tentries\tsize")
+	var entries int
+	var size int64
+	for _, u := range usage {
+		fmt.Fprintf(w, "%s\t%d\t%s\n", u.Kind, u.Entries, formatSize(u.Size))
+		entries += u.Entries
+		size += u.Size
+	}
+	fmt.Fprintf(w, "total\t%d\t%s\n", entries, formatSize(size))
+	return w.Flush()
+}
+
+func formatSize(size int64) string {
+	switch {
+	case size >= 1<<30:
+		return fmt.Sprintf("%.1fGB", float64(size)/(1<<30))
+	case size >= 1<<20:
+		return fmt.Sprintf("%.1fMB", float64(size)/(1<<20))
+	case size >= 1<<10:
+		return fmt.Sprintf("%.1fKB", float64(size)/(1<<10))
+	}
+	return fmt.Sprintf("%dB", size)
+}
+
+// cacheClean is a cache subcommand to remove the entries of the cache.
+type cacheClean struct {
+	Size int64 `flag:"size" help:"if positive, only evict the least recently used entries until the cache is at most this many bytes"`
+}
+
+func (c *cacheClean) Name() string  { return "clean" }
+func (c *cacheClean) Usage() string { return "[clean-flags]" }
+func (c *cacheClean) ShortHelp() string {
+	return "remove the entries of the cache"
+}
+func (c *cacheClean) DetailedHelp(f *flag.FlagSet) {
+	fmt.Fprint(f.Output(), `
+Example: remove all entries, or only enough to bring the cache to 100MB
+
+  $ gopls cache clean
+  $ gopls cache clean -size=100000000
+
+clean-flags:
+`)
+	f.PrintDefaults()
+}
+
+func (c *cacheClean) Run(ctx context.Context, args ...string) error {
+	if len(args) != 0 {
+		return tool.CommandLineErrorf("cache clean expects no arguments")
+	}
+	fc, err := openFileCache()
+	if err != nil {
+		return err
+	}
+	if c.Size > 0 {
+		return fc.Trim(c.Size)
+	}
+	return fc.Clean()
+}
@@ -8,35 +8,75 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"os"
+	"strings"
 
+	"golang.org/x/tools/internal/lsp/protocol"
 	"golang.org/x/tools/internal/span"
+	"golang.org/x/tools/internal/tool"
 	errors "golang.org/x/xerrors"
 )
 
 // check implements the check verb for gopls.
 type check struct {
+	Format   string `flag:"format" help:"the output format: text, json or sarif"`
+	Severity string `flag:"severity" help:"exit with a non-zero status if there are diagnostics of one of these comma-separated severities: error, warning, information or hint"`
+
 	app *Application
 }
 
 func (c *check) Name() string      { return "check" }
-func (c *check) Usage() string     { return "<filename>" }
-func (c *check) ShortHelp() string { return "show diagnostic results for the specified file" }
+func (c *check) Usage() string     { return "<filename or package pattern>..." }
+func (c *check) ShortHelp() string { return "show diagnostic results for files or packages" }
 func (c *check) DetailedHelp(f *flag.FlagSet) {
 	fmt.Fprint(f.Output(), `
 Example: show the diagnostic results of this file:
 
   $ gopls check internal/lsp/cmd/check.go
+
+Example: report the diagnostics of all packages in SARIF, and fail if there
+are errors or warnings:
+
+  $ gopls check -format=sarif -severity=error,warning ./... > gopls.sarif
+
+The json and sarif formats also report the suggestions of the convenience
+analyzers, such as fillstruct, and include the edits of the suggested fixes
+of each diagnostic.
+
+gopls check flags are:
 `)
 	f.PrintDefaults()
 }
 
-// Run performs the check on the files specified by args and prints the
-// results to stdout.
+// Run performs the check on the files or packages specified by args and
+// prints the results to stdout.
 func (c *check) Run(ctx context.Context, args ...string) error {
+	var machine bool
+	switch c.Format {
+	case "", "text":
+	case "json", "sarif":
+		machine = true
+	default:
+		return tool.CommandLineErrorf("unknown format %q", c.Format)
+	}
+	failOn := map[protocol.DiagnosticSeverity]bool{}
+	if c.Severity != "" {
+		for _, name := range strings.Split(c.Severity, ",") {
+			severity := protocol.ParseDiagnosticSeverity(strings.Title(strings.ToLower(strings.TrimSpace(name))))
+			if severity == 0 {
+				return tool.CommandLineErrorf("unknown severity %q", name)
+			}
+			failOn[severity] = true
+		}
+	}
 	if len(args) == 0 {
 		// no files, so no results
 		return nil
 	}
+	filenames, err := c.app.expandFiles(args)
+	if err != nil {
+		return err
+	}
 	checking := map[span.URI]*cmdFile{}
 	var uris []span.URI
 	// now we ready to kick things off
@@ -45,8 +85,8 @@ func (c *check) Run(ctx context.Context, args ...string) error {
 		return err
 	}
 	defer conn.terminate(ctx)
-	for _, arg := range args {
-		uri := span.URIFromPath(arg)
+	for _, filename := range filenames {
+		uri := span.URIFromPath(filename)
 		uris = append(uris, uri)
 		file := conn.AddFile(ctx, uri)
 		if file.err != nil {
@@ -54,19 +94,122 @@ func (c *check) Run(ctx context.Context, args ...string) error {
 		}
 		checking[uri] = file
 	}
-	if err := conn.diagnoseFiles(ctx, uris); err != nil {
+	if err := conn.diagnoseFiles(ctx, uris, machine); err != nil {
 		return err
 	}
+	var results []*checkResult
 	conn.Client.filesMu.Lock()
-	defer conn.Client.filesMu.Unlock()
-
-	for _, file := range checking {
+	for _, uri := range uris {
+		file := checking[uri]
 		for _, d := range file.diagnostics {
 			spn, err := file.mapper.RangeSpan(d.Range)
 			if err != nil {
+				conn.Client.filesMu.Unlock()
 				return errors.Errorf("Could not convert position %v for %q", d.Range, d.Message)
 			}
-			fmt.Printf("%v: %v\n", spn, d.Message)
+			results = append(results, &checkResult{file: file, span: spn, diagnostic: d})
+		}
+	}
+	conn.Client.filesMu.Unlock()
+
+	switch c.Format {
+	case "json":
+		if err := c.addFixes(ctx, conn, results); err != nil {
+			return err
+		}
+		if err := printJSON(os.Stdout, results); err != nil {
+			return err
+		}
+	case "sarif":
+		if err := c.addFixes(ctx, conn, results); err != nil {
+			return err
+		}
+		if err := printSARIF(os.Stdout, c.app.wd, results); err != nil {
+			return err
+		}
+	default:
+		for _, r := range results {
+			fmt.Printf("%v: %v\n", r.span, r.diagnostic.Message)
+		}
+	}
+
+	failed := 0
+	for _, r := range results {
+		if failOn[r.diagnostic.Severity] {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return errors.Errorf("%d diagnostics with severity %s", failed, c.Severity)
+	}
+	return nil
+}
+
+// A checkResult is a diagnostic reported by the check verb, with the
+// edits of its suggested fixes.
+type checkResult struct {
+	file       *cmdFile
+	span       span.Span
+	diagnostic protocol.Diagnostic
+	fixes      []protocol.CodeAction
+}
+
+// addFixes adds to results the code actions that fix their diagnostics,
+// with their edits.
+func (c *check) addFixes(ctx context.Context, conn *connection, results []*checkResult) error {
+	byFile := map[*cmdFile][]*checkResult{}
+	var files []*cmdFile
+	for _, r := range results {
+		if byFile[r.file] == nil {
+			files = append(files, r.file)
+		}
+		byFile[r.file] = append(byFile[r.file], r)
+	}
+	for _, file := range files {
+		fileResults := byFile[file]
+		// The range of the code actions spans all the diagnostics.
+		var diagnostics []protocol.Diagnostic
+		rng := fileResults[0].diagnostic.Range
+		for _, r := range fileResults {
+			diagnostics = append(diagnostics, r.diagnostic)
+			if protocol.ComparePosition(r.diagnostic.Range.Start, rng.Start) < 0 {
+				rng.Start = r.diagnostic.Range.Start
+			}
+			if protocol.ComparePosition(r.diagnostic.Range.End, rng.End) > 0 {
+				rng.End = r.diagnostic.Range.End
+			}
+		}
+		actions, err := conn.CodeAction(ctx, &protocol.CodeActionParams{
+			TextDocument: protocol.TextDocumentIdentifier{
+				URI: protocol.URIFromSpanURI(file.uri),
+			},
+			Context: protocol.CodeActionContext{
+				Only:        []protocol.CodeActionKind{protocol.QuickFix, protocol.SourceFixAll, protocol.RefactorRewrite},
+				Diagnostics: diagnostics,
+			},
+			Range: rng,
+		})
+		if err != nil {
+			return errors.Errorf("%v: %v", file.uri, err)
+		}
+		for _, action := range actions {
+			if len(action.Diagnostics) == 0 {
+				continue
+			}
+			action, err := conn.resolveCodeAction(ctx, action)
+			if err != nil {
+				return errors.Errorf("%v: %v", file.uri, err)
+			}
+			if len(action.Edit.DocumentChanges) == 0 {
+				continue
+			}
+			for _, d := range action.Diagnostics {
+				for _, r := range fileResults {
+					if r.diagnostic.Message == d.Message && protocol.CompareRange(r.diagnostic.Range, d.Range) == 0 {
+						r.fixes = append(r.fixes, action)
+					}
+				}
+			}
 		}
 	}
 	return nil
@@ -0,0 +1,295 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/internal/lsp/protocol"
+)
+
+// This file implements the json and sarif output formats of the check verb.
+
+// A jsonDiagnostic is a diagnostic in the json output of the check verb.
+// Its positions are those of the LSP: zero-based lines and UTF-16 columns.
+type jsonDiagnostic struct {
+	File     string         `json:"file"`
+	Posn     string         `json:"posn"`
+	Range    protocol.Range `json:"range"`
+	Severity string         `json:"severity"`
+	Code     string         `json:"code,omitempty"`
+	CodeHref string         `json:"codeHref,omitempty"`
+	Source   string         `json:"source,omitempty"`
+	Message  string         `json:"message"`
+	Fixes    []jsonFix      `json:"fixes,omitempty"`
+}
+
+// A jsonFix is a suggested fix of a diagnostic.
+type jsonFix struct {
+	Title string     `json:"title"`
+	Edits []jsonEdit `json:"edits"`
+}
+
+// A jsonEdit is an edit of a suggested fix.
+type jsonEdit struct {
+	File    string         `json:"file"`
+	Range   protocol.Range `json:"range"`
+	NewText string         `json:"newText"`
+}
+
+// printJSON prints results to w as a JSON array of diagnostics.
+func printJSON(w io.Writer, results []*checkResult) error {
+	diagnostics := []jsonDiagnostic{}
+	for _, r := range results {
+		d := jsonDiagnostic{
+			File:     r.file.uri.Filename(),
+			Posn:     fmt.Sprint(r.span),
+			Range:    r.diagnostic.Range,
+			Severity: severityName(r.diagnostic.Severity),
+			Code:     diagnosticCode(r.diagnostic),
+			Source:   r.diagnostic.Source,
+			Message:  r.diagnostic.Message,
+		}
+		if r.diagnostic.CodeDescription != nil {
+			d.CodeHref = string(r.diagnostic.CodeDescription.Href)
+		}
+		for _, action := range r.fixes {
+			fix := jsonFix{Title: action.Title}
+			for _, c := range action.Edit.DocumentChanges {
+				change := c.TextDocumentEdit
+				if change == nil {
+					continue
+				}
+				for _, edit := range change.Edits {
+					fix.Edits = append(fix.Edits, jsonEdit{
+						File:    fileURI(change.TextDocument.URI).Filename(),
+						Range:   edit.Range,
+						NewText: edit.NewText,
+					})
+				}
+			}
+			d.Fixes = append(d.Fixes, fix)
+		}
+		diagnostics = append(diagnostics, d)
+	}
+	data, err := json.MarshalIndent(diagnostics, "", "\t")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", data)
+	return err
+}
+
+// severityName returns the lower case name of severity, as accepted by the
+// -severity flag of the check verb.
+func severityName(severity protocol.DiagnosticSeverity) string {
+	return strings.ToLower(fmt.Sprint(severity))
+}
+
+// diagnosticCode returns the code of d as a string.
+func diagnosticCode(d protocol.Diagnostic) string {
+	if d.Code == nil {
+		return ""
+	}
+	return fmt.Sprint(d.Code)
+}
+
+// The following types are the subset of the SARIF 2.1.0 format used by the
+// check verb. See https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html.
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool               sarifTool                        `json:"tool"`
+	OriginalURIBaseIDs map[string]sarifArtifactLocation `json:"originalUriBaseIds,omitempty"`
+	ColumnKind         string                           `json:"columnKind"`
+	Results            []sarifResult                    `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID      string `json:"id"`
+	HelpURI string `json:"helpUri,omitempty"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+	Fixes     []sarifFix      `json:"fixes,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+// A sarifRegion has one-based lines and columns. The unit of the columns
+// is that of the columnKind of the run.
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn"`
+	EndLine     int `json:"endLine"`
+	EndColumn   int `json:"endColumn"`
+}
+
+type sarifFix struct {
+	Description     sarifMessage          `json:"description"`
+	ArtifactChanges []sarifArtifactChange `json:"artifactChanges"`
+}
+
+type sarifArtifactChange struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Replacements     []sarifReplacement    `json:"replacements"`
+}
+
+type sarifReplacement struct {
+	DeletedRegion   sarifRegion   `json:"deletedRegion"`
+	InsertedContent *sarifMessage `json:"insertedContent,omitempty"`
+}
+
+// srcRoot is the base id of the locations of the files under the working
+// directory in the sarif output.
+const srcRoot = "%SRCROOT%"
+
+// printSARIF prints results to w as a SARIF log with a single run, whose
+// files under wd are relative to it.
+func printSARIF(w io.Writer, wd string, results []*checkResult) error {
+	location := func(uri protocol.DocumentURI) sarifArtifactLocation {
+		filename := fileURI(uri).Filename()
+		if rel, err := filepath.Rel(wd, filename); err == nil && !strings.HasPrefix(rel, "..") {
+			return sarifArtifactLocation{URI: filepath.ToSlash(rel), URIBaseID: srcRoot}
+		}
+		return sarifArtifactLocation{URI: string(uri)}
+	}
+	run := sarifRun{
+		Tool: sarifTool{
+			Driver: sarifDriver{
+				Name:           "gopls",
+				InformationURI: "https://pkg.go.dev/golang.org/x/tools/gopls",
+				Rules:          []sarifRule{},
+			},
+		},
+		OriginalURIBaseIDs: map[string]sarifArtifactLocation{
+			srcRoot: {URI: string(protocol.URIFromPath(wd)) + "/"},
+		},
+		ColumnKind: "utf16CodeUnits",
+		Results:    []sarifResult{},
+	}
+	rules := map[string]sarifRule{}
+	for _, r := range results {
+		ruleID := diagnosticCode(r.diagnostic)
+		if ruleID == "" {
+			ruleID = r.diagnostic.Source
+		}
+		if _, ok := rules[ruleID]; !ok {
+			rule := sarifRule{ID: ruleID}
+			if r.diagnostic.CodeDescription != nil {
+				rule.HelpURI = string(r.diagnostic.CodeDescription.Href)
+			}
+			rules[ruleID] = rule
+		}
+		result := sarifResult{
+			RuleID:  ruleID,
+			Level:   sarifLevel(r.diagnostic.Severity),
+			Message: sarifMessage{Text: r.diagnostic.Message},
+			Locations: []sarifLocation{{
+				PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: location(protocol.URIFromSpanURI(r.file.uri)),
+					Region:           toSARIFRegion(r.diagnostic.Range),
+				},
+			}},
+		}
+		for _, action := range r.fixes {
+			fix := sarifFix{Description: sarifMessage{Text: action.Title}}
+			for _, c := range action.Edit.DocumentChanges {
+				change := c.TextDocumentEdit
+				if change == nil {
+					continue
+				}
+				artifactChange := sarifArtifactChange{ArtifactLocation: location(change.TextDocument.URI)}
+				for _, edit := range change.Edits {
+					replacement := sarifReplacement{DeletedRegion: toSARIFRegion(edit.Range)}
+					if edit.NewText != "" {
+						replacement.InsertedContent = &sarifMessage{Text: edit.NewText}
+					}
+					artifactChange.Replacements = append(artifactChange.Replacements, replacement)
+				}
+				fix.ArtifactChanges = append(fix.ArtifactChanges, artifactChange)
+			}
+			result.Fixes = append(result.Fixes, fix)
+		}
+		run.Results = append(run.Results, result)
+	}
+	for _, rule := range rules {
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, rule)
+	}
+	sort.Slice(run.Tool.Driver.Rules, func(i, j int) bool {
+		return run.Tool.Driver.Rules[i].ID < run.Tool.Driver.Rules[j].ID
+	})
+	data, err := json.MarshalIndent(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	}, "", "\t")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", data)
+	return err
+}
+
+// sarifLevel returns the SARIF level of a diagnostic severity.
+func sarifLevel(severity protocol.DiagnosticSeverity) string {
+	switch severity {
+	case protocol.SeverityError:
+		return "error"
+	case protocol.SeverityWarning:
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+func toSARIFRegion(rng protocol.Range) sarifRegion {
+	return sarifRegion{
+		StartLine:   int(rng.Start.Line) + 1,
+		StartColumn: int(rng.Start.Character) + 1,
+		EndLine:     int(rng.End.Line) + 1,
+		EndColumn:   int(rng.End.Character) + 1,
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/tools/internal/lsp/protocol"
+	"golang.org/x/tools/internal/span"
+)
+
+func checkResults(wd string) []*checkResult {
+	uri := span.URIFromPath(filepath.Join(wd, "a", "a.go"))
+	rng := protocol.Range{
+		Start: protocol.Position{Line: 4, Character: 12},
+		End:   protocol.Position{Line: 4, Character: 13},
+	}
+	return []*checkResult{{
+		file: &cmdFile{uri: uri},
+		span: span.New(uri, span.NewPoint(5, 13, 0), span.NewPoint(5, 14, 0)),
+		diagnostic: protocol.Diagnostic{
+			Range:    rng,
+			Severity: protocol.SeverityWarning,
+			Source:   "simplifycompositelit",
+			Message:  "redundant type from array, slice, or map composite literal",
+		},
+		fixes: []protocol.CodeAction{{
+			Title: "Remove 'T'",
+			Edit: protocol.WorkspaceEdit{
+				DocumentChanges: []protocol.DocumentChanges{{
+					TextDocumentEdit: &protocol.TextDocumentEdit{
+						TextDocument: protocol.OptionalVersionedTextDocumentIdentifier{
+							TextDocumentIdentifier: protocol.TextDocumentIdentifier{URI: protocol.URIFromSpanURI(uri)},
+						},
+						Edits: []protocol.TextEdit{{Range: rng}},
+					},
+				}},
+			},
+		}},
+	}}
+}
+
+func TestPrintJSON(t *testing.T) {
+	wd := filepath.FromSlash("/src")
+	var buf bytes.Buffer
+	if err := printJSON(&buf, checkResults(wd)); err != nil {
+		t.Fatal(err)
+	}
+	var got []jsonDiagnostic
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("got %d diagnostics, want 1", len(got))
+	}
+	d := got[0]
+	if d.Severity != "warning" || d.Source != "simplifycompositelit" || d.Range.Start.Character != 12 {
+		t.Errorf("got diagnostic %+v, want a simplifycompositelit warning at character 12", d)
+	}
+	if len(d.Fixes) != 1 || len(d.Fixes[0].Edits) != 1 || d.Fixes[0].Edits[0].File != filepath.Join(wd, "a", "a.go") {
+		t.Errorf("got fixes %+v, want 1 fix with 1 edit of a/a.go", d.Fixes)
+	}
+}
+
+func TestPrintSARIF(t *testing.T) {
+	wd := filepath.FromSlash("/src")
+	var buf bytes.Buffer
+	if err := printSARIF(&buf, wd, checkResults(wd)); err != nil {
+		t.Fatal(err)
+	}
+	var got sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Version != "2.1.0" || len(got.Runs) != 1 {
+		t.Fatalf("got version %q with %d runs, want 2.1.0 with 1 run", got.Version, len(got.Runs))
+	}
+	run := got.Runs[0]
+	if len(run.Tool.Driver.Rules) != 1 || run.Tool.Driver.Rules[0].ID != "simplifycompositelit" {
+		t.Errorf("got rules %+v, want simplifycompositelit", run.Tool.Driver.Rules)
+	}
+	if len(run.Results) != 1 {
+		t.Fatalf("got %d results, want 1", len(run.Results))
+	}
+	result := run.Results[0]
+	if result.Level != "warning" || result.RuleID != "simplifycompositelit" {
+		t.Errorf("got level %q and rule %q, want warning and simplifycompositelit", result.Level, result.RuleID)
+	}
+	loc := result.Locations[0].PhysicalLocation
+	wantRegion := sarifRegion{StartLine: 5, StartColumn: 13, EndLine: 5, EndColumn: 14}
+	if loc.ArtifactLocation != (sarifArtifactLocation{URI: "a/a.go", URIBaseID: srcRoot}) || loc.Region != wantRegion {
+		t.Errorf("got location %+v, want a/a.go relative to %s at %+v", loc, srcRoot, wantRegion)
+	}
+	if len(result.Fixes) != 1 || len(result.Fixes[0].ArtifactChanges) != 1 {
+		t.Fatalf("got fixes %+v, want 1 fix of 1 file", result.Fixes)
+	}
+	if replacements := result.Fixes[0].ArtifactChanges[0].Replacements; len(replacements) != 1 || replacements[0].DeletedRegion != wantRegion || replacements[0].InsertedContent != nil {
+		t.Errorf("got replacements %+v, want a deletion of %+v", replacements, wantRegion)
+	}
+}
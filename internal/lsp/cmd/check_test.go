@@ -0,0 +1,87 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/internal/tool"
+)
+
+func TestCheckPatterns(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "check")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	files := map[string]string{
+		"go.mod":      "module mod.com\n\ngo 1.12\n",
+		"a/a.go":      "package a\n\nvar x int = \"hello\"\n",
+		"b/b.go":      "package b\n\nvar y string = 1\n",
+		"c/c.go":      "package c\n",
+		"c/c_test.go": "package c\n\nvar z bool = 0\n",
+	}
+	for name, content := range files {
+		filename := filepath.Join(tmpDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filename, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, test := range []struct {
+		args    []string
+		wantErr bool
+	}{
+		{[]string{"check", "./..."}, false},
+		{[]string{"check", "-severity=warning", "./..."}, false},
+		{[]string{"check", "-severity=error", "./..."}, true},
+	} {
+		app := New("gopls-test", tmpDir, os.Environ(), nil)
+		out, err := captureStdout(t, func() error {
+			return tool.Run(context.Background(), app, test.args)
+		})
+		if gotErr := err != nil; gotErr != test.wantErr {
+			t.Errorf("gopls %s: got error %v, want error: %t", strings.Join(test.args, " "), err, test.wantErr)
+		}
+		// The pattern expands to the files of the packages and their tests.
+		for _, name := range []string{"a/a.go", "b/b.go", "c/c_test.go"} {
+			if !strings.Contains(out, filepath.Join(tmpDir, filepath.FromSlash(name))+":") {
+				t.Errorf("gopls %s: no diagnostic of %s in output:\n%s", strings.Join(test.args, " "), name, out)
+			}
+		}
+	}
+}
+
+// captureStdout returns what f writes to os.Stdout, and the error of f.
+func captureStdout(t *testing.T, f func() error) (string, error) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	done := make(chan struct{})
+	go func() {
+		io.Copy(&buf, r)
+		close(done)
+	}()
+	stdout := os.Stdout
+	os.Stdout = w
+	err = f()
+	os.Stdout = stdout
+	w.Close()
+	<-done
+	r.Close()
+	return buf.String(), err
+}
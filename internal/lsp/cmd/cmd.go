@@ -9,16 +9,19 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"go/token"
 	"io/ioutil"
 	"log"
 	"os"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
+	"golang.org/x/tools/go/packages"
 	"golang.org/x/tools/internal/jsonrpc2"
 	"golang.org/x/tools/internal/lsp"
 	"golang.org/x/tools/internal/lsp/cache"
@@ -69,6 +72,9 @@ type Application struct {
 	// Control ocagent export of telemetry
 	OCAgent string `flag:"ocagent" help:"the address of the ocagent (e.g. http://localhost:55678), or off"`
 
+	// Control OpenTelemetry export of metrics
+	OTLP string `flag:"otlp" help:"the address of the OpenTelemetry collector to export metrics to using OTLP/HTTP (e.g. http://localhost:4318), or off"`
+
 	// PrepareOptions is called to update the options when a new view is built.
 	// It is primarily to allow the behavior of gopls to be modified by hooks.
 	PrepareOptions func(*source.Options)
@@ -89,6 +95,7 @@ func New(name, wd string, env []string, options func(*source.Options)) *Applicat
 		wd:      wd,
 		env:     env,
 		OCAgent: "off", //TODO: Remove this line to default the exporter to on
+		OTLP:    "off",
 
 		Serve: Serve{
 			RemoteListenTimeout: 1 * time.Minute,
@@ -141,7 +148,7 @@ gopls flags are:
 // If no arguments are passed it will invoke the server sub command, as a
 // temporary measure for compatibility.
 func (app *Application) Run(ctx context.Context, args ...string) error {
-	ctx = debug.WithInstance(ctx, app.wd, app.OCAgent)
+	ctx = debug.WithInstance(ctx, app.wd, app.OCAgent, app.OTLP)
 	app.Serve.app = app
 	if len(args) == 0 {
 		return tool.Run(ctx, &app.Serve, args)
@@ -172,6 +179,7 @@ func (app *Application) mainCommands() []tool.Application {
 		&bug{},
 		&apiJSON{},
 		&licenses{app: app},
+		&cacheCommand{app: app},
 	}
 }
 
@@ -179,6 +187,7 @@ func (app *Application) featureCommands() []tool.Application {
 	return []tool.Application{
 		&callHierarchy{app: app},
 		&check{app: app},
+		&declaration{app: app},
 		&definition{app: app},
 		&foldingRanges{app: app},
 		&format{app: app},
@@ -190,6 +199,7 @@ func (app *Application) featureCommands() []tool.Application {
 		&prepareRename{app: app},
 		&references{app: app},
 		&rename{app: app},
+		&selectionRange{app: app},
 		&semtok{app: app},
 		&signature{app: app},
 		&suggestedFix{app: app},
@@ -289,6 +299,9 @@ func (c *connection) initialize(ctx context.Context, options func(*source.Option
 	params.Capabilities.TextDocument.SemanticTokens.Requests.Full = true
 	params.Capabilities.TextDocument.SemanticTokens.TokenTypes = lsp.SemanticTypes()
 	params.Capabilities.TextDocument.SemanticTokens.TokenModifiers = lsp.SemanticModifiers()
+	// The edits of code actions are resolved by resolveCodeAction.
+	params.Capabilities.TextDocument.CodeAction.DataSupport = true
+	params.Capabilities.TextDocument.CodeAction.ResolveSupport.Properties = []string{"edit"}
 	params.InitializationOptions = map[string]interface{}{
 		"symbolMatcher": matcherString[opts.SymbolMatcher],
 	}
@@ -345,6 +358,57 @@ func fileURI(uri protocol.DocumentURI) span.URI {
 	return sURI
 }
 
+// expandFiles returns the names of the files of args, which are either file
+// names or package patterns, such as ./...
+func (app *Application) expandFiles(args []string) ([]string, error) {
+	var filenames, patterns []string
+	for _, arg := range args {
+		if strings.HasSuffix(arg, ".go") {
+			if fi, err := os.Stat(arg); err == nil && !fi.IsDir() {
+				filenames = append(filenames, arg)
+				continue
+			}
+		}
+		patterns = append(patterns, arg)
+	}
+	if len(patterns) == 0 {
+		return filenames, nil
+	}
+	pkgs, err := packages.Load(&packages.Config{
+		Mode:  packages.NeedName | packages.NeedFiles,
+		Dir:   app.wd,
+		Env:   append(os.Environ(), app.env...),
+		Tests: true,
+	}, patterns...)
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	for _, filename := range filenames {
+		seen[filename] = true
+	}
+	var expanded []string
+	for _, pkg := range pkgs {
+		// The files of test main packages are generated in the build cache.
+		if strings.HasSuffix(pkg.PkgPath, ".test") {
+			continue
+		}
+		for _, err := range pkg.Errors {
+			if err.Kind == packages.ListError && len(pkg.GoFiles) == 0 {
+				return nil, errors.Errorf("%s: %v", pkg.PkgPath, err)
+			}
+		}
+		for _, filename := range pkg.GoFiles {
+			if !seen[filename] {
+				seen[filename] = true
+				expanded = append(expanded, filename)
+			}
+		}
+	}
+	sort.Strings(expanded)
+	return append(filenames, expanded...), nil
+}
+
 func (c *cmdClient) ShowMessage(ctx context.Context, p *protocol.ShowMessageParams) error { return nil }
 
 func (c *cmdClient) ShowMessageRequest(ctx context.Context, p *protocol.ShowMessageRequestParams) (*protocol.MessageActionItem, error) {
@@ -517,7 +581,9 @@ func (c *connection) semanticTokens(ctx context.Context, file span.URI) (*protoc
 	return resp, nil
 }
 
-func (c *connection) diagnoseFiles(ctx context.Context, files []span.URI) error {
+// diagnoseFiles waits for the diagnostics of files. If convenience is set,
+// they include the suggestions of the convenience analyzers.
+func (c *connection) diagnoseFiles(ctx context.Context, files []span.URI, convenience bool) error {
 	var untypedFiles []interface{}
 	for _, file := range files {
 		untypedFiles = append(untypedFiles, string(file))
@@ -526,9 +592,44 @@ func (c *connection) diagnoseFiles(ctx context.Context, files []span.URI) error
 	defer c.Client.diagnosticsMu.Unlock()
 
 	c.Client.diagnosticsDone = make(chan struct{})
-	_, err := c.Server.NonstandardRequest(ctx, "gopls/diagnoseFiles", map[string]interface{}{"files": untypedFiles})
+	result, err := c.Server.NonstandardRequest(ctx, "gopls/diagnoseFiles", map[string]interface{}{
+		"files":       untypedFiles,
+		"convenience": convenience,
+	})
+	if err != nil {
+		// The server reports the end of the diagnostics only on success.
+		return err
+	}
 	<-c.Client.diagnosticsDone
-	return err
+	// The published diagnostics of the files may have been overwritten by
+	// those published when they were opened, so reset them from the result.
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	var reports []protocol.PublishDiagnosticsParams
+	if err := json.Unmarshal(data, &reports); err != nil {
+		return err
+	}
+	c.Client.filesMu.Lock()
+	defer c.Client.filesMu.Unlock()
+	for _, report := range reports {
+		c.Client.getFile(ctx, fileURI(report.URI)).diagnostics = report.Diagnostics
+	}
+	return nil
+}
+
+// resolveCodeAction returns action with its edits, which the server may
+// leave to be computed by a codeAction/resolve request.
+func (c *connection) resolveCodeAction(ctx context.Context, action protocol.CodeAction) (protocol.CodeAction, error) {
+	if action.Data == nil {
+		return action, nil
+	}
+	resolved, err := c.ResolveCodeAction(ctx, &action)
+	if err != nil {
+		return protocol.CodeAction{}, err
+	}
+	return *resolved, nil
 }
 
 func (c *connection) terminate(ctx context.Context) {
@@ -0,0 +1,87 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"sort"
+
+	"golang.org/x/tools/internal/lsp/protocol"
+	"golang.org/x/tools/internal/span"
+	"golang.org/x/tools/internal/tool"
+)
+
+// declaration implements the declaration verb for gopls
+type declaration struct {
+	app *Application
+}
+
+func (d *declaration) Name() string      { return "declaration" }
+func (d *declaration) Usage() string     { return "<position>" }
+func (d *declaration) ShortHelp() string { return "display selected identifier's declaration" }
+func (d *declaration) DetailedHelp(f *flag.FlagSet) {
+	fmt.Fprint(f.Output(), `
+Example:
+
+  $ # 1-indexed location (:line:column or :#offset) of the target identifier
+  $ gopls declaration helper/helper.go:8:6
+  $ gopls declaration helper/helper.go:#53
+`)
+	f.PrintDefaults()
+}
+
+func (d *declaration) Run(ctx context.Context, args ...string) error {
+	if len(args) != 1 {
+		return tool.CommandLineErrorf("declaration expects 1 argument (position)")
+	}
+
+	conn, err := d.app.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.terminate(ctx)
+
+	from := span.Parse(args[0])
+	file := conn.AddFile(ctx, from.URI())
+	if file.err != nil {
+		return file.err
+	}
+
+	loc, err := file.mapper.Location(from)
+	if err != nil {
+		return err
+	}
+
+	p := protocol.DeclarationParams{
+		TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: loc.URI},
+			Position:     loc.Range.Start,
+		},
+	}
+
+	declarations, err := conn.Declaration(ctx, &p)
+	if err != nil {
+		return err
+	}
+
+	var spans []string
+	for _, decl := range declarations {
+		f := conn.AddFile(ctx, fileURI(decl.URI))
+		span, err := f.mapper.Span(decl)
+		if err != nil {
+			return err
+		}
+		spans = append(spans, fmt.Sprint(span))
+	}
+	sort.Strings(spans)
+
+	for _, s := range spans {
+		fmt.Println(s)
+	}
+
+	return nil
+}
@@ -81,8 +81,11 @@ func (r *rename) Run(ctx context.Context, args ...string) error {
 	var orderedURIs []string
 	edits := map[span.URI][]protocol.TextEdit{}
 	for _, c := range edit.DocumentChanges {
-		uri := fileURI(c.TextDocument.URI)
-		edits[uri] = append(edits[uri], c.Edits...)
+		if c.TextDocumentEdit == nil {
+			continue
+		}
+		uri := fileURI(c.TextDocumentEdit.TextDocument.URI)
+		edits[uri] = append(edits[uri], c.TextDocumentEdit.Edits...)
 		orderedURIs = append(orderedURIs, string(uri))
 	}
 	sort.Strings(orderedURIs)
@@ -0,0 +1,94 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"golang.org/x/tools/internal/lsp/protocol"
+	"golang.org/x/tools/internal/span"
+	"golang.org/x/tools/internal/tool"
+)
+
+// selectionRange implements the selection verb for gopls.
+type selectionRange struct {
+	app *Application
+}
+
+func (r *selectionRange) Name() string      { return "selection" }
+func (r *selectionRange) Usage() string     { return "<position>..." }
+func (r *selectionRange) ShortHelp() string { return "display selected positions' enclosing ranges" }
+func (r *selectionRange) DetailedHelp(f *flag.FlagSet) {
+	fmt.Fprint(f.Output(), `
+For each position, the enclosing ranges are printed innermost first, one per
+line. All positions must be in the same file.
+
+Example:
+
+  $ # 1-indexed location (:line:column or :#offset) of the target position
+  $ gopls selection helper/helper.go:8:6
+  $ gopls selection helper/helper.go:8:6 helper/helper.go:#53
+`)
+	f.PrintDefaults()
+}
+
+func (r *selectionRange) Run(ctx context.Context, args ...string) error {
+	if len(args) == 0 {
+		return tool.CommandLineErrorf("selection expects at least 1 argument (position)")
+	}
+
+	conn, err := r.app.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.terminate(ctx)
+
+	var froms []span.Span
+	for _, arg := range args {
+		from := span.Parse(arg)
+		if len(froms) > 0 && from.URI() != froms[0].URI() {
+			return tool.CommandLineErrorf("selection positions must be in the same file")
+		}
+		froms = append(froms, from)
+	}
+	file := conn.AddFile(ctx, froms[0].URI())
+	if file.err != nil {
+		return file.err
+	}
+
+	p := protocol.SelectionRangeParams{
+		TextDocument: protocol.TextDocumentIdentifier{
+			URI: protocol.URIFromSpanURI(froms[0].URI()),
+		},
+	}
+	for _, from := range froms {
+		loc, err := file.mapper.Location(from)
+		if err != nil {
+			return err
+		}
+		p.Positions = append(p.Positions, loc.Range.Start)
+	}
+
+	ranges, err := conn.SelectionRange(ctx, &p)
+	if err != nil {
+		return err
+	}
+
+	for i, sr := range ranges {
+		if i > 0 {
+			fmt.Println()
+		}
+		for cur := &sr; cur != nil; cur = cur.Parent {
+			s, err := file.mapper.Span(protocol.Location{Range: cur.Range})
+			if err != nil {
+				return err
+			}
+			fmt.Println(s)
+		}
+	}
+	return nil
+}
@@ -11,6 +11,7 @@ import (
 	"io"
 	"log"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -35,9 +36,16 @@ type Serve struct {
 	Trace       bool          `flag:"rpc.trace" help:"print the full rpc trace in lsp inspector format"`
 	Debug       string        `flag:"debug" help:"serve debug information on the supplied address"`
 
+	MemoryBudget  string        `flag:"memory.budget" help:"when used with -listen, the heap size (such as 4GiB) above which the package data of the least recently used views is released, and new clients are refused"`
+	SessionMemory string        `flag:"memory.session" help:"when used with -listen, the estimated size (such as 1GiB) of the package data of a client session above which that of its least recently used views is released"`
+	MemoryIdle    time.Duration `flag:"memory.idle" help:"when used with -listen, release the package data of views without requests for this duration"`
+
 	RemoteListenTimeout time.Duration `flag:"remote.listen.timeout" help:"when used with -remote=auto, the -listen.timeout value used to start the daemon"`
 	RemoteDebug         string        `flag:"remote.debug" help:"when used with -remote=auto, the -debug value used to start the daemon"`
 	RemoteLogfile       string        `flag:"remote.logfile" help:"when used with -remote=auto, the -logfile value used to start the daemon"`
+	RemoteMemoryBudget  string        `flag:"remote.memory.budget" help:"when used with -remote=auto, the -memory.budget value used to start the daemon"`
+	RemoteSessionMemory string        `flag:"remote.memory.session" help:"when used with -remote=auto, the -memory.session value used to start the daemon"`
+	RemoteMemoryIdle    time.Duration `flag:"remote.memory.idle" help:"when used with -remote=auto, the -memory.idle value used to start the daemon"`
 
 	app *Application
 }
@@ -84,9 +92,22 @@ func (s *Serve) Run(ctx context.Context, args ...string) error {
 			lsprpc.RemoteDebugAddress(s.RemoteDebug),
 			lsprpc.RemoteListenTimeout(s.RemoteListenTimeout),
 			lsprpc.RemoteLogfile(s.RemoteLogfile),
+			lsprpc.RemoteMemoryBudget(s.RemoteMemoryBudget),
+			lsprpc.RemoteSessionMemory(s.RemoteSessionMemory),
+			lsprpc.RemoteMemoryIdle(s.RemoteMemoryIdle),
 		)
 	} else {
-		ss = lsprpc.NewStreamServer(cache.New(ctx, s.app.options), isDaemon)
+		c := cache.New(ctx, s.app.options)
+		if isDaemon {
+			budget, err := s.memoryBudget()
+			if err != nil {
+				return err
+			}
+			if budget != (cache.MemoryBudget{}) {
+				c.ManageMemory(ctx, budget)
+			}
+		}
+		ss = lsprpc.NewStreamServer(c, isDaemon)
 	}
 
 	var network, addr string
@@ -114,6 +135,51 @@ func (s *Serve) Run(ctx context.Context, args ...string) error {
 	return err
 }
 
+// memoryBudget returns the memory budget of the -memory.* flags.
+func (s *Serve) memoryBudget() (cache.MemoryBudget, error) {
+	budget := cache.MemoryBudget{Idle: s.MemoryIdle}
+	if s.MemoryBudget != "" {
+		heap, err := parseBytes(s.MemoryBudget)
+		if err != nil {
+			return budget, tool.CommandLineErrorf("invalid -memory.budget: %v", err)
+		}
+		budget.Heap = uint64(heap)
+	}
+	if s.SessionMemory != "" {
+		session, err := parseBytes(s.SessionMemory)
+		if err != nil {
+			return budget, tool.CommandLineErrorf("invalid -memory.session: %v", err)
+		}
+		budget.Session = session
+	}
+	return budget, nil
+}
+
+// parseBytes parses a size in bytes, with an optional unit such as MB or
+// MiB.
+func parseBytes(s string) (int64, error) {
+	units := []struct {
+		suffix string
+		size   int64
+	}{
+		{"KiB", 1 << 10}, {"MiB", 1 << 20}, {"GiB", 1 << 30},
+		{"KB", 1e3}, {"MB", 1e6}, {"GB", 1e9},
+		{"B", 1},
+	}
+	number, size := s, int64(1)
+	for _, u := range units {
+		if strings.HasSuffix(s, u.suffix) {
+			number, size = strings.TrimSpace(strings.TrimSuffix(s, u.suffix)), u.size
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(number, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%q is not a size such as 512MiB", s)
+	}
+	return int64(n * float64(size)), nil
+}
+
 // parseAddr parses the -listen flag in to a network, and address.
 func parseAddr(listen string) (network string, address string) {
 	// Allow passing just -remote=auto, as a shorthand for using automatic remote
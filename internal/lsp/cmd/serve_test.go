@@ -26,3 +26,35 @@ func TestListenParsing(t *testing.T) {
 		}
 	}
 }
+
+func TestBytesParsing(t *testing.T) {
+	tests := []struct {
+		input string
+		want  int64
+	}{
+		{"1024", 1024},
+		{"512B", 512},
+		{"4GiB", 4 << 30},
+		{"1.5 MiB", 3 << 19},
+		{"2KB", 2000},
+		{"3GB", 3e9},
+		{"GiB", -1},
+		{"-1MiB", -1},
+		{"4TiB", -1},
+	}
+
+	for _, test := range tests {
+		got, err := parseBytes(test.input)
+		if test.want < 0 {
+			if err == nil {
+				t.Errorf("parseBytes(%q) = %d, want an error", test.input, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseBytes(%q): %v", test.input, err)
+		} else if got != test.want {
+			t.Errorf("parseBytes(%q) = %d, want %d", test.input, got, test.want)
+		}
+	}
+}
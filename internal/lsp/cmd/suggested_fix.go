@@ -6,10 +6,14 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"io/ioutil"
+	"os"
+	"strings"
 
+	"golang.org/x/tools/internal/lsp/command"
 	"golang.org/x/tools/internal/lsp/diff"
 	"golang.org/x/tools/internal/lsp/protocol"
 	"golang.org/x/tools/internal/lsp/source"
@@ -20,15 +24,16 @@ import (
 
 // suggestedFix implements the fix verb for gopls.
 type suggestedFix struct {
-	Diff  bool `flag:"d" help:"display diffs instead of rewriting files"`
-	Write bool `flag:"w" help:"write result to (source) file instead of stdout"`
-	All   bool `flag:"a" help:"apply all fixes, not just preferred fixes"`
+	Diff      bool   `flag:"d,diff" help:"display diffs instead of rewriting files"`
+	Write     bool   `flag:"w" help:"write result to (source) file instead of stdout"`
+	All       bool   `flag:"a" help:"apply all fixes, not just preferred fixes"`
+	Analyzers string `flag:"analyzers" help:"apply the fixes of these comma-separated analyzers to all the files or packages of the arguments"`
 
 	app *Application
 }
 
 func (s *suggestedFix) Name() string      { return "fix" }
-func (s *suggestedFix) Usage() string     { return "<filename>" }
+func (s *suggestedFix) Usage() string     { return "<filename> | -analyzers=<names> <files or packages>" }
 func (s *suggestedFix) ShortHelp() string { return "apply suggested fixes" }
 func (s *suggestedFix) DetailedHelp(f *flag.FlagSet) {
 	fmt.Fprintf(f.Output(), `
@@ -49,6 +54,9 @@ func (s *suggestedFix) Run(ctx context.Context, args ...string) error {
 	if len(args) < 1 {
 		return tool.CommandLineErrorf("fix expects at least 1 argument")
 	}
+	if s.Analyzers != "" {
+		return s.runAnalyzers(ctx, args)
+	}
 	conn, err := s.app.connect(ctx)
 	if err != nil {
 		return err
@@ -62,7 +70,7 @@ func (s *suggestedFix) Run(ctx context.Context, args ...string) error {
 		return file.err
 	}
 
-	if err := conn.diagnoseFiles(ctx, []span.URI{uri}); err != nil {
+	if err := conn.diagnoseFiles(ctx, []span.URI{uri}, false); err != nil {
 		return err
 	}
 	conn.Client.filesMu.Lock()
@@ -96,16 +104,20 @@ func (s *suggestedFix) Run(ctx context.Context, args ...string) error {
 	}
 	var edits []protocol.TextEdit
 	for _, a := range actions {
-		if a.Command != nil {
-			return fmt.Errorf("ExecuteCommand is not yet supported on the command line")
-		}
 		if !a.IsPreferred && !s.All {
 			continue
 		}
+		a, err := conn.resolveCodeAction(ctx, a)
+		if err != nil {
+			return errors.Errorf("%v: %v", from, err)
+		}
+		if a.Command != nil {
+			return fmt.Errorf("ExecuteCommand is not yet supported on the command line")
+		}
 		if !from.HasPosition() {
 			for _, c := range a.Edit.DocumentChanges {
-				if fileURI(c.TextDocument.URI) == uri {
-					edits = append(edits, c.Edits...)
+				if c.TextDocumentEdit != nil && fileURI(c.TextDocumentEdit.TextDocument.URI) == uri {
+					edits = append(edits, c.TextDocumentEdit.Edits...)
 				}
 			}
 			continue
@@ -119,8 +131,8 @@ func (s *suggestedFix) Run(ctx context.Context, args ...string) error {
 			}
 			if span.ComparePoint(from.Start(), spn.Start()) == 0 {
 				for _, c := range a.Edit.DocumentChanges {
-					if fileURI(c.TextDocument.URI) == uri {
-						edits = append(edits, c.Edits...)
+					if c.TextDocumentEdit != nil && fileURI(c.TextDocumentEdit.TextDocument.URI) == uri {
+						edits = append(edits, c.TextDocumentEdit.Edits...)
 					}
 				}
 				break
@@ -130,8 +142,8 @@ func (s *suggestedFix) Run(ctx context.Context, args ...string) error {
 		// If suggested fix is not a diagnostic, still must collect edits.
 		if len(a.Diagnostics) == 0 {
 			for _, c := range a.Edit.DocumentChanges {
-				if fileURI(c.TextDocument.URI) == uri {
-					edits = append(edits, c.Edits...)
+				if c.TextDocumentEdit != nil && fileURI(c.TextDocumentEdit.TextDocument.URI) == uri {
+					edits = append(edits, c.TextDocumentEdit.Edits...)
 				}
 			}
 		}
@@ -157,3 +169,94 @@ func (s *suggestedFix) Run(ctx context.Context, args ...string) error {
 	}
 	return nil
 }
+
+// runAnalyzers applies the fixes of the analyzers of the -analyzers flag to
+// the files or packages specified by args, and reports the fixes that were
+// not applied as they conflict with another fix.
+func (s *suggestedFix) runAnalyzers(ctx context.Context, args []string) error {
+	var analyzers []string
+	for _, name := range strings.Split(s.Analyzers, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			analyzers = append(analyzers, name)
+		}
+	}
+	filenames, err := s.app.expandFiles(args)
+	if err != nil {
+		return err
+	}
+	conn, err := s.app.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.terminate(ctx)
+
+	var uris []protocol.DocumentURI
+	for _, filename := range filenames {
+		file := conn.AddFile(ctx, span.URIFromPath(filename))
+		if file.err != nil {
+			return file.err
+		}
+		uris = append(uris, protocol.URIFromSpanURI(file.uri))
+	}
+	cmd, err := command.NewAnalyzerFixesCommand("", command.AnalyzerFixesArgs{
+		URIs:      uris,
+		Analyzers: analyzers,
+	})
+	if err != nil {
+		return err
+	}
+	res, err := conn.ExecuteCommand(ctx, &protocol.ExecuteCommandParams{
+		Command:   cmd.Command,
+		Arguments: cmd.Arguments,
+	})
+	if err != nil {
+		return err
+	}
+	// The result is decoded as a map, so convert it back.
+	data, err := json.Marshal(res)
+	if err != nil {
+		return err
+	}
+	var result command.AnalyzerFixesResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return err
+	}
+
+	for _, conflict := range result.Conflicts {
+		file := conn.AddFile(ctx, fileURI(conflict.Location.URI))
+		if file.err != nil {
+			return file.err
+		}
+		spn, err := file.mapper.RangeSpan(conflict.Location.Range)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "%v: %s: %s: conflicts with another fix\n", spn, conflict.Analyzer, conflict.Title)
+	}
+	for _, change := range result.Edits {
+		file := conn.AddFile(ctx, fileURI(change.TextDocument.URI))
+		if file.err != nil {
+			return file.err
+		}
+		edits, err := source.FromProtocolEdits(file.mapper, change.Edits)
+		if err != nil {
+			return errors.Errorf("%v: %v", file.uri, err)
+		}
+		newContent := diff.ApplyEdits(string(file.mapper.Content), edits)
+
+		filename := file.uri.Filename()
+		switch {
+		case s.Write:
+			if err := ioutil.WriteFile(filename, []byte(newContent), 0644); err != nil {
+				return err
+			}
+		case s.Diff:
+			diffs := diff.ToUnified(filename+".orig", filename, string(file.mapper.Content), edits)
+			fmt.Print(diffs)
+		default:
+			fmt.Printf("%s:\n", filename)
+			fmt.Print(newContent)
+		}
+	}
+	return nil
+}
@@ -48,7 +48,7 @@ func TestCommandLine(t *testing.T, testdata string, options func(*source.Options
 }
 
 func NewTestServer(ctx context.Context, options func(*source.Options)) *servertest.TCPServer {
-	ctx = debug.WithInstance(ctx, "", "")
+	ctx = debug.WithInstance(ctx, "", "", "")
 	cache := cache.New(ctx, options)
 	ss := lsprpc.NewStreamServer(cache, false)
 	return servertest.NewTCPServer(ctx, ss, nil)
@@ -100,6 +100,14 @@ func (r *runner) FunctionExtraction(t *testing.T, start span.Span, end span.Span
 	//TODO: function extraction not supported on command line
 }
 
+func (r *runner) MethodExtraction(t *testing.T, start span.Span, end span.Span) {
+	//TODO: method extraction not supported on command line
+}
+
+func (r *runner) SuggestedFixError(t *testing.T, spn span.Span, fixErr tests.SuggestedFixError) {
+	//TODO: fix errors are not reported distinctly on the command line
+}
+
 func (r *runner) runGoplsCmd(t testing.TB, args ...string) (string, string) {
 	rStdout, wStdout, err := os.Pipe()
 	if err != nil {
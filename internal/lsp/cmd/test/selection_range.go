@@ -0,0 +1,30 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmdtest
+
+import (
+	"fmt"
+	"testing"
+
+	"golang.org/x/tools/internal/span"
+)
+
+func (r *runner) SelectionRanges(t *testing.T, uri span.URI, spans []span.Span) {
+	filename := uri.Filename()
+	args := []string{"selection"}
+	for _, spn := range spans {
+		args = append(args, fmt.Sprintf("%v:%v:%v", filename, spn.Start().Line(), spn.Start().Column()))
+	}
+	got, stderr := r.NormalizeGoplsCmd(t, args...)
+	if stderr != "" {
+		t.Fatalf("selection failed for %s: %s", filename, stderr)
+	}
+	expect := string(r.data.Golden("selectionrange-cmd", filename, func() ([]byte, error) {
+		return []byte(got), nil
+	}))
+	if expect != got {
+		t.Errorf("selection failed for %s expected:\n%s\ngot:\n%s", filename, expect, got)
+	}
+}
@@ -6,6 +6,7 @@ package lsp
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"sort"
 	"strings"
@@ -58,6 +59,11 @@ func (s *Server) codeAction(ctx context.Context, params *protocol.CodeActionPara
 		return nil, fmt.Errorf("no supported code action to execute for %s, wanted %v", uri, params.Context.Only)
 	}
 
+	// If the client can resolve code actions, the edits of the expensive
+	// ones are computed by codeAction/resolve, and only for the action that
+	// the user selects.
+	lazy := snapshot.View().Options().CodeActionResolveSupported
+
 	var codeActions []protocol.CodeAction
 	switch fh.Kind() {
 	case source.Mod:
@@ -69,7 +75,7 @@ func (s *Server) codeAction(ctx context.Context, params *protocol.CodeActionPara
 			if err != nil {
 				return nil, err
 			}
-			quickFixes, err := codeActionsMatchingDiagnostics(ctx, snapshot, diagnostics, diags)
+			quickFixes, err := codeActionsMatchingDiagnostics(ctx, snapshot, diagnostics, diags, lazy)
 			if err != nil {
 				return nil, err
 			}
@@ -86,70 +92,72 @@ func (s *Server) codeAction(ctx context.Context, params *protocol.CodeActionPara
 		// First, process any missing imports and pair them with the
 		// diagnostics they fix.
 		if wantQuickFixes := wanted[protocol.QuickFix] && len(diagnostics) > 0; wantQuickFixes || wanted[protocol.SourceOrganizeImports] {
-			importEdits, importEditsPerFix, err := source.AllImportsFixes(ctx, snapshot, fh)
+			importFixes, err := source.ImportFixes(ctx, snapshot, fh)
 			if err != nil {
 				event.Error(ctx, "imports fixes", err, tag.File.Of(fh.URI().Filename()))
 			}
 			// Separate this into a set of codeActions per diagnostic, where
 			// each action is the addition, removal, or renaming of one import.
 			if wantQuickFixes {
-				for _, importFix := range importEditsPerFix {
-					fixes := importDiagnostics(importFix.Fix, diagnostics)
+				for _, importFix := range importFixes {
+					fixes := importDiagnostics(importFix, diagnostics)
 					if len(fixes) == 0 {
 						continue
 					}
-					codeActions = append(codeActions, protocol.CodeAction{
-						Title: importFixTitle(importFix.Fix),
-						Kind:  protocol.QuickFix,
-						Edit: protocol.WorkspaceEdit{
-							DocumentChanges: documentChanges(fh, importFix.Edits),
-						},
+					action := protocol.CodeAction{
+						Title:       importFixTitle(importFix),
+						Kind:        protocol.QuickFix,
 						Diagnostics: fixes,
-					})
+					}
+					if lazy {
+						action.Data = codeActionData{URI: params.TextDocument.URI, ImportFix: importFix}
+					} else {
+						edits, err := source.ImportFixEdits(ctx, snapshot, fh, []*imports.ImportFix{importFix})
+						if err != nil {
+							event.Error(ctx, "imports fixes", err, tag.File.Of(fh.URI().Filename()))
+							continue
+						}
+						action.Edit.DocumentChanges = documentChanges(fh, edits)
+					}
+					codeActions = append(codeActions, action)
 				}
 			}
 
 			// Send all of the import edits as one code action if the file is
 			// being organized.
-			if wanted[protocol.SourceOrganizeImports] && len(importEdits) > 0 {
-				codeActions = append(codeActions, protocol.CodeAction{
-					Title: "Organize Imports",
-					Kind:  protocol.SourceOrganizeImports,
-					Edit: protocol.WorkspaceEdit{
-						DocumentChanges: documentChanges(fh, importEdits),
-					},
-				})
+			if wanted[protocol.SourceOrganizeImports] && err == nil {
+				importEdits, err := source.ImportFixEdits(ctx, snapshot, fh, importFixes)
+				if err != nil {
+					event.Error(ctx, "imports fixes", err, tag.File.Of(fh.URI().Filename()))
+				} else if len(importEdits) > 0 {
+					codeActions = append(codeActions, protocol.CodeAction{
+						Title: "Organize Imports",
+						Kind:  protocol.SourceOrganizeImports,
+						Edit: protocol.WorkspaceEdit{
+							DocumentChanges: documentChanges(fh, importEdits),
+						},
+					})
+				}
 			}
 		}
 		if ctx.Err() != nil {
 			return nil, ctx.Err()
 		}
-		pkg, err := snapshot.PackageForFile(ctx, fh.URI(), source.TypecheckFull, source.WidestPackage)
-		if err != nil {
-			return nil, err
-		}
-
-		pkgDiagnostics, err := snapshot.DiagnosePackage(ctx, pkg)
-		if err != nil {
-			return nil, err
-		}
-		analysisDiags, err := source.Analyze(ctx, snapshot, pkg, true)
-		if err != nil {
-			return nil, err
-		}
-		fileDiags := append(pkgDiagnostics[uri], analysisDiags[uri]...)
-		modURI := snapshot.GoModForFile(fh.URI())
-		if modURI != "" {
-			modFH, err := snapshot.GetVersionedFile(ctx, modURI)
+		var fileDiags []*source.Diagnostic
+		if lazy {
+			// The edits are left to codeAction/resolve, so build the actions
+			// from the diagnostics of the last diagnostics pass instead of
+			// analyzing the package again.
+			fileDiags, err = convenienceDiagnostics(ctx, snapshot, fh, wanted)
 			if err != nil {
 				return nil, err
 			}
-			modDiags, err := mod.DiagnosticsForMod(ctx, snapshot, modFH)
-			if err != nil && !source.IsNonFatalGoModError(err) {
-				// Not a fatal error.
-				event.Error(ctx, "module suggested fixes failed", err, tag.Directory.Of(snapshot.View().Folder()))
+			fileDiags = append(fileDiags, s.storedDiagnostics(uri)...)
+		} else {
+			fileDiags, err = fileDiagnostics(ctx, snapshot, fh)
+			if err != nil {
+				return nil, err
 			}
-			fileDiags = append(fileDiags, modDiags...)
 		}
 
 		// Split diagnostics into fixes, which must match incoming diagnostics,
@@ -171,7 +179,7 @@ func (s *Server) codeAction(ctx context.Context, params *protocol.CodeActionPara
 			}
 		}
 
-		fixActions, err := codeActionsMatchingDiagnostics(ctx, snapshot, diagnostics, fixDiags)
+		fixActions, err := codeActionsMatchingDiagnostics(ctx, snapshot, diagnostics, fixDiags, lazy)
 		if err != nil {
 			return nil, err
 		}
@@ -183,7 +191,16 @@ func (s *Server) codeAction(ctx context.Context, params *protocol.CodeActionPara
 			if !protocol.Intersect(nonfix.Range, params.Range) {
 				continue
 			}
-			actions, err := codeActionsForDiagnostic(ctx, snapshot, nonfix, nil)
+			// Clients that were sent the diagnostic, such as gopls check,
+			// can then tell which suggestion the action applies.
+			var pd *protocol.Diagnostic
+			for i := range diagnostics {
+				if sameDiagnostic(diagnostics[i], nonfix) {
+					pd = &diagnostics[i]
+					break
+				}
+			}
+			actions, err := codeActionsForDiagnostic(ctx, snapshot, nonfix, pd, lazy)
 			if err != nil {
 				return nil, err
 			}
@@ -191,7 +208,23 @@ func (s *Server) codeAction(ctx context.Context, params *protocol.CodeActionPara
 		}
 
 		if wanted[protocol.RefactorExtract] {
-			fixes, err := extractionFixes(ctx, snapshot, pkg, uri, params.Range)
+			fixes, err := extractionFixes(ctx, snapshot, uri, params.Range)
+			if err != nil {
+				return nil, err
+			}
+			codeActions = append(codeActions, fixes...)
+		}
+
+		if wanted[protocol.RefactorInline] {
+			fixes, err := inlineFixes(ctx, snapshot, uri, params.Range)
+			if err != nil {
+				return nil, err
+			}
+			codeActions = append(codeActions, fixes...)
+		}
+
+		if wanted[protocol.RefactorRewrite] {
+			fixes, err := addTestFixes(ctx, snapshot, uri, params.Range)
 			if err != nil {
 				return nil, err
 			}
@@ -213,13 +246,188 @@ func (s *Server) codeAction(ctx context.Context, params *protocol.CodeActionPara
 
 	var filtered []protocol.CodeAction
 	for _, action := range codeActions {
-		if wanted[action.Kind] {
-			filtered = append(filtered, action)
+		if !wanted[action.Kind] {
+			continue
 		}
+		if lazy {
+			if err := deferApplyFix(&action); err != nil {
+				return nil, err
+			}
+		}
+		filtered = append(filtered, action)
 	}
 	return filtered, nil
 }
 
+// fileDiagnostics returns the diagnostics of a Go file that may carry
+// suggested fixes.
+func fileDiagnostics(ctx context.Context, snapshot source.Snapshot, fh source.FileHandle) ([]*source.Diagnostic, error) {
+	pkg, err := snapshot.PackageForFile(ctx, fh.URI(), source.TypecheckFull, source.WidestPackage)
+	if err != nil {
+		return nil, err
+	}
+	pkgDiagnostics, err := snapshot.DiagnosePackage(ctx, pkg)
+	if err != nil {
+		return nil, err
+	}
+	analysisDiags, err := source.Analyze(ctx, snapshot, pkg, true)
+	if err != nil {
+		return nil, err
+	}
+	fileDiags := append(pkgDiagnostics[fh.URI()], analysisDiags[fh.URI()]...)
+	modURI := snapshot.GoModForFile(fh.URI())
+	if modURI != "" {
+		modFH, err := snapshot.GetVersionedFile(ctx, modURI)
+		if err != nil {
+			return nil, err
+		}
+		modDiags, err := mod.DiagnosticsForMod(ctx, snapshot, modFH)
+		if err != nil && !source.IsNonFatalGoModError(err) {
+			// Not a fatal error.
+			event.Error(ctx, "module suggested fixes failed", err, tag.Directory.Of(snapshot.View().Folder()))
+		}
+		fileDiags = append(fileDiags, modDiags...)
+	}
+	return fileDiags, nil
+}
+
+// convenienceDiagnostics returns the diagnostics of a Go file reported by
+// the convenience analyzers whose action kind is wanted. Their diagnostics
+// are not published, so they are not stored with the others.
+func convenienceDiagnostics(ctx context.Context, snapshot source.Snapshot, fh source.FileHandle, wanted map[protocol.CodeActionKind]bool) ([]*source.Diagnostic, error) {
+	var diags []*source.Diagnostic
+	for name, a := range snapshot.View().Options().ConvenienceAnalyzers {
+		if !a.IsEnabled(snapshot.View()) || !wanted[a.ActionKind] {
+			continue
+		}
+		d, err := source.AnalyzerDiagnostics(ctx, snapshot, fh, name)
+		if err != nil {
+			return nil, err
+		}
+		diags = append(diags, d...)
+	}
+	return diags, nil
+}
+
+// codeActionData is the data payload of a code action whose edits are
+// computed by codeAction/resolve. Exactly one of its optional fields is set.
+type codeActionData struct {
+	URI protocol.DocumentURI
+
+	// ApplyFix holds the arguments of an apply_fix command.
+	ApplyFix *command.ApplyFixArgs `json:",omitempty"`
+
+	// ImportFix is a single fix to the imports of the file.
+	ImportFix *imports.ImportFix `json:",omitempty"`
+
+	// Diagnostic identifies the diagnostic whose suggested fix, named by the
+	// title of the action, supplies the edits.
+	Diagnostic *protocol.Diagnostic `json:",omitempty"`
+
+	// Analyzer names the analyzer that reported Diagnostic, so that only it
+	// runs again. It is empty for type errors, whose fixes come from the
+	// type error analyzers, and for go.mod diagnostics.
+	Analyzer string `json:",omitempty"`
+}
+
+// deferApplyFix replaces an action's apply_fix command with a data payload
+// holding the command's arguments. The edits of the fix are then computed
+// by codeAction/resolve, only if the user selects the action.
+func deferApplyFix(action *protocol.CodeAction) error {
+	if action.Command == nil || action.Command.Command != command.ApplyFix.ID() {
+		return nil
+	}
+	var args command.ApplyFixArgs
+	if err := command.UnmarshalArgs(action.Command.Arguments, &args); err != nil {
+		return err
+	}
+	action.Command = nil
+	action.Data = codeActionData{URI: args.URI, ApplyFix: &args}
+	return nil
+}
+
+func (s *Server) resolveCodeAction(ctx context.Context, action *protocol.CodeAction) (*protocol.CodeAction, error) {
+	if action.Data == nil {
+		return action, nil
+	}
+	// The data payload has been round-tripped through the client, so it
+	// must be decoded again.
+	raw, err := json.Marshal(action.Data)
+	if err != nil {
+		return nil, err
+	}
+	var data codeActionData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, errors.Errorf("unmarshaling code action data: %w", err)
+	}
+	snapshot, fh, ok, release, err := s.beginFileRequest(ctx, data.URI, source.UnknownKind)
+	defer release()
+	if !ok {
+		return nil, err
+	}
+	switch {
+	case data.ApplyFix != nil:
+		edits, err := source.ApplyFix(ctx, data.ApplyFix.Fix, snapshot, fh, data.ApplyFix.Range)
+		if err != nil {
+			return nil, err
+		}
+		action.Edit.DocumentChanges = protocol.TextDocumentChanges(edits)
+	case data.ImportFix != nil:
+		edits, err := source.ImportFixEdits(ctx, snapshot, fh, []*imports.ImportFix{data.ImportFix})
+		if err != nil {
+			return nil, err
+		}
+		action.Edit.DocumentChanges = documentChanges(fh, edits)
+	case data.Diagnostic != nil:
+		var diags []*source.Diagnostic
+		switch {
+		case fh.Kind() == source.Mod:
+			diags, err = mod.DiagnosticsForMod(ctx, snapshot, fh)
+		case data.Analyzer != "":
+			diags, err = source.AnalyzerDiagnostics(ctx, snapshot, fh, data.Analyzer)
+		default:
+			var pkg source.Package
+			pkg, err = snapshot.PackageForFile(ctx, fh.URI(), source.TypecheckFull, source.WidestPackage)
+			if err == nil {
+				var pkgDiags map[span.URI][]*source.Diagnostic
+				pkgDiags, err = snapshot.DiagnosePackage(ctx, pkg)
+				diags = pkgDiags[fh.URI()]
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		fix, err := suggestedFix(diags, *data.Diagnostic, action.Title)
+		if err != nil {
+			return nil, err
+		}
+		action.Edit.DocumentChanges, err = suggestedFixChanges(ctx, snapshot, fix)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errors.Errorf("no edits to resolve for code action %q", action.Title)
+	}
+	action.Data = nil
+	return action, nil
+}
+
+// suggestedFix returns the suggested fix with the given title, of the
+// diagnostic identified by pd.
+func suggestedFix(diags []*source.Diagnostic, pd protocol.Diagnostic, title string) (source.SuggestedFix, error) {
+	for _, sd := range diags {
+		if !sameDiagnostic(pd, sd) {
+			continue
+		}
+		for _, fix := range sd.SuggestedFixes {
+			if fix.Title == title {
+				return fix, nil
+			}
+		}
+	}
+	return source.SuggestedFix{}, errors.Errorf("no fix %q for diagnostic %q: the file may have changed", title, pd.Message)
+}
+
 func (s *Server) getSupportedCodeActions() []protocol.CodeActionKind {
 	allCodeActionKinds := make(map[protocol.CodeActionKind]struct{})
 	for _, kinds := range s.session.Options().SupportedCodeActions {
@@ -278,7 +486,7 @@ func importDiagnostics(fix *imports.ImportFix, diagnostics []protocol.Diagnostic
 	return results
 }
 
-func extractionFixes(ctx context.Context, snapshot source.Snapshot, pkg source.Package, uri span.URI, rng protocol.Range) ([]protocol.CodeAction, error) {
+func extractionFixes(ctx context.Context, snapshot source.Snapshot, uri span.URI, rng protocol.Range) ([]protocol.CodeAction, error) {
 	if rng.Start == rng.End {
 		return nil, nil
 	}
@@ -286,7 +494,7 @@ func extractionFixes(ctx context.Context, snapshot source.Snapshot, pkg source.P
 	if err != nil {
 		return nil, err
 	}
-	_, pgf, err := source.GetParsedFile(ctx, snapshot, fh, source.NarrowestPackage)
+	pkg, pgf, err := source.GetParsedFile(ctx, snapshot, fh, source.NarrowestPackage)
 	if err != nil {
 		return nil, errors.Errorf("getting file for Identifier: %w", err)
 	}
@@ -296,7 +504,7 @@ func extractionFixes(ctx context.Context, snapshot source.Snapshot, pkg source.P
 	}
 	puri := protocol.URIFromSpanURI(uri)
 	var commands []protocol.Command
-	if _, ok, _ := source.CanExtractFunction(snapshot.FileSet(), srng, pgf.Src, pgf.File); ok {
+	if _, ok, methodOK, _ := source.CanExtractFunction(snapshot.FileSet(), srng, pgf.Src, pgf.File, pkg.GetTypesInfo()); ok {
 		cmd, err := command.NewApplyFixCommand("Extract to function", command.ApplyFixArgs{
 			URI:   puri,
 			Fix:   source.ExtractFunction,
@@ -306,6 +514,17 @@ func extractionFixes(ctx context.Context, snapshot source.Snapshot, pkg source.P
 			return nil, err
 		}
 		commands = append(commands, cmd)
+		if methodOK {
+			cmd, err := command.NewApplyFixCommand("Extract to method", command.ApplyFixArgs{
+				URI:   puri,
+				Fix:   source.ExtractMethod,
+				Range: rng,
+			})
+			if err != nil {
+				return nil, err
+			}
+			commands = append(commands, cmd)
+		}
 	}
 	if _, _, ok, _ := source.CanExtractVariable(srng, pgf.File); ok {
 		cmd, err := command.NewApplyFixCommand("Extract variable", command.ApplyFixArgs{
@@ -318,6 +537,16 @@ func extractionFixes(ctx context.Context, snapshot source.Snapshot, pkg source.P
 		}
 		commands = append(commands, cmd)
 	}
+	if source.CanMoveDeclarations(pgf.File, srng) {
+		cmd, err := command.NewMoveDeclarationsCommand("Move declarations to new file", command.MoveDeclarationsArgs{
+			URI:   puri,
+			Range: rng,
+		})
+		if err != nil {
+			return nil, err
+		}
+		commands = append(commands, cmd)
+	}
 	var actions []protocol.CodeAction
 	for _, cmd := range commands {
 		actions = append(actions, protocol.CodeAction{
@@ -329,21 +558,101 @@ func extractionFixes(ctx context.Context, snapshot source.Snapshot, pkg source.P
 	return actions, nil
 }
 
-func documentChanges(fh source.VersionedFileHandle, edits []protocol.TextEdit) []protocol.TextDocumentEdit {
-	return []protocol.TextDocumentEdit{
+func inlineFixes(ctx context.Context, snapshot source.Snapshot, uri span.URI, rng protocol.Range) ([]protocol.CodeAction, error) {
+	fh, err := snapshot.GetFile(ctx, uri)
+	if err != nil {
+		return nil, err
+	}
+	pkg, pgf, err := source.GetParsedFile(ctx, snapshot, fh, source.NarrowestPackage)
+	if err != nil {
+		return nil, errors.Errorf("getting file for Identifier: %w", err)
+	}
+	srng, err := pgf.Mapper.RangeToSpanRange(rng)
+	if err != nil {
+		return nil, err
+	}
+	puri := protocol.URIFromSpanURI(uri)
+	var commands []protocol.Command
+	if v, ok := source.CanInlineVariable(srng, pgf.File, pkg.GetTypesInfo()); ok {
+		cmd, err := command.NewApplyFixCommand(fmt.Sprintf("Inline variable %s", v.Name()), command.ApplyFixArgs{
+			URI:   puri,
+			Fix:   source.InlineVariable,
+			Range: rng,
+		})
+		if err != nil {
+			return nil, err
+		}
+		commands = append(commands, cmd)
+	} else if fn, ok := source.CanInlineCall(srng, pgf.File, pkg); ok {
+		cmd, err := command.NewApplyFixCommand(fmt.Sprintf("Inline call to %s", fn.Name()), command.ApplyFixArgs{
+			URI:   puri,
+			Fix:   source.InlineCall,
+			Range: rng,
+		})
+		if err != nil {
+			return nil, err
+		}
+		commands = append(commands, cmd)
+	}
+	var actions []protocol.CodeAction
+	for _, cmd := range commands {
+		actions = append(actions, protocol.CodeAction{
+			Title:   cmd.Title,
+			Kind:    protocol.RefactorInline,
+			Command: &cmd,
+		})
+	}
+	return actions, nil
+}
+
+func addTestFixes(ctx context.Context, snapshot source.Snapshot, uri span.URI, rng protocol.Range) ([]protocol.CodeAction, error) {
+	fh, err := snapshot.GetFile(ctx, uri)
+	if err != nil {
+		return nil, err
+	}
+	pgf, err := snapshot.ParseGo(ctx, fh, source.ParseFull)
+	if err != nil {
+		return nil, errors.Errorf("getting file for AddTest: %w", err)
+	}
+	srng, err := pgf.Mapper.RangeToSpanRange(rng)
+	if err != nil {
+		return nil, err
+	}
+	decl, ok := source.CanAddTest(pgf, srng)
+	if !ok {
+		return nil, nil
+	}
+	cmd, err := command.NewAddTestCommand(fmt.Sprintf("Add a test for %s", decl.Name.Name), command.AddTestArgs{
+		URI:   protocol.URIFromSpanURI(uri),
+		Range: rng,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return []protocol.CodeAction{{
+		Title:   cmd.Title,
+		Kind:    protocol.RefactorRewrite,
+		Command: &cmd,
+	}}, nil
+}
+
+func documentChanges(fh source.VersionedFileHandle, edits []protocol.TextEdit) []protocol.DocumentChanges {
+	return []protocol.DocumentChanges{
 		{
-			TextDocument: protocol.OptionalVersionedTextDocumentIdentifier{
-				Version: fh.Version(),
-				TextDocumentIdentifier: protocol.TextDocumentIdentifier{
-					URI: protocol.URIFromSpanURI(fh.URI()),
+			TextDocumentEdit: &protocol.TextDocumentEdit{
+				TextDocument: protocol.OptionalVersionedTextDocumentIdentifier{
+					Version: fh.Version(),
+					TextDocumentIdentifier: protocol.TextDocumentIdentifier{
+						URI: protocol.URIFromSpanURI(fh.URI()),
+					},
 				},
+				Edits: edits,
 			},
-			Edits: edits,
 		},
 	}
 }
 
-func codeActionsMatchingDiagnostics(ctx context.Context, snapshot source.Snapshot, pdiags []protocol.Diagnostic, sdiags []*source.Diagnostic) ([]protocol.CodeAction, error) {
+func codeActionsMatchingDiagnostics(ctx context.Context, snapshot source.Snapshot, pdiags []protocol.Diagnostic, sdiags []*source.Diagnostic, lazy bool) ([]protocol.CodeAction, error) {
 	var actions []protocol.CodeAction
 	for _, sd := range sdiags {
 		var diag *protocol.Diagnostic
@@ -356,7 +665,7 @@ func codeActionsMatchingDiagnostics(ctx context.Context, snapshot source.Snapsho
 		if diag == nil {
 			continue
 		}
-		diagActions, err := codeActionsForDiagnostic(ctx, snapshot, sd, diag)
+		diagActions, err := codeActionsForDiagnostic(ctx, snapshot, sd, diag, lazy)
 		if err != nil {
 			return nil, err
 		}
@@ -366,7 +675,9 @@ func codeActionsMatchingDiagnostics(ctx context.Context, snapshot source.Snapsho
 	return actions, nil
 }
 
-func codeActionsForDiagnostic(ctx context.Context, snapshot source.Snapshot, sd *source.Diagnostic, pd *protocol.Diagnostic) ([]protocol.CodeAction, error) {
+// codeActionsForDiagnostic returns an action for each suggested fix of sd.
+// If lazy is set, the edits of a fix are left to codeAction/resolve.
+func codeActionsForDiagnostic(ctx context.Context, snapshot source.Snapshot, sd *source.Diagnostic, pd *protocol.Diagnostic, lazy bool) ([]protocol.CodeAction, error) {
 	var actions []protocol.CodeAction
 	for _, fix := range sd.SuggestedFixes {
 		action := protocol.CodeAction{
@@ -381,27 +692,43 @@ func codeActionsForDiagnostic(ctx context.Context, snapshot source.Snapshot, sd
 		if sd.Analyzer != nil && sd.Analyzer.ActionKind != "" {
 			action.Kind = sd.Analyzer.ActionKind
 		}
-
-		for uri, edits := range fix.Edits {
-			fh, err := snapshot.GetVersionedFile(ctx, uri)
+		if lazy && len(fix.Edits) > 0 && fix.Command == nil {
+			data := codeActionData{
+				URI: protocol.URIFromSpanURI(sd.URI),
+				Diagnostic: &protocol.Diagnostic{
+					Range:   sd.Range,
+					Message: sd.Message,
+					Source:  string(sd.Source),
+				},
+			}
+			if sd.Analyzer != nil {
+				data.Analyzer = sd.Analyzer.Analyzer.Name
+			}
+			action.Data = data
+		} else {
+			changes, err := suggestedFixChanges(ctx, snapshot, fix)
 			if err != nil {
 				return nil, err
 			}
-			action.Edit.DocumentChanges = append(action.Edit.DocumentChanges, protocol.TextDocumentEdit{
-				TextDocument: protocol.OptionalVersionedTextDocumentIdentifier{
-					Version: fh.Version(),
-					TextDocumentIdentifier: protocol.TextDocumentIdentifier{
-						URI: protocol.URIFromSpanURI(uri),
-					},
-				},
-				Edits: edits,
-			})
+			action.Edit.DocumentChanges = changes
 		}
 		actions = append(actions, action)
 	}
 	return actions, nil
 }
 
+func suggestedFixChanges(ctx context.Context, snapshot source.Snapshot, fix source.SuggestedFix) ([]protocol.DocumentChanges, error) {
+	var changes []protocol.DocumentChanges
+	for uri, edits := range fix.Edits {
+		fh, err := snapshot.GetVersionedFile(ctx, uri)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, documentChanges(fh, edits)...)
+	}
+	return changes, nil
+}
+
 func sameDiagnostic(pd protocol.Diagnostic, sd *source.Diagnostic) bool {
 	return pd.Message == sd.Message && protocol.CompareRange(pd.Range, sd.Range) == 0 && pd.Source == string(sd.Source)
 }
@@ -124,7 +124,7 @@ func (c *commandHandler) ApplyFix(ctx context.Context, args command.ApplyFixArgs
 		}
 		r, err := c.s.client.ApplyEdit(ctx, &protocol.ApplyWorkspaceEditParams{
 			Edit: protocol.WorkspaceEdit{
-				DocumentChanges: edits,
+				DocumentChanges: protocol.TextDocumentChanges(edits),
 			},
 		})
 		if err != nil {
@@ -272,15 +272,7 @@ func (c *commandHandler) RemoveDependency(ctx context.Context, args command.Remo
 		}
 		response, err := c.s.client.ApplyEdit(ctx, &protocol.ApplyWorkspaceEditParams{
 			Edit: protocol.WorkspaceEdit{
-				DocumentChanges: []protocol.TextDocumentEdit{{
-					TextDocument: protocol.OptionalVersionedTextDocumentIdentifier{
-						Version: deps.fh.Version(),
-						TextDocumentIdentifier: protocol.TextDocumentIdentifier{
-							URI: protocol.URIFromSpanURI(deps.fh.URI()),
-						},
-					},
-					Edits: edits,
-				}},
+				DocumentChanges: documentChanges(deps.fh, edits),
 			},
 		})
 		if err != nil {
@@ -503,7 +495,7 @@ func (s *Server) runGoModUpdateCommands(ctx context.Context, snapshot source.Sna
 	}
 	response, err := s.client.ApplyEdit(ctx, &protocol.ApplyWorkspaceEditParams{
 		Edit: protocol.WorkspaceEdit{
-			DocumentChanges: changes,
+			DocumentChanges: protocol.TextDocumentChanges(changes),
 		},
 	})
 	if err != nil {
@@ -659,6 +651,197 @@ func (c *commandHandler) GenerateGoplsMod(ctx context.Context, args command.URIA
 	})
 }
 
+func (c *commandHandler) MoveDeclarations(ctx context.Context, args command.MoveDeclarationsArgs) error {
+	return c.run(ctx, commandConfig{
+		// Note: no progress here. Moving declarations should be quick.
+		forURI: args.URI,
+	}, func(ctx context.Context, deps commandDeps) error {
+		newURI, content, edits, err := source.MoveDeclarations(ctx, deps.snapshot, deps.fh, args.Range)
+		if err != nil {
+			return err
+		}
+		// Create the new file in the same edit that removes the
+		// declarations, so that the client can undo the move as a whole.
+		// A client that cannot create files gets only the removal, after
+		// the new file is written, so that nothing is lost if the edit
+		// fails.
+		var changes []protocol.DocumentChanges
+		if deps.snapshot.View().Options().CreateFileSupported {
+			changes = createFileChanges(newURI, content)
+		} else if err := ioutil.WriteFile(newURI.Filename(), content, 0644); err != nil {
+			return errors.Errorf("writing %s: %w", newURI.Filename(), err)
+		}
+		changes = append(changes, documentChanges(deps.fh, edits)...)
+		r, err := c.s.client.ApplyEdit(ctx, &protocol.ApplyWorkspaceEditParams{
+			Edit: protocol.WorkspaceEdit{
+				DocumentChanges: changes,
+			},
+		})
+		if err != nil {
+			return err
+		}
+		if !r.Applied {
+			return errors.New(r.FailureReason)
+		}
+		return nil
+	})
+}
+
+// createFileChanges returns the document changes that create the file uri
+// with the given content.
+func createFileChanges(uri span.URI, content []byte) []protocol.DocumentChanges {
+	protocolURI := protocol.URIFromSpanURI(uri)
+	return []protocol.DocumentChanges{
+		{
+			CreateFile: &protocol.CreateFile{
+				Kind: string(protocol.Create),
+				URI:  protocolURI,
+			},
+		},
+		{
+			TextDocumentEdit: &protocol.TextDocumentEdit{
+				TextDocument: protocol.OptionalVersionedTextDocumentIdentifier{
+					TextDocumentIdentifier: protocol.TextDocumentIdentifier{
+						URI: protocolURI,
+					},
+				},
+				Edits: []protocol.TextEdit{{NewText: string(content)}},
+			},
+		},
+	}
+}
+
+func (c *commandHandler) AddTest(ctx context.Context, args command.AddTestArgs) error {
+	return c.run(ctx, commandConfig{
+		forURI: args.URI,
+	}, func(ctx context.Context, deps commandDeps) error {
+		testURI, content, edits, err := source.AddTest(ctx, deps.snapshot, deps.fh, args.Range)
+		if err != nil {
+			return err
+		}
+		// A new test file is created by the client, as MoveDeclarations
+		// does, unless it cannot create files.
+		var changes []protocol.DocumentChanges
+		switch {
+		case content == nil:
+			fh, err := deps.snapshot.GetVersionedFile(ctx, testURI)
+			if err != nil {
+				return err
+			}
+			changes = documentChanges(fh, edits)
+		case deps.snapshot.View().Options().CreateFileSupported:
+			changes = createFileChanges(testURI, content)
+		default:
+			if err := ioutil.WriteFile(testURI.Filename(), content, 0644); err != nil {
+				return errors.Errorf("writing %s: %w", testURI.Filename(), err)
+			}
+			return nil
+		}
+		r, err := c.s.client.ApplyEdit(ctx, &protocol.ApplyWorkspaceEditParams{
+			Edit: protocol.WorkspaceEdit{
+				DocumentChanges: changes,
+			},
+		})
+		if err != nil {
+			return err
+		}
+		if !r.Applied {
+			return errors.New(r.FailureReason)
+		}
+		return nil
+	})
+}
+
+func (c *commandHandler) ChangeSignature(ctx context.Context, args command.ChangeSignatureArgs) error {
+	return c.run(ctx, commandConfig{
+		progress: "Changing signature",
+		forURI:   args.URI,
+	}, func(ctx context.Context, deps commandDeps) error {
+		var params []source.SignatureParam
+		for _, p := range args.Params {
+			params = append(params, source.SignatureParam{
+				OldIndex: p.OldIndex,
+				Name:     p.Name,
+				Type:     p.Type,
+				Value:    p.Value,
+			})
+		}
+		edits, problems, err := source.ChangeSignature(ctx, deps.snapshot, deps.fh, args.Position, params)
+		if err != nil {
+			return err
+		}
+		var changes []protocol.DocumentChanges
+		for uri, e := range edits {
+			fh, err := deps.snapshot.GetVersionedFile(ctx, uri)
+			if err != nil {
+				return err
+			}
+			changes = append(changes, documentChanges(fh, e)...)
+		}
+		r, err := c.s.client.ApplyEdit(ctx, &protocol.ApplyWorkspaceEditParams{
+			Edit: protocol.WorkspaceEdit{
+				DocumentChanges: changes,
+			},
+		})
+		if err != nil {
+			return err
+		}
+		if !r.Applied {
+			return errors.New(r.FailureReason)
+		}
+		if len(problems) == 0 {
+			return nil
+		}
+		return c.s.client.ShowMessage(ctx, &protocol.ShowMessageParams{
+			Type:    protocol.Warning,
+			Message: "Some references were not updated:\n" + strings.Join(problems, "\n"),
+		})
+	})
+}
+
+func (c *commandHandler) ImplementInterface(ctx context.Context, args command.ImplementInterfaceArgs) error {
+	return c.run(ctx, commandConfig{
+		forURI: args.URI,
+	}, func(ctx context.Context, deps commandDeps) error {
+		edits, err := source.ImplementInterface(ctx, deps.snapshot, deps.fh, args.Position, args.Interface)
+		if err != nil {
+			return err
+		}
+		var changes []protocol.DocumentChanges
+		for uri, e := range edits {
+			fh, err := deps.snapshot.GetVersionedFile(ctx, uri)
+			if err != nil {
+				return err
+			}
+			changes = append(changes, documentChanges(fh, e)...)
+		}
+		r, err := c.s.client.ApplyEdit(ctx, &protocol.ApplyWorkspaceEditParams{
+			Edit: protocol.WorkspaceEdit{
+				DocumentChanges: changes,
+			},
+		})
+		if err != nil {
+			return err
+		}
+		if !r.Applied {
+			return errors.New(r.FailureReason)
+		}
+		return nil
+	})
+}
+
+func (c *commandHandler) ListInterfaces(ctx context.Context, args command.URIArg) (command.ListInterfacesResult, error) {
+	var result command.ListInterfacesResult
+	err := c.run(ctx, commandConfig{
+		forURI: args.URI,
+	}, func(ctx context.Context, deps commandDeps) error {
+		var err error
+		result.Interfaces, err = source.ListInterfaces(ctx, deps.snapshot, deps.fh)
+		return err
+	})
+	return result, err
+}
+
 func (c *commandHandler) ListKnownPackages(ctx context.Context, args command.URIArg) (command.ListKnownPackagesResult, error) {
 	var result command.ListKnownPackagesResult
 	err := c.run(ctx, commandConfig{
@@ -672,6 +855,117 @@ func (c *commandHandler) ListKnownPackages(ctx context.Context, args command.URI
 	return result, err
 }
 
+func (c *commandHandler) InlayHint(ctx context.Context, args protocol.InlayHintParams) ([]protocol.InlayHint, error) {
+	var result []protocol.InlayHint
+	err := c.run(ctx, commandConfig{
+		forURI: args.TextDocument.URI,
+	}, func(ctx context.Context, deps commandDeps) error {
+		var err error
+		result, err = source.InlayHint(ctx, deps.snapshot, deps.fh, args.Range)
+		return err
+	})
+	return result, err
+}
+
+func (c *commandHandler) PrepareTypeHierarchy(ctx context.Context, args protocol.TypeHierarchyPrepareParams) ([]protocol.TypeHierarchyItem, error) {
+	var result []protocol.TypeHierarchyItem
+	err := c.run(ctx, commandConfig{
+		forURI: args.TextDocument.URI,
+	}, func(ctx context.Context, deps commandDeps) error {
+		var err error
+		result, err = source.PrepareTypeHierarchy(ctx, deps.snapshot, deps.fh, args.Position)
+		return err
+	})
+	return result, err
+}
+
+func (c *commandHandler) TypeHierarchySupertypes(ctx context.Context, args protocol.TypeHierarchySupertypesParams) ([]protocol.TypeHierarchyItem, error) {
+	var result []protocol.TypeHierarchyItem
+	err := c.run(ctx, commandConfig{
+		forURI: args.Item.URI,
+	}, func(ctx context.Context, deps commandDeps) error {
+		var err error
+		result, err = source.Supertypes(ctx, deps.snapshot, deps.fh, args.Item.SelectionRange.Start)
+		return err
+	})
+	return result, err
+}
+
+func (c *commandHandler) TypeHierarchySubtypes(ctx context.Context, args protocol.TypeHierarchySubtypesParams) ([]protocol.TypeHierarchyItem, error) {
+	var result []protocol.TypeHierarchyItem
+	err := c.run(ctx, commandConfig{
+		forURI: args.Item.URI,
+	}, func(ctx context.Context, deps commandDeps) error {
+		var err error
+		result, err = source.Subtypes(ctx, deps.snapshot, deps.fh, args.Item.SelectionRange.Start)
+		return err
+	})
+	return result, err
+}
+
+func (c *commandHandler) DocumentDiagnostic(ctx context.Context, args protocol.DocumentDiagnosticParams) (protocol.DocumentDiagnosticReport, error) {
+	var result protocol.DocumentDiagnosticReport
+	err := c.run(ctx, commandConfig{
+		forURI: args.TextDocument.URI,
+	}, func(ctx context.Context, deps commandDeps) error {
+		var err error
+		result, err = c.s.documentDiagnostic(ctx, deps.snapshot, deps.fh, args.PreviousResultID)
+		return err
+	})
+	return result, err
+}
+
+func (c *commandHandler) WorkspaceDiagnostic(ctx context.Context, args protocol.WorkspaceDiagnosticParams) (protocol.WorkspaceDiagnosticReport, error) {
+	var result protocol.WorkspaceDiagnosticReport
+	err := c.run(ctx, commandConfig{}, func(ctx context.Context, deps commandDeps) error {
+		previousResultIDs := make(map[span.URI]string)
+		for _, prev := range args.PreviousResultIds {
+			previousResultIDs[prev.URI.SpanURI()] = prev.Value
+		}
+		var err error
+		result, err = c.s.workspaceDiagnostic(ctx, previousResultIDs)
+		return err
+	})
+	return result, err
+}
+
+func (c *commandHandler) AnalyzerFixes(ctx context.Context, args command.AnalyzerFixesArgs) (command.AnalyzerFixesResult, error) {
+	var result command.AnalyzerFixesResult
+	err := c.run(ctx, commandConfig{
+		progress: "Computing fixes",
+	}, func(ctx context.Context, deps commandDeps) error {
+		// Fix the files of each view in its snapshot.
+		var views []source.View
+		snapshots := make(map[source.View]source.Snapshot)
+		uris := make(map[source.View][]span.URI)
+		for _, uri := range args.URIs {
+			snapshot, fh, ok, release, err := c.s.beginFileRequest(ctx, uri, source.Go)
+			defer release()
+			if !ok {
+				return err
+			}
+			view := snapshot.View()
+			if _, ok := snapshots[view]; !ok {
+				views = append(views, view)
+				snapshots[view] = snapshot
+			}
+			uris[view] = append(uris[view], fh.URI())
+		}
+		result = command.AnalyzerFixesResult{}
+		for _, view := range views {
+			fixes, err := source.AnalyzerFixes(ctx, snapshots[view], uris[view], args.Analyzers)
+			if err != nil {
+				return err
+			}
+			result.Edits = append(result.Edits, fixes.Edits...)
+			result.Fixes = append(result.Fixes, fixes.Fixes...)
+			result.Conflicts = append(result.Conflicts, fixes.Conflicts...)
+		}
+		return nil
+	})
+	return result, err
+}
+
 func (c *commandHandler) AddImport(ctx context.Context, args command.AddImportArgs) (command.AddImportResult, error) {
 	var result command.AddImportResult
 	err := c.run(ctx, commandConfig{
@@ -19,45 +19,69 @@ import (
 )
 
 const (
-	AddDependency     Command = "add_dependency"
-	AddImport         Command = "add_import"
-	ApplyFix          Command = "apply_fix"
-	CheckUpgrades     Command = "check_upgrades"
-	GCDetails         Command = "gc_details"
-	Generate          Command = "generate"
-	GenerateGoplsMod  Command = "generate_gopls_mod"
-	GoGetPackage      Command = "go_get_package"
-	ListKnownPackages Command = "list_known_packages"
-	RegenerateCgo     Command = "regenerate_cgo"
-	RemoveDependency  Command = "remove_dependency"
-	RunTests          Command = "run_tests"
-	Test              Command = "test"
-	Tidy              Command = "tidy"
-	ToggleGCDetails   Command = "toggle_gc_details"
-	UpdateGoSum       Command = "update_go_sum"
-	UpgradeDependency Command = "upgrade_dependency"
-	Vendor            Command = "vendor"
+	AddDependency           Command = "add_dependency"
+	AddImport               Command = "add_import"
+	AddTest                 Command = "add_test"
+	AnalyzerFixes           Command = "analyzer_fixes"
+	ApplyFix                Command = "apply_fix"
+	ChangeSignature         Command = "change_signature"
+	CheckUpgrades           Command = "check_upgrades"
+	DocumentDiagnostic      Command = "document_diagnostic"
+	GCDetails               Command = "gc_details"
+	Generate                Command = "generate"
+	GenerateGoplsMod        Command = "generate_gopls_mod"
+	GoGetPackage            Command = "go_get_package"
+	ImplementInterface      Command = "implement_interface"
+	InlayHint               Command = "inlay_hint"
+	ListInterfaces          Command = "list_interfaces"
+	ListKnownPackages       Command = "list_known_packages"
+	MoveDeclarations        Command = "move_declarations"
+	PrepareTypeHierarchy    Command = "prepare_type_hierarchy"
+	RegenerateCgo           Command = "regenerate_cgo"
+	RemoveDependency        Command = "remove_dependency"
+	RunTests                Command = "run_tests"
+	Test                    Command = "test"
+	Tidy                    Command = "tidy"
+	ToggleGCDetails         Command = "toggle_gc_details"
+	TypeHierarchySubtypes   Command = "type_hierarchy_subtypes"
+	TypeHierarchySupertypes Command = "type_hierarchy_supertypes"
+	UpdateGoSum             Command = "update_go_sum"
+	UpgradeDependency       Command = "upgrade_dependency"
+	Vendor                  Command = "vendor"
+	WorkspaceDiagnostic     Command = "workspace_diagnostic"
 )
 
 var Commands = []Command{
 	AddDependency,
 	AddImport,
+	AddTest,
+	AnalyzerFixes,
 	ApplyFix,
+	ChangeSignature,
 	CheckUpgrades,
+	DocumentDiagnostic,
 	GCDetails,
 	Generate,
 	GenerateGoplsMod,
 	GoGetPackage,
+	ImplementInterface,
+	InlayHint,
+	ListInterfaces,
 	ListKnownPackages,
+	MoveDeclarations,
+	PrepareTypeHierarchy,
 	RegenerateCgo,
 	RemoveDependency,
 	RunTests,
 	Test,
 	Tidy,
 	ToggleGCDetails,
+	TypeHierarchySubtypes,
+	TypeHierarchySupertypes,
 	UpdateGoSum,
 	UpgradeDependency,
 	Vendor,
+	WorkspaceDiagnostic,
 }
 
 func Dispatch(ctx context.Context, params *protocol.ExecuteCommandParams, s Interface) (interface{}, error) {
@@ -74,18 +98,42 @@ func Dispatch(ctx context.Context, params *protocol.ExecuteCommandParams, s Inte
 			return nil, err
 		}
 		return s.AddImport(ctx, a0)
+	case "gopls.add_test":
+		var a0 AddTestArgs
+		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
+			return nil, err
+		}
+		return nil, s.AddTest(ctx, a0)
+	case "gopls.analyzer_fixes":
+		var a0 AnalyzerFixesArgs
+		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
+			return nil, err
+		}
+		return s.AnalyzerFixes(ctx, a0)
 	case "gopls.apply_fix":
 		var a0 ApplyFixArgs
 		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
 			return nil, err
 		}
 		return nil, s.ApplyFix(ctx, a0)
+	case "gopls.change_signature":
+		var a0 ChangeSignatureArgs
+		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
+			return nil, err
+		}
+		return nil, s.ChangeSignature(ctx, a0)
 	case "gopls.check_upgrades":
 		var a0 CheckUpgradesArgs
 		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
 			return nil, err
 		}
 		return nil, s.CheckUpgrades(ctx, a0)
+	case "gopls.document_diagnostic":
+		var a0 protocol.DocumentDiagnosticParams
+		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
+			return nil, err
+		}
+		return s.DocumentDiagnostic(ctx, a0)
 	case "gopls.gc_details":
 		var a0 protocol.DocumentURI
 		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
@@ -110,12 +158,42 @@ func Dispatch(ctx context.Context, params *protocol.ExecuteCommandParams, s Inte
 			return nil, err
 		}
 		return nil, s.GoGetPackage(ctx, a0)
+	case "gopls.implement_interface":
+		var a0 ImplementInterfaceArgs
+		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
+			return nil, err
+		}
+		return nil, s.ImplementInterface(ctx, a0)
+	case "gopls.inlay_hint":
+		var a0 protocol.InlayHintParams
+		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
+			return nil, err
+		}
+		return s.InlayHint(ctx, a0)
+	case "gopls.list_interfaces":
+		var a0 URIArg
+		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
+			return nil, err
+		}
+		return s.ListInterfaces(ctx, a0)
 	case "gopls.list_known_packages":
 		var a0 URIArg
 		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
 			return nil, err
 		}
 		return s.ListKnownPackages(ctx, a0)
+	case "gopls.move_declarations":
+		var a0 MoveDeclarationsArgs
+		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
+			return nil, err
+		}
+		return nil, s.MoveDeclarations(ctx, a0)
+	case "gopls.prepare_type_hierarchy":
+		var a0 protocol.TypeHierarchyPrepareParams
+		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
+			return nil, err
+		}
+		return s.PrepareTypeHierarchy(ctx, a0)
 	case "gopls.regenerate_cgo":
 		var a0 URIArg
 		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
@@ -154,6 +232,18 @@ func Dispatch(ctx context.Context, params *protocol.ExecuteCommandParams, s Inte
 			return nil, err
 		}
 		return nil, s.ToggleGCDetails(ctx, a0)
+	case "gopls.type_hierarchy_subtypes":
+		var a0 protocol.TypeHierarchySubtypesParams
+		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
+			return nil, err
+		}
+		return s.TypeHierarchySubtypes(ctx, a0)
+	case "gopls.type_hierarchy_supertypes":
+		var a0 protocol.TypeHierarchySupertypesParams
+		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
+			return nil, err
+		}
+		return s.TypeHierarchySupertypes(ctx, a0)
 	case "gopls.update_go_sum":
 		var a0 URIArgs
 		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
@@ -172,6 +262,12 @@ func Dispatch(ctx context.Context, params *protocol.ExecuteCommandParams, s Inte
 			return nil, err
 		}
 		return nil, s.Vendor(ctx, a0)
+	case "gopls.workspace_diagnostic":
+		var a0 protocol.WorkspaceDiagnosticParams
+		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
+			return nil, err
+		}
+		return s.WorkspaceDiagnostic(ctx, a0)
 	}
 	return nil, fmt.Errorf("unsupported command %q", params.Command)
 }
@@ -200,6 +296,30 @@ func NewAddImportCommand(title string, a0 AddImportArgs) (protocol.Command, erro
 	}, nil
 }
 
+func NewAddTestCommand(title string, a0 AddTestArgs) (protocol.Command, error) {
+	args, err := MarshalArgs(a0)
+	if err != nil {
+		return protocol.Command{}, err
+	}
+	return protocol.Command{
+		Title:     title,
+		Command:   "gopls.add_test",
+		Arguments: args,
+	}, nil
+}
+
+func NewAnalyzerFixesCommand(title string, a0 AnalyzerFixesArgs) (protocol.Command, error) {
+	args, err := MarshalArgs(a0)
+	if err != nil {
+		return protocol.Command{}, err
+	}
+	return protocol.Command{
+		Title:     title,
+		Command:   "gopls.analyzer_fixes",
+		Arguments: args,
+	}, nil
+}
+
 func NewApplyFixCommand(title string, a0 ApplyFixArgs) (protocol.Command, error) {
 	args, err := MarshalArgs(a0)
 	if err != nil {
@@ -212,6 +332,18 @@ func NewApplyFixCommand(title string, a0 ApplyFixArgs) (protocol.Command, error)
 	}, nil
 }
 
+func NewChangeSignatureCommand(title string, a0 ChangeSignatureArgs) (protocol.Command, error) {
+	args, err := MarshalArgs(a0)
+	if err != nil {
+		return protocol.Command{}, err
+	}
+	return protocol.Command{
+		Title:     title,
+		Command:   "gopls.change_signature",
+		Arguments: args,
+	}, nil
+}
+
 func NewCheckUpgradesCommand(title string, a0 CheckUpgradesArgs) (protocol.Command, error) {
 	args, err := MarshalArgs(a0)
 	if err != nil {
@@ -224,6 +356,18 @@ func NewCheckUpgradesCommand(title string, a0 CheckUpgradesArgs) (protocol.Comma
 	}, nil
 }
 
+func NewDocumentDiagnosticCommand(title string, a0 protocol.DocumentDiagnosticParams) (protocol.Command, error) {
+	args, err := MarshalArgs(a0)
+	if err != nil {
+		return protocol.Command{}, err
+	}
+	return protocol.Command{
+		Title:     title,
+		Command:   "gopls.document_diagnostic",
+		Arguments: args,
+	}, nil
+}
+
 func NewGCDetailsCommand(title string, a0 protocol.DocumentURI) (protocol.Command, error) {
 	args, err := MarshalArgs(a0)
 	if err != nil {
@@ -272,6 +416,42 @@ func NewGoGetPackageCommand(title string, a0 GoGetPackageArgs) (protocol.Command
 	}, nil
 }
 
+func NewImplementInterfaceCommand(title string, a0 ImplementInterfaceArgs) (protocol.Command, error) {
+	args, err := MarshalArgs(a0)
+	if err != nil {
+		return protocol.Command{}, err
+	}
+	return protocol.Command{
+		Title:     title,
+		Command:   "gopls.implement_interface",
+		Arguments: args,
+	}, nil
+}
+
+func NewInlayHintCommand(title string, a0 protocol.InlayHintParams) (protocol.Command, error) {
+	args, err := MarshalArgs(a0)
+	if err != nil {
+		return protocol.Command{}, err
+	}
+	return protocol.Command{
+		Title:     title,
+		Command:   "gopls.inlay_hint",
+		Arguments: args,
+	}, nil
+}
+
+func NewListInterfacesCommand(title string, a0 URIArg) (protocol.Command, error) {
+	args, err := MarshalArgs(a0)
+	if err != nil {
+		return protocol.Command{}, err
+	}
+	return protocol.Command{
+		Title:     title,
+		Command:   "gopls.list_interfaces",
+		Arguments: args,
+	}, nil
+}
+
 func NewListKnownPackagesCommand(title string, a0 URIArg) (protocol.Command, error) {
 	args, err := MarshalArgs(a0)
 	if err != nil {
@@ -284,6 +464,30 @@ func NewListKnownPackagesCommand(title string, a0 URIArg) (protocol.Command, err
 	}, nil
 }
 
+func NewMoveDeclarationsCommand(title string, a0 MoveDeclarationsArgs) (protocol.Command, error) {
+	args, err := MarshalArgs(a0)
+	if err != nil {
+		return protocol.Command{}, err
+	}
+	return protocol.Command{
+		Title:     title,
+		Command:   "gopls.move_declarations",
+		Arguments: args,
+	}, nil
+}
+
+func NewPrepareTypeHierarchyCommand(title string, a0 protocol.TypeHierarchyPrepareParams) (protocol.Command, error) {
+	args, err := MarshalArgs(a0)
+	if err != nil {
+		return protocol.Command{}, err
+	}
+	return protocol.Command{
+		Title:     title,
+		Command:   "gopls.prepare_type_hierarchy",
+		Arguments: args,
+	}, nil
+}
+
 func NewRegenerateCgoCommand(title string, a0 URIArg) (protocol.Command, error) {
 	args, err := MarshalArgs(a0)
 	if err != nil {
@@ -356,6 +560,30 @@ func NewToggleGCDetailsCommand(title string, a0 URIArg) (protocol.Command, error
 	}, nil
 }
 
+func NewTypeHierarchySubtypesCommand(title string, a0 protocol.TypeHierarchySubtypesParams) (protocol.Command, error) {
+	args, err := MarshalArgs(a0)
+	if err != nil {
+		return protocol.Command{}, err
+	}
+	return protocol.Command{
+		Title:     title,
+		Command:   "gopls.type_hierarchy_subtypes",
+		Arguments: args,
+	}, nil
+}
+
+func NewTypeHierarchySupertypesCommand(title string, a0 protocol.TypeHierarchySupertypesParams) (protocol.Command, error) {
+	args, err := MarshalArgs(a0)
+	if err != nil {
+		return protocol.Command{}, err
+	}
+	return protocol.Command{
+		Title:     title,
+		Command:   "gopls.type_hierarchy_supertypes",
+		Arguments: args,
+	}, nil
+}
+
 func NewUpdateGoSumCommand(title string, a0 URIArgs) (protocol.Command, error) {
 	args, err := MarshalArgs(a0)
 	if err != nil {
@@ -391,3 +619,15 @@ func NewVendorCommand(title string, a0 URIArg) (protocol.Command, error) {
 		Arguments: args,
 	}, nil
 }
+
+func NewWorkspaceDiagnosticCommand(title string, a0 protocol.WorkspaceDiagnosticParams) (protocol.Command, error) {
+	args, err := MarshalArgs(a0)
+	if err != nil {
+		return protocol.Command{}, err
+	}
+	return protocol.Command{
+		Title:     title,
+		Command:   "gopls.workspace_diagnostic",
+		Arguments: args,
+	}, nil
+}
@@ -115,8 +115,87 @@ type Interface interface {
 	// (Re)generate the gopls.mod file for a workspace.
 	GenerateGoplsMod(context.Context, URIArg) error
 
+	// MoveDeclarations: Move declarations to new file
+	//
+	// Moves the selected top-level declarations to a new file in the package.
+	MoveDeclarations(context.Context, MoveDeclarationsArgs) error
+
+	// AddTest: Add a test for a function
+	//
+	// Adds a table-driven test of the selected function or method to the
+	// matching _test.go file, creating it if needed.
+	AddTest(context.Context, AddTestArgs) error
+
+	// ChangeSignature: Change function signature
+	//
+	// Adds, removes, or reorders the parameters of a function, updating its
+	// callers and the interface methods it implements.
+	ChangeSignature(context.Context, ChangeSignatureArgs) error
+
+	// ImplementInterface: Implement interface
+	//
+	// Declares stubs for the methods that a type lacks to implement an
+	// interface.
+	ImplementInterface(context.Context, ImplementInterfaceArgs) error
+
+	// InlayHint: Compute inlay hints
+	//
+	// Returns the inlay hints of a range of a Go file that are enabled by the
+	// inlay hint settings. It has the parameters and result of the proposed
+	// LSP 3.17 textDocument/inlayHint request.
+	InlayHint(context.Context, protocol.InlayHintParams) ([]protocol.InlayHint, error)
+
+	// ListInterfaces: List interfaces
+	//
+	// Lists the interfaces that a type of a package may be made to
+	// implement with ImplementInterface.
+	ListInterfaces(context.Context, URIArg) (ListInterfacesResult, error)
+
 	ListKnownPackages(context.Context, URIArg) (ListKnownPackagesResult, error)
 
+	// PrepareTypeHierarchy: Prepare type hierarchy
+	//
+	// Returns the item of the named type at a position, for use with
+	// TypeHierarchySupertypes and TypeHierarchySubtypes. This and the other
+	// type hierarchy commands have the parameters and results of the
+	// proposed LSP 3.17 type hierarchy requests.
+	PrepareTypeHierarchy(context.Context, protocol.TypeHierarchyPrepareParams) ([]protocol.TypeHierarchyItem, error)
+
+	// TypeHierarchySupertypes: List supertypes
+	//
+	// Returns the items of the interfaces implemented by the type of an item.
+	TypeHierarchySupertypes(context.Context, protocol.TypeHierarchySupertypesParams) ([]protocol.TypeHierarchyItem, error)
+
+	// TypeHierarchySubtypes: List subtypes
+	//
+	// Returns the items of the concrete types and interfaces that implement
+	// the interface of an item.
+	TypeHierarchySubtypes(context.Context, protocol.TypeHierarchySubtypesParams) ([]protocol.TypeHierarchyItem, error)
+
+	// DocumentDiagnostic: Pull diagnostics of a file
+	//
+	// Computes the diagnostics of a file. If they are those of the report
+	// of the previous result id, an unchanged report is returned. It has the
+	// parameters and result of the proposed LSP 3.17 textDocument/diagnostic
+	// request.
+	DocumentDiagnostic(context.Context, protocol.DocumentDiagnosticParams) (protocol.DocumentDiagnosticReport, error)
+
+	// WorkspaceDiagnostic: Pull diagnostics of the workspace
+	//
+	// Computes the diagnostics of all the files of the workspace packages
+	// and go.mod files, with unchanged reports for the files whose
+	// diagnostics are those of their previous result ids. It has the
+	// parameters and result of the proposed LSP 3.17 workspace/diagnostic
+	// request.
+	WorkspaceDiagnostic(context.Context, protocol.WorkspaceDiagnosticParams) (protocol.WorkspaceDiagnosticReport, error)
+
+	// AnalyzerFixes: Compute the fixes of analyzers
+	//
+	// Returns the edits of the suggested fixes of the given analyzers for the
+	// diagnostics of the given files, except for the fixes whose edits
+	// conflict with those of another fix, which are listed instead.
+	AnalyzerFixes(context.Context, AnalyzerFixesArgs) (AnalyzerFixesResult, error)
+
 	AddImport(context.Context, AddImportArgs) (AddImportResult, error)
 }
 
@@ -150,6 +229,51 @@ type ApplyFixArgs struct {
 	Range protocol.Range
 }
 
+type MoveDeclarationsArgs struct {
+	// The file URI containing the declarations.
+	URI protocol.DocumentURI
+	// The range of the declarations to move.
+	Range protocol.Range
+}
+
+type AddTestArgs struct {
+	// The file URI containing the function.
+	URI protocol.DocumentURI
+	// The range of the name or signature of the function.
+	Range protocol.Range
+}
+
+type ChangeSignatureArgs struct {
+	// The file URI containing the function.
+	URI protocol.DocumentURI
+	// The position of the function name, at its declaration or a reference.
+	Position protocol.Position
+	// The parameters of the new signature, in order.
+	Params []SignatureParam
+}
+
+type SignatureParam struct {
+	// The index of the parameter in the current signature, or -1 for an
+	// added parameter.
+	OldIndex int
+	// The name of an added parameter.
+	Name string
+	// The type of an added parameter.
+	Type string
+	// The argument passed for an added parameter by existing callers.
+	Value string
+}
+
+type ImplementInterfaceArgs struct {
+	// The file URI containing the type.
+	URI protocol.DocumentURI
+	// The position of the type name, at its declaration or a reference.
+	Position protocol.Position
+	// The interface to implement, qualified by its package path as in
+	// "io.Reader", unless it is declared in the package of the type.
+	Interface string
+}
+
 type URIArg struct {
 	// The file URI.
 	URI protocol.DocumentURI
@@ -203,6 +327,37 @@ type AddImportResult struct {
 	Edits []protocol.TextDocumentEdit
 }
 
+type AnalyzerFixesArgs struct {
+	// The URIs of the files to fix.
+	URIs []protocol.DocumentURI
+	// The names of the analyzers whose fixes to apply, such as fillreturns.
+	Analyzers []string
+}
+
+type AnalyzerFixesResult struct {
+	// The merged edits of the applied fixes.
+	Edits []protocol.TextDocumentEdit
+	// The applied fixes.
+	Fixes []AnalyzerFix
+	// The fixes that were not applied, as their edits conflict with those of
+	// an applied fix.
+	Conflicts []AnalyzerFix
+}
+
+type AnalyzerFix struct {
+	// The name of the analyzer.
+	Analyzer string
+	// The title of the fix.
+	Title string
+	// The location of the diagnostic that the fix resolves.
+	Location protocol.Location
+}
+
 type ListKnownPackagesResult struct {
 	Packages []string
 }
+
+type ListInterfacesResult struct {
+	// The interfaces, named as in ImplementInterfaceArgs.
+	Interfaces []string
+}
@@ -8,12 +8,14 @@ import (
 	"golang.org/x/tools/internal/event/export/metric"
 	"golang.org/x/tools/internal/event/label"
 	"golang.org/x/tools/internal/lsp/debug/tag"
+	"golang.org/x/tools/internal/memoize"
 )
 
 var (
 	// the distributions we use for histograms
 	bytesDistribution        = []int64{1 << 10, 1 << 11, 1 << 12, 1 << 14, 1 << 16, 1 << 20}
 	millisecondsDistribution = []float64{0.1, 0.5, 1, 2, 5, 10, 50, 100, 500, 1000, 5000, 10000, 50000, 100000}
+	packagesDistribution     = []int64{1, 2, 5, 10, 20, 50, 100, 200, 500, 1000, 2000, 5000}
 
 	receivedBytes = metric.HistogramInt64{
 		Name:        "received_bytes",
@@ -47,6 +49,37 @@ var (
 		Description: "Count of RPCs completed by method and status.",
 		Keys:        []label.Key{tag.RPCDirection, tag.Method, tag.StatusCode},
 	}
+
+	typeCheckLatency = metric.HistogramFloat64{
+		Name:        "type_check_latency",
+		Description: "Distribution of the time to type check a package in milliseconds, by parse mode.",
+		Keys:        []label.Key{tag.ParseMode},
+		Buckets:     millisecondsDistribution,
+	}
+
+	loadLatency = metric.HistogramFloat64{
+		Name:        "load_latency",
+		Description: "Distribution of the time of go list invocations in milliseconds.",
+		Buckets:     millisecondsDistribution,
+	}
+
+	loadedPackages = metric.HistogramInt64{
+		Name:        "loaded_packages",
+		Description: "Distribution of the number of packages loaded by go list invocations.",
+		Buckets:     packagesDistribution,
+	}
+
+	memoizeHits = metric.Scalar{
+		Name:        "memoize_hits",
+		Description: "Count of gets of memoized values that were already computed or being computed, by key type.",
+		Keys:        []label.Key{memoize.KeyType},
+	}
+
+	memoizeMisses = metric.Scalar{
+		Name:        "memoize_misses",
+		Description: "Count of gets of memoized values that had to be computed, by key type.",
+		Keys:        []label.Key{memoize.KeyType},
+	}
 )
 
 func registerMetrics(m *metric.Config) {
@@ -55,4 +88,9 @@ func registerMetrics(m *metric.Config) {
 	latency.Record(m, tag.Latency)
 	started.Count(m, tag.Started)
 	completed.Count(m, tag.Latency)
+	typeCheckLatency.Record(m, tag.TypeCheckLatency)
+	loadLatency.Record(m, tag.LoadLatency)
+	loadedPackages.Record(m, tag.LoadedPackages)
+	memoizeHits.SumInt64(m, memoize.KeyHit)
+	memoizeMisses.SumInt64(m, memoize.KeyMiss)
 }
@@ -0,0 +1,52 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package debug
+
+import (
+	"context"
+	"testing"
+
+	"golang.org/x/tools/internal/event"
+	"golang.org/x/tools/internal/event/core"
+	"golang.org/x/tools/internal/event/export/metric"
+	"golang.org/x/tools/internal/event/label"
+	"golang.org/x/tools/internal/memoize"
+)
+
+func TestMemoizeMetrics(t *testing.T) {
+	m := &metric.Config{}
+	registerMetrics(m)
+	got := make(map[string]int64)
+	event.SetExporter(m.Exporter(func(ctx context.Context, ev core.Event, lm label.Map) context.Context {
+		entries, _ := metric.Entries.Get(lm).([]metric.Data)
+		for _, data := range entries {
+			if data, ok := data.(*metric.Int64Data); ok {
+				var total int64
+				for _, v := range data.Rows {
+					total += v
+				}
+				got[data.Handle()] = total
+			}
+		}
+		return ctx
+	}))
+	defer event.SetExporter(nil)
+
+	ctx := context.Background()
+	s := &memoize.Store{}
+	g := s.Generation("g")
+	defer g.Destroy()
+	h := g.Bind("key", func(context.Context, memoize.Arg) interface{} { return "res" }, nil)
+	for i := 0; i < 3; i++ {
+		if _, err := h.Get(ctx, g, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s.RecordHits(ctx)
+
+	if got["memoize_hits"] != 2 || got["memoize_misses"] != 1 {
+		t.Errorf("got memoize_hits %d and memoize_misses %d, want 2 and 1", got["memoize_hits"], got["memoize_misses"])
+	}
+}
@@ -30,6 +30,7 @@ import (
 	"golang.org/x/tools/internal/event/export"
 	"golang.org/x/tools/internal/event/export/metric"
 	"golang.org/x/tools/internal/event/export/ocagent"
+	"golang.org/x/tools/internal/event/export/otlp"
 	"golang.org/x/tools/internal/event/export/prometheus"
 	"golang.org/x/tools/internal/event/keys"
 	"golang.org/x/tools/internal/event/label"
@@ -57,12 +58,14 @@ type Instance struct {
 	ListenedDebugAddress string
 	Workdir              string
 	OCAgentConfig        string
+	OTLPConfig           string
 
 	LogWriter io.Writer
 
 	exporter event.Exporter
 
 	ocagent    *ocagent.Exporter
+	otlp       *otlp.Exporter
 	prometheus *prometheus.Exporter
 	rpcs       *Rpcs
 	traces     *traces
@@ -347,24 +350,53 @@ func GetInstance(ctx context.Context) *Instance {
 }
 
 // WithInstance creates debug instance ready for use using the supplied
-// configuration and stores it in the returned context.
-func WithInstance(ctx context.Context, workdir, agent string) context.Context {
+// configuration and stores it in the returned context. Metrics are exported
+// to the ocagent at the agent address and the OpenTelemetry collector at the
+// collector address, unless they are empty or "off".
+func WithInstance(ctx context.Context, workdir, agent, collector string) context.Context {
 	i := &Instance{
 		StartTime:     time.Now(),
 		Workdir:       workdir,
 		OCAgentConfig: agent,
+		OTLPConfig:    collector,
 	}
 	i.LogWriter = os.Stderr
 	ocConfig := ocagent.Discover()
 	//TODO: we should not need to adjust the discovered configuration
 	ocConfig.Address = i.OCAgentConfig
 	i.ocagent = ocagent.Connect(ocConfig)
+	i.otlp = otlp.Connect(&otlp.Config{
+		Address: i.OTLPConfig,
+		Service: "gopls",
+	})
 	i.prometheus = prometheus.New()
 	i.rpcs = &Rpcs{}
 	i.traces = &traces{}
 	i.State = &State{}
 	i.exporter = makeInstanceExporter(i)
-	return context.WithValue(ctx, instanceKey, i)
+	ctx = context.WithValue(ctx, instanceKey, i)
+	if i.otlp != nil {
+		// The caches only count their hits, which must be recorded before the
+		// exporter sends the metrics.
+		go func() {
+			for range time.Tick(cacheHitsRate) {
+				i.recordCacheHits(ctx)
+			}
+		}()
+	}
+	return ctx
+}
+
+// cacheHitsRate is the rate at which the metrics of the hits of the caches
+// are recorded for the OpenTelemetry collector.
+const cacheHitsRate = 10 * time.Second
+
+// recordCacheHits records the metrics of the hits of the caches of i since
+// the last call.
+func (i *Instance) recordCacheHits(ctx context.Context) {
+	for _, c := range i.State.Caches() {
+		c.RecordHits(ctx)
+	}
 }
 
 // SetLogFile sets the logfile for use with this instance.
@@ -424,7 +456,10 @@ func (i *Instance) Serve(ctx context.Context) error {
 		mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
 		mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
 		if i.prometheus != nil {
-			mux.HandleFunc("/metrics/", i.prometheus.Serve)
+			mux.HandleFunc("/metrics/", func(w http.ResponseWriter, r *http.Request) {
+				i.recordCacheHits(ctx)
+				i.prometheus.Serve(w, r)
+			})
 		}
 		if i.rpcs != nil {
 			mux.HandleFunc("/rpc/", render(RPCTmpl, i.rpcs.getData))
@@ -432,7 +467,10 @@ func (i *Instance) Serve(ctx context.Context) error {
 		if i.traces != nil {
 			mux.HandleFunc("/trace/", render(TraceTmpl, i.traces.getData))
 		}
-		mux.HandleFunc("/cache/", render(CacheTmpl, i.getCache))
+		mux.HandleFunc("/cache/", render(CacheTmpl, func(r *http.Request) interface{} {
+			i.recordCacheHits(ctx)
+			return i.getCache(r)
+		}))
 		mux.HandleFunc("/session/", render(SessionTmpl, i.getSession))
 		mux.HandleFunc("/view/", render(ViewTmpl, i.getView))
 		mux.HandleFunc("/client/", render(ClientTmpl, i.getClient))
@@ -565,6 +603,9 @@ func makeInstanceExporter(i *Instance) event.Exporter {
 		if i.ocagent != nil {
 			ctx = i.ocagent.ProcessEvent(ctx, ev, lm)
 		}
+		if i.otlp != nil {
+			ctx = i.otlp.ProcessEvent(ctx, ev, lm)
+		}
 		if i.prometheus != nil {
 			ctx = i.prometheus.ProcessEvent(ctx, ev, lm)
 		}
@@ -850,6 +891,13 @@ var ViewTmpl = template.Must(template.Must(BaseTemplate.Clone()).Parse(`
 Name: <b>{{.Name}}</b><br>
 Folder: <b>{{.Folder}}</b><br>
 From: <b>{{template "sessionlink" .Session.ID}}</b><br>
+<h2>Toolchain</h2>
+{{with .Toolchain}}
+Version: <b>{{.Version}}</b><br>
+GOROOT: <b>{{.GOROOT}}</b><br>
+Go command: <b>{{.GoCmd}}</b><br>
+Selected by: <b>{{if .Selection}}{{.Selection}}{{else}}PATH{{end}}</b><br>
+{{end}}
 <h2>Environment</h2>
 <ul>{{range .Options.Env}}<li>{{.}}</li>{{end}}</ul>
 {{end}}
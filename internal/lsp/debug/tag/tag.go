@@ -24,6 +24,7 @@ var (
 	Query         = keys.New("query", "")
 	Snapshot      = keys.NewUInt64("snapshot", "")
 	Operation     = keys.NewString("operation", "")
+	ParseMode     = keys.NewString("parse_mode", "")
 
 	Position     = keys.New("position", "")
 	Category     = keys.NewString("category", "")
@@ -51,6 +52,10 @@ var (
 	ReceivedBytes = keys.NewInt64("received_bytes", "Bytes received.")            //, unit.Bytes)
 	SentBytes     = keys.NewInt64("sent_bytes", "Bytes sent.")                    //, unit.Bytes)
 	Latency       = keys.NewFloat64("latency_ms", "Elapsed time in milliseconds") //, unit.Milliseconds)
+
+	TypeCheckLatency = keys.NewFloat64("type_check_latency_ms", "Elapsed time type checking a package in milliseconds") //, unit.Milliseconds)
+	LoadLatency      = keys.NewFloat64("load_latency_ms", "Elapsed time of a go list invocation in milliseconds")       //, unit.Milliseconds)
+	LoadedPackages   = keys.NewInt64("loaded_packages", "Packages loaded by a go list invocation.")
 )
 
 const (
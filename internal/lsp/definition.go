@@ -46,18 +46,14 @@ func (s *Server) typeDefinition(ctx context.Context, params *protocol.TypeDefini
 	if !ok {
 		return nil, err
 	}
-	ident, err := source.Identifier(ctx, snapshot, fh, params.Position)
-	if err != nil {
-		return nil, err
-	}
-	identRange, err := ident.Type.Range()
-	if err != nil {
+	return source.TypeDefinitions(ctx, snapshot, fh, params.Position)
+}
+
+func (s *Server) declaration(ctx context.Context, params *protocol.DeclarationParams) ([]protocol.Location, error) {
+	snapshot, fh, ok, release, err := s.beginFileRequest(ctx, params.TextDocument.URI, source.Go)
+	defer release()
+	if !ok {
 		return nil, err
 	}
-	return []protocol.Location{
-		{
-			URI:   protocol.URIFromSpanURI(ident.Type.URI()),
-			Range: identRange,
-		},
-	}, nil
+	return source.Declarations(ctx, snapshot, fh, params.Position)
 }
@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"sync"
 
@@ -163,14 +164,19 @@ func (s *Server) diagnose(ctx context.Context, snapshot source.Snapshot, forceAn
 	ctx, done := event.Start(ctx, "Server.diagnose", tag.Snapshot.Of(snapshot.ID()))
 	defer done()
 
-	// Wait for a free diagnostics slot.
+	// Wait for a free diagnostics slot. It is released before the analysis
+	// of the workspace packages, so that the diagnostics of later snapshots
+	// are not held up by it.
 	select {
 	case <-ctx.Done():
 		return
 	case s.diagnosticsSema <- struct{}{}:
 	}
+	holdingSema := true
 	defer func() {
-		<-s.diagnosticsSema
+		if holdingSema {
+			<-s.diagnosticsSema
+		}
 	}()
 
 	// First, diagnose the go.mod file.
@@ -240,11 +246,126 @@ func (s *Server) diagnose(ctx context.Context, snapshot source.Snapshot, forceAn
 		}
 		s.storeDiagnostics(snapshot, o.URI(), orphanedSource, []*source.Diagnostic{diagnostic})
 	}
+
+	// In the workspace scope, analyze the packages that have no open files,
+	// after publishing the diagnostics computed so far, so that the
+	// diagnostics of open files are not delayed by the analysis of the
+	// whole workspace. The analysis runs in the background context of the
+	// snapshot, rather than ctx, which is detached for the initial workspace
+	// load, so that a change cancels it.
+	if !forceAnalysis && snapshot.View().Options().DiagnosticsScope == source.WorkspaceScope {
+		s.publishDiagnostics(ctx, false, snapshot)
+		<-s.diagnosticsSema
+		holdingSema = false
+		s.analyzeWorkspace(snapshot.BackgroundContext(), snapshot, wsPkgs)
+	}
+}
+
+// AnalyzingWorkspace is the title of the progress reports of the analysis
+// of workspace packages in the workspace diagnostics scope.
+const AnalyzingWorkspace = "Analyzing workspace packages"
+
+// analyzeWorkspace runs the analyzers on the packages of pkgs that have no
+// open files, which diagnosePkg does not analyze, with bounded parallelism,
+// and stores their diagnostics. It stops early if ctx is cancelled, as it
+// is when a change invalidates the snapshot.
+func (s *Server) analyzeWorkspace(ctx context.Context, snapshot source.Snapshot, pkgs []source.Package) {
+	ctx, done := event.Start(ctx, "Server.analyzeWorkspace", tag.Snapshot.Of(snapshot.ID()))
+	defer done()
+
+	var toAnalyze []source.Package
+	for _, pkg := range pkgs {
+		if pkg.HasListOrParseErrors() {
+			continue
+		}
+		open, ignored := false, true
+		for _, pgf := range pkg.CompiledGoFiles() {
+			open = open || snapshot.IsOpen(pgf.URI)
+			ignored = ignored && snapshot.IgnoredFile(pgf.URI)
+		}
+		if !open && !ignored {
+			toAnalyze = append(toAnalyze, pkg)
+		}
+	}
+	if len(toAnalyze) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	// Don't fall back to messages for clients that don't support progress
+	// reports, as the workspace is analyzed after every change.
+	var work *workDone
+	if s.progress.supportsWorkDoneProgress {
+		work = s.progress.start(ctx, AnalyzingWorkspace, fmt.Sprintf("0/%d packages", len(toAnalyze)), nil, cancel)
+	}
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		analyzed int
+		sema     = make(chan struct{}, runtime.GOMAXPROCS(0))
+	)
+	for _, pkg := range toAnalyze {
+		select {
+		case <-ctx.Done():
+		case sema <- struct{}{}:
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func(pkg source.Package) {
+			defer func() {
+				<-sema
+				wg.Done()
+			}()
+			reports, err := source.Analyze(ctx, snapshot, pkg, false)
+			if err != nil {
+				if ctx.Err() == nil {
+					event.Error(ctx, "warning: analyzing package", err, tag.Snapshot.Of(snapshot.ID()), tag.Package.Of(pkg.ID()))
+				}
+				return
+			}
+			for _, cgf := range pkg.CompiledGoFiles() {
+				s.storeDiagnostics(snapshot, cgf.URI, analysisSource, reports[cgf.URI])
+			}
+			mu.Lock()
+			analyzed++
+			n := analyzed
+			mu.Unlock()
+			work.report(fmt.Sprintf("%d/%d packages", n, len(toAnalyze)), 100*float64(n)/float64(len(toAnalyze)))
+		}(pkg)
+	}
+	wg.Wait()
+	if ctx.Err() != nil {
+		work.end("Cancelled.")
+		return
+	}
+	work.end("Done.")
 }
 
 func (s *Server) diagnosePkg(ctx context.Context, snapshot source.Snapshot, pkg source.Package, alwaysAnalyze bool) {
+	for uri, reports := range s.pkgDiagnostics(ctx, snapshot, pkg, alwaysAnalyze) {
+		for dsource, diags := range reports {
+			s.storeDiagnostics(snapshot, uri, dsource, diags)
+		}
+	}
+}
+
+// pkgDiagnostics returns the diagnostics of pkg by file and source. A file
+// of a source without diagnostics has a nil entry, so that storing it clears
+// the diagnostics previously stored.
+func (s *Server) pkgDiagnostics(ctx context.Context, snapshot source.Snapshot, pkg source.Package, alwaysAnalyze bool) map[span.URI]map[diagnosticSource][]*source.Diagnostic {
 	ctx, done := event.Start(ctx, "Server.diagnosePkg", tag.Snapshot.Of(snapshot.ID()), tag.Package.Of(pkg.ID()))
 	defer done()
+	result := make(map[span.URI]map[diagnosticSource][]*source.Diagnostic)
+	add := func(uri span.URI, dsource diagnosticSource, diags []*source.Diagnostic) {
+		if result[uri] == nil {
+			result[uri] = make(map[diagnosticSource][]*source.Diagnostic)
+		}
+		result[uri][dsource] = diags
+	}
 	enableDiagnostics := false
 	includeAnalysis := alwaysAnalyze // only run analyses for packages with open files
 	for _, pgf := range pkg.CompiledGoFiles() {
@@ -253,25 +374,25 @@ func (s *Server) diagnosePkg(ctx context.Context, snapshot source.Snapshot, pkg
 	}
 	// Don't show any diagnostics on ignored files.
 	if !enableDiagnostics {
-		return
+		return nil
 	}
 
 	pkgDiagnostics, err := snapshot.DiagnosePackage(ctx, pkg)
 	if err != nil {
 		event.Error(ctx, "warning: diagnosing package", err, tag.Snapshot.Of(snapshot.ID()), tag.Package.Of(pkg.ID()))
-		return
+		return nil
 	}
 	for _, cgf := range pkg.CompiledGoFiles() {
-		s.storeDiagnostics(snapshot, cgf.URI, typeCheckSource, pkgDiagnostics[cgf.URI])
+		add(cgf.URI, typeCheckSource, pkgDiagnostics[cgf.URI])
 	}
 	if includeAnalysis && !pkg.HasListOrParseErrors() {
 		reports, err := source.Analyze(ctx, snapshot, pkg, false)
 		if err != nil {
 			event.Error(ctx, "warning: analyzing package", err, tag.Snapshot.Of(snapshot.ID()), tag.Package.Of(pkg.ID()))
-			return
+			return result
 		}
 		for _, cgf := range pkg.CompiledGoFiles() {
-			s.storeDiagnostics(snapshot, cgf.URI, analysisSource, reports[cgf.URI])
+			add(cgf.URI, analysisSource, reports[cgf.URI])
 		}
 	}
 
@@ -292,9 +413,10 @@ func (s *Server) diagnosePkg(ctx context.Context, snapshot source.Snapshot, pkg
 			if fh == nil || !fh.Saved() {
 				continue
 			}
-			s.storeDiagnostics(snapshot, id.URI, gcDetailsSource, diags)
+			add(id.URI, gcDetailsSource, diags)
 		}
 	}
+	return result
 }
 
 // storeDiagnostics stores results from a single diagnostic source. If merge is
@@ -329,6 +451,25 @@ func (s *Server) storeDiagnostics(snapshot source.Snapshot, uri span.URI, dsourc
 	s.diagnostics[uri].reports[dsource] = report
 }
 
+// storedDiagnostics returns the diagnostics of uri from every source, as
+// last stored by the diagnostics passes, in order.
+func (s *Server) storedDiagnostics(uri span.URI) []*source.Diagnostic {
+	s.diagnosticsMu.Lock()
+	defer s.diagnosticsMu.Unlock()
+	r := s.diagnostics[uri]
+	if r == nil {
+		return nil
+	}
+	var diags []*source.Diagnostic
+	for _, report := range r.reports {
+		for _, d := range report.diags {
+			diags = append(diags, d)
+		}
+	}
+	source.SortDiagnostics(diags)
+	return diags
+}
+
 // clearDiagnosticSource clears all diagnostics for a given source type. It is
 // necessary for cases where diagnostics have been invalidated by something
 // other than a snapshot change, for example when gc_details is toggled.
@@ -117,8 +117,17 @@ func (c *Client) ApplyEdit(ctx context.Context, params *protocol.ApplyWorkspaceE
 		return &protocol.ApplyWorkspaceEditResponse{FailureReason: "Edit.Changes is unsupported"}, nil
 	}
 	for _, change := range params.Edit.DocumentChanges {
-		path := c.editor.sandbox.Workdir.URIToPath(change.TextDocument.URI)
-		edits := convertEdits(change.Edits)
+		if change.CreateFile != nil {
+			path := c.editor.sandbox.Workdir.URIToPath(change.CreateFile.URI)
+			if !c.editor.HasBuffer(path) {
+				if err := c.editor.CreateBuffer(ctx, path, ""); err != nil {
+					return nil, err
+				}
+			}
+			continue
+		}
+		path := c.editor.sandbox.Workdir.URIToPath(change.TextDocumentEdit.TextDocument.URI)
+		edits := convertEdits(change.TextDocumentEdit.Edits)
 		if !c.editor.HasBuffer(path) {
 			err := c.editor.OpenFile(ctx, path)
 			if os.IsNotExist(err) {
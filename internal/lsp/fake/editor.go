@@ -111,6 +111,18 @@ type EditorConfig struct {
 	VerboseOutput bool
 
 	ImportShortcut string
+
+	// EagerCodeActions disables support for codeAction/resolve, so that the
+	// server computes the edits of every code action it returns.
+	EagerCodeActions bool
+
+	// NoResourceOperations disables support for the creation of files in
+	// workspace edits, so that the server creates them itself.
+	NoResourceOperations bool
+
+	// Settings holds additional settings to send to the server, which take
+	// precedence over those derived from the fields above.
+	Settings map[string]interface{}
 }
 
 // NewEditor Creates a new Editor.
@@ -248,6 +260,10 @@ func (e *Editor) configuration() map[string]interface{} {
 	// designated experimental.
 	config["experimentalDiagnosticsDelay"] = "10ms"
 
+	for k, v := range e.Config.Settings {
+		config[k] = v
+	}
+
 	// ExperimentalWorkspaceModule is only set as a mode, not a configuration.
 	return config
 }
@@ -281,6 +297,27 @@ func (e *Editor) initialize(ctx context.Context, workspaceFolders []string) erro
 	// true.
 	params.Capabilities.Workspace.DidChangeWatchedFiles.DynamicRegistration = true
 
+	// The editor sends willRenameFiles and didRenameFiles when asked to.
+	params.Capabilities.Workspace.FileOperations = &protocol.FileOperationClientCapabilities{
+		DynamicRegistration: true,
+		WillRename:          true,
+		DidRename:           true,
+	}
+
+	// The editor creates the files of workspace edits.
+	if !e.Config.NoResourceOperations {
+		params.Capabilities.Workspace.WorkspaceEdit = &protocol.WorkspaceEditClientCapabilities{
+			ResourceOperations: []protocol.ResourceOperationKind{protocol.Create},
+		}
+	}
+
+	// The editor resolves the edits of code actions lazily, when they are
+	// applied.
+	if !e.Config.EagerCodeActions {
+		params.Capabilities.TextDocument.CodeAction.DataSupport = true
+		params.Capabilities.TextDocument.CodeAction.ResolveSupport.Properties = []string{"edit"}
+	}
+
 	params.Trace = "messages"
 	// TODO: support workspace folders.
 	if e.Server != nil {
@@ -704,11 +741,51 @@ func (e *Editor) GoToDefinition(ctx context.Context, path string, pos Pos) (stri
 	if err != nil {
 		return "", Pos{}, errors.Errorf("definition: %w", err)
 	}
-	if len(resp) == 0 {
+	return e.jumpTo(ctx, resp)
+}
+
+// GoToDeclaration jumps to the first declaration of the symbol at the given
+// position in an open buffer.
+func (e *Editor) GoToDeclaration(ctx context.Context, path string, pos Pos) (string, Pos, error) {
+	if err := e.checkBufferPosition(path, pos); err != nil {
+		return "", Pos{}, err
+	}
+	params := &protocol.DeclarationParams{}
+	params.TextDocument.URI = e.sandbox.Workdir.URI(path)
+	params.Position = pos.ToProtocolPosition()
+
+	resp, err := e.Server.Declaration(ctx, params)
+	if err != nil {
+		return "", Pos{}, errors.Errorf("declaration: %w", err)
+	}
+	return e.jumpTo(ctx, resp)
+}
+
+// GoToTypeDefinition jumps to the definition of the type of the symbol at
+// the given position in an open buffer.
+func (e *Editor) GoToTypeDefinition(ctx context.Context, path string, pos Pos) (string, Pos, error) {
+	if err := e.checkBufferPosition(path, pos); err != nil {
+		return "", Pos{}, err
+	}
+	params := &protocol.TypeDefinitionParams{}
+	params.TextDocument.URI = e.sandbox.Workdir.URI(path)
+	params.Position = pos.ToProtocolPosition()
+
+	resp, err := e.Server.TypeDefinition(ctx, params)
+	if err != nil {
+		return "", Pos{}, errors.Errorf("typeDefinition: %w", err)
+	}
+	return e.jumpTo(ctx, resp)
+}
+
+// jumpTo opens the file of the first of locs, if any, and returns its path
+// and the start of the location.
+func (e *Editor) jumpTo(ctx context.Context, locs []protocol.Location) (string, Pos, error) {
+	if len(locs) == 0 {
 		return "", Pos{}, nil
 	}
-	newPath := e.sandbox.Workdir.URIToPath(resp[0].URI)
-	newPos := fromProtocolPosition(resp[0].Range.Start)
+	newPath := e.sandbox.Workdir.URIToPath(locs[0].URI)
+	newPos := fromProtocolPosition(locs[0].Range.Start)
 	if !e.HasBuffer(newPath) {
 		if err := e.OpenFile(ctx, newPath); err != nil {
 			return "", Pos{}, errors.Errorf("OpenFile: %w", err)
@@ -799,7 +876,18 @@ func (e *Editor) codeAction(ctx context.Context, path string, rng *protocol.Rang
 			continue
 		}
 		applied++
-		for _, change := range action.Edit.DocumentChanges {
+		if action.Data != nil {
+			resolved, err := e.Server.ResolveCodeAction(ctx, &action)
+			if err != nil {
+				return 0, errors.Errorf("resolving code action %q: %w", action.Title, err)
+			}
+			action = *resolved
+		}
+		for _, c := range action.Edit.DocumentChanges {
+			change := c.TextDocumentEdit
+			if change == nil {
+				continue
+			}
 			path := e.sandbox.Workdir.URIToPath(change.TextDocument.URI)
 			if int32(e.buffers[path].version) != change.TextDocument.Version {
 				// Skip edits for old versions.
@@ -893,6 +981,53 @@ func (e *Editor) FormatBuffer(ctx context.Context, path string) error {
 	if err != nil {
 		return errors.Errorf("textDocument/formatting: %w", err)
 	}
+	return e.applyFormattingEdits(ctx, path, version, resp)
+}
+
+// FormatRange formats the given range of the buffer at path.
+func (e *Editor) FormatRange(ctx context.Context, path string, rng Range) error {
+	if e.Server == nil {
+		return nil
+	}
+	e.mu.Lock()
+	version := e.buffers[path].version
+	e.mu.Unlock()
+	params := &protocol.DocumentRangeFormattingParams{
+		Range: protocol.Range{
+			Start: rng.Start.ToProtocolPosition(),
+			End:   rng.End.ToProtocolPosition(),
+		},
+	}
+	params.TextDocument.URI = e.sandbox.Workdir.URI(path)
+	resp, err := e.Server.RangeFormatting(ctx, params)
+	if err != nil {
+		return errors.Errorf("textDocument/rangeFormatting: %w", err)
+	}
+	return e.applyFormattingEdits(ctx, path, version, resp)
+}
+
+// FormatOnType requests the formatting edits for typing ch at pos in the
+// buffer at path, and applies them.
+func (e *Editor) FormatOnType(ctx context.Context, path string, pos Pos, ch string) error {
+	if e.Server == nil {
+		return nil
+	}
+	e.mu.Lock()
+	version := e.buffers[path].version
+	e.mu.Unlock()
+	params := &protocol.DocumentOnTypeFormattingParams{
+		Position: pos.ToProtocolPosition(),
+		Ch:       ch,
+	}
+	params.TextDocument.URI = e.sandbox.Workdir.URI(path)
+	resp, err := e.Server.OnTypeFormatting(ctx, params)
+	if err != nil {
+		return errors.Errorf("textDocument/onTypeFormatting: %w", err)
+	}
+	return e.applyFormattingEdits(ctx, path, version, resp)
+}
+
+func (e *Editor) applyFormattingEdits(ctx context.Context, path string, version int, resp []protocol.TextEdit) error {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 	if versionAfter := e.buffers[path].version; versionAfter != version {
@@ -0,0 +1,332 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package filecache implements a persistent cache of files, so that gopls
+// can reuse the results of expensive computations across restarts.
+//
+// Entries are grouped by kind, and are addressed by keys that are hashes of
+// the inputs of the computations that produced them. Each entry is stored
+// with a checksum of its content, so that entries that were truncated or
+// otherwise corrupted are detected and discarded. When the total size of the
+// entries exceeds the maximum size of the cache, the least recently used
+// ones are evicted.
+//
+// A cache directory may be shared by several processes.
+package filecache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	errors "golang.org/x/xerrors"
+)
+
+// DefaultMaxSize is the default maximum size of a cache, in bytes.
+const DefaultMaxSize = 1 << 30
+
+// ErrNotFound is returned by Get when there is no entry for a key.
+var ErrNotFound = errors.New("no cache entry")
+
+// tmpPrefix is the prefix of the names of the files that are being written
+// by Set.
+const tmpPrefix = "tmp-"
+
+// A Cache is a cache of files in a directory.
+type Cache struct {
+	dir     string
+	maxSize int64
+
+	mu   sync.Mutex
+	size int64 // total size of the entries, or -1 if not yet computed
+}
+
+// DefaultDir returns the default directory of the cache: $GOPLSCACHE if set,
+// or the gopls subdirectory of the user's cache directory.
+func DefaultDir() (string, error) {
+	if dir := os.Getenv("GOPLSCACHE"); dir != "" {
+		return dir, nil
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "gopls"), nil
+}
+
+// Open returns the cache in dir, creating the directory if needed. The
+// least recently used entries of the cache are evicted when the total size
+// of its entries exceeds maxSize bytes.
+func Open(dir string, maxSize int64) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return nil, err
+	}
+	return &Cache{
+		dir:     dir,
+		maxSize: maxSize,
+		size:    -1,
+	}, nil
+}
+
+// Dir returns the directory of the cache.
+func (c *Cache) Dir() string {
+	return c.dir
+}
+
+// Key returns a key for the given inputs of a computation.
+func Key(inputs ...string) string {
+	h := sha256.New()
+	for _, in := range inputs {
+		fmt.Fprintf(h, "%d:%s", len(in), in)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (c *Cache) path(kind, key string) (string, error) {
+	if kind == "" || strings.ContainsAny(kind, `/\.`) {
+		return "", errors.Errorf("invalid kind %q", kind)
+	}
+	if len(key) < 3 || strings.ContainsAny(key, `/\.`) {
+		return "", errors.Errorf("invalid key %q", key)
+	}
+	return filepath.Join(c.dir, kind, key[:2], key), nil
+}
+
+// Get returns the content of the entry of the given kind and key. It
+// returns ErrNotFound if there is none. If the entry is corrupt, it is
+// removed and Get returns an error.
+func (c *Cache) Get(kind, key string) ([]byte, error) {
+	path, err := c.path(kind, key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	content, ok := checkEntry(data)
+	if !ok {
+		c.remove(path, int64(len(data)))
+		return nil, errors.Errorf("corrupt cache entry %s", path)
+	}
+	// Record the use of the entry, for eviction.
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+	return content, nil
+}
+
+// Set stores content as the entry of the given kind and key, replacing any
+// existing one.
+func (c *Cache) Set(kind, key string, content []byte) error {
+	path, err := c.path(kind, key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+		return err
+	}
+	// Write the entry to a temporary file that is renamed, so that other
+	// processes never see a partial entry.
+	f, err := ioutil.TempFile(filepath.Dir(path), tmpPrefix)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(content)
+	_, err = f.Write(content)
+	if err == nil {
+		_, err = f.Write(sum[:])
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.size >= 0 {
+		c.size += int64(len(content) + len(sum))
+	}
+	if c.size < 0 || c.size > c.maxSize {
+		// Evict entries until the cache is comfortably below its maximum
+		// size, so as not to trim it again on the next Set.
+		return c.trimLocked(c.maxSize / 10 * 9)
+	}
+	return nil
+}
+
+// checkEntry returns the content of the entry data, and reports whether
+// its checksum is correct.
+func checkEntry(data []byte) ([]byte, bool) {
+	if len(data) < sha256.Size {
+		return nil, false
+	}
+	content, sum := data[:len(data)-sha256.Size], data[len(data)-sha256.Size:]
+	want := sha256.Sum256(content)
+	return content, bytes.Equal(sum, want[:])
+}
+
+func (c *Cache) remove(path string, size int64) {
+	if os.Remove(path) != nil {
+		return
+	}
+	c.mu.Lock()
+	if c.size >= 0 {
+		c.size -= size
+	}
+	c.mu.Unlock()
+}
+
+// An entry is a file of the cache.
+type entry struct {
+	path    string
+	kind    string
+	size    int64
+	modTime time.Time
+	tmp     bool
+}
+
+// entries returns the entries of the cache, including the temporary files
+// of entries being written.
+func (c *Cache) entries() ([]entry, error) {
+	var entries []entry
+	err := filepath.Walk(c.dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			// Entries may be removed concurrently by other processes.
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(c.dir, path)
+		if err != nil {
+			return err
+		}
+		parts := strings.Split(filepath.ToSlash(rel), "/")
+		if len(parts) != 3 {
+			return nil
+		}
+		entries = append(entries, entry{
+			path:    path,
+			kind:    parts[0],
+			size:    info.Size(),
+			modTime: info.ModTime(),
+			tmp:     strings.HasPrefix(parts[2], tmpPrefix),
+		})
+		return nil
+	})
+	return entries, err
+}
+
+// Trim evicts the least recently used entries of the cache until the total
+// size of its entries is at most size bytes.
+func (c *Cache) Trim(size int64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.trimLocked(size)
+}
+
+func (c *Cache) trimLocked(size int64) error {
+	entries, err := c.entries()
+	if err != nil {
+		return err
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].modTime.Before(entries[j].modTime)
+	})
+	var total int64
+	for _, e := range entries {
+		total += e.size
+	}
+	// Temporary files that are older than an hour were left behind by
+	// processes that did not finish writing them.
+	stale := time.Now().Add(-time.Hour)
+	for _, e := range entries {
+		if e.tmp {
+			if e.modTime.After(stale) {
+				continue
+			}
+		} else if total <= size {
+			continue
+		}
+		if err := os.Remove(e.path); err == nil || os.IsNotExist(err) {
+			total -= e.size
+		}
+	}
+	c.size = total
+	return nil
+}
+
+// Usage describes the entries of one kind in a cache.
+type Usage struct {
+	Kind    string
+	Entries int
+	Size    int64
+}
+
+// Usage returns the number and size of the entries of each kind in the
+// cache, sorted by kind.
+func (c *Cache) Usage() ([]Usage, error) {
+	entries, err := c.entries()
+	if err != nil {
+		return nil, err
+	}
+	byKind := make(map[string]*Usage)
+	var usage []Usage
+	for _, e := range entries {
+		if e.tmp {
+			continue
+		}
+		u, ok := byKind[e.kind]
+		if !ok {
+			u = &Usage{Kind: e.kind}
+			byKind[e.kind] = u
+		}
+		u.Entries++
+		u.Size += e.size
+	}
+	for _, u := range byKind {
+		usage = append(usage, *u)
+	}
+	sort.Slice(usage, func(i, j int) bool {
+		return usage[i].Kind < usage[j].Kind
+	})
+	return usage, nil
+}
+
+// Clean removes all the entries of the cache.
+func (c *Cache) Clean() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	infos, err := ioutil.ReadDir(c.dir)
+	if err != nil {
+		return err
+	}
+	for _, info := range infos {
+		if err := os.RemoveAll(filepath.Join(c.dir, info.Name())); err != nil {
+			return err
+		}
+	}
+	c.size = 0
+	return nil
+}
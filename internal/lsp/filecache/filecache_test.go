@@ -0,0 +1,151 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package filecache_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/tools/internal/lsp/filecache"
+	errors "golang.org/x/xerrors"
+)
+
+// openCache opens a cache in a temporary directory, and returns it with a
+// function that removes the directory.
+func openCache(t *testing.T, maxSize int64) (*filecache.Cache, func()) {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "filecache-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := filecache.Open(dir, maxSize)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	return c, func() { os.RemoveAll(dir) }
+}
+
+func TestGetSet(t *testing.T) {
+	c, cleanup := openCache(t, filecache.DefaultMaxSize)
+	defer cleanup()
+	key := filecache.Key("a", "b")
+	if key == filecache.Key("ab") || key == filecache.Key("a", "b", "") {
+		t.Errorf("Key does not separate its inputs")
+	}
+	if _, err := c.Get("export", key); !errors.Is(err, filecache.ErrNotFound) {
+		t.Fatalf("Get of missing entry: got error %v, want ErrNotFound", err)
+	}
+	want := []byte("hello")
+	if err := c.Set("export", key, want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := c.Get("export", key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("Get: got %q, want %q", got, want)
+	}
+	if _, err := c.Get("analysis", key); !errors.Is(err, filecache.ErrNotFound) {
+		t.Errorf("Get of another kind: got error %v, want ErrNotFound", err)
+	}
+	if err := c.Set("../x", key, want); err == nil {
+		t.Errorf("Set with invalid kind succeeded")
+	}
+}
+
+func TestCorruption(t *testing.T) {
+	c, cleanup := openCache(t, filecache.DefaultMaxSize)
+	defer cleanup()
+	key := filecache.Key("corrupt")
+	if err := c.Set("export", key, []byte("some export data")); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(c.Dir(), "export", key[:2], key)
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[0] ^= 1
+	if err := ioutil.WriteFile(path, data, 0666); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Get("export", key); err == nil || errors.Is(err, filecache.ErrNotFound) {
+		t.Fatalf("Get of corrupt entry: got error %v, want corruption error", err)
+	}
+	// The corrupt entry is removed.
+	if _, err := c.Get("export", key); !errors.Is(err, filecache.ErrNotFound) {
+		t.Errorf("Get after corruption: got error %v, want ErrNotFound", err)
+	}
+}
+
+func TestEviction(t *testing.T) {
+	// Each entry takes 100 bytes, plus 32 bytes of checksum.
+	c, cleanup := openCache(t, 600)
+	defer cleanup()
+	content := bytes.Repeat([]byte("x"), 100)
+	keys := []string{"k1", "k2", "k3", "k4"}
+	for i, k := range keys {
+		if err := c.Set("analysis", filecache.Key(k), content); err != nil {
+			t.Fatal(err)
+		}
+		// Make the order of use of the entries unambiguous.
+		path := filepath.Join(c.Dir(), "analysis", filecache.Key(k)[:2], filecache.Key(k))
+		mtime := time.Now().Add(time.Duration(i-len(keys)) * time.Minute)
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Using k1 makes k2 the least recently used entry.
+	if _, err := c.Get("analysis", filecache.Key("k1")); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Set("analysis", filecache.Key("k5"), content); err != nil {
+		t.Fatal(err)
+	}
+	for _, k := range []string{"k1", "k3", "k4", "k5"} {
+		if _, err := c.Get("analysis", filecache.Key(k)); err != nil {
+			t.Errorf("Get(%s): %v", k, err)
+		}
+	}
+	if _, err := c.Get("analysis", filecache.Key("k2")); !errors.Is(err, filecache.ErrNotFound) {
+		t.Errorf("Get(k2): got error %v, want ErrNotFound", err)
+	}
+	usage, err := c.Usage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(usage) != 1 || usage[0].Kind != "analysis" || usage[0].Size > 540 {
+		t.Errorf("Usage after eviction: got %+v, want at most 540 bytes of analysis entries", usage)
+	}
+}
+
+func TestClean(t *testing.T) {
+	c, cleanup := openCache(t, filecache.DefaultMaxSize)
+	defer cleanup()
+	for _, kind := range []string{"export", "analysis"} {
+		if err := c.Set(kind, filecache.Key(kind), []byte(kind)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	usage, err := c.Usage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(usage) != 2 || usage[0].Kind != "analysis" || usage[1].Kind != "export" || usage[0].Entries != 1 {
+		t.Fatalf("Usage: got %+v, want one entry of each kind", usage)
+	}
+	if err := c.Clean(); err != nil {
+		t.Fatal(err)
+	}
+	if usage, err := c.Usage(); err != nil || len(usage) != 0 {
+		t.Errorf("Usage after Clean: got %+v, %v, want no entries", usage, err)
+	}
+}
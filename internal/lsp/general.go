@@ -74,6 +74,7 @@ func (s *Server) initialize(ctx context.Context, params *protocol.ParamInitializ
 		// Using CodeActionOptions is only valid if codeActionLiteralSupport is set.
 		codeActionProvider = &protocol.CodeActionOptions{
 			CodeActionKinds: s.getSupportedCodeActions(),
+			ResolveProvider: true,
 		}
 	}
 	var renameOpts interface{} = true
@@ -95,12 +96,18 @@ func (s *Server) initialize(ctx context.Context, params *protocol.ParamInitializ
 			CompletionProvider: protocol.CompletionOptions{
 				TriggerCharacters: []string{"."},
 			},
-			DefinitionProvider:         true,
-			TypeDefinitionProvider:     true,
-			ImplementationProvider:     true,
-			DocumentFormattingProvider: true,
-			DocumentSymbolProvider:     true,
-			WorkspaceSymbolProvider:    true,
+			DeclarationProvider:             true,
+			DefinitionProvider:              true,
+			TypeDefinitionProvider:          true,
+			ImplementationProvider:          true,
+			DocumentFormattingProvider:      true,
+			DocumentRangeFormattingProvider: true,
+			DocumentOnTypeFormattingProvider: protocol.DocumentOnTypeFormattingOptions{
+				FirstTriggerCharacter: "}",
+				MoreTriggerCharacter:  []string{"\n"},
+			},
+			DocumentSymbolProvider:  true,
+			WorkspaceSymbolProvider: true,
 			ExecuteCommandProvider: protocol.ExecuteCommandOptions{
 				Commands: options.SupportedCommands,
 			},
@@ -110,6 +117,7 @@ func (s *Server) initialize(ctx context.Context, params *protocol.ParamInitializ
 			DocumentLinkProvider:      protocol.DocumentLinkOptions{},
 			ReferencesProvider:        true,
 			RenameProvider:            renameOpts,
+			SelectionRangeProvider:    true,
 			SignatureHelpProvider: protocol.SignatureHelpOptions{
 				TriggerCharacters: []string{"(", ","},
 			},
@@ -179,6 +187,13 @@ func (s *Server) initialized(ctx context.Context, params *protocol.InitializedPa
 			return err
 		}
 	}
+	if options.DynamicRenameFilesSupported {
+		if err := s.client.RegisterCapability(ctx, &protocol.RegistrationParams{
+			Registrations: renameFilesRegistrations(),
+		}); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -405,6 +405,39 @@ func (r *runner) Format(t *testing.T, spn span.Span) {
 	}
 }
 
+func (r *runner) SelectionRanges(t *testing.T, uri span.URI, spans []span.Span) {
+	m, err := r.data.Mapper(uri)
+	if err != nil {
+		t.Fatal(err)
+	}
+	params := &protocol.SelectionRangeParams{
+		TextDocument: protocol.TextDocumentIdentifier{
+			URI: protocol.URIFromSpanURI(uri),
+		},
+	}
+	for _, spn := range spans {
+		rng, err := m.Range(spn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		params.Positions = append(params.Positions, rng.Start)
+	}
+	ranges, err := r.server.SelectionRange(r.ctx, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := tests.SelectionRangesString(m, ranges)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := string(r.data.Golden("selectionrange", uri.Filename(), func() ([]byte, error) {
+		return []byte(got), nil
+	}))
+	if want != got {
+		t.Errorf("selection ranges failed for %s:\n%s", uri.Filename(), tests.Diff(t, want, got))
+	}
+}
+
 func (r *runner) SemanticTokens(t *testing.T, spn span.Span) {
 	uri := spn.URI()
 	filename := uri.Filename()
@@ -562,6 +595,40 @@ func (r *runner) SuggestedFix(t *testing.T, spn span.Span, actionKinds []string,
 	}
 }
 
+func (r *runner) SuggestedFixError(t *testing.T, spn span.Span, fixErr tests.SuggestedFixError) {
+	uri := spn.URI()
+	m, err := r.data.Mapper(uri)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rng, err := m.Range(spn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	actions, err := r.server.CodeAction(r.ctx, &protocol.CodeActionParams{
+		TextDocument: protocol.TextDocumentIdentifier{
+			URI: protocol.URIFromSpanURI(uri),
+		},
+		Range: rng,
+		Context: protocol.CodeActionContext{
+			Only: []protocol.CodeActionKind{protocol.CodeActionKind(fixErr.ActionKind)},
+		},
+	})
+	if err != nil {
+		t.Fatalf("CodeAction %s failed: %v", spn, err)
+	}
+	if len(actions) != 1 || actions[0].Command == nil {
+		t.Fatalf("unexpected code actions, want one command, got %v", actions)
+	}
+	_, err = r.server.ExecuteCommand(r.ctx, &protocol.ExecuteCommandParams{
+		Command:   actions[0].Command.Command,
+		Arguments: actions[0].Command.Arguments,
+	})
+	if err == nil || !strings.Contains(err.Error(), fixErr.Message) {
+		t.Errorf("%s: got error %v, want error containing %q", actions[0].Title, err, fixErr.Message)
+	}
+}
+
 func (r *runner) FunctionExtraction(t *testing.T, start span.Span, end span.Span) {
 	uri := start.URI()
 	m, err := r.data.Mapper(uri)
@@ -608,6 +675,59 @@ func (r *runner) FunctionExtraction(t *testing.T, start span.Span, end span.Span
 	}
 }
 
+func (r *runner) MethodExtraction(t *testing.T, start span.Span, end span.Span) {
+	uri := start.URI()
+	m, err := r.data.Mapper(uri)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spn := span.New(start.URI(), start.Start(), end.End())
+	rng, err := m.Range(spn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	actions, err := r.server.CodeAction(r.ctx, &protocol.CodeActionParams{
+		TextDocument: protocol.TextDocumentIdentifier{
+			URI: protocol.URIFromSpanURI(uri),
+		},
+		Range: rng,
+		Context: protocol.CodeActionContext{
+			Only: []protocol.CodeActionKind{"refactor.extract"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The selection can also be extracted to a function, so look for the
+	// method extraction specifically.
+	var action *protocol.CodeAction
+	for i, a := range actions {
+		if a.Title == "Extract to method" {
+			action = &actions[i]
+			break
+		}
+	}
+	if action == nil {
+		t.Fatalf("no method extraction code action among %v", len(actions))
+	}
+	_, err = r.server.ExecuteCommand(r.ctx, &protocol.ExecuteCommandParams{
+		Command:   action.Command.Command,
+		Arguments: action.Command.Arguments,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := <-r.editRecv
+	for u, got := range res {
+		want := string(r.data.Golden("methodextraction_"+tests.SpanName(spn), u.Filename(), func() ([]byte, error) {
+			return []byte(got), nil
+		}))
+		if want != got {
+			t.Errorf("method extraction failed for %s:\n%s", u.Filename(), tests.Diff(t, want, got))
+		}
+	}
+}
+
 func (r *runner) Definition(t *testing.T, spn span.Span, d tests.Definition) {
 	sm, err := r.data.Mapper(d.Src.URI())
 	if err != nil {
@@ -925,9 +1045,13 @@ func (r *runner) PrepareRename(t *testing.T, src span.Span, want *source.Prepare
 	}
 }
 
-func applyTextDocumentEdits(r *runner, edits []protocol.TextDocumentEdit) (map[span.URI]string, error) {
+func applyTextDocumentEdits(r *runner, edits []protocol.DocumentChanges) (map[span.URI]string, error) {
 	res := map[span.URI]string{}
-	for _, docEdits := range edits {
+	for _, change := range edits {
+		docEdits := change.TextDocumentEdit
+		if docEdits == nil {
+			continue
+		}
 		uri := docEdits.TextDocument.URI.SpanURI()
 		var m *protocol.ColumnMapper
 		// If we have already edited this file, we use the edited version (rather than the
@@ -13,6 +13,7 @@ import (
 	"log"
 	"net"
 	"os"
+	"runtime"
 	"strconv"
 	"sync/atomic"
 	"time"
@@ -77,11 +78,18 @@ func (s *StreamServer) ServeStream(ctx context.Context, conn jsonrpc2.Conn) erro
 		executable = ""
 	}
 	ctx = protocol.WithClient(ctx, client)
+	handler := protocol.ServerHandler(server, jsonrpc2.MethodNotFound)
+	// Refuse the LSP requests of new clients while over the memory budget, but
+	// still answer handshakes and queries of the server state.
+	if err := s.cache.Admit(ctx); err != nil {
+		if s.daemon {
+			log.Printf("Session %s: refused: %v", session.ID(), err)
+		}
+		handler = refuser(err)
+	}
 	conn.Go(ctx,
 		protocol.Handlers(
-			handshaker(session, executable, s.daemon,
-				protocol.ServerHandler(server,
-					jsonrpc2.MethodNotFound))))
+			handshaker(s.cache, session, executable, s.daemon, handler)))
 	if s.daemon {
 		log.Printf("Session %s: connected", session.ID())
 		defer log.Printf("Session %s: exited", session.ID())
@@ -110,6 +118,9 @@ type remoteConfig struct {
 	debug         string
 	listenTimeout time.Duration
 	logfile       string
+	memoryBudget  string
+	sessionMemory string
+	memoryIdle    time.Duration
 }
 
 // A RemoteOption configures the behavior of the auto-started remote.
@@ -141,6 +152,30 @@ func (l RemoteLogfile) set(cfg *remoteConfig) {
 	cfg.logfile = string(l)
 }
 
+// RemoteMemoryBudget configures the heap budget of the auto-started gopls
+// daemon, such as "4GiB".
+type RemoteMemoryBudget string
+
+func (b RemoteMemoryBudget) set(cfg *remoteConfig) {
+	cfg.memoryBudget = string(b)
+}
+
+// RemoteSessionMemory configures the size of the package data of a client
+// session of the auto-started gopls daemon, such as "1GiB".
+type RemoteSessionMemory string
+
+func (m RemoteSessionMemory) set(cfg *remoteConfig) {
+	cfg.sessionMemory = string(m)
+}
+
+// RemoteMemoryIdle configures the duration without requests after which the
+// auto-started gopls daemon releases the package data of a view.
+type RemoteMemoryIdle time.Duration
+
+func (d RemoteMemoryIdle) set(cfg *remoteConfig) {
+	cfg.memoryIdle = time.Duration(d)
+}
+
 func defaultRemoteConfig() remoteConfig {
 	return remoteConfig{
 		listenTimeout: 1 * time.Minute,
@@ -341,6 +376,15 @@ func connectToRemote(ctx context.Context, inNetwork, inAddr, goplsPath string, r
 		if rcfg.debug != "" {
 			args = append(args, "-debug", rcfg.debug)
 		}
+		if rcfg.memoryBudget != "" {
+			args = append(args, "-memory.budget", rcfg.memoryBudget)
+		}
+		if rcfg.sessionMemory != "" {
+			args = append(args, "-memory.session", rcfg.sessionMemory)
+		}
+		if rcfg.memoryIdle != 0 {
+			args = append(args, "-memory.idle", rcfg.memoryIdle.String())
+		}
 		if err := startRemote(goplsPath, args...); err != nil {
 			return nil, errors.Errorf("startRemote(%q, %v): %w", goplsPath, args, err)
 		}
@@ -486,6 +530,24 @@ type ClientSession struct {
 	SessionID string `json:"sessionID"`
 	Logfile   string `json:"logfile"`
 	DebugAddr string `json:"debugAddr"`
+
+	// MemoryBytes is the estimated size of the package data of the session,
+	// that is the sum of that of its views.
+	MemoryBytes int64       `json:"memoryBytes"`
+	Views       []ViewState `json:"views"`
+}
+
+// ViewState holds the memory accounting of a view of a client session.
+// Packages shared by several views are counted in each of them.
+type ViewState struct {
+	Name        string    `json:"name"`
+	Folder      string    `json:"folder"`
+	Packages    int       `json:"packages"`
+	MemoryBytes int64     `json:"memoryBytes"`
+	LastUsed    time.Time `json:"lastUsed"`
+	// Released counts the times the package data of the view was released to
+	// stay within the memory budget of the server.
+	Released int `json:"released"`
 }
 
 // ServerState holds information about the gopls daemon process, including its
@@ -497,6 +559,14 @@ type ServerState struct {
 	GoplsPath       string          `json:"goplsPath"`
 	CurrentClientID string          `json:"currentClientID"`
 	Clients         []ClientSession `json:"clients"`
+
+	// HeapAlloc is the size of the heap of the server, and MemoryBudget the
+	// size above which it releases package data and refuses new clients, or
+	// zero if it has no budget.
+	HeapAlloc    uint64 `json:"heapAlloc"`
+	MemoryBudget uint64 `json:"memoryBudget"`
+	// Refused counts the clients refused for being over the memory budget.
+	Refused int `json:"refused"`
 }
 
 const (
@@ -504,7 +574,7 @@ const (
 	sessionsMethod  = "gopls/sessions"
 )
 
-func handshaker(session *cache.Session, goplsPath string, logHandshakes bool, handler jsonrpc2.Handler) jsonrpc2.Handler {
+func handshaker(c *cache.Cache, session *cache.Session, goplsPath string, logHandshakes bool, handler jsonrpc2.Handler) jsonrpc2.Handler {
 	return func(ctx context.Context, reply jsonrpc2.Replier, r jsonrpc2.Request) error {
 		switch r.Method() {
 		case handshakeMethod:
@@ -540,19 +610,37 @@ func handshaker(session *cache.Session, goplsPath string, logHandshakes bool, ha
 
 			return reply(ctx, resp, nil)
 		case sessionsMethod:
+			var mem runtime.MemStats
+			runtime.ReadMemStats(&mem)
+			budget, refused := c.MemoryBudget()
 			resp := ServerState{
 				GoplsPath:       goplsPath,
 				CurrentClientID: session.ID(),
+				HeapAlloc:       mem.HeapAlloc,
+				MemoryBudget:    budget.Heap,
+				Refused:         refused,
 			}
 			if di := debug.GetInstance(ctx); di != nil {
 				resp.Logfile = di.Logfile
 				resp.DebugAddr = di.ListenedDebugAddress
 				for _, c := range di.State.Clients() {
-					resp.Clients = append(resp.Clients, ClientSession{
+					client := ClientSession{
 						SessionID: c.Session.ID(),
 						Logfile:   c.Logfile,
 						DebugAddr: c.DebugAddress,
-					})
+					}
+					for _, v := range c.Session.MemoryUsage() {
+						client.MemoryBytes += v.Bytes
+						client.Views = append(client.Views, ViewState{
+							Name:        v.Name,
+							Folder:      v.Folder.Filename(),
+							Packages:    v.Packages,
+							MemoryBytes: v.Bytes,
+							LastUsed:    v.LastUsed,
+							Released:    v.Released,
+						})
+					}
+					resp.Clients = append(resp.Clients, client)
 				}
 			}
 			return reply(ctx, resp, nil)
@@ -561,6 +649,14 @@ func handshaker(session *cache.Session, goplsPath string, logHandshakes bool, ha
 	}
 }
 
+// refuser replies to all requests with err, for the clients that the server
+// refuses to serve.
+func refuser(err error) jsonrpc2.Handler {
+	return func(ctx context.Context, reply jsonrpc2.Replier, r jsonrpc2.Request) error {
+		return reply(ctx, nil, err)
+	}
+}
+
 func sendError(ctx context.Context, reply jsonrpc2.Replier, err error) {
 	err = errors.Errorf("%v: %w", err, jsonrpc2.ErrParse)
 	if err := reply(ctx, nil, err); err != nil {
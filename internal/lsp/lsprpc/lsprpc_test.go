@@ -7,6 +7,7 @@ package lsprpc
 import (
 	"context"
 	"regexp"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -56,7 +57,7 @@ func TestClientLogging(t *testing.T) {
 	server := pingServer{}
 	client := fakeClient{logs: make(chan string, 10)}
 
-	ctx = debug.WithInstance(ctx, "", "")
+	ctx = debug.WithInstance(ctx, "", "", "")
 	ss := NewStreamServer(cache.New(ctx, nil), false)
 	ss.serverForTest = server
 	ts := servertest.NewPipeServer(ctx, ss, nil)
@@ -113,12 +114,12 @@ func checkClose(t *testing.T, closer func() error) {
 
 func setupForwarding(ctx context.Context, t *testing.T, s protocol.Server) (direct, forwarded servertest.Connector, cleanup func()) {
 	t.Helper()
-	serveCtx := debug.WithInstance(ctx, "", "")
+	serveCtx := debug.WithInstance(ctx, "", "", "")
 	ss := NewStreamServer(cache.New(serveCtx, nil), false)
 	ss.serverForTest = s
 	tsDirect := servertest.NewTCPServer(serveCtx, ss, nil)
 
-	forwarderCtx := debug.WithInstance(ctx, "", "")
+	forwarderCtx := debug.WithInstance(ctx, "", "", "")
 	forwarder := NewForwarder("tcp", tsDirect.Addr)
 	tsForwarded := servertest.NewPipeServer(forwarderCtx, forwarder, nil)
 	return tsDirect, tsForwarded, func() {
@@ -214,8 +215,8 @@ func TestDebugInfoLifecycle(t *testing.T) {
 
 	baseCtx, cancel := context.WithCancel(context.Background())
 	defer cancel()
-	clientCtx := debug.WithInstance(baseCtx, "", "")
-	serverCtx := debug.WithInstance(baseCtx, "", "")
+	clientCtx := debug.WithInstance(baseCtx, "", "", "")
+	serverCtx := debug.WithInstance(baseCtx, "", "", "")
 
 	cache := cache.New(serverCtx, nil)
 	ss := NewStreamServer(cache, false)
@@ -323,3 +324,35 @@ func TestEnvForwarding(t *testing.T) {
 		t.Errorf("GONOPROXY environment variable was overwritten")
 	}
 }
+
+func TestMemoryBudget(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	ctx = debug.WithInstance(ctx, "", "", "")
+
+	// The heap is always over a budget of one byte.
+	c := cache.New(ctx, nil)
+	c.ManageMemory(ctx, cache.MemoryBudget{Heap: 1})
+	ss := NewStreamServer(c, false)
+	ss.serverForTest = &initServer{}
+	ts := servertest.NewPipeServer(ctx, ss, nil)
+
+	conn := ts.Connect(ctx)
+	conn.Go(ctx, jsonrpc2.MethodNotFound)
+	_, err := protocol.ServerDispatcher(conn).Initialize(ctx, &protocol.ParamInitialize{})
+	if err == nil || !strings.Contains(err.Error(), "memory budget") {
+		t.Errorf("Initialize: got error %v, want an error about the memory budget", err)
+	}
+
+	// The refused client may still query the server state.
+	var state ServerState
+	if err := protocol.Call(ctx, conn, sessionsMethod, nil, &state); err != nil {
+		t.Fatal(err)
+	}
+	if state.MemoryBudget != 1 || state.Refused != 1 {
+		t.Errorf("got memory budget %d and %d refused clients, want 1 and 1", state.MemoryBudget, state.Refused)
+	}
+	if state.HeapAlloc == 0 {
+		t.Errorf("got a heap of 0 bytes")
+	}
+}
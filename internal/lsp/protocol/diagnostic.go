@@ -0,0 +1,124 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protocol
+
+// The types in this file are those of the pull diagnostics requests
+// proposed for version 3.17 of the LSP. Until they are part of the
+// specification, gopls provides these requests as commands. The full and
+// unchanged reports of the proposal are represented by a single type,
+// distinguished by its kind.
+
+/**
+ * Parameters of the document diagnostic request. PreviousResultID is the
+ * result id of the previous report of the document, if any.
+ *
+ * @since 3.17.0 - proposed state
+ */
+type DocumentDiagnosticParams struct {
+	WorkDoneProgressParams
+	TextDocument     TextDocumentIdentifier `json:"textDocument"`
+	Identifier       string                 `json:"identifier,omitempty"`
+	PreviousResultID string                 `json:"previousResultId,omitempty"`
+}
+
+/**
+ * The document diagnostic report kinds.
+ *
+ * @since 3.17.0 - proposed state
+ */
+type DocumentDiagnosticReportKind string
+
+const (
+	/**
+	 * A diagnostic report with a full
+	 * set of problems.
+	 */
+	DiagnosticFull DocumentDiagnosticReportKind = "full"
+	/**
+	 * A report indicating that the last
+	 * returned report is still accurate.
+	 */
+	DiagnosticUnchanged DocumentDiagnosticReportKind = "unchanged"
+)
+
+/**
+ * A diagnostic report of a document. A full report holds all the
+ * diagnostics of the document; an unchanged report indicates that the
+ * report whose result id is resultId is still accurate.
+ *
+ * @since 3.17.0 - proposed state
+ */
+type DocumentDiagnosticReport struct {
+	/**
+	 * The kind of the report.
+	 */
+	Kind DocumentDiagnosticReportKind `json:"kind"`
+	/**
+	 * An optional result id. If provided it will
+	 * be sent on the next diagnostic request for the
+	 * same document. It is required for unchanged reports.
+	 */
+	ResultID string `json:"resultId,omitempty"`
+	/**
+	 * The actual items of a full report.
+	 */
+	Items []Diagnostic `json:"items,omitempty"`
+}
+
+/**
+ * A previous result id in a workspace pull request.
+ *
+ * @since 3.17.0 - proposed state
+ */
+type PreviousResultID struct {
+	/**
+	 * The URI for which the client knowns a
+	 * result id.
+	 */
+	URI DocumentURI `json:"uri"`
+	/**
+	 * The value of the previous result id.
+	 */
+	Value string `json:"value"`
+}
+
+/**
+ * Parameters of the workspace diagnostic request. PreviousResultIds are the
+ * result ids of the reports the client currently knows.
+ *
+ * @since 3.17.0 - proposed state
+ */
+type WorkspaceDiagnosticParams struct {
+	WorkDoneProgressParams
+	Identifier        string             `json:"identifier,omitempty"`
+	PreviousResultIds []PreviousResultID `json:"previousResultIds"`
+}
+
+/**
+ * A diagnostic report of a document in a workspace diagnostic report.
+ *
+ * @since 3.17.0 - proposed state
+ */
+type WorkspaceDocumentDiagnosticReport struct {
+	DocumentDiagnosticReport
+	/**
+	 * The URI for which diagnostic information is reported.
+	 */
+	URI DocumentURI `json:"uri"`
+	/**
+	 * The version number for which the diagnostics are reported.
+	 * If the document is not marked as open `null` can be provided.
+	 */
+	Version *int32 `json:"version"`
+}
+
+/**
+ * A workspace diagnostic report.
+ *
+ * @since 3.17.0 - proposed state
+ */
+type WorkspaceDiagnosticReport struct {
+	Items []WorkspaceDocumentDiagnosticReport `json:"items"`
+}
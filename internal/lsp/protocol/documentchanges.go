@@ -0,0 +1,56 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protocol
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// DocumentChanges is an element of the DocumentChanges of a WorkspaceEdit:
+// a text document edit or a file creation. Exactly one of its fields is
+// set. The generator of tsprotocol.go uses this type for the union of the
+// specification, of which gopls does not send the other operations.
+type DocumentChanges struct {
+	TextDocumentEdit *TextDocumentEdit
+	CreateFile       *CreateFile
+}
+
+// TextDocumentChanges returns the document changes of edits.
+func TextDocumentChanges(edits []TextDocumentEdit) []DocumentChanges {
+	changes := make([]DocumentChanges, 0, len(edits))
+	for i := range edits {
+		changes = append(changes, DocumentChanges{TextDocumentEdit: &edits[i]})
+	}
+	return changes
+}
+
+func (d DocumentChanges) MarshalJSON() ([]byte, error) {
+	switch {
+	case d.TextDocumentEdit != nil:
+		return json.Marshal(d.TextDocumentEdit)
+	case d.CreateFile != nil:
+		return json.Marshal(d.CreateFile)
+	}
+	return nil, fmt.Errorf("empty DocumentChanges")
+}
+
+func (d *DocumentChanges) UnmarshalJSON(data []byte) error {
+	var op struct {
+		Kind string `json:"kind"`
+	}
+	if err := json.Unmarshal(data, &op); err != nil {
+		return err
+	}
+	switch op.Kind {
+	case "":
+		d.TextDocumentEdit = new(TextDocumentEdit)
+		return json.Unmarshal(data, d.TextDocumentEdit)
+	case "create":
+		d.CreateFile = new(CreateFile)
+		return json.Unmarshal(data, d.CreateFile)
+	}
+	return fmt.Errorf("unsupported document change of kind %q", op.Kind)
+}
@@ -929,7 +929,7 @@ function goUnionType(n: ts.UnionTypeNode, nm: string): string {
       break;
     }
     case 4:
-      if (nm == 'documentChanges') return `TextDocumentEdit ${help} `;
+      if (nm == 'documentChanges') return `DocumentChanges ${help} `;
       if (nm == 'textDocument/prepareRename') return `Range ${help} `;
     // eslint-disable-next-line no-fallthrough
     default:
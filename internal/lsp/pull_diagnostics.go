@@ -0,0 +1,229 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lsp
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"runtime"
+	"sort"
+	"sync"
+
+	"golang.org/x/tools/internal/event"
+	"golang.org/x/tools/internal/lsp/debug/tag"
+	"golang.org/x/tools/internal/lsp/mod"
+	"golang.org/x/tools/internal/lsp/protocol"
+	"golang.org/x/tools/internal/lsp/source"
+	"golang.org/x/tools/internal/span"
+)
+
+// This file implements pull diagnostics. The diagnostics computed for a
+// request are only reported to it: they are kept out of the store of push
+// diagnostics, which they would otherwise extend with the analyses of the
+// packages without open files.
+
+// documentDiagnostic returns the report of the diagnostics of fh in
+// snapshot, or an unchanged report if previousResultID is its result id.
+func (s *Server) documentDiagnostic(ctx context.Context, snapshot source.Snapshot, fh source.VersionedFileHandle, previousResultID string) (protocol.DocumentDiagnosticReport, error) {
+	ctx, done := event.Start(ctx, "Server.documentDiagnostic", tag.Snapshot.Of(snapshot.ID()), tag.URI.Of(fh.URI()))
+	defer done()
+
+	pulled := newPulledDiagnostics()
+	switch fh.Kind() {
+	case source.Go:
+		pkgs, err := snapshot.PackagesForFile(ctx, fh.URI(), source.TypecheckFull)
+		if err != nil || len(pkgs) == 0 {
+			if diagnostic := s.checkForOrphanedFile(ctx, snapshot, fh); diagnostic != nil {
+				pulled.add(fh.URI(), []*source.Diagnostic{diagnostic})
+			}
+		}
+		var wg sync.WaitGroup
+		for _, pkg := range pkgs {
+			wg.Add(1)
+			go func(pkg source.Package) {
+				defer wg.Done()
+				s.pullPkg(ctx, snapshot, pkg, pulled)
+			}(pkg)
+		}
+		wg.Wait()
+	case source.Mod:
+		if err := s.diagnoseModFiles(ctx, snapshot, pulled); err != nil {
+			return protocol.DocumentDiagnosticReport{}, err
+		}
+	}
+	if ctx.Err() != nil {
+		return protocol.DocumentDiagnosticReport{}, ctx.Err()
+	}
+	return pulled.report(fh, previousResultID), nil
+}
+
+// workspaceDiagnostic returns the reports of the diagnostics of the files of
+// the workspace packages and go.mod files of all views. The report of a file
+// is unchanged if its result id is that of the file in previousResultIDs.
+func (s *Server) workspaceDiagnostic(ctx context.Context, previousResultIDs map[span.URI]string) (protocol.WorkspaceDiagnosticReport, error) {
+	ctx, done := event.Start(ctx, "Server.workspaceDiagnostic")
+	defer done()
+
+	result := protocol.WorkspaceDiagnosticReport{
+		Items: []protocol.WorkspaceDocumentDiagnosticReport{},
+	}
+	seen := make(map[span.URI]bool)
+	for _, view := range s.session.Views() {
+		snapshot, release := view.Snapshot(ctx)
+		items, err := s.snapshotDiagnostic(ctx, snapshot, previousResultIDs, seen)
+		release()
+		if err != nil {
+			return protocol.WorkspaceDiagnosticReport{}, err
+		}
+		result.Items = append(result.Items, items...)
+	}
+	sort.Slice(result.Items, func(i, j int) bool {
+		return result.Items[i].URI < result.Items[j].URI
+	})
+	return result, nil
+}
+
+// snapshotDiagnostic returns the reports of the diagnostics of the files of
+// the workspace packages and go.mod files of snapshot, skipping the files
+// that are already in seen.
+func (s *Server) snapshotDiagnostic(ctx context.Context, snapshot source.Snapshot, previousResultIDs map[span.URI]string, seen map[span.URI]bool) ([]protocol.WorkspaceDocumentDiagnosticReport, error) {
+	pulled := newPulledDiagnostics()
+	if err := s.diagnoseModFiles(ctx, snapshot, pulled); err != nil {
+		return nil, err
+	}
+	uris := snapshot.ModFiles()
+	wsPkgs, err := snapshot.WorkspacePackages(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		wg   sync.WaitGroup
+		sema = make(chan struct{}, runtime.GOMAXPROCS(0))
+	)
+	for _, pkg := range wsPkgs {
+		for _, pgf := range pkg.CompiledGoFiles() {
+			uris = append(uris, pgf.URI)
+		}
+		wg.Add(1)
+		sema <- struct{}{}
+		go func(pkg source.Package) {
+			defer func() {
+				<-sema
+				wg.Done()
+			}()
+			s.pullPkg(ctx, snapshot, pkg, pulled)
+		}(pkg)
+	}
+	wg.Wait()
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	var items []protocol.WorkspaceDocumentDiagnosticReport
+	for _, uri := range uris {
+		if seen[uri] || snapshot.IgnoredFile(uri) {
+			continue
+		}
+		seen[uri] = true
+		fh := snapshot.FindFile(uri)
+		if fh == nil {
+			continue
+		}
+		item := protocol.WorkspaceDocumentDiagnosticReport{
+			DocumentDiagnosticReport: pulled.report(fh, previousResultIDs[uri]),
+			URI:                      protocol.URIFromSpanURI(uri),
+		}
+		if snapshot.IsOpen(uri) {
+			version := fh.Version()
+			item.Version = &version
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// diagnoseModFiles adds the diagnostics of the go.mod files of snapshot to
+// pulled.
+func (s *Server) diagnoseModFiles(ctx context.Context, snapshot source.Snapshot, pulled *pulledDiagnostics) error {
+	modReports, err := mod.Diagnostics(ctx, snapshot)
+	if err != nil {
+		return err
+	}
+	for id, diags := range modReports {
+		if id.URI == "" {
+			continue
+		}
+		pulled.add(id.URI, diags)
+	}
+	return nil
+}
+
+// pullPkg adds the diagnostics of pkg, including its analyses, to pulled.
+func (s *Server) pullPkg(ctx context.Context, snapshot source.Snapshot, pkg source.Package, pulled *pulledDiagnostics) {
+	for uri, reports := range s.pkgDiagnostics(ctx, snapshot, pkg, true) {
+		for _, diags := range reports {
+			pulled.add(uri, diags)
+		}
+	}
+}
+
+// pulledDiagnostics holds the diagnostics computed for a pull request, by
+// file. It is safe for concurrent use.
+type pulledDiagnostics struct {
+	mu    sync.Mutex
+	diags map[span.URI]map[string]*source.Diagnostic // by hash
+}
+
+func newPulledDiagnostics() *pulledDiagnostics {
+	return &pulledDiagnostics{diags: make(map[span.URI]map[string]*source.Diagnostic)}
+}
+
+// add adds the diagnostics of uri. Identical diagnostics, such as those of a
+// file in a package and its test variant, are added once.
+func (p *pulledDiagnostics) add(uri span.URI, diags []*source.Diagnostic) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.diags[uri] == nil {
+		p.diags[uri] = make(map[string]*source.Diagnostic)
+	}
+	for _, d := range diags {
+		p.diags[uri][hashDiagnostics(d)] = d
+	}
+}
+
+// report returns the report of the diagnostics of fh, or an unchanged report
+// if previousResultID is its result id.
+func (p *pulledDiagnostics) report(fh source.FileHandle, previousResultID string) protocol.DocumentDiagnosticReport {
+	var diags []*source.Diagnostic
+	p.mu.Lock()
+	for _, d := range p.diags[fh.URI()] {
+		diags = append(diags, d)
+	}
+	p.mu.Unlock()
+
+	id := diagnosticResultID(fh, diags)
+	if id == previousResultID {
+		return protocol.DocumentDiagnosticReport{
+			Kind:     protocol.DiagnosticUnchanged,
+			ResultID: id,
+		}
+	}
+	source.SortDiagnostics(diags)
+	return protocol.DocumentDiagnosticReport{
+		Kind:     protocol.DiagnosticFull,
+		ResultID: id,
+		Items:    toProtocolDiagnostics(diags),
+	}
+}
+
+// diagnosticResultID returns the result id of the diagnostics of a file,
+// derived from the identity of the file and the hash of its diagnostics, so
+// that it changes when either changes.
+func diagnosticResultID(fh source.FileHandle, diags []*source.Diagnostic) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s:%s", fh.FileIdentity().Hash, hashDiagnostics(diags...))
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
@@ -0,0 +1,169 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lsp
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/tools/internal/lsp/protocol"
+	"golang.org/x/tools/internal/lsp/source"
+	"golang.org/x/tools/internal/span"
+)
+
+func (s *Server) willRenameFiles(ctx context.Context, params *protocol.RenameFilesParams) (*protocol.WorkspaceEdit, error) {
+	// Group the renames by the view that contains them, so that each view
+	// computes the edits for its own packages.
+	renames := make(map[source.View][]protocol.FileRename)
+	for _, rename := range params.Files {
+		uri := span.URIFromURI(rename.OldURI)
+		if !uri.IsFile() {
+			continue
+		}
+		view, err := s.session.ViewOf(uri)
+		if err != nil {
+			return nil, err
+		}
+		renames[view] = append(renames[view], rename)
+	}
+
+	var docChanges []protocol.DocumentChanges
+	for view, files := range renames {
+		changes, err := renameFilesInView(ctx, view, files)
+		if err != nil {
+			// The files are renamed whatever the result, so tell the user
+			// why their references will not be updated.
+			if err := s.client.ShowMessage(ctx, &protocol.ShowMessageParams{
+				Type:    protocol.Warning,
+				Message: fmt.Sprintf("References to the renamed files were not updated: %v", err),
+			}); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		docChanges = append(docChanges, changes...)
+	}
+	if len(docChanges) == 0 {
+		return nil, nil
+	}
+	return &protocol.WorkspaceEdit{
+		DocumentChanges: docChanges,
+	}, nil
+}
+
+func renameFilesInView(ctx context.Context, view source.View, renames []protocol.FileRename) ([]protocol.DocumentChanges, error) {
+	snapshot, release := view.Snapshot(ctx)
+	defer release()
+
+	edits, err := source.RenameFiles(ctx, snapshot, renames)
+	if err != nil {
+		return nil, err
+	}
+	var docChanges []protocol.DocumentChanges
+	for uri, e := range edits {
+		fh, err := snapshot.GetVersionedFile(ctx, uri)
+		if err != nil {
+			return nil, err
+		}
+		docChanges = append(docChanges, documentChanges(fh, e)...)
+	}
+	return docChanges, nil
+}
+
+func (s *Server) didRenameFiles(ctx context.Context, params *protocol.RenameFilesParams) error {
+	// The files have already been moved on disk. Treat the rename as the
+	// deletion of the old files and the creation of the new ones.
+	var modifications []source.FileModification
+	for _, rename := range params.Files {
+		oldURI := span.URIFromURI(rename.OldURI)
+		newURI := span.URIFromURI(rename.NewURI)
+		if !oldURI.IsFile() || !newURI.IsFile() {
+			continue
+		}
+		// Directories are expanded to their known files by didModifyFiles.
+		modifications = append(modifications, source.FileModification{
+			URI:    oldURI,
+			Action: source.Delete,
+			OnDisk: true,
+		})
+		// The new directory's files are not yet known, so find them on disk.
+		for _, uri := range filesUnder(newURI) {
+			modifications = append(modifications, source.FileModification{
+				URI:    uri,
+				Action: source.Create,
+				OnDisk: true,
+			})
+		}
+	}
+	if len(modifications) == 0 {
+		return nil
+	}
+	return s.didModifyFiles(ctx, modifications, FromDidChangeWatchedFiles)
+}
+
+// filesUnder returns uri if it is a file, or the files beneath it that
+// gopls cares about if it is a directory. Like the go command, it skips
+// directories whose names begin with "." or "_", and testdata.
+func filesUnder(uri span.URI) []span.URI {
+	root := uri.Filename()
+	fi, err := os.Stat(root)
+	if err != nil || !fi.IsDir() {
+		return []span.URI{uri}
+	}
+	var uris []span.URI
+	filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		name := info.Name()
+		if info.IsDir() {
+			if path != root && (strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") || name == "testdata") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasSuffix(name, ".go") || name == "go.mod" || name == "go.sum" {
+			uris = append(uris, span.URIFromPath(path))
+		}
+		return nil
+	})
+	return uris
+}
+
+func renameFilesRegistrations() []protocol.Registration {
+	options := &protocol.FileOperationRegistrationOptions{
+		Filters: []protocol.FileOperationFilter{
+			{
+				Scheme: "file",
+				Pattern: protocol.FileOperationPattern{
+					Glob:    "**/*.go",
+					Matches: protocol.FileOp,
+				},
+			},
+			{
+				Scheme: "file",
+				Pattern: protocol.FileOperationPattern{
+					Glob:    "**",
+					Matches: protocol.FolderOp,
+				},
+			},
+		},
+	}
+	return []protocol.Registration{
+		{
+			ID:              "workspace/willRenameFiles",
+			Method:          "workspace/willRenameFiles",
+			RegisterOptions: options,
+		},
+		{
+			ID:              "workspace/didRenameFiles",
+			Method:          "workspace/didRenameFiles",
+			RegisterOptions: options,
+		},
+	}
+}
@@ -118,6 +118,10 @@ func (s *Server) nonstandardRequest(ctx context.Context, method string, params i
 	switch method {
 	case "gopls/diagnoseFiles":
 		paramMap := params.(map[string]interface{})
+		convenience, _ := paramMap["convenience"].(bool)
+		// The diagnostics are also returned, since they may be overwritten
+		// by diagnostics published concurrently for the same files.
+		var reports []*protocol.PublishDiagnosticsParams
 		for _, file := range paramMap["files"].([]interface{}) {
 			snapshot, fh, ok, release, err := s.beginFileRequest(ctx, protocol.DocumentURI(file.(string)), source.UnknownKind)
 			defer release()
@@ -125,24 +129,26 @@ func (s *Server) nonstandardRequest(ctx context.Context, method string, params i
 				return nil, err
 			}
 
-			fileID, diagnostics, err := source.FileDiagnostics(ctx, snapshot, fh.URI())
+			fileID, diagnostics, err := source.FileDiagnostics(ctx, snapshot, fh.URI(), convenience)
 			if err != nil {
 				return nil, err
 			}
-			if err := s.client.PublishDiagnostics(ctx, &protocol.PublishDiagnosticsParams{
+			report := &protocol.PublishDiagnosticsParams{
 				URI:         protocol.URIFromSpanURI(fh.URI()),
 				Diagnostics: toProtocolDiagnostics(diagnostics),
 				Version:     fileID.Version,
-			}); err != nil {
+			}
+			if err := s.client.PublishDiagnostics(ctx, report); err != nil {
 				return nil, err
 			}
+			reports = append(reports, report)
 		}
 		if err := s.client.PublishDiagnostics(ctx, &protocol.PublishDiagnosticsParams{
 			URI: "gopls://diagnostics-done",
 		}); err != nil {
 			return nil, err
 		}
-		return struct{}{}, nil
+		return reports, nil
 	}
 	return nil, notImplemented(method)
 }
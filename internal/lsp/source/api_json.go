@@ -31,6 +31,32 @@ var GeneratedAPIJSON = &APIJSON{
 				Status:     "",
 				Hierarchy:  "build",
 			},
+			{
+				Name: "goroot",
+				Type: "string",
+				Doc:  "goroot is the GOROOT of the Go toolchain that runs the go command for\nthe workspace folder, such as `/usr/local/go1.16`. By default, the go\ncommand on PATH is run.\n",
+				EnumKeys: EnumKeys{
+					ValueType: "",
+					Keys:      nil,
+				},
+				EnumValues: nil,
+				Default:    "\"\"",
+				Status:     "experimental",
+				Hierarchy:  "build",
+			},
+			{
+				Name: "toolchainsDir",
+				Type: "string",
+				Doc:  "toolchainsDir is a directory of Go toolchains, such as `~/sdk` where\n`golang.org/dl` installs them. If it is set, and goroot is not, the go\ncommand is run from the toolchain for the version of the `go`\ndirective of the go.mod file of the workspace folder: the latest\npatch release of that version in the directory, such as `go1.16.5`\nfor `go 1.16`. The go command on PATH is run if there is no such\ntoolchain.\n",
+				EnumKeys: EnumKeys{
+					ValueType: "",
+					Keys:      nil,
+				},
+				EnumValues: nil,
+				Default:    "\"\"",
+				Status:     "experimental",
+				Hierarchy:  "build",
+			},
 			{
 				Name: "directoryFilters",
 				Type: "[]string",
@@ -83,6 +109,19 @@ var GeneratedAPIJSON = &APIJSON{
 				Status:     "experimental",
 				Hierarchy:  "build",
 			},
+			{
+				Name: "experimentalPersistentCache",
+				Type: "bool",
+				Doc:  "experimentalPersistentCache controls whether to store the type\ninformation of dependencies and the results of analyses in a cache on\ndisk, so that they are reused when gopls restarts. The cache is in the\ngopls subdirectory of the user's cache directory, or in the directory\nnamed by $GOPLSCACHE. It may be inspected and cleaned with the\n`gopls cache` command.\n",
+				EnumKeys: EnumKeys{
+					ValueType: "",
+					Keys:      nil,
+				},
+				EnumValues: nil,
+				Default:    "false",
+				Status:     "experimental",
+				Hierarchy:  "build",
+			},
 			{
 				Name: "allowModfileModifications",
 				Type: "bool",
@@ -501,6 +540,11 @@ var GeneratedAPIJSON = &APIJSON{
 							Doc:     "suggested fixes for \"no result values expected\"\n\nThis checker provides suggested fixes for type errors of the\ntype \"no result values expected\". For example:\n\tfunc z() { return nil }\nwill turn into\n\tfunc z() { return }\n",
 							Default: "true",
 						},
+						{
+							Name:    "\"stubmethods\"",
+							Doc:     "suggested fixes for \"missing method <>\"\n\nThis checker provides suggested fixes for type errors of the\ntype \"missing method <>\", when a value of a type declared in the\ncurrent package is used as an interface. It will declare stubs of the\nmissing methods after the declaration of the type. For example:\n\tvar _ io.Reader = T{}\nwill declare\n\tfunc (t T) Read(p []byte) (n int, err error) {\n\t\tpanic(\"unimplemented\")\n\t}\n",
+							Default: "true",
+						},
 						{
 							Name:    "\"undeclaredname\"",
 							Doc:     "suggested fixes for \"undeclared name: <>\"\n\nThis checker provides suggested fixes for type errors of the\ntype \"undeclared name: <>\". It will insert a new statement:\n\"<> := \".",
@@ -578,6 +622,106 @@ var GeneratedAPIJSON = &APIJSON{
 				Status:     "experimental",
 				Hierarchy:  "ui.diagnostic",
 			},
+			{
+				Name: "diagnosticsScope",
+				Type: "enum",
+				Doc:  "diagnosticsScope controls which packages are analyzed. Type errors are\nalways reported for all workspace packages, but by default analyzers\nonly run on the packages of open files. In the \"Workspace\" scope, all\nworkspace packages are analyzed in the background after each change,\nand their diagnostics are published even if their files are not open.\n",
+				EnumKeys: EnumKeys{
+					ValueType: "",
+					Keys:      nil,
+				},
+				EnumValues: []EnumValue{
+					{
+						Value: "\"OpenFiles\"",
+						Doc:   "`\"OpenFiles\"` runs analyzers only on the packages of open files.\n",
+					},
+					{
+						Value: "\"Workspace\"",
+						Doc:   "`\"Workspace\"` runs analyzers on all workspace packages.\n",
+					},
+				},
+				Default:   "\"OpenFiles\"",
+				Status:    "experimental",
+				Hierarchy: "ui.diagnostic",
+			},
+			{
+				Name: "requireIgnoreReason",
+				Type: "bool",
+				Doc:  "requireIgnoreReason reports the `//lint:ignore` and `//lint:file-ignore`\ndirectives that suppress the diagnostics of analyzers without giving a\nreason.\n",
+				EnumKeys: EnumKeys{
+					ValueType: "",
+					Keys:      nil,
+				},
+				EnumValues: nil,
+				Default:    "false",
+				Status:     "experimental",
+				Hierarchy:  "ui.diagnostic",
+			},
+			{
+				Name: "parameterNameHints",
+				Type: "bool",
+				Doc:  "parameterNameHints enables inlay hints for the names of the parameters\nof functions at call sites, as in `NewServer(ctx: ctx, port: 0)`.\n",
+				EnumKeys: EnumKeys{
+					ValueType: "",
+					Keys:      nil,
+				},
+				EnumValues: nil,
+				Default:    "false",
+				Status:     "experimental",
+				Hierarchy:  "ui.inlayhint",
+			},
+			{
+				Name: "assignVariableTypeHints",
+				Type: "bool",
+				Doc:  "assignVariableTypeHints enables inlay hints for the types of the\nvariables declared by `:=` assignments, as in `i int := 0`.\n",
+				EnumKeys: EnumKeys{
+					ValueType: "",
+					Keys:      nil,
+				},
+				EnumValues: nil,
+				Default:    "false",
+				Status:     "experimental",
+				Hierarchy:  "ui.inlayhint",
+			},
+			{
+				Name: "rangeVariableTypeHints",
+				Type: "bool",
+				Doc:  "rangeVariableTypeHints enables inlay hints for the types of the\nvariables declared by range statements, as in\n`for k int, v string := range s`.\n",
+				EnumKeys: EnumKeys{
+					ValueType: "",
+					Keys:      nil,
+				},
+				EnumValues: nil,
+				Default:    "false",
+				Status:     "experimental",
+				Hierarchy:  "ui.inlayhint",
+			},
+			{
+				Name: "constantValueHints",
+				Type: "bool",
+				Doc:  "constantValueHints enables inlay hints for the values of the constants\nof declarations that use iota, as in `KindPrint = 1`.\n",
+				EnumKeys: EnumKeys{
+					ValueType: "",
+					Keys:      nil,
+				},
+				EnumValues: nil,
+				Default:    "false",
+				Status:     "experimental",
+				Hierarchy:  "ui.inlayhint",
+			},
+			{
+				Name: "compositeLiteralFieldHints",
+				Type: "bool",
+				Doc:  "compositeLiteralFieldHints enables inlay hints for the names of the\nfields of unkeyed struct literals, as in `{in: \"abc\", want: \"cba\"}`.\n",
+				EnumKeys: EnumKeys{
+					ValueType: "",
+					Keys:      nil,
+				},
+				EnumValues: nil,
+				Default:    "false",
+				Status:     "experimental",
+				Hierarchy:  "ui.inlayhint",
+			},
 			{
 				Name: "codelenses",
 				Type: "map[string]bool",
@@ -694,18 +838,42 @@ var GeneratedAPIJSON = &APIJSON{
 			Doc:     "",
 			ArgDoc:  "{\n\t\"ImportPath\": string,\n\t\"URI\": string,\n}",
 		},
+		{
+			Command: "gopls.add_test",
+			Title:   "Add a test for a function",
+			Doc:     "Adds a table-driven test of the selected function or method to the\nmatching _test.go file, creating it if needed.",
+			ArgDoc:  "{\n\t// The file URI containing the function.\n\t\"URI\": string,\n\t// The range of the name or signature of the function.\n\t\"Range\": {\n\t\t\"start\": {\n\t\t\t\"line\": uint32,\n\t\t\t\"character\": uint32,\n\t\t},\n\t\t\"end\": {\n\t\t\t\"line\": uint32,\n\t\t\t\"character\": uint32,\n\t\t},\n\t},\n}",
+		},
+		{
+			Command: "gopls.analyzer_fixes",
+			Title:   "Compute the fixes of analyzers",
+			Doc:     "Returns the edits of the suggested fixes of the given analyzers for the\ndiagnostics of the given files, except for the fixes whose edits\nconflict with those of another fix, which are listed instead.",
+			ArgDoc:  "{\n\t// The URIs of the files to fix.\n\t\"URIs\": []string,\n\t// The names of the analyzers whose fixes to apply, such as fillreturns.\n\t\"Analyzers\": []string,\n}",
+		},
 		{
 			Command: "gopls.apply_fix",
 			Title:   "Apply a fix",
 			Doc:     "Applies a fix to a region of source code.",
 			ArgDoc:  "{\n\t// The fix to apply.\n\t\"Fix\": string,\n\t// The file URI for the document to fix.\n\t\"URI\": string,\n\t// The document range to scan for fixes.\n\t\"Range\": {\n\t\t\"start\": {\n\t\t\t\"line\": uint32,\n\t\t\t\"character\": uint32,\n\t\t},\n\t\t\"end\": {\n\t\t\t\"line\": uint32,\n\t\t\t\"character\": uint32,\n\t\t},\n\t},\n}",
 		},
+		{
+			Command: "gopls.change_signature",
+			Title:   "Change function signature",
+			Doc:     "Adds, removes, or reorders the parameters of a function, updating its\ncallers and the interface methods it implements.",
+			ArgDoc:  "{\n\t// The file URI containing the function.\n\t\"URI\": string,\n\t// The position of the function name, at its declaration or a reference.\n\t\"Position\": {\n\t\t\"line\": uint32,\n\t\t\"character\": uint32,\n\t},\n\t// The parameters of the new signature, in order.\n\t\"Params\": []struct{OldIndex int; Name string; Type string; Value string},\n}",
+		},
 		{
 			Command: "gopls.check_upgrades",
 			Title:   "Check for upgrades",
 			Doc:     "Checks for module upgrades.",
 			ArgDoc:  "{\n\t// The go.mod file URI.\n\t\"URI\": string,\n\t// The modules to check.\n\t\"Modules\": []string,\n}",
 		},
+		{
+			Command: "gopls.document_diagnostic",
+			Title:   "Pull diagnostics of a file",
+			Doc:     "Computes the diagnostics of a file. If they are those of the report\nof the previous result id, an unchanged report is returned. It has the\nparameters and result of the proposed LSP 3.17 textDocument/diagnostic\nrequest.",
+			ArgDoc:  "{\n\t\"WorkDoneProgressParams\": {\n\t\t\"workDoneToken\": interface{},\n\t},\n\t\"textDocument\": {\n\t\t\"uri\": string,\n\t},\n\t\"identifier\": string,\n\t\"previousResultId\": string,\n}",
+		},
 		{
 			Command: "gopls.gc_details",
 			Title:   "Toggle gc_details",
@@ -730,12 +898,42 @@ var GeneratedAPIJSON = &APIJSON{
 			Doc:     "Runs `go get` to fetch a package.",
 			ArgDoc:  "{\n\t// Any document URI within the relevant module.\n\t\"URI\": string,\n\t// The package to go get.\n\t\"Pkg\": string,\n\t\"AddRequire\": bool,\n}",
 		},
+		{
+			Command: "gopls.implement_interface",
+			Title:   "Implement interface",
+			Doc:     "Declares stubs for the methods that a type lacks to implement an\ninterface.",
+			ArgDoc:  "{\n\t// The file URI containing the type.\n\t\"URI\": string,\n\t// The position of the type name, at its declaration or a reference.\n\t\"Position\": {\n\t\t\"line\": uint32,\n\t\t\"character\": uint32,\n\t},\n\t// The interface to implement, qualified by its package path as in\n\t// \"io.Reader\", unless it is declared in the package of the type.\n\t\"Interface\": string,\n}",
+		},
+		{
+			Command: "gopls.inlay_hint",
+			Title:   "Compute inlay hints",
+			Doc:     "Returns the inlay hints of a range of a Go file that are enabled by the\ninlay hint settings. It has the parameters and result of the proposed\nLSP 3.17 textDocument/inlayHint request.",
+			ArgDoc:  "{\n\t\"WorkDoneProgressParams\": {\n\t\t\"workDoneToken\": interface{},\n\t},\n\t// The text document.\n\t\"textDocument\": {\n\t\t\"uri\": string,\n\t},\n\t// The visible document range for which inlay hints should be computed.\n\t\"range\": {\n\t\t\"start\": {\n\t\t\t\"line\": uint32,\n\t\t\t\"character\": uint32,\n\t\t},\n\t\t\"end\": {\n\t\t\t\"line\": uint32,\n\t\t\t\"character\": uint32,\n\t\t},\n\t},\n}",
+		},
+		{
+			Command: "gopls.list_interfaces",
+			Title:   "List interfaces",
+			Doc:     "Lists the interfaces that a type of a package may be made to\nimplement with ImplementInterface.",
+			ArgDoc:  "{\n\t// The file URI.\n\t\"URI\": string,\n}",
+		},
 		{
 			Command: "gopls.list_known_packages",
 			Title:   "",
 			Doc:     "",
 			ArgDoc:  "{\n\t// The file URI.\n\t\"URI\": string,\n}",
 		},
+		{
+			Command: "gopls.move_declarations",
+			Title:   "Move declarations to new file",
+			Doc:     "Moves the selected top-level declarations to a new file in the package.",
+			ArgDoc:  "{\n\t// The file URI containing the declarations.\n\t\"URI\": string,\n\t// The range of the declarations to move.\n\t\"Range\": {\n\t\t\"start\": {\n\t\t\t\"line\": uint32,\n\t\t\t\"character\": uint32,\n\t\t},\n\t\t\"end\": {\n\t\t\t\"line\": uint32,\n\t\t\t\"character\": uint32,\n\t\t},\n\t},\n}",
+		},
+		{
+			Command: "gopls.prepare_type_hierarchy",
+			Title:   "Prepare type hierarchy",
+			Doc:     "Returns the item of the named type at a position, for use with\nTypeHierarchySupertypes and TypeHierarchySubtypes. This and the other\ntype hierarchy commands have the parameters and results of the\nproposed LSP 3.17 type hierarchy requests.",
+			ArgDoc:  "{\n\t\"TextDocumentPositionParams\": {\n\t\t\"textDocument\": {\n\t\t\t\"uri\": string,\n\t\t},\n\t\t\"position\": {\n\t\t\t\"line\": uint32,\n\t\t\t\"character\": uint32,\n\t\t},\n\t},\n\t\"WorkDoneProgressParams\": {\n\t\t\"workDoneToken\": interface{},\n\t},\n}",
+		},
 		{
 			Command: "gopls.regenerate_cgo",
 			Title:   "Regenerate cgo",
@@ -772,6 +970,18 @@ var GeneratedAPIJSON = &APIJSON{
 			Doc:     "Toggle the calculation of gc annotations.",
 			ArgDoc:  "{\n\t// The file URI.\n\t\"URI\": string,\n}",
 		},
+		{
+			Command: "gopls.type_hierarchy_subtypes",
+			Title:   "List subtypes",
+			Doc:     "Returns the items of the concrete types and interfaces that implement\nthe interface of an item.",
+			ArgDoc:  "{\n\t\"item\": {\n\t\t\"name\": string,\n\t\t\"kind\": float64,\n\t\t\"tags\": []float64,\n\t\t\"detail\": string,\n\t\t\"uri\": string,\n\t\t\"range\": {\n\t\t\t\"start\": { ... },\n\t\t\t\"end\": { ... },\n\t\t},\n\t\t\"selectionRange\": {\n\t\t\t\"start\": { ... },\n\t\t\t\"end\": { ... },\n\t\t},\n\t\t\"data\": interface{},\n\t},\n\t\"WorkDoneProgressParams\": {\n\t\t\"workDoneToken\": interface{},\n\t},\n\t\"PartialResultParams\": {\n\t\t\"partialResultToken\": interface{},\n\t},\n}",
+		},
+		{
+			Command: "gopls.type_hierarchy_supertypes",
+			Title:   "List supertypes",
+			Doc:     "Returns the items of the interfaces implemented by the type of an item.",
+			ArgDoc:  "{\n\t\"item\": {\n\t\t\"name\": string,\n\t\t\"kind\": float64,\n\t\t\"tags\": []float64,\n\t\t\"detail\": string,\n\t\t\"uri\": string,\n\t\t\"range\": {\n\t\t\t\"start\": { ... },\n\t\t\t\"end\": { ... },\n\t\t},\n\t\t\"selectionRange\": {\n\t\t\t\"start\": { ... },\n\t\t\t\"end\": { ... },\n\t\t},\n\t\t\"data\": interface{},\n\t},\n\t\"WorkDoneProgressParams\": {\n\t\t\"workDoneToken\": interface{},\n\t},\n\t\"PartialResultParams\": {\n\t\t\"partialResultToken\": interface{},\n\t},\n}",
+		},
 		{
 			Command: "gopls.update_go_sum",
 			Title:   "Update go.sum",
@@ -790,6 +1000,12 @@ var GeneratedAPIJSON = &APIJSON{
 			Doc:     "Runs `go mod vendor` for a module.",
 			ArgDoc:  "{\n\t// The file URI.\n\t\"URI\": string,\n}",
 		},
+		{
+			Command: "gopls.workspace_diagnostic",
+			Title:   "Pull diagnostics of the workspace",
+			Doc:     "Computes the diagnostics of all the files of the workspace packages\nand go.mod files, with unchanged reports for the files whose\ndiagnostics are those of their previous result ids. It has the\nparameters and result of the proposed LSP 3.17 workspace/diagnostic\nrequest.",
+			ArgDoc:  "{\n\t\"WorkDoneProgressParams\": {\n\t\t\"workDoneToken\": interface{},\n\t},\n\t\"identifier\": string,\n\t\"previousResultIds\": []struct{URI golang.org/x/tools/internal/lsp/protocol.DocumentURI \"json:\\\"uri\\\"\"; Value string \"json:\\\"value\\\"\"},\n}",
+		},
 	},
 	Lenses: []*LensJSON{
 		{
@@ -1024,6 +1240,11 @@ var GeneratedAPIJSON = &APIJSON{
 			Doc:     "suggested fixes for \"no result values expected\"\n\nThis checker provides suggested fixes for type errors of the\ntype \"no result values expected\". For example:\n\tfunc z() { return nil }\nwill turn into\n\tfunc z() { return }\n",
 			Default: true,
 		},
+		{
+			Name:    "stubmethods",
+			Doc:     "suggested fixes for \"missing method <>\"\n\nThis checker provides suggested fixes for type errors of the\ntype \"missing method <>\", when a value of a type declared in the\ncurrent package is used as an interface. It will declare stubs of the\nmissing methods after the declaration of the type. For example:\n\tvar _ io.Reader = T{}\nwill declare\n\tfunc (t T) Read(p []byte) (n int, err error) {\n\t\tpanic(\"unimplemented\")\n\t}\n",
+			Default: true,
+		},
 		{
 			Name:    "undeclaredname",
 			Doc:     "suggested fixes for \"undeclared name: <>\"\n\nThis checker provides suggested fixes for type errors of the\ntype \"undeclared name: <>\". It will insert a new statement:\n\"<> := \".",
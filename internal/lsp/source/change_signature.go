@@ -0,0 +1,725 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package source
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/internal/event"
+	"golang.org/x/tools/internal/lsp/protocol"
+	"golang.org/x/tools/internal/span"
+	errors "golang.org/x/xerrors"
+)
+
+// SignatureParam describes a parameter of the signature computed by
+// ChangeSignature.
+type SignatureParam struct {
+	// OldIndex is the index of the parameter in the current signature, or
+	// -1 if the parameter is added. Existing parameters keep their name and
+	// type.
+	OldIndex int
+
+	// Name and Type are the name and type expression of an added parameter.
+	Name, Type string
+
+	// Value is the expression passed for an added parameter by existing
+	// callers.
+	Value string
+}
+
+// ChangeSignature computes the edits that change the parameters of the
+// function or method at pp to params, which lists the new parameters in
+// order. Parameters of the current signature that are not listed are
+// removed.
+//
+// The declaration is updated, along with every call, which has its
+// arguments rearranged to match. Other references to the function are
+// wrapped in a function literal with the old signature, so that function
+// values keep their type. Changing an interface method changes its
+// implementations, and changing a concrete method changes the interface
+// methods it implements.
+//
+// Sites that cannot be rewritten without changing the behavior of the
+// program, such as calls that would no longer evaluate an argument with
+// side effects, are left alone and described by the returned problems.
+func ChangeSignature(ctx context.Context, snapshot Snapshot, fh FileHandle, pp protocol.Position, params []SignatureParam) (map[span.URI][]protocol.TextEdit, []string, error) {
+	ctx, done := event.Start(ctx, "source.ChangeSignature")
+	defer done()
+
+	qos, err := qualifiedObjsAtProtocolPos(ctx, snapshot, fh, pp)
+	if err != nil {
+		return nil, nil, err
+	}
+	fn, ok := qos[0].obj.(*types.Func)
+	if !ok {
+		return nil, nil, errors.Errorf("%s is not a function", qos[0].obj.Name())
+	}
+	c := &signatureChanger{
+		snapshot: snapshot,
+		fn:       fn,
+		sig:      fn.Type().(*types.Signature),
+		params:   params,
+		pgfs:     make(map[span.URI]*ParsedGoFile),
+		edits:    make(map[span.URI][]offsetEdit),
+		reported: make(map[string]bool),
+	}
+	if err := c.checkParams(); err != nil {
+		return nil, nil, err
+	}
+
+	// Methods must keep satisfying the interfaces they satisfied before, so
+	// the signature of an interface method changes along with those of all
+	// its implementations. Changing a concrete method changes the interface
+	// methods it implements, and so their other implementations too.
+	groups := [][]qualifiedObject{qos}
+	related, err := implementations(ctx, snapshot, fh, pp)
+	if err != nil && !errors.Is(err, ErrNotAType) {
+		return nil, nil, err
+	}
+	if len(related) > 0 && !IsInterface(c.sig.Recv().Type()) {
+		var all []qualifiedObject
+		for _, qo := range related {
+			all = append(all, qo)
+			impls, err := implementationsOf(ctx, snapshot, qo)
+			if err != nil {
+				return nil, nil, err
+			}
+			all = append(all, impls...)
+		}
+		related = all
+	}
+	if len(related) > 0 {
+		pkgs, err := snapshot.WorkspacePackages(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		workspace := make(map[string]bool)
+		for _, pkg := range pkgs {
+			workspace[pkg.ID()] = true
+		}
+		fset := snapshot.FileSet()
+		seen := map[token.Position]bool{fset.Position(fn.Pos()): true}
+		for _, qo := range related {
+			pos := fset.Position(qo.obj.Pos())
+			if seen[pos] {
+				continue
+			}
+			seen[pos] = true
+			// A method that cannot be changed would leave the workspace
+			// broken, so refuse the whole operation.
+			if qo.pkg == nil || !workspace[qo.pkg.ID()] {
+				return nil, nil, errors.Errorf("cannot change the signature of %s: %s must match it and is outside the workspace", fn.Name(), qo.obj.(*types.Func).FullName())
+			}
+			groups = append(groups, []qualifiedObject{qo})
+		}
+	}
+
+	var refs []*ReferenceInfo
+	for _, group := range groups {
+		groupRefs, err := references(ctx, snapshot, group, true, false, false)
+		if err != nil {
+			return nil, nil, err
+		}
+		refs = append(refs, groupRefs...)
+	}
+	// Rewrite the references in each file from last to first, so that the
+	// text of a call nested in the arguments of another already has its
+	// own edits when the outer call is rewritten.
+	sort.Slice(refs, func(i, j int) bool {
+		if x := CompareURI(refs[i].URI(), refs[j].URI()); x != 0 {
+			return x < 0
+		}
+		return refs[i].ident.Pos() > refs[j].ident.Pos()
+	})
+	seen := make(map[span.URI]map[int]bool)
+	for _, ref := range refs {
+		pgf, err := ref.pkg.File(ref.URI())
+		if err != nil {
+			return nil, nil, err
+		}
+		// The same file may be seen through several packages.
+		offset := pgf.Tok.Offset(ref.ident.Pos())
+		if seen[pgf.URI] == nil {
+			seen[pgf.URI] = make(map[int]bool)
+		}
+		if seen[pgf.URI][offset] {
+			continue
+		}
+		seen[pgf.URI][offset] = true
+		c.pgfs[pgf.URI] = pgf
+		if err := c.update(ref.pkg, pgf, ref.ident); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	result := make(map[span.URI][]protocol.TextEdit)
+	for uri, edits := range c.edits {
+		pgf := c.pgfs[uri]
+		for _, edit := range edits {
+			rng, err := NewMappedRange(snapshot.FileSet(), pgf.Mapper, pgf.Tok.Pos(edit.start), pgf.Tok.Pos(edit.end)).Range()
+			if err != nil {
+				return nil, nil, err
+			}
+			result[uri] = append(result[uri], protocol.TextEdit{Range: rng, NewText: edit.text})
+		}
+	}
+	return result, c.problems, nil
+}
+
+// removeParameter removes the parameter declared at rng from the signature
+// of its function, as ChangeSignature does. It fails if any reference to
+// the function cannot be updated.
+func removeParameter(ctx context.Context, snapshot Snapshot, fh VersionedFileHandle, pgf *ParsedGoFile, rng span.Range) (map[span.URI][]protocol.TextEdit, error) {
+	path, _ := astutil.PathEnclosingInterval(pgf.File, rng.Start, rng.End)
+	var decl *ast.FuncDecl
+	for _, n := range path {
+		if n, ok := n.(*ast.FuncDecl); ok {
+			decl = n
+			break
+		}
+	}
+	if decl == nil {
+		return nil, errors.New("no function declaration for parameter")
+	}
+	var (
+		params []SignatureParam
+		name   *ast.Ident
+		i      int
+	)
+	for _, field := range decl.Type.Params.List {
+		names := field.Names
+		if len(names) == 0 {
+			names = []*ast.Ident{nil}
+		}
+		for _, id := range names {
+			if id != nil && id.Pos() <= rng.Start && rng.Start < id.End() {
+				name = id
+			} else {
+				params = append(params, SignatureParam{OldIndex: i})
+			}
+			i++
+		}
+	}
+	if name == nil {
+		return nil, errors.Errorf("no parameter of %s at position", decl.Name.Name)
+	}
+	pRng, err := NewMappedRange(snapshot.FileSet(), pgf.Mapper, decl.Name.Pos(), decl.Name.End()).Range()
+	if err != nil {
+		return nil, err
+	}
+	edits, problems, err := ChangeSignature(ctx, snapshot, fh, pRng.Start, params)
+	if err != nil {
+		return nil, err
+	}
+	if len(problems) > 0 {
+		return nil, errors.Errorf("cannot remove parameter %s:\n%s", name.Name, strings.Join(problems, "\n"))
+	}
+	return edits, nil
+}
+
+// implementationsOf returns the implementations of the interface method qo.
+func implementationsOf(ctx context.Context, snapshot Snapshot, qo qualifiedObject) ([]qualifiedObject, error) {
+	pgf, _, err := FindPosInPackage(snapshot, qo.pkg, qo.obj.Pos())
+	if err != nil {
+		return nil, err
+	}
+	fh, err := snapshot.GetFile(ctx, pgf.URI)
+	if err != nil {
+		return nil, err
+	}
+	rng, err := NewMappedRange(snapshot.FileSet(), pgf.Mapper, qo.obj.Pos(), qo.obj.Pos()).Range()
+	if err != nil {
+		return nil, err
+	}
+	return implementations(ctx, snapshot, fh, rng.Start)
+}
+
+type signatureChanger struct {
+	snapshot Snapshot
+	fn       *types.Func
+	sig      *types.Signature
+	params   []SignatureParam
+
+	pgfs     map[span.URI]*ParsedGoFile
+	edits    map[span.URI][]offsetEdit
+	problems []string
+	reported map[string]bool
+}
+
+// offsetEdit replaces the bytes [start, end) of a file with text.
+type offsetEdit struct {
+	start, end int
+	text       string
+}
+
+// checkParams reports whether the new parameter list is valid for the
+// current signature.
+func (c *signatureChanger) checkParams() error {
+	n := c.sig.Params().Len()
+	names := make(map[string]bool)
+	kept := make(map[int]bool)
+	for i, p := range c.params {
+		if p.OldIndex >= 0 {
+			if p.OldIndex >= n {
+				return errors.Errorf("%s has no parameter %d", c.fn.Name(), p.OldIndex)
+			}
+			if kept[p.OldIndex] {
+				return errors.Errorf("parameter %d of %s is listed more than once", p.OldIndex, c.fn.Name())
+			}
+			kept[p.OldIndex] = true
+			if c.sig.Variadic() && p.OldIndex == n-1 && i != len(c.params)-1 {
+				return errors.Errorf("the variadic parameter of %s must remain last", c.fn.Name())
+			}
+			if name := c.sig.Params().At(p.OldIndex).Name(); name != "" && name != "_" {
+				names[name] = true
+			}
+			continue
+		}
+		if p.Name != "" && p.Name != "_" {
+			if !isValidIdentifier(p.Name) {
+				return errors.Errorf("invalid parameter name %q", p.Name)
+			}
+			if names[p.Name] {
+				return errors.Errorf("duplicate parameter %s", p.Name)
+			}
+			names[p.Name] = true
+		}
+		if _, err := parser.ParseExpr(p.Type); err != nil {
+			return errors.Errorf("invalid type %q for parameter %s: %v", p.Type, p.Name, err)
+		}
+		if _, err := parser.ParseExpr(p.Value); err != nil {
+			return errors.Errorf("invalid value %q for parameter %s: %v", p.Value, p.Name, err)
+		}
+		if c.sig.Variadic() && kept[n-1] {
+			return errors.Errorf("the variadic parameter of %s must remain last", c.fn.Name())
+		}
+	}
+	return nil
+}
+
+// update rewrites the reference id to the function.
+func (c *signatureChanger) update(pkg Package, pgf *ParsedGoFile, id *ast.Ident) error {
+	path, _ := astutil.PathEnclosingInterval(pgf.File, id.Pos(), id.End())
+	if len(path) < 2 {
+		return errors.Errorf("no enclosing node for %s", id.Name)
+	}
+	switch parent := path[1].(type) {
+	case *ast.FuncDecl:
+		if parent.Name == id {
+			return c.updateDecl(pkg, pgf, parent.Type, parent.Body)
+		}
+	case *ast.Field:
+		// A method of an interface type.
+		if ft, ok := parent.Type.(*ast.FuncType); ok {
+			return c.updateDecl(pkg, pgf, ft, nil)
+		}
+	}
+
+	var expr ast.Expr = id
+	rest := path[1:]
+	if sel, ok := rest[0].(*ast.SelectorExpr); ok && sel.Sel == id {
+		expr, rest = sel, rest[1:]
+	}
+	fun := expr
+	for len(rest) > 0 {
+		paren, ok := rest[0].(*ast.ParenExpr)
+		if !ok {
+			break
+		}
+		fun, rest = paren, rest[1:]
+	}
+	if len(rest) > 0 {
+		if call, ok := rest[0].(*ast.CallExpr); ok && call.Fun == fun {
+			return c.updateCall(pkg, pgf, call, expr)
+		}
+	}
+	return c.adapt(pkg, pgf, expr)
+}
+
+// declParam is a parameter of a declared function.
+type declParam struct {
+	name  *ast.Ident // nil if unnamed
+	typ   string
+	field int // index of the field declaring the parameter
+}
+
+// updateDecl rewrites the parameters of the function type ft, declared
+// with the given body, if any.
+func (c *signatureChanger) updateDecl(pkg Package, pgf *ParsedGoFile, ft *ast.FuncType, body *ast.BlockStmt) error {
+	var old []declParam
+	named := false
+	for i, field := range ft.Params.List {
+		typ := c.text(pgf, field.Type.Pos(), field.Type.End())
+		if len(field.Names) == 0 {
+			old = append(old, declParam{typ: typ, field: i})
+			continue
+		}
+		named = true
+		for _, name := range field.Names {
+			old = append(old, declParam{name: name, typ: typ, field: i})
+		}
+	}
+	if len(old) != c.sig.Params().Len() {
+		return errors.Errorf("%s: parameters do not match the signature of %s", c.position(ft.Pos()), c.fn.Name())
+	}
+	if len(old) == 0 {
+		for _, p := range c.params {
+			if p.Name != "" {
+				named = true
+			}
+		}
+	}
+
+	info := pkg.GetTypesInfo()
+	kept := make(map[int]bool)
+	for _, p := range c.params {
+		if p.OldIndex >= 0 {
+			kept[p.OldIndex] = true
+		}
+	}
+	if body != nil {
+		var removed []types.Object
+		for i, p := range old {
+			if !kept[i] && p.name != nil {
+				if obj := info.Defs[p.name]; obj != nil {
+					removed = append(removed, obj)
+				}
+			}
+		}
+		var err error
+		ast.Inspect(body, func(n ast.Node) bool {
+			id, ok := n.(*ast.Ident)
+			if !ok || err != nil {
+				return err == nil
+			}
+			obj := info.Uses[id]
+			for _, r := range removed {
+				if obj == r {
+					err = errors.Errorf("%s: parameter %s is used", c.position(id.Pos()), id.Name)
+				}
+			}
+			// An added parameter must not shadow a name the body uses.
+			for _, p := range c.params {
+				if p.OldIndex < 0 && p.Name == id.Name && obj != nil && (obj.Pos() < ft.Pos() || obj.Pos() >= body.End()) {
+					err = errors.Errorf("%s: parameter %s would shadow %s", c.position(id.Pos()), p.Name, obj.Name())
+				}
+			}
+			return err == nil
+		})
+		if err != nil {
+			return err
+		}
+		if scope := info.Scopes[ft]; scope != nil {
+			for _, p := range c.params {
+				if p.OldIndex >= 0 || p.Name == "" || p.Name == "_" {
+					continue
+				}
+				if obj := scope.Lookup(p.Name); obj != nil && !isRemovedParam(obj, old, kept, info) {
+					return errors.Errorf("%s: %s is already declared", c.position(obj.Pos()), p.Name)
+				}
+			}
+		}
+	}
+
+	var buf strings.Builder
+	for i, p := range c.params {
+		name, typ := p.Name, p.Type
+		if p.OldIndex >= 0 {
+			name, typ = "", old[p.OldIndex].typ
+			if id := old[p.OldIndex].name; id != nil {
+				name = id.Name
+			}
+		}
+		if !named {
+			name = ""
+		} else if name == "" {
+			name = "_"
+		}
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		buf.WriteString(name)
+		// Keep parameters that shared a type sharing it.
+		if i+1 < len(c.params) && p.OldIndex >= 0 {
+			next := c.params[i+1].OldIndex
+			if next == p.OldIndex+1 && old[next].field == old[p.OldIndex].field && named {
+				continue
+			}
+		}
+		if name != "" {
+			buf.WriteString(" ")
+		}
+		buf.WriteString(typ)
+	}
+	c.replace(pgf, ft.Params.Opening+1, ft.Params.Closing, buf.String())
+	return nil
+}
+
+// isRemovedParam reports whether obj is a parameter that is being removed.
+func isRemovedParam(obj types.Object, old []declParam, kept map[int]bool, info *types.Info) bool {
+	for i, p := range old {
+		if !kept[i] && p.name != nil && info.Defs[p.name] == obj {
+			return true
+		}
+	}
+	return false
+}
+
+// updateCall rearranges the arguments of call, whose function is fun.
+func (c *signatureChanger) updateCall(pkg Package, pgf *ParsedGoFile, call *ast.CallExpr, fun ast.Expr) error {
+	info := pkg.GetTypesInfo()
+	args := call.Args
+	var recv string
+	if sel, ok := fun.(*ast.SelectorExpr); ok {
+		// A method expression takes the receiver as its first argument.
+		if s, ok := info.Selections[sel]; ok && s.Kind() == types.MethodExpr && len(args) > 0 {
+			recv = c.text(pgf, args[0].Pos(), args[0].End())
+			args = args[1:]
+		}
+	}
+	n := c.sig.Params().Len()
+	if len(args) == 1 && n != 1 {
+		if _, ok := info.TypeOf(args[0]).(*types.Tuple); ok {
+			c.reportf(call.Pos(), "call with a multi-value argument was not changed")
+			return nil
+		}
+	}
+
+	if len(args) != n && !(c.sig.Variadic() && len(args) >= n-1) {
+		c.reportf(call.Pos(), "call does not match the signature of %s and was not changed", c.fn.Name())
+		return nil
+	}
+
+	// Arguments of the old signature, with those for a variadic parameter
+	// gathered together.
+	oldArgs := make([]string, n)
+	pure := make([]bool, n)
+	for i := range oldArgs {
+		pure[i] = true
+	}
+	for i, arg := range args {
+		j := i
+		if j >= n {
+			j = n - 1
+		}
+		if j < i {
+			oldArgs[j] += ", "
+		}
+		oldArgs[j] += c.text(pgf, arg.Pos(), arg.End())
+		pure[j] = pure[j] && isPure(info, arg)
+	}
+	if call.Ellipsis.IsValid() && n > 0 {
+		oldArgs[n-1] += "..."
+	}
+
+	kept := make(map[int]bool)
+	last := -1
+	for _, p := range c.params {
+		if p.OldIndex < 0 {
+			continue
+		}
+		kept[p.OldIndex] = true
+		// Arguments with side effects must be evaluated in the same order.
+		if !pure[p.OldIndex] {
+			if p.OldIndex < last {
+				c.reportf(call.Pos(), "call was not changed, as it would evaluate its arguments in a different order")
+				return nil
+			}
+			last = p.OldIndex
+		}
+	}
+	for i := range oldArgs {
+		if !kept[i] && !pure[i] {
+			c.reportf(call.Pos(), "call was not changed, as it would no longer evaluate %s", oldArgs[i])
+			return nil
+		}
+	}
+
+	var newArgs []string
+	if recv != "" {
+		newArgs = append(newArgs, recv)
+	}
+	for _, p := range c.params {
+		arg := p.Value
+		if p.OldIndex >= 0 {
+			arg = oldArgs[p.OldIndex]
+		}
+		if arg != "" {
+			newArgs = append(newArgs, arg)
+		}
+	}
+	c.replace(pgf, call.Lparen+1, call.Rparen, strings.Join(newArgs, ", "))
+	return nil
+}
+
+// adapt replaces the function value expr with a function literal that has
+// the old signature and calls the function with the new one.
+func (c *signatureChanger) adapt(pkg Package, pgf *ParsedGoFile, expr ast.Expr) error {
+	info := pkg.GetTypesInfo()
+	if sel, ok := expr.(*ast.SelectorExpr); ok {
+		// Method values and method expressions, but not qualified
+		// identifiers, are selections.
+		if _, ok := info.Selections[sel]; ok {
+			c.reportf(expr.Pos(), "method value %s was not changed", c.text(pgf, sel.Pos(), sel.End()))
+			return nil
+		}
+	}
+
+	// The types in the old signature must be expressible in the file.
+	imported := make(map[string]bool)
+	for _, imp := range pgf.File.Imports {
+		if path, err := strconv.Unquote(imp.Path.Value); err == nil {
+			imported[path] = true
+		}
+	}
+	qual := Qualifier(pgf.File, pkg.GetTypes(), info)
+	missing := ""
+	qf := func(p *types.Package) string {
+		if p != pkg.GetTypes() && !imported[p.Path()] {
+			missing = p.Path()
+		}
+		return qual(p)
+	}
+
+	// Name the parameters of the literal so that they do not capture any
+	// name used by the function value or the added arguments.
+	used := make(map[string]bool)
+	collectNames(expr, used)
+	for _, p := range c.params {
+		if p.OldIndex < 0 {
+			if e, err := parser.ParseExpr(p.Value); err == nil {
+				collectNames(e, used)
+			}
+		}
+	}
+	sigParams := c.sig.Params()
+	names := make([]string, sigParams.Len())
+	var params []string
+	for i := range names {
+		v := sigParams.At(i)
+		name := v.Name()
+		if !isValidIdentifier(name) || used[name] {
+			name = "p" + strconv.Itoa(i)
+		}
+		for used[name] {
+			name += "_"
+		}
+		used[name] = true
+		names[i] = name
+		typ := types.TypeString(v.Type(), qf)
+		if c.sig.Variadic() && i == sigParams.Len()-1 {
+			typ = "..." + types.TypeString(v.Type().(*types.Slice).Elem(), qf)
+		}
+		params = append(params, name+" "+typ)
+	}
+	var results []string
+	for i := 0; i < c.sig.Results().Len(); i++ {
+		results = append(results, types.TypeString(c.sig.Results().At(i).Type(), qf))
+	}
+	if missing != "" {
+		c.reportf(expr.Pos(), "function value was not changed, as its file does not import %s", missing)
+		return nil
+	}
+
+	var args []string
+	for _, p := range c.params {
+		if p.OldIndex < 0 {
+			args = append(args, p.Value)
+			continue
+		}
+		arg := names[p.OldIndex]
+		if c.sig.Variadic() && p.OldIndex == sigParams.Len()-1 {
+			arg += "..."
+		}
+		args = append(args, arg)
+	}
+
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "func(%s)", strings.Join(params, ", "))
+	switch len(results) {
+	case 0:
+	case 1:
+		fmt.Fprintf(&buf, " %s", results[0])
+	default:
+		fmt.Fprintf(&buf, " (%s)", strings.Join(results, ", "))
+	}
+	buf.WriteString(" { ")
+	if len(results) > 0 {
+		buf.WriteString("return ")
+	}
+	fmt.Fprintf(&buf, "%s(%s) }", c.text(pgf, expr.Pos(), expr.End()), strings.Join(args, ", "))
+	c.replace(pgf, expr.Pos(), expr.End(), buf.String())
+	return nil
+}
+
+// collectNames adds the identifiers appearing in n to names.
+func collectNames(n ast.Node, names map[string]bool) {
+	ast.Inspect(n, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok {
+			names[id.Name] = true
+		}
+		return true
+	})
+}
+
+// text returns the text of pgf between start and end, including the edits
+// already made within it.
+func (c *signatureChanger) text(pgf *ParsedGoFile, start, end token.Pos) string {
+	from, to := pgf.Tok.Offset(start), pgf.Tok.Offset(end)
+	var inner []offsetEdit
+	for _, edit := range c.edits[pgf.URI] {
+		if edit.start >= from && edit.end <= to {
+			inner = append(inner, edit)
+		}
+	}
+	sort.Slice(inner, func(i, j int) bool { return inner[i].start < inner[j].start })
+	var buf strings.Builder
+	for _, edit := range inner {
+		buf.Write(pgf.Src[from:edit.start])
+		buf.WriteString(edit.text)
+		from = edit.end
+	}
+	buf.Write(pgf.Src[from:to])
+	return buf.String()
+}
+
+// replace replaces the text of pgf between start and end. Edits within the
+// replaced text are superseded, as their text is assumed to be part of the
+// replacement.
+func (c *signatureChanger) replace(pgf *ParsedGoFile, start, end token.Pos, text string) {
+	from, to := pgf.Tok.Offset(start), pgf.Tok.Offset(end)
+	if string(pgf.Src[from:to]) == text {
+		return
+	}
+	edits := c.edits[pgf.URI][:0]
+	for _, edit := range c.edits[pgf.URI] {
+		if edit.start < from || edit.end > to {
+			edits = append(edits, edit)
+		}
+	}
+	c.edits[pgf.URI] = append(edits, offsetEdit{start: from, end: to, text: text})
+}
+
+// reportf records a site that was not changed.
+func (c *signatureChanger) reportf(pos token.Pos, format string, args ...interface{}) {
+	msg := fmt.Sprintf("%s: %s", c.position(pos), fmt.Sprintf(format, args...))
+	if !c.reported[msg] {
+		c.reported[msg] = true
+		c.problems = append(c.problems, msg)
+	}
+}
+
+func (c *signatureChanger) position(pos token.Pos) token.Position {
+	return c.snapshot.FileSet().Position(pos)
+}
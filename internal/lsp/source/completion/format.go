@@ -224,7 +224,7 @@ func (c *completer) item(ctx context.Context, cand candidate) (CompletionItem, e
 	if err != nil {
 		return CompletionItem{}, err
 	}
-	decl := posToDecl[obj.Pos()]
+	decl := posToDecl[source.IdentPos(c.snapshot.FileSet(), pgf, obj.Pos(), obj.Name())]
 	if decl == nil {
 		return item, nil
 	}
@@ -0,0 +1,150 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package source
+
+import (
+	"context"
+	"go/ast"
+	"go/types"
+
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/internal/event"
+	"golang.org/x/tools/internal/lsp/protocol"
+)
+
+// Declarations returns the locations of the declarations of the identifier
+// at pp. For the f of a selector x.f where f is a field or method promoted
+// through an embedded field of the type of x, that is the embedded field.
+// For a method of a concrete type, those are the interface methods that it
+// implements. Otherwise, it is the definition of the identifier.
+func Declarations(ctx context.Context, snapshot Snapshot, fh FileHandle, pp protocol.Position) ([]protocol.Location, error) {
+	ctx, done := event.Start(ctx, "source.Declarations")
+	defer done()
+
+	ident, err := Identifier(ctx, snapshot, fh, pp)
+	if err != nil {
+		return nil, err
+	}
+	if field := promotingField(ident); field != nil {
+		rng, err := objToMappedRange(snapshot, ident.pkg, field)
+		if err != nil {
+			return nil, err
+		}
+		loc, err := mappedRangeToLocation(rng)
+		if err != nil {
+			return nil, err
+		}
+		return []protocol.Location{loc}, nil
+	}
+	if fn, ok := ident.Declaration.obj.(*types.Func); ok {
+		if recv := fn.Type().(*types.Signature).Recv(); recv != nil && !IsInterface(recv.Type()) {
+			locs, err := Implementation(ctx, snapshot, fh, pp)
+			if err != nil {
+				return nil, err
+			}
+			if len(locs) > 0 {
+				return locs, nil
+			}
+		}
+	}
+	var locs []protocol.Location
+	for _, rng := range ident.Declaration.MappedRange {
+		loc, err := mappedRangeToLocation(rng)
+		if err != nil {
+			return nil, err
+		}
+		locs = append(locs, loc)
+	}
+	return locs, nil
+}
+
+// TypeDefinitions returns the locations of the declaration of the type of
+// the identifier at pp. Beyond pointers, it goes to the element type of a
+// slice, array or channel, and for a function that returns a single value,
+// optionally with an error, to the type of the result. Maps, which have two
+// types, are left out. Types without a declaration, such as error, have no
+// location.
+func TypeDefinitions(ctx context.Context, snapshot Snapshot, fh FileHandle, pp protocol.Position) ([]protocol.Location, error) {
+	ctx, done := event.Start(ctx, "source.TypeDefinitions")
+	defer done()
+
+	ident, err := Identifier(ctx, snapshot, fh, pp)
+	if err != nil {
+		return nil, err
+	}
+	obj := typeDefinitionObject(ident.pkg.GetTypesInfo().TypeOf(ident.ident))
+	if obj == nil || obj.Pkg() == nil {
+		return nil, nil
+	}
+	rng, err := objToMappedRange(snapshot, ident.pkg, obj)
+	if err != nil {
+		return nil, err
+	}
+	loc, err := mappedRangeToLocation(rng)
+	if err != nil {
+		return nil, err
+	}
+	return []protocol.Location{loc}, nil
+}
+
+// typeDefinitionObject returns the type name that TypeDefinitions goes to
+// for typ, or nil.
+func typeDefinitionObject(typ types.Type) types.Object {
+	switch typ := typ.(type) {
+	case *types.Slice:
+		return typeDefinitionObject(typ.Elem())
+	case *types.Array:
+		return typeDefinitionObject(typ.Elem())
+	case *types.Chan:
+		return typeDefinitionObject(typ.Elem())
+	case *types.Pointer:
+		return typeDefinitionObject(typ.Elem())
+	case *types.Signature:
+		results := typ.Results()
+		if results.Len() == 1 || (results.Len() == 2 && isErrorType(results.At(1).Type())) {
+			return typeDefinitionObject(results.At(0).Type())
+		}
+		return nil
+	default:
+		return typeToObject(typ)
+	}
+}
+
+// promotingField returns the embedded field of the type of x through which
+// the field or method f is promoted, if ident is the f of a selector x.f.
+func promotingField(ident *IdentifierInfo) *types.Var {
+	pgf, err := ident.pkg.File(ident.MappedRange.URI())
+	if err != nil {
+		return nil
+	}
+	path, _ := astutil.PathEnclosingInterval(pgf.File, ident.ident.Pos(), ident.ident.End())
+	if len(path) < 2 {
+		return nil
+	}
+	expr, ok := path[1].(*ast.SelectorExpr)
+	if !ok || expr.Sel != ident.ident {
+		return nil
+	}
+	sel, ok := ident.pkg.GetTypesInfo().Selections[expr]
+	if !ok || len(sel.Index()) < 2 {
+		return nil
+	}
+	st, ok := Deref(sel.Recv()).Underlying().(*types.Struct)
+	if !ok {
+		return nil
+	}
+	return st.Field(sel.Index()[0])
+}
+
+func mappedRangeToLocation(rng MappedRange) (protocol.Location, error) {
+	pr, err := rng.Range()
+	if err != nil {
+		return protocol.Location{}, err
+	}
+	return protocol.Location{
+		URI:   protocol.URIFromSpanURI(rng.URI()),
+		Range: pr,
+	}, nil
+}
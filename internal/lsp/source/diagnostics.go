@@ -6,7 +6,10 @@ package source
 
 import (
 	"context"
+	"go/token"
 
+	"golang.org/x/tools/internal/event"
+	"golang.org/x/tools/internal/lintignore"
 	"golang.org/x/tools/internal/lsp/protocol"
 	"golang.org/x/tools/internal/span"
 )
@@ -49,16 +52,73 @@ func Analyze(ctx context.Context, snapshot Snapshot, pkg Package, includeConveni
 	if err != nil {
 		return nil, err
 	}
+	analysisDiagnostics, problems := ignoreDiagnostics(ctx, snapshot, pkg, analyzers, analysisDiagnostics)
 
 	reports := map[span.URI][]*Diagnostic{}
 	// Report diagnostics and errors from root analyzers.
 	for _, diag := range analysisDiagnostics {
 		reports[diag.URI] = append(reports[diag.URI], diag)
 	}
+	for _, diag := range problems {
+		reports[diag.URI] = append(reports[diag.URI], diag)
+	}
 	return reports, nil
 }
 
-func FileDiagnostics(ctx context.Context, snapshot Snapshot, uri span.URI) (VersionedFileIdentity, []*Diagnostic, error) {
+// ignoreDiagnostics removes the diagnostics of analyzers suppressed by the
+// //lint:ignore directives of the files of pkg. It returns the remaining
+// diagnostics, and the problems of the directives of the analyzers that ran.
+func ignoreDiagnostics(ctx context.Context, snapshot Snapshot, pkg Package, analyzers []*Analyzer, diagnostics []*Diagnostic) (kept, problems []*Diagnostic) {
+	fset := snapshot.FileSet()
+	set := lintignore.NewSet()
+	for _, pgf := range pkg.CompiledGoFiles() {
+		set.Add(fset, pgf.File)
+	}
+	for _, diag := range diagnostics {
+		name := string(diag.Source)
+		if diag.Analyzer != nil {
+			name = diag.Analyzer.Analyzer.Name
+		}
+		posn := token.Position{Filename: diag.URI.Filename(), Line: int(diag.Range.Start.Line) + 1}
+		if !set.Suppressed(name, posn) {
+			kept = append(kept, diag)
+		}
+	}
+
+	ran := make(map[string]bool)
+	for _, a := range analyzers {
+		if a.IsEnabled(snapshot.View()) {
+			ran[a.Analyzer.Name] = true
+		}
+	}
+	options := snapshot.View().Options()
+	for _, p := range set.Problems(func(name, _ string) bool { return ran[name] }, options.RequireIgnoreReason) {
+		for _, pgf := range pkg.CompiledGoFiles() {
+			if pgf.URI.Filename() != p.Position.Filename {
+				continue
+			}
+			rng, err := NewMappedRange(fset, pgf.Mapper, p.Pos, p.End).Range()
+			if err != nil {
+				event.Error(ctx, "converting the range of a //lint:ignore directive", err)
+				break
+			}
+			problems = append(problems, &Diagnostic{
+				URI:      pgf.URI,
+				Range:    rng,
+				Severity: protocol.SeverityWarning,
+				Source:   IgnoreDirectiveError,
+				Message:  p.Message,
+			})
+			break
+		}
+	}
+	return kept, problems
+}
+
+// FileDiagnostics returns the diagnostics of the file uri. If
+// includeConvenience is set, they include the suggestions of the
+// convenience analyzers.
+func FileDiagnostics(ctx context.Context, snapshot Snapshot, uri span.URI, includeConvenience bool) (VersionedFileIdentity, []*Diagnostic, error) {
 	fh, err := snapshot.GetVersionedFile(ctx, uri)
 	if err != nil {
 		return VersionedFileIdentity{}, nil, err
@@ -73,7 +133,7 @@ func FileDiagnostics(ctx context.Context, snapshot Snapshot, uri span.URI) (Vers
 	}
 	fileDiags := diagnostics[fh.URI()]
 	if !pkg.HasListOrParseErrors() {
-		analysisDiags, err := Analyze(ctx, snapshot, pkg, false)
+		analysisDiags, err := Analyze(ctx, snapshot, pkg, includeConvenience)
 		if err != nil {
 			return VersionedFileIdentity{}, nil, err
 		}
@@ -82,6 +142,31 @@ func FileDiagnostics(ctx context.Context, snapshot Snapshot, uri span.URI) (Vers
 	return fh.VersionedFileIdentity(), fileDiags, nil
 }
 
+// AnalyzerDiagnostics returns the diagnostics of the named analyzer for the
+// file of fh, in the widest package that contains it. Unlike Analyze, it
+// runs no other analyzer.
+func AnalyzerDiagnostics(ctx context.Context, snapshot Snapshot, fh FileHandle, name string) ([]*Diagnostic, error) {
+	analyzers, err := namedAnalyzers(snapshot, []string{name})
+	if err != nil {
+		return nil, err
+	}
+	pkg, err := snapshot.PackageForFile(ctx, fh.URI(), TypecheckFull, WidestPackage)
+	if err != nil {
+		return nil, err
+	}
+	diagnostics, err := snapshot.Analyze(ctx, pkg.ID(), analyzers)
+	if err != nil {
+		return nil, err
+	}
+	var fileDiags []*Diagnostic
+	for _, diag := range diagnostics {
+		if diag.URI == fh.URI() {
+			fileDiags = append(fileDiags, diag)
+		}
+	}
+	return fileDiags, nil
+}
+
 func isConvenienceAnalyzer(category string) bool {
 	for _, a := range DefaultOptions().ConvenienceAnalyzers {
 		if category == a.Analyzer.Name {
@@ -144,6 +144,19 @@ func generateAvailableIdentifier(pos token.Pos, file *ast.File, path []ast.Node,
 	return name
 }
 
+// generateAvailableMethodName adjusts the new method name until it does not
+// collide with a field or method of the receiver type.
+func generateAvailableMethodName(recv types.Type, pkg *types.Package, prefix string, idx int) string {
+	name := prefix + fmt.Sprintf("%d", idx)
+	for {
+		if obj, _, _ := types.LookupFieldOrMethod(recv, true, pkg, name); obj == nil {
+			return name
+		}
+		idx++
+		name = fmt.Sprintf("%v%d", prefix, idx)
+	}
+}
+
 // isValidName checks for variable collision in scope.
 func isValidName(name string, scopes []*types.Scope) bool {
 	for _, scope := range scopes {
@@ -172,27 +185,57 @@ type returnVariable struct {
 }
 
 // extractFunction refactors the selected block of code into a new function.
-// It also replaces the selected block of code with a call to the extracted
-// function. First, we manually adjust the selection range. We remove trailing
-// and leading whitespace characters to ensure the range is precisely bounded
-// by AST nodes. Next, we determine the variables that will be the parameters
-// and return values of the extracted function. Lastly, we construct the call
-// of the function and insert this call as well as the extracted function into
-// their proper locations.
 func extractFunction(fset *token.FileSet, rng span.Range, src []byte, file *ast.File, pkg *types.Package, info *types.Info) (*analysis.SuggestedFix, error) {
-	p, ok, err := CanExtractFunction(fset, rng, src, file)
-	if !ok {
-		return nil, fmt.Errorf("extractFunction: cannot extract %s: %v",
+	return extractFunctionMethod(fset, rng, src, file, pkg, info, false)
+}
+
+// extractMethod refactors the selected block of code into a new method on
+// the receiver of the enclosing method.
+func extractMethod(fset *token.FileSet, rng span.Range, src []byte, file *ast.File, pkg *types.Package, info *types.Info) (*analysis.SuggestedFix, error) {
+	return extractFunctionMethod(fset, rng, src, file, pkg, info, true)
+}
+
+// extractFunctionMethod refactors the selected block of code into a new
+// function or method. It also replaces the selected block of code with a
+// call to the extracted function. First, we manually adjust the selection
+// range. We remove trailing and leading whitespace characters to ensure the
+// range is precisely bounded by AST nodes. Next, we determine the variables
+// that will be the parameters and return values of the extracted function.
+// Lastly, we construct the call of the function and insert this call as well
+// as the extracted function into their proper locations.
+func extractFunctionMethod(fset *token.FileSet, rng span.Range, src []byte, file *ast.File, pkg *types.Package, info *types.Info, isMethod bool) (*analysis.SuggestedFix, error) {
+	errorPrefix := "extractFunction"
+	if isMethod {
+		errorPrefix = "extractMethod"
+	}
+	p, ok, methodOK, err := CanExtractFunction(fset, rng, src, file, info)
+	if ok && isMethod && !methodOK {
+		err = fmt.Errorf("the selection does not use the receiver, or modifies it")
+	}
+	if !ok || (isMethod && !methodOK) {
+		return nil, fmt.Errorf("%s: cannot extract %s: %v", errorPrefix,
 			fset.Position(rng.Start), err)
 	}
 	tok, path, rng, outer, start := p.tok, p.path, p.rng, p.outer, p.start
 	fileScope := info.Scopes[file]
 	if fileScope == nil {
-		return nil, fmt.Errorf("extractFunction: file scope is empty")
+		return nil, fmt.Errorf("%s: file scope is empty", errorPrefix)
 	}
 	pkgScope := fileScope.Parent()
 	if pkgScope == nil {
-		return nil, fmt.Errorf("extractFunction: package scope is empty")
+		return nil, fmt.Errorf("%s: package scope is empty", errorPrefix)
+	}
+
+	// A method is extracted onto the receiver of the enclosing method, which
+	// is then available in the extracted method without being passed in.
+	var receiver *ast.Ident
+	var receiverObj types.Object
+	if isMethod {
+		receiver = outer.Recv.List[0].Names[0]
+		receiverObj = info.Defs[receiver]
+		if receiverObj == nil {
+			return nil, fmt.Errorf("%s: no object for receiver %s", errorPrefix, receiver.Name)
+		}
 	}
 
 	// TODO: Support non-nested return statements.
@@ -222,7 +265,7 @@ func extractFunction(fset *token.FileSet, rng span.Range, src []byte, file *ast.
 		return false
 	})
 	if hasNonNestedReturn {
-		return nil, fmt.Errorf("extractFunction: selected block contains non-nested return")
+		return nil, fmt.Errorf("%s: selected block contains non-nested return", errorPrefix)
 	}
 	containsReturnStatement := len(retStmts) > 0
 
@@ -303,7 +346,8 @@ func extractFunction(fset *token.FileSet, rng span.Range, src []byte, file *ast.
 		// An identifier must meet two conditions to become a parameter of the
 		// extracted function. (1) it must be free (isFree), and (2) its first
 		// use within the selection cannot be its own definition (isDefined).
-		if v.free && !v.defined {
+		// The receiver of an extracted method is not a parameter.
+		if v.free && !v.defined && v.obj != receiverObj {
 			params = append(params, identifier)
 			paramTypes = append(paramTypes, &ast.Field{
 				Names: []*ast.Ident{identifier},
@@ -434,12 +478,30 @@ func extractFunction(fset *token.FileSet, rng span.Range, src []byte, file *ast.
 	if canDefine {
 		sym = token.DEFINE
 	}
-	funName := generateAvailableIdentifier(rng.Start, file, path, info, "fn", 0)
+	var funName string
+	var fun ast.Expr
+	var recv *ast.FieldList
+	if isMethod {
+		funName = generateAvailableMethodName(receiverObj.Type(), pkg, "fn", 0)
+		fun = &ast.SelectorExpr{X: ast.NewIdent(receiver.Name), Sel: ast.NewIdent(funName)}
+		recvType := analysisinternal.TypeExpr(fset, file, pkg, receiverObj.Type())
+		if recvType == nil {
+			return nil, fmt.Errorf("%s: nil AST expression for receiver type", errorPrefix)
+		}
+		recv = &ast.FieldList{List: []*ast.Field{{
+			Names: []*ast.Ident{ast.NewIdent(receiver.Name)},
+			Type:  recvType,
+		}}}
+	} else {
+		funName = generateAvailableIdentifier(rng.Start, file, path, info, "fn", 0)
+		fun = ast.NewIdent(funName)
+	}
 	extractedFunCall := generateFuncCall(hasReturnValues, params,
-		append(returns, getNames(retVars)...), funName, sym)
+		append(returns, getNames(retVars)...), fun, sym)
 
 	// Build the extracted function.
 	newFunc := &ast.FuncDecl{
+		Recv: recv,
 		Name: ast.NewIdent(funName),
 		Type: &ast.FuncType{
 			Params:  &ast.FieldList{List: paramTypes},
@@ -793,25 +855,28 @@ type fnExtractParams struct {
 }
 
 // CanExtractFunction reports whether the code in the given range can be
-// extracted to a function.
-func CanExtractFunction(fset *token.FileSet, rng span.Range, src []byte, file *ast.File) (*fnExtractParams, bool, error) {
+// extracted to a function. It also reports whether it can be extracted to a
+// method, which requires the selection to be within a method and to refer
+// to the method's receiver, without modifying a receiver that is not a
+// pointer, according to info.
+func CanExtractFunction(fset *token.FileSet, rng span.Range, src []byte, file *ast.File, info *types.Info) (*fnExtractParams, bool, bool, error) {
 	if rng.Start == rng.End {
-		return nil, false, fmt.Errorf("start and end are equal")
+		return nil, false, false, fmt.Errorf("start and end are equal")
 	}
 	tok := fset.File(file.Pos())
 	if tok == nil {
-		return nil, false, fmt.Errorf("no file for pos %v", fset.Position(file.Pos()))
+		return nil, false, false, fmt.Errorf("no file for pos %v", fset.Position(file.Pos()))
 	}
 	rng = adjustRangeForWhitespace(rng, tok, src)
 	path, _ := astutil.PathEnclosingInterval(file, rng.Start, rng.End)
 	if len(path) == 0 {
-		return nil, false, fmt.Errorf("no path enclosing interval")
+		return nil, false, false, fmt.Errorf("no path enclosing interval")
 	}
 	// Node that encloses the selection must be a statement.
 	// TODO: Support function extraction for an expression.
 	_, ok := path[0].(ast.Stmt)
 	if !ok {
-		return nil, false, fmt.Errorf("node is not a statement")
+		return nil, false, false, fmt.Errorf("node is not a statement")
 	}
 
 	// Find the function declaration that encloses the selection.
@@ -823,7 +888,7 @@ func CanExtractFunction(fset *token.FileSet, rng span.Range, src []byte, file *a
 		}
 	}
 	if outer == nil {
-		return nil, false, fmt.Errorf("no enclosing function")
+		return nil, false, false, fmt.Errorf("no enclosing function")
 	}
 
 	// Find the nodes at the start and end of the selection.
@@ -843,7 +908,7 @@ func CanExtractFunction(fset *token.FileSet, rng span.Range, src []byte, file *a
 		return n.Pos() <= rng.End
 	})
 	if start == nil || end == nil {
-		return nil, false, fmt.Errorf("range does not map to AST nodes")
+		return nil, false, false, fmt.Errorf("range does not map to AST nodes")
 	}
 	return &fnExtractParams{
 		tok:   tok,
@@ -851,7 +916,109 @@ func CanExtractFunction(fset *token.FileSet, rng span.Range, src []byte, file *a
 		rng:   rng,
 		outer: outer,
 		start: start,
-	}, true, nil
+	}, true, usesReceiver(info, outer, rng) && !modifiesReceiver(info, outer, rng), nil
+}
+
+// usesReceiver reports whether the given range of the method decl refers to
+// the method's named receiver.
+func usesReceiver(info *types.Info, decl *ast.FuncDecl, rng span.Range) bool {
+	if decl.Recv == nil || len(decl.Recv.List) == 0 || len(decl.Recv.List[0].Names) == 0 {
+		return false
+	}
+	obj := info.Defs[decl.Recv.List[0].Names[0]]
+	if obj == nil {
+		return false
+	}
+	used, _ := objUsed(info, rng, obj)
+	return used
+}
+
+// modifiesReceiver reports whether the given range of the method decl
+// modifies its receiver variable: assigns to it or takes its address, or if
+// the receiver is not a pointer, modifies the value it holds. An extracted
+// method would modify a copy of the receiver instead.
+func modifiesReceiver(info *types.Info, decl *ast.FuncDecl, rng span.Range) bool {
+	if decl.Recv == nil || len(decl.Recv.List) == 0 || len(decl.Recv.List[0].Names) == 0 {
+		return false
+	}
+	obj := info.Defs[decl.Recv.List[0].Names[0]]
+	if obj == nil {
+		return false
+	}
+	// The fields of a pointer receiver are not in the variable.
+	denotes := denotesVar
+	_, isPointer := obj.Type().Underlying().(*types.Pointer)
+	if isPointer {
+		denotes = func(info *types.Info, expr ast.Expr, obj types.Object) bool {
+			id, ok := astutil.Unparen(expr).(*ast.Ident)
+			return ok && info.Uses[id] == obj
+		}
+	}
+	modified := false
+	ast.Inspect(decl.Body, func(n ast.Node) bool {
+		if modified || n == nil || n.End() < rng.Start || n.Pos() > rng.End {
+			return false
+		}
+		if n.Pos() < rng.Start || n.End() > rng.End {
+			return true
+		}
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			if n.Tok == token.DEFINE {
+				break
+			}
+			for _, lhs := range n.Lhs {
+				modified = modified || denotes(info, lhs, obj)
+			}
+		case *ast.IncDecStmt:
+			modified = denotes(info, n.X, obj)
+		case *ast.UnaryExpr:
+			modified = n.Op == token.AND && denotes(info, n.X, obj)
+		case *ast.SelectorExpr:
+			// A method with a pointer receiver takes the address of the
+			// receiver implicitly, unless it is a pointer already.
+			if sel := info.Selections[n]; !isPointer && sel != nil && sel.Kind() == types.MethodVal {
+				if _, ok := sel.Obj().Type().(*types.Signature).Recv().Type().(*types.Pointer); ok {
+					modified = denotesVar(info, n.X, obj)
+				}
+			}
+		}
+		return !modified
+	})
+	return modified
+}
+
+// denotesVar reports whether expr denotes the variable obj, or memory within
+// it: a field or an array element, not memory it points to.
+func denotesVar(info *types.Info, expr ast.Expr, obj types.Object) bool {
+	for {
+		switch e := expr.(type) {
+		case *ast.ParenExpr:
+			expr = e.X
+		case *ast.SelectorExpr:
+			t := info.TypeOf(e.X)
+			if t == nil {
+				return false
+			}
+			if _, ok := t.Underlying().(*types.Pointer); ok {
+				return false
+			}
+			expr = e.X
+		case *ast.IndexExpr:
+			t := info.TypeOf(e.X)
+			if t == nil {
+				return false
+			}
+			if _, ok := t.Underlying().(*types.Array); !ok {
+				return false
+			}
+			expr = e.X
+		case *ast.Ident:
+			return info.Uses[e] == obj
+		default:
+			return false
+		}
+	}
 }
 
 // objUsed checks if the object is used within the range. It returns the first
@@ -1030,11 +1197,11 @@ func adjustReturnStatements(returnTypes []*ast.Field, seenVars map[types.Object]
 
 // generateFuncCall constructs a call expression for the extracted function, described by the
 // given parameters and return variables.
-func generateFuncCall(hasReturnVals bool, params, returns []ast.Expr, name string, token token.Token) ast.Node {
+func generateFuncCall(hasReturnVals bool, params, returns []ast.Expr, fun ast.Expr, token token.Token) ast.Node {
 	var replace ast.Node
 	if hasReturnVals {
 		callExpr := &ast.CallExpr{
-			Fun:  ast.NewIdent(name),
+			Fun:  fun,
 			Args: params,
 		}
 		replace = &ast.AssignStmt{
@@ -1044,7 +1211,7 @@ func generateFuncCall(hasReturnVals bool, params, returns []ast.Expr, name strin
 		}
 	} else {
 		replace = &ast.CallExpr{
-			Fun:  ast.NewIdent(name),
+			Fun:  fun,
 			Args: params,
 		}
 	}
@@ -0,0 +1,95 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package source
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/internal/span"
+)
+
+func TestModifiesReceiver(t *testing.T) {
+	const src = `package p
+
+type T struct {
+	x   string
+	arr [1]string
+	p   *T
+}
+
+func (t *T) set() {}
+
+func (t T) get() string { return t.x }
+
+func (t T) M() {
+	t.x = "a"
+	t.arr[0] = "b"
+	t.p.x = "c"
+	t.set()
+	_ = t.get()
+	u := &t
+	_ = u
+	x := t.x
+	_ = x
+}
+
+func (t *T) P() {
+	t.x = "d"
+	t.set()
+	t = t.p
+	v := &t
+	_ = v
+}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := &types.Info{
+		Types:      make(map[ast.Expr]types.TypeAndValue),
+		Defs:       make(map[*ast.Ident]types.Object),
+		Uses:       make(map[*ast.Ident]types.Object),
+		Selections: make(map[*ast.SelectorExpr]*types.Selection),
+	}
+	conf := types.Config{Importer: importer.Default()}
+	if _, err := conf.Check("p", fset, []*ast.File{f}, info); err != nil {
+		t.Fatal(err)
+	}
+	decls := make(map[string]*ast.FuncDecl)
+	for _, decl := range f.Decls {
+		if decl, ok := decl.(*ast.FuncDecl); ok {
+			decls[decl.Name.Name] = decl
+		}
+	}
+	for _, test := range []struct {
+		method, stmt string
+		want         bool
+	}{
+		{"M", `t.x = "a"`, true},
+		{"M", `t.arr[0] = "b"`, true},
+		{"M", `t.p.x = "c"`, false}, // through a pointer
+		{"M", `t.set()`, true},
+		{"M", `_ = t.get()`, false},
+		{"M", `u := &t`, true},
+		{"M", `x := t.x`, false},
+		{"P", `t.x = "d"`, false}, // pointer receiver
+		{"P", `t.set()`, false},
+		{"P", `t = t.p`, true},
+		{"P", `v := &t`, true},
+	} {
+		start := f.Pos() + token.Pos(strings.Index(src, test.stmt))
+		rng := span.NewRange(fset, start, start+token.Pos(len(test.stmt)))
+		if got := modifiesReceiver(info, decls[test.method], rng); got != test.want {
+			t.Errorf("modifiesReceiver(%s, %q) = %t, want %t", test.method, test.stmt, got, test.want)
+		}
+	}
+}
@@ -32,6 +32,11 @@ const (
 	UndeclaredName  = "undeclared_name"
 	ExtractVariable = "extract_variable"
 	ExtractFunction = "extract_function"
+	ExtractMethod   = "extract_method"
+	InlineCall      = "inline_call"
+	InlineVariable  = "inline_variable"
+	RemoveParameter = "remove_parameter"
+	StubMethods     = "stub_methods"
 )
 
 // suggestedFixes maps a suggested fix command id to its handler.
@@ -40,6 +45,30 @@ var suggestedFixes = map[string]SuggestedFixFunc{
 	UndeclaredName:  undeclaredname.SuggestedFix,
 	ExtractVariable: extractVariable,
 	ExtractFunction: extractFunction,
+	ExtractMethod:   extractMethod,
+	InlineVariable:  inlineVariable,
+}
+
+// packageFixFunc is like SuggestedFixFunc, for fixes that need the syntax
+// of the whole package, such as the declarations of functions in other
+// files. The edits of the fix must be in the file of pgf.
+type packageFixFunc func(fset *token.FileSet, rng span.Range, pgf *ParsedGoFile, pkg Package) (*analysis.SuggestedFix, error)
+
+// packageFixes maps a suggested fix command id to its handler, for the
+// fixes that need the whole package.
+var packageFixes = map[string]packageFixFunc{
+	InlineCall: inlineCall,
+}
+
+// workspaceFixFunc is like packageFixFunc, for fixes whose edits span
+// several files, such as those of the callers of a function.
+type workspaceFixFunc func(ctx context.Context, snapshot Snapshot, fh VersionedFileHandle, pgf *ParsedGoFile, rng span.Range) (map[span.URI][]protocol.TextEdit, error)
+
+// workspaceFixes maps a suggested fix command id to its handler, for the
+// fixes that edit several files.
+var workspaceFixes = map[string]workspaceFixFunc{
+	RemoveParameter: removeParameter,
+	StubMethods:     stubMethods,
 }
 
 func SuggestedFixFromCommand(cmd protocol.Command) SuggestedFix {
@@ -52,15 +81,38 @@ func SuggestedFixFromCommand(cmd protocol.Command) SuggestedFix {
 // ApplyFix applies the command's suggested fix to the given file and
 // range, returning the resulting edits.
 func ApplyFix(ctx context.Context, fix string, snapshot Snapshot, fh VersionedFileHandle, pRng protocol.Range) ([]protocol.TextDocumentEdit, error) {
-	handler, ok := suggestedFixes[fix]
-	if !ok {
-		return nil, fmt.Errorf("no suggested fix function for %s", fix)
+	pkg, pgf, err := GetParsedFile(ctx, snapshot, fh, NarrowestPackage)
+	if err != nil {
+		return nil, errors.Errorf("getting file for ApplyFix: %w", err)
 	}
-	fset, rng, src, file, m, pkg, info, err := getAllSuggestedFixInputs(ctx, snapshot, fh, pRng)
+	rng, err := pgf.Mapper.RangeToSpanRange(pRng)
 	if err != nil {
 		return nil, err
 	}
-	suggestion, err := handler(fset, rng, src, file, pkg, info)
+	if handler, ok := workspaceFixes[fix]; ok {
+		edits, err := handler(ctx, snapshot, fh, pgf, rng)
+		if err != nil {
+			return nil, err
+		}
+		var changes []protocol.TextDocumentEdit
+		for uri, e := range edits {
+			fh, err := snapshot.GetVersionedFile(ctx, uri)
+			if err != nil {
+				return nil, err
+			}
+			changes = append(changes, documentEdit(fh, e...))
+		}
+		return changes, nil
+	}
+	fset, m := snapshot.FileSet(), pgf.Mapper
+	var suggestion *analysis.SuggestedFix
+	if handler, ok := suggestedFixes[fix]; ok {
+		suggestion, err = handler(fset, rng, pgf.Src, pgf.File, pkg.GetTypes(), pkg.GetTypesInfo())
+	} else if handler, ok := packageFixes[fix]; ok {
+		suggestion, err = handler(fset, rng, pgf, pkg)
+	} else {
+		return nil, fmt.Errorf("no suggested fix function for %s", fix)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -79,34 +131,23 @@ func ApplyFix(ctx context.Context, fix string, snapshot Snapshot, fh VersionedFi
 		if err != nil {
 			return nil, err
 		}
-		edits = append(edits, protocol.TextDocumentEdit{
-			TextDocument: protocol.OptionalVersionedTextDocumentIdentifier{
-				Version: fh.Version(),
-				TextDocumentIdentifier: protocol.TextDocumentIdentifier{
-					URI: protocol.URIFromSpanURI(fh.URI()),
-				},
-			},
-			Edits: []protocol.TextEdit{
-				{
-					Range:   clRng,
-					NewText: string(edit.NewText),
-				},
-			},
-		})
+		edits = append(edits, documentEdit(fh, protocol.TextEdit{
+			Range:   clRng,
+			NewText: string(edit.NewText),
+		}))
 	}
 	return edits, nil
 }
 
-// getAllSuggestedFixInputs is a helper function to collect all possible needed
-// inputs for an AppliesFunc or SuggestedFixFunc.
-func getAllSuggestedFixInputs(ctx context.Context, snapshot Snapshot, fh FileHandle, pRng protocol.Range) (*token.FileSet, span.Range, []byte, *ast.File, *protocol.ColumnMapper, *types.Package, *types.Info, error) {
-	pkg, pgf, err := GetParsedFile(ctx, snapshot, fh, NarrowestPackage)
-	if err != nil {
-		return nil, span.Range{}, nil, nil, nil, nil, nil, errors.Errorf("getting file for Identifier: %w", err)
-	}
-	rng, err := pgf.Mapper.RangeToSpanRange(pRng)
-	if err != nil {
-		return nil, span.Range{}, nil, nil, nil, nil, nil, err
+// documentEdit returns the edit applying edits to the given version of fh.
+func documentEdit(fh VersionedFileHandle, edits ...protocol.TextEdit) protocol.TextDocumentEdit {
+	return protocol.TextDocumentEdit{
+		TextDocument: protocol.OptionalVersionedTextDocumentIdentifier{
+			Version: fh.Version(),
+			TextDocumentIdentifier: protocol.TextDocumentIdentifier{
+				URI: protocol.URIFromSpanURI(fh.URI()),
+			},
+		},
+		Edits: edits,
 	}
-	return snapshot.FileSet(), rng, pgf.Src, pgf.File, pgf.Mapper, pkg.GetTypes(), pkg.GetTypesInfo(), nil
 }
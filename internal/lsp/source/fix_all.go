@@ -0,0 +1,228 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package source
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"golang.org/x/tools/internal/lsp/command"
+	"golang.org/x/tools/internal/lsp/protocol"
+	"golang.org/x/tools/internal/span"
+	errors "golang.org/x/xerrors"
+)
+
+// An analyzerFix is a suggested fix of an analyzer, with the edits that
+// it makes to each file.
+type analyzerFix struct {
+	command.AnalyzerFix
+	edits map[span.URI][]protocol.TextEdit
+}
+
+// AnalyzerFixes returns the suggested fixes of the named analyzers for the
+// diagnostics of the files uris. The fixes are applied in order of
+// position, and those whose edits conflict with the edits of a fix that was
+// already applied are skipped. The result holds the merged edits of the
+// applied fixes.
+func AnalyzerFixes(ctx context.Context, snapshot Snapshot, uris []span.URI, names []string) (command.AnalyzerFixesResult, error) {
+	var result command.AnalyzerFixesResult
+	analyzers, err := namedAnalyzers(snapshot, names)
+	if err != nil {
+		return result, err
+	}
+
+	// Analyze each package once, and keep the diagnostics of the files
+	// being fixed.
+	fixing := make(map[span.URI]bool)
+	pkgs := make(map[string]Package)
+	for _, uri := range uris {
+		fixing[uri] = true
+		pkg, err := snapshot.PackageForFile(ctx, uri, TypecheckFull, WidestPackage)
+		if err != nil {
+			return result, err
+		}
+		pkgs[pkg.ID()] = pkg
+	}
+	var ids []string
+	for id := range pkgs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	var fixes []*analyzerFix
+	seen := make(map[string]bool)
+	for _, id := range ids {
+		diagnostics, err := snapshot.Analyze(ctx, id, analyzers)
+		if err != nil {
+			return result, err
+		}
+		// The fixes of suppressed diagnostics are not applied.
+		diagnostics, _ = ignoreDiagnostics(ctx, snapshot, pkgs[id], analyzers, diagnostics)
+		for _, d := range diagnostics {
+			if !fixing[d.URI] {
+				continue
+			}
+			name := string(d.Source)
+			if d.Analyzer != nil {
+				name = d.Analyzer.Analyzer.Name
+			}
+			for _, fix := range d.SuggestedFixes {
+				// A file may be analyzed in several packages, such as its
+				// test variant.
+				key := fmt.Sprintf("%s:%s:%v:%s", name, d.URI, d.Range, fix.Title)
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+				edits, err := suggestedFixEdits(ctx, snapshot, fix)
+				if err != nil {
+					return result, err
+				}
+				if len(edits) == 0 {
+					continue
+				}
+				fixes = append(fixes, &analyzerFix{
+					AnalyzerFix: command.AnalyzerFix{
+						Analyzer: name,
+						Title:    fix.Title,
+						Location: protocol.Location{
+							URI:   protocol.URIFromSpanURI(d.URI),
+							Range: d.Range,
+						},
+					},
+					edits: edits,
+				})
+			}
+		}
+	}
+	sort.SliceStable(fixes, func(i, j int) bool {
+		li, lj := fixes[i].Location, fixes[j].Location
+		if li.URI != lj.URI {
+			return li.URI < lj.URI
+		}
+		return protocol.ComparePosition(li.Range.Start, lj.Range.Start) < 0
+	})
+
+	applied := make(map[span.URI][]protocol.TextEdit)
+	for _, fix := range fixes {
+		if conflicts(applied, fix.edits) {
+			result.Conflicts = append(result.Conflicts, fix.AnalyzerFix)
+			continue
+		}
+		for uri, edits := range fix.edits {
+		edits:
+			for _, edit := range edits {
+				// Skip the edits that another fix already made.
+				for _, prev := range applied[uri] {
+					if edit == prev {
+						continue edits
+					}
+				}
+				applied[uri] = append(applied[uri], edit)
+			}
+		}
+		result.Fixes = append(result.Fixes, fix.AnalyzerFix)
+	}
+
+	var fixed []span.URI
+	for uri := range applied {
+		fixed = append(fixed, uri)
+	}
+	sort.Slice(fixed, func(i, j int) bool { return fixed[i] < fixed[j] })
+	for _, uri := range fixed {
+		fh, err := snapshot.GetVersionedFile(ctx, uri)
+		if err != nil {
+			return result, err
+		}
+		edits := applied[uri]
+		sort.Slice(edits, func(i, j int) bool {
+			return protocol.CompareRange(edits[i].Range, edits[j].Range) < 0
+		})
+		result.Edits = append(result.Edits, documentEdit(fh, edits...))
+	}
+	return result, nil
+}
+
+// namedAnalyzers returns the analyzers of snapshot with the given names,
+// which must be enabled.
+func namedAnalyzers(snapshot Snapshot, names []string) ([]*Analyzer, error) {
+	options := snapshot.View().Options()
+	var analyzers []*Analyzer
+	for _, name := range names {
+		var found *Analyzer
+		for _, m := range []map[string]*Analyzer{
+			options.DefaultAnalyzers,
+			options.TypeErrorAnalyzers,
+			options.ConvenienceAnalyzers,
+			options.StaticcheckAnalyzers,
+		} {
+			if a, ok := m[name]; ok {
+				found = a
+				break
+			}
+		}
+		if found == nil {
+			return nil, errors.Errorf("unknown analyzer %q", name)
+		}
+		if !found.IsEnabled(snapshot.View()) {
+			return nil, errors.Errorf("analyzer %q is not enabled", name)
+		}
+		analyzers = append(analyzers, found)
+	}
+	return analyzers, nil
+}
+
+// suggestedFixEdits returns the edits of fix by file. The edits of a fix
+// that is computed by the apply_fix command are computed now, and the fixes
+// of other commands have no edits.
+func suggestedFixEdits(ctx context.Context, snapshot Snapshot, fix SuggestedFix) (map[span.URI][]protocol.TextEdit, error) {
+	if fix.Command == nil {
+		return fix.Edits, nil
+	}
+	if fix.Command.Command != command.ApplyFix.ID() {
+		return nil, nil
+	}
+	var args command.ApplyFixArgs
+	if err := command.UnmarshalArgs(fix.Command.Arguments, &args); err != nil {
+		return nil, err
+	}
+	fh, err := snapshot.GetVersionedFile(ctx, args.URI.SpanURI())
+	if err != nil {
+		return nil, err
+	}
+	changes, err := ApplyFix(ctx, args.Fix, snapshot, fh, args.Range)
+	if err != nil {
+		return nil, err
+	}
+	edits := make(map[span.URI][]protocol.TextEdit)
+	for _, change := range changes {
+		uri := change.TextDocument.URI.SpanURI()
+		edits[uri] = append(edits[uri], change.Edits...)
+	}
+	return edits, nil
+}
+
+// conflicts reports whether an edit of edits overlaps a different edit of
+// applied. Insertions at the start of another edit conflict with it, as
+// the order of the inserted texts would be ambiguous.
+func conflicts(applied, edits map[span.URI][]protocol.TextEdit) bool {
+	for uri, fileEdits := range edits {
+		for _, edit := range fileEdits {
+			for _, prev := range applied[uri] {
+				if edit == prev {
+					continue
+				}
+				a, b := edit.Range, prev.Range
+				if protocol.ComparePosition(a.Start, b.End) < 0 && protocol.ComparePosition(b.Start, a.End) < 0 {
+					return true
+				}
+				if protocol.ComparePosition(a.Start, b.Start) == 0 && (protocol.IsPoint(a) || protocol.IsPoint(b)) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package source
+
+import (
+	"testing"
+
+	"golang.org/x/tools/internal/lsp/protocol"
+	"golang.org/x/tools/internal/span"
+)
+
+func TestConflicts(t *testing.T) {
+	const uri = span.URI("file:///a.go")
+	edit := func(start, end uint32, text string) protocol.TextEdit {
+		return protocol.TextEdit{
+			Range: protocol.Range{
+				Start: protocol.Position{Line: 1, Character: start},
+				End:   protocol.Position{Line: 1, Character: end},
+			},
+			NewText: text,
+		}
+	}
+	applied := map[span.URI][]protocol.TextEdit{
+		uri: {edit(4, 8, "x"), edit(12, 12, "y")},
+	}
+	for _, test := range []struct {
+		name string
+		edit protocol.TextEdit
+		want bool
+	}{
+		{"before", edit(0, 4, ""), false},
+		{"after", edit(8, 10, ""), false},
+		{"overlapping", edit(6, 10, ""), true},
+		{"inside", edit(5, 6, ""), true},
+		{"identical", edit(4, 8, "x"), false},
+		{"same range", edit(4, 8, "z"), true},
+		{"insertion at start", edit(4, 4, "z"), true},
+		{"insertion at end", edit(8, 8, "z"), false},
+		{"identical insertion", edit(12, 12, "y"), false},
+		{"insertion at insertion", edit(12, 12, "z"), true},
+		{"replacement at insertion", edit(12, 14, ""), true},
+		{"replacement before insertion", edit(10, 12, ""), false},
+	} {
+		edits := map[span.URI][]protocol.TextEdit{uri: {test.edit}}
+		if got := conflicts(applied, edits); got != test.want {
+			t.Errorf("%s: conflicts(%v) = %v, want %v", test.name, test.edit.Range, got, test.want)
+		}
+		other := map[span.URI][]protocol.TextEdit{"file:///b.go": {test.edit}}
+		if conflicts(applied, other) {
+			t.Errorf("%s: edit of another file conflicts", test.name)
+		}
+	}
+}
@@ -42,6 +42,16 @@ func Format(ctx context.Context, snapshot Snapshot, fh FileHandle) ([]protocol.T
 		return computeTextEdits(ctx, snapshot, pgf, string(formatted))
 	}
 
+	formatted, err := formatFile(ctx, snapshot, pgf)
+	if err != nil {
+		return nil, err
+	}
+	return computeTextEdits(ctx, snapshot, pgf, formatted)
+}
+
+// formatFile returns the formatted contents of the file parsed in pgf,
+// which must not have parse errors.
+func formatFile(ctx context.Context, snapshot Snapshot, pgf *ParsedGoFile) (string, error) {
 	fset := snapshot.FileSet()
 
 	// format.Node changes slightly from one release to another, so the version
@@ -50,7 +60,7 @@ func Format(ctx context.Context, snapshot Snapshot, fh FileHandle) ([]protocol.T
 	// the LSP server on each Go release.
 	buf := &bytes.Buffer{}
 	if err := format.Node(buf, fset, pgf.File); err != nil {
-		return nil, err
+		return "", err
 	}
 	formatted := buf.String()
 
@@ -59,11 +69,11 @@ func Format(ctx context.Context, snapshot Snapshot, fh FileHandle) ([]protocol.T
 	if format := snapshot.View().Options().Hooks.GofumptFormat; snapshot.View().Options().Gofumpt && format != nil {
 		b, err := format(ctx, buf.Bytes())
 		if err != nil {
-			return nil, err
+			return "", err
 		}
 		formatted = string(b)
 	}
-	return computeTextEdits(ctx, snapshot, pgf, formatted)
+	return formatted, nil
 }
 
 func formatSource(ctx context.Context, fh FileHandle) ([]byte, error) {
@@ -77,66 +87,62 @@ func formatSource(ctx context.Context, fh FileHandle) ([]byte, error) {
 	return format.Source(data)
 }
 
-type ImportFix struct {
-	Fix   *imports.ImportFix
-	Edits []protocol.TextEdit
-}
-
-// AllImportsFixes formats f for each possible fix to the imports.
-// In addition to returning the result of applying all edits,
-// it returns a list of fixes that could be applied to the file, with the
-// corresponding TextEdits that would be needed to apply that fix.
-func AllImportsFixes(ctx context.Context, snapshot Snapshot, fh FileHandle) (allFixEdits []protocol.TextEdit, editsPerFix []*ImportFix, err error) {
-	ctx, done := event.Start(ctx, "source.AllImportsFixes")
+// ImportFixes returns the fixes needed for the imports of fh. It does not
+// compute the edits that apply them, which ImportFixEdits does, so that
+// callers pay for the edits of only the fixes they use.
+func ImportFixes(ctx context.Context, snapshot Snapshot, fh FileHandle) ([]*imports.ImportFix, error) {
+	ctx, done := event.Start(ctx, "source.ImportFixes")
 	defer done()
 
 	pgf, err := snapshot.ParseGo(ctx, fh, ParseFull)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
+	var fixes []*imports.ImportFix
 	if err := snapshot.RunProcessEnvFunc(ctx, func(opts *imports.Options) error {
-		allFixEdits, editsPerFix, err = computeImportEdits(snapshot, pgf, opts)
+		fixes, err = imports.FixImports(pgf.URI.Filename(), pgf.Src, opts)
 		return err
 	}); err != nil {
-		return nil, nil, fmt.Errorf("AllImportsFixes: %v", err)
+		return nil, fmt.Errorf("ImportFixes: %v", err)
 	}
-	return allFixEdits, editsPerFix, nil
+	return fixes, nil
 }
 
-// computeImportEdits computes a set of edits that perform one or all of the
-// necessary import fixes.
-func computeImportEdits(snapshot Snapshot, pgf *ParsedGoFile, options *imports.Options) (allFixEdits []protocol.TextEdit, editsPerFix []*ImportFix, err error) {
-	filename := pgf.URI.Filename()
-
-	// Build up basic information about the original file.
-	allFixes, err := imports.FixImports(filename, pgf.Src, options)
-	if err != nil {
-		return nil, nil, err
-	}
+// ImportFixEdits returns the edits that apply fixes to the imports of fh.
+// Given all the fixes returned by ImportFixes, the edits organize the
+// imports of the file.
+func ImportFixEdits(ctx context.Context, snapshot Snapshot, fh FileHandle, fixes []*imports.ImportFix) ([]protocol.TextEdit, error) {
+	ctx, done := event.Start(ctx, "source.ImportFixEdits")
+	defer done()
 
-	allFixEdits, err = computeFixEdits(snapshot, pgf, options, allFixes)
+	pgf, err := snapshot.ParseGo(ctx, fh, ParseFull)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
-
-	// Apply all of the import fixes to the file.
-	// Add the edits for each fix to the result.
-	for _, fix := range allFixes {
-		edits, err := computeFixEdits(snapshot, pgf, options, []*imports.ImportFix{fix})
-		if err != nil {
-			return nil, nil, err
-		}
-		editsPerFix = append(editsPerFix, &ImportFix{
-			Fix:   fix,
-			Edits: edits,
-		})
+	var edits []protocol.TextEdit
+	if err := snapshot.RunProcessEnvFunc(ctx, func(opts *imports.Options) error {
+		edits, err = computeFixEdits(snapshot, pgf, opts, fixes)
+		return err
+	}); err != nil {
+		return nil, fmt.Errorf("ImportFixEdits: %v", err)
 	}
-	return allFixEdits, editsPerFix, nil
+	return edits, nil
 }
 
 // ComputeOneImportFixEdits returns text edits for a single import fix.
 func ComputeOneImportFixEdits(snapshot Snapshot, pgf *ParsedGoFile, fix *imports.ImportFix) ([]protocol.TextEdit, error) {
-	options := &imports.Options{
+	return computeImportFixEdits(snapshot, pgf, fix)
+}
+
+// computeImportFixEdits returns text edits for the given import fixes,
+// without otherwise formatting the file.
+func computeImportFixEdits(snapshot Snapshot, pgf *ParsedGoFile, fixes ...*imports.ImportFix) ([]protocol.TextEdit, error) {
+	return computeFixEdits(snapshot, pgf, importFixOptions(snapshot), fixes)
+}
+
+// importFixOptions returns the options with which to apply import fixes.
+func importFixOptions(snapshot Snapshot) *imports.Options {
+	return &imports.Options{
 		LocalPrefix: snapshot.View().Options().Local,
 		// Defaults.
 		AllErrors:  true,
@@ -146,7 +152,6 @@ func ComputeOneImportFixEdits(snapshot Snapshot, pgf *ParsedGoFile, fix *imports
 		TabIndent:  true,
 		TabWidth:   8,
 	}
-	return computeFixEdits(snapshot, pgf, options, []*imports.ImportFix{fix})
 }
 
 func computeFixEdits(snapshot Snapshot, pgf *ParsedGoFile, options *imports.Options, fixes []*imports.ImportFix) ([]protocol.TextEdit, error) {
@@ -0,0 +1,328 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package source
+
+import (
+	"bytes"
+	"context"
+	"go/ast"
+	"go/parser"
+	"go/token"
+
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/internal/event"
+	"golang.org/x/tools/internal/lsp/protocol"
+	errors "golang.org/x/xerrors"
+)
+
+// FormatRange formats the smallest sequence of complete statements or
+// declarations that covers rng, leaving the rest of the file untouched.
+func FormatRange(ctx context.Context, snapshot Snapshot, fh FileHandle, rng protocol.Range) ([]protocol.TextEdit, error) {
+	ctx, done := event.Start(ctx, "source.FormatRange")
+	defer done()
+
+	pgf, err := snapshot.ParseGo(ctx, fh, ParseFull)
+	if err != nil {
+		return nil, err
+	}
+	// Unlike Format, we can't fall back to formatting the source: without an
+	// accurate AST, there is no way to tell which lines belong to the range.
+	if pgf.ParseErr != nil {
+		return nil, errors.Errorf("cannot format range of file with parse errors: %v", pgf.ParseErr)
+	}
+	spn, err := pgf.Mapper.RangeSpan(rng)
+	if err != nil {
+		return nil, err
+	}
+	srng, err := spn.Range(pgf.Mapper.Converter)
+	if err != nil {
+		return nil, err
+	}
+	region, ok := enclosingRegion(pgf, srng.Start, srng.End)
+	if !ok {
+		return nil, nil
+	}
+	return formatRegion(ctx, snapshot, pgf, region)
+}
+
+// FormatOnType formats the code affected by typing ch at pos. When ch is a
+// closing brace, the block it closes is reformatted; when ch is a newline,
+// the statements on the line that was just ended are reformatted.
+func FormatOnType(ctx context.Context, snapshot Snapshot, fh FileHandle, pos protocol.Position, ch string) ([]protocol.TextEdit, error) {
+	ctx, done := event.Start(ctx, "source.FormatOnType")
+	defer done()
+
+	pgf, err := snapshot.ParseGo(ctx, fh, ParseFull)
+	if err != nil {
+		return nil, err
+	}
+	// The file is usually incomplete while the user is typing, so parse
+	// errors are expected here and not worth reporting.
+	if pgf.ParseErr != nil {
+		return nil, nil
+	}
+	spn, err := pgf.Mapper.PointSpan(pos)
+	if err != nil {
+		return nil, err
+	}
+	srng, err := spn.Range(pgf.Mapper.Converter)
+	if err != nil {
+		return nil, err
+	}
+	p := srng.Start
+
+	var region fileRegion
+	switch ch {
+	case "}":
+		offset := pgf.Tok.Offset(p)
+		if offset == 0 || pgf.Src[offset-1] != '}' {
+			return nil, nil
+		}
+		// Reformat the whole statement or declaration that ends with the
+		// brace, so that its header is formatted along with the block.
+		path, _ := astutil.PathEnclosingInterval(pgf.File, p-1, p)
+		var closed ast.Node
+		for i, n := range path {
+			if closed == nil && n.End() != p {
+				continue
+			}
+			closed = n
+			if i+1 < len(path) && nodeList(path[i+1]) != nil {
+				break
+			}
+		}
+		if closed == nil {
+			return nil, nil
+		}
+		var ok bool
+		if region, ok = enclosingRegion(pgf, closed.Pos(), closed.End()); !ok {
+			return nil, nil
+		}
+	case "\n":
+		line := int(pos.Line) // the 1-based number of the line that was just ended
+		if line < 1 || line >= pgf.Tok.LineCount() {
+			return nil, nil
+		}
+		cursorLine := pgf.Tok.LineStart(line + 1)
+		var ok bool
+		if region, ok = enclosingRegion(pgf, pgf.Tok.LineStart(line), cursorLine-1); !ok {
+			return nil, nil
+		}
+		// Don't touch the line the cursor is on: the editor has most likely
+		// just indented it, and formatting would strip that indentation.
+		if region.end >= pgf.Tok.Offset(cursorLine) {
+			return nil, nil
+		}
+	default:
+		return nil, nil
+	}
+	return formatRegion(ctx, snapshot, pgf, region)
+}
+
+// A fileRegion is a run of consecutive statements or declarations, all
+// elements of the same list, that occupy whole lines of a file.
+type fileRegion struct {
+	// indexes holds the child indexes, as visited by ast.Inspect, of the path
+	// from the root of the file to the node holding the list.
+	indexes     []int
+	first, last int // indexes of the first and last elements of the list
+
+	start, end int // byte offsets of the region within the file
+}
+
+// enclosingRegion returns the smallest region of pgf that includes every
+// statement or declaration overlapping [start, end]. It reports false if
+// there is nothing to format in that range.
+func enclosingRegion(pgf *ParsedGoFile, start, end token.Pos) (fileRegion, bool) {
+	path, _ := astutil.PathEnclosingInterval(pgf.File, start, end)
+	for i, n := range path {
+		list := nodeList(n)
+		if list == nil {
+			continue
+		}
+		first, last := -1, -1
+		for j, elem := range list {
+			overlaps := regionStart(elem) < end && start < elem.End()
+			if start == end {
+				overlaps = regionStart(elem) <= start && start <= elem.End()
+			}
+			if overlaps {
+				if first < 0 {
+					first = j
+				}
+				last = j
+			}
+		}
+		if first < 0 {
+			// The range is between the elements of the innermost list,
+			// so there are no statements or declarations to format.
+			return fileRegion{}, false
+		}
+		regStart, regEnd, ok := lineExtent(pgf, regionStart(list[first]), list[last].End())
+		if !ok {
+			// Other code shares the lines of the selected elements, so
+			// try again with the statement or declaration enclosing them.
+			continue
+		}
+		var indexes []int
+		for k := len(path) - 1; k > i; k-- {
+			indexes = append(indexes, childIndex(path[k], path[k-1]))
+		}
+		return fileRegion{
+			indexes: indexes,
+			first:   first,
+			last:    last,
+			start:   regStart,
+			end:     regEnd,
+		}, true
+	}
+	return fileRegion{}, false
+}
+
+// formatRegion formats the file and replaces the given region with its
+// formatted counterpart, returning the resulting edits.
+func formatRegion(ctx context.Context, snapshot Snapshot, pgf *ParsedGoFile, region fileRegion) ([]protocol.TextEdit, error) {
+	formatted, err := formatFile(ctx, snapshot, pgf)
+	if err != nil {
+		return nil, err
+	}
+	// Formatting only changes the layout of the file, not its structure, so
+	// the region can be found in the formatted file by following the same
+	// path through the AST.
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, pgf.URI.Filename(), formatted, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	var n ast.Node = file
+	for _, index := range region.indexes {
+		if n = nthChild(n, index); n == nil {
+			return nil, errors.Errorf("formatted %s has an unexpected structure", pgf.URI.Filename())
+		}
+	}
+	list := nodeList(n)
+	if region.last >= len(list) {
+		return nil, errors.Errorf("formatted %s has an unexpected structure", pgf.URI.Filename())
+	}
+	tok := fset.File(file.Pos())
+	fstart := lineStart([]byte(formatted), tok.Offset(regionStart(list[region.first])))
+	fend := lineEnd([]byte(formatted), tok.Offset(list[region.last].End()))
+
+	var buf bytes.Buffer
+	buf.Write(pgf.Src[:region.start])
+	buf.WriteString(formatted[fstart:fend])
+	buf.Write(pgf.Src[region.end:])
+	return computeTextEdits(ctx, snapshot, pgf, buf.String())
+}
+
+// nodeList returns the statements or declarations held by n, or nil if n
+// does not hold a list of them.
+func nodeList(n ast.Node) []ast.Node {
+	var list []ast.Node
+	switch n := n.(type) {
+	case *ast.File:
+		for _, decl := range n.Decls {
+			list = append(list, decl)
+		}
+	case *ast.BlockStmt:
+		for _, stmt := range n.List {
+			list = append(list, stmt)
+		}
+	case *ast.CaseClause:
+		for _, stmt := range n.Body {
+			list = append(list, stmt)
+		}
+	case *ast.CommClause:
+		for _, stmt := range n.Body {
+			list = append(list, stmt)
+		}
+	}
+	return list
+}
+
+// regionStart returns the start of n, including its doc comment.
+func regionStart(n ast.Node) token.Pos {
+	switch n := n.(type) {
+	case *ast.FuncDecl:
+		if n.Doc != nil {
+			return n.Doc.Pos()
+		}
+	case *ast.GenDecl:
+		if n.Doc != nil {
+			return n.Doc.Pos()
+		}
+	}
+	return n.Pos()
+}
+
+// lineExtent returns the offsets of the start of the line containing start
+// and the end of the line containing end. It reports false if there is code
+// on those lines outside of [start, end].
+func lineExtent(pgf *ParsedGoFile, start, end token.Pos) (int, int, bool) {
+	startOffset, endOffset := pgf.Tok.Offset(start), pgf.Tok.Offset(end)
+	regStart := lineStart(pgf.Src, startOffset)
+	regEnd := lineEnd(pgf.Src, endOffset)
+	if len(bytes.TrimSpace(pgf.Src[regStart:startOffset])) > 0 {
+		return 0, 0, false
+	}
+	if rest := bytes.TrimSpace(pgf.Src[endOffset:regEnd]); len(rest) > 0 && !bytes.HasPrefix(rest, []byte("//")) {
+		return 0, 0, false
+	}
+	return regStart, regEnd, true
+}
+
+// lineStart returns the offset of the start of the line containing offset.
+func lineStart(src []byte, offset int) int {
+	return bytes.LastIndexByte(src[:offset], '\n') + 1
+}
+
+// lineEnd returns the offset of the newline ending the line containing
+// offset, or the length of src if that line is the last.
+func lineEnd(src []byte, offset int) int {
+	if i := bytes.IndexByte(src[offset:], '\n'); i >= 0 {
+		return offset + i
+	}
+	return len(src)
+}
+
+// childIndex returns the position of child among the direct children of
+// parent, in the order in which ast.Inspect visits them, or -1.
+func childIndex(parent, child ast.Node) int {
+	index, i := -1, 0
+	ast.Inspect(parent, func(n ast.Node) bool {
+		switch n {
+		case nil:
+			return false
+		case parent:
+			return true
+		case child:
+			index = i
+		}
+		i++
+		return false
+	})
+	return index
+}
+
+// nthChild returns the child of parent at the given position, in the order
+// in which ast.Inspect visits them, or nil.
+func nthChild(parent ast.Node, index int) ast.Node {
+	var result ast.Node
+	i := 0
+	ast.Inspect(parent, func(n ast.Node) bool {
+		switch n {
+		case nil:
+			return false
+		case parent:
+			return true
+		}
+		if i == index {
+			result = n
+		}
+		i++
+		return false
+	})
+	return result
+}
@@ -13,6 +13,7 @@ import (
 	"go/doc"
 	"go/format"
 	"go/types"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -218,6 +219,11 @@ func moduleAtVersion(path string, i *IdentifierInfo) (string, string, bool) {
 		return "", "", false
 	}
 	if impPkg.Version() == nil {
+		// Link the standard library packages to the documentation of the
+		// version of the toolchain selected for the view, if any.
+		if toolchain := i.Snapshot.View().Toolchain(); toolchain.Selection != "" && toolchain.Version != "" && inGOROOT(impPkg, toolchain.GOROOT) {
+			return path, toolchain.Version, true
+		}
 		return "", "", false
 	}
 	version, modpath := impPkg.Version().Version, impPkg.Version().Path
@@ -227,6 +233,15 @@ func moduleAtVersion(path string, i *IdentifierInfo) (string, string, bool) {
 	return modpath, version, true
 }
 
+// inGOROOT reports whether the files of pkg are in the standard library of
+// goroot.
+func inGOROOT(pkg Package, goroot string) bool {
+	for _, pgf := range pkg.CompiledGoFiles() {
+		return InDir(filepath.Join(goroot, "src"), pgf.URI.Filename())
+	}
+	return false
+}
+
 // objectString is a wrapper around the types.ObjectString function.
 // It handles adding more information to the object string.
 func objectString(obj types.Object, qf types.Qualifier) string {
@@ -333,6 +348,16 @@ func formatGenDecl(node *ast.GenDecl, obj types.Object, typ types.Type) (*HoverI
 			break
 		}
 	}
+	if spec == nil {
+		// Objects imported from export data have imprecise positions, but
+		// the names of the specs of a declaration are unique.
+		for _, s := range node.Specs {
+			if specDeclares(s, obj.Name()) {
+				spec = s
+				break
+			}
+		}
+	}
 	if spec == nil {
 		return nil, errors.Errorf("no spec for node %v at position %v", node, obj.Pos())
 	}
@@ -384,7 +409,7 @@ func formatVar(node ast.Spec, obj types.Object, decl *ast.GenDecl) *HoverInforma
 	if fieldList != nil {
 		for i := 0; i < len(fieldList.List); i++ {
 			field := fieldList.List[i]
-			if field.Pos() <= obj.Pos() && obj.Pos() <= field.End() {
+			if field.Pos() <= obj.Pos() && obj.Pos() <= field.End() || fieldDeclares(field, obj) {
 				if field.Doc.Text() != "" {
 					return &HoverInformation{source: obj, comment: field.Doc}
 				}
@@ -395,6 +420,33 @@ func formatVar(node ast.Spec, obj types.Object, decl *ast.GenDecl) *HoverInforma
 	return &HoverInformation{source: obj, comment: decl.Doc}
 }
 
+// specDeclares reports whether spec declares name.
+func specDeclares(spec ast.Spec, name string) bool {
+	switch spec := spec.(type) {
+	case *ast.TypeSpec:
+		return spec.Name.Name == name
+	case *ast.ValueSpec:
+		for _, id := range spec.Names {
+			if id.Name == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// fieldDeclares reports whether field declares the name of obj. Like the
+// names of specs, it identifies the field of an object imported from export
+// data.
+func fieldDeclares(field *ast.Field, obj types.Object) bool {
+	for _, id := range field.Names {
+		if id.Name == obj.Name() {
+			return true
+		}
+	}
+	return false
+}
+
 func FormatHover(h *HoverInformation, options *Options) (string, error) {
 	signature := h.Signature
 	if signature != "" && options.PreferredContentFormat == protocol.Markdown {
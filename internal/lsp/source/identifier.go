@@ -341,7 +341,7 @@ func objToDecl(ctx context.Context, snapshot Snapshot, srcPkg Package, obj types
 	if err != nil {
 		return nil, err
 	}
-	return posToDecl[obj.Pos()], nil
+	return posToDecl[IdentPos(snapshot.FileSet(), pgf, obj.Pos(), obj.Name())], nil
 }
 
 // importSpec handles positions inside of an *ast.ImportSpec.
@@ -93,31 +93,10 @@ func implementations(ctx context.Context, s Snapshot, f FileHandle, pp protocol.
 			return nil, nil
 		}
 
-		// Find all named types, even local types (which can have methods
-		// due to promotion).
-		var (
-			allNamed []*types.Named
-			pkgs     = make(map[*types.Package]Package)
-		)
-		knownPkgs, err := s.KnownPackages(ctx)
+		allNamed, pkgs, err := namedTypes(ctx, s)
 		if err != nil {
 			return nil, err
 		}
-		for _, pkg := range knownPkgs {
-			pkgs[pkg.GetTypes()] = pkg
-			info := pkg.GetTypesInfo()
-			for _, obj := range info.Defs {
-				obj, ok := obj.(*types.TypeName)
-				// We ignore aliases 'type M = N' to avoid duplicate reporting
-				// of the Named type N.
-				if !ok || obj.IsAlias() {
-					continue
-				}
-				if named, ok := obj.Type().(*types.Named); ok {
-					allNamed = append(allNamed, named)
-				}
-			}
-		}
 
 		// Find all the named types that match our query.
 		for _, named := range allNamed {
@@ -162,6 +141,36 @@ func implementations(ctx context.Context, s Snapshot, f FileHandle, pp protocol.
 	return impls, nil
 }
 
+// namedTypes returns all the named types of the known packages of s, even
+// local types (which can have methods due to promotion), and a map from
+// their types.Packages to the packages.
+func namedTypes(ctx context.Context, s Snapshot) ([]*types.Named, map[*types.Package]Package, error) {
+	var (
+		allNamed []*types.Named
+		pkgs     = make(map[*types.Package]Package)
+	)
+	knownPkgs, err := s.KnownPackages(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, pkg := range knownPkgs {
+		pkgs[pkg.GetTypes()] = pkg
+		info := pkg.GetTypesInfo()
+		for _, obj := range info.Defs {
+			obj, ok := obj.(*types.TypeName)
+			// We ignore aliases 'type M = N' to avoid duplicate reporting
+			// of the Named type N.
+			if !ok || obj.IsAlias() {
+				continue
+			}
+			if named, ok := obj.Type().(*types.Named); ok {
+				allNamed = append(allNamed, named)
+			}
+		}
+	}
+	return allNamed, pkgs, nil
+}
+
 // concreteImplementsIntf returns true if a is an interface type implemented by
 // concrete type b, or vice versa.
 func concreteImplementsIntf(a, b types.Type) bool {
@@ -0,0 +1,293 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package source
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/internal/event"
+	"golang.org/x/tools/internal/lsp/protocol"
+)
+
+// An inlayHintFunc returns the inlay hints of a node, if it is of the kind
+// of node it handles.
+type inlayHintFunc func(node ast.Node, info *types.Info, qf types.Qualifier) []inlayHint
+
+// inlayHint is an inlay hint, before its position is mapped to the
+// protocol. Hints of parameters are padded on their right, as they precede
+// arguments, and other hints on their left.
+type inlayHint struct {
+	pos   token.Pos
+	label string
+	kind  protocol.InlayHintKind // zero for the values of constants
+}
+
+// InlayHint returns the inlay hints of the file in rng that are enabled by
+// the InlayHintOptions of the snapshot. The hints of the whole file are
+// returned if rng is empty.
+func InlayHint(ctx context.Context, snapshot Snapshot, fh FileHandle, rng protocol.Range) ([]protocol.InlayHint, error) {
+	ctx, done := event.Start(ctx, "source.InlayHint")
+	defer done()
+
+	opts := snapshot.View().Options()
+	var funcs []inlayHintFunc
+	if opts.ParameterNameHints {
+		funcs = append(funcs, parameterNames)
+	}
+	if opts.AssignVariableTypeHints {
+		funcs = append(funcs, assignVariableTypes)
+	}
+	if opts.RangeVariableTypeHints {
+		funcs = append(funcs, rangeVariableTypes)
+	}
+	if opts.ConstantValueHints {
+		funcs = append(funcs, constantValues)
+	}
+	if opts.CompositeLiteralFieldHints {
+		funcs = append(funcs, compositeLiteralFields)
+	}
+	if len(funcs) == 0 || fh.Kind() != Go {
+		return nil, nil
+	}
+
+	pkg, pgf, err := GetParsedFile(ctx, snapshot, fh, NarrowestPackage)
+	if err != nil {
+		return nil, err
+	}
+	start, end := pgf.File.Pos(), pgf.File.End()
+	if rng.Start != rng.End {
+		spn, err := pgf.Mapper.RangeSpan(rng)
+		if err != nil {
+			return nil, err
+		}
+		srng, err := spn.Range(pgf.Mapper.Converter)
+		if err != nil {
+			return nil, err
+		}
+		start, end = srng.Start, srng.End
+	}
+
+	info := pkg.GetTypesInfo()
+	qf := Qualifier(pgf.File, pkg.GetTypes(), info)
+	var hints []protocol.InlayHint
+	var mapErr error
+	ast.Inspect(pgf.File, func(n ast.Node) bool {
+		if n == nil || n.End() < start || n.Pos() > end || mapErr != nil {
+			return false
+		}
+		for _, f := range funcs {
+			for _, h := range f(n, info, qf) {
+				if h.pos < start || h.pos > end {
+					continue
+				}
+				pr, err := NewMappedRange(snapshot.FileSet(), pgf.Mapper, h.pos, h.pos).Range()
+				if err != nil {
+					mapErr = err
+					return false
+				}
+				hints = append(hints, protocol.InlayHint{
+					Position:     pr.Start,
+					Label:        []protocol.InlayHintLabelPart{{Value: h.label}},
+					Kind:         h.kind,
+					PaddingLeft:  h.kind != protocol.Parameter,
+					PaddingRight: h.kind == protocol.Parameter,
+				})
+			}
+		}
+		return true
+	})
+	if mapErr != nil {
+		return nil, mapErr
+	}
+	return hints, nil
+}
+
+// parameterNames returns hints for the names of the parameters of a call,
+// before its arguments.
+func parameterNames(node ast.Node, info *types.Info, _ types.Qualifier) []inlayHint {
+	call, ok := node.(*ast.CallExpr)
+	if !ok {
+		return nil
+	}
+	if tv, ok := info.Types[call.Fun]; !ok || tv.IsType() {
+		// Conversions have no parameters.
+		return nil
+	}
+	sig, ok := info.TypeOf(call.Fun).Underlying().(*types.Signature)
+	if !ok {
+		return nil
+	}
+	params := sig.Params()
+	var hints []inlayHint
+	for i, arg := range call.Args {
+		j := i
+		if sig.Variadic() && j >= params.Len()-1 {
+			j = params.Len() - 1
+			if i > j {
+				// Only the first of the variadic arguments is labeled.
+				break
+			}
+		}
+		if j >= params.Len() {
+			break
+		}
+		name := params.At(j).Name()
+		if name == "" || name == "_" {
+			continue
+		}
+		if id, ok := arg.(*ast.Ident); ok && id.Name == name {
+			// The argument already says what the parameter is.
+			continue
+		}
+		if sig.Variadic() && j == params.Len()-1 && !call.Ellipsis.IsValid() {
+			name += "..."
+		}
+		hints = append(hints, inlayHint{
+			pos:   arg.Pos(),
+			label: name + ":",
+			kind:  protocol.Parameter,
+		})
+	}
+	return hints
+}
+
+// assignVariableTypes returns hints for the types of the variables declared
+// by a := assignment, after their names.
+func assignVariableTypes(node ast.Node, info *types.Info, qf types.Qualifier) []inlayHint {
+	stmt, ok := node.(*ast.AssignStmt)
+	if !ok || stmt.Tok != token.DEFINE {
+		return nil
+	}
+	var hints []inlayHint
+	for _, lhs := range stmt.Lhs {
+		if h, ok := variableType(lhs, info, qf); ok {
+			hints = append(hints, h)
+		}
+	}
+	return hints
+}
+
+// rangeVariableTypes returns hints for the types of the variables declared
+// by a range statement, after their names.
+func rangeVariableTypes(node ast.Node, info *types.Info, qf types.Qualifier) []inlayHint {
+	stmt, ok := node.(*ast.RangeStmt)
+	if !ok || stmt.Tok != token.DEFINE {
+		return nil
+	}
+	var hints []inlayHint
+	for _, e := range []ast.Expr{stmt.Key, stmt.Value} {
+		if h, ok := variableType(e, info, qf); ok {
+			hints = append(hints, h)
+		}
+	}
+	return hints
+}
+
+// variableType returns the hint for the type of the variable declared by
+// the identifier e, if it declares one.
+func variableType(e ast.Expr, info *types.Info, qf types.Qualifier) (inlayHint, bool) {
+	id, ok := e.(*ast.Ident)
+	if !ok || id.Name == "_" {
+		return inlayHint{}, false
+	}
+	// Identifiers that are assigned but not declared by := are in Uses.
+	obj, ok := info.Defs[id].(*types.Var)
+	if !ok {
+		return inlayHint{}, false
+	}
+	return inlayHint{
+		pos:   id.End(),
+		label: types.TypeString(obj.Type(), qf),
+		kind:  protocol.Type,
+	}, true
+}
+
+// constantValues returns hints for the values of the constants of a
+// declaration that uses iota, after their specs. Specs whose values do not
+// depend on iota are not hinted, as their values are explicit.
+func constantValues(node ast.Node, info *types.Info, _ types.Qualifier) []inlayHint {
+	decl, ok := node.(*ast.GenDecl)
+	if !ok || decl.Tok != token.CONST {
+		return nil
+	}
+	var hasIota bool
+	usesIota := make(map[*ast.ValueSpec]bool)
+	for _, spec := range decl.Specs {
+		spec := spec.(*ast.ValueSpec)
+		for _, v := range spec.Values {
+			ast.Inspect(v, func(n ast.Node) bool {
+				if id, ok := n.(*ast.Ident); ok && info.Uses[id] == types.Universe.Lookup("iota") {
+					usesIota[spec] = true
+				}
+				return !usesIota[spec]
+			})
+		}
+		hasIota = hasIota || usesIota[spec]
+	}
+	if !hasIota {
+		return nil
+	}
+	var hints []inlayHint
+	for _, spec := range decl.Specs {
+		spec := spec.(*ast.ValueSpec)
+		if len(spec.Values) > 0 && !usesIota[spec] {
+			continue
+		}
+		var values []string
+		for _, name := range spec.Names {
+			obj, ok := info.Defs[name].(*types.Const)
+			if !ok || name.Name == "_" {
+				continue
+			}
+			values = append(values, obj.Val().String())
+		}
+		if len(values) == 0 {
+			continue
+		}
+		hints = append(hints, inlayHint{
+			pos:   spec.End(),
+			label: fmt.Sprintf("= %s", strings.Join(values, ", ")),
+		})
+	}
+	return hints
+}
+
+// compositeLiteralFields returns hints for the names of the fields of an
+// unkeyed struct literal, before its elements.
+func compositeLiteralFields(node ast.Node, info *types.Info, _ types.Qualifier) []inlayHint {
+	lit, ok := node.(*ast.CompositeLit)
+	if !ok || len(lit.Elts) == 0 {
+		return nil
+	}
+	typ := info.TypeOf(lit)
+	if typ == nil {
+		return nil
+	}
+	st, ok := Deref(typ).Underlying().(*types.Struct)
+	if !ok {
+		return nil
+	}
+	var hints []inlayHint
+	for i, elt := range lit.Elts {
+		if _, ok := elt.(*ast.KeyValueExpr); ok {
+			// The literal is keyed.
+			return nil
+		}
+		if i >= st.NumFields() {
+			break
+		}
+		hints = append(hints, inlayHint{
+			pos:   elt.Pos(),
+			label: st.Field(i).Name() + ":",
+			kind:  protocol.Parameter,
+		})
+	}
+	return hints
+}
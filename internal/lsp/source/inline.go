@@ -0,0 +1,1280 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package source
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/internal/span"
+)
+
+// CanInlineCall reports whether the given range is within a call to a
+// function or method whose body is declared in pkg, returning the callee.
+// Whether the call can actually be inlined is only determined by
+// inlineCall, which reports the reason if it cannot.
+func CanInlineCall(rng span.Range, file *ast.File, pkg Package) (*types.Func, bool) {
+	path, fn := callAt(rng, file, pkg.GetTypesInfo())
+	if path == nil {
+		return nil, false
+	}
+	if _, decl := findFuncDecl(pkg, fn); decl == nil || decl.Body == nil {
+		return nil, false
+	}
+	return fn, true
+}
+
+// inlineCall replaces the call at rng with the body of the callee, which
+// must be declared in pkg. Parameters are replaced by their arguments,
+// except where an argument has side effects or is used more than once, in
+// which case it is assigned to a temporary variable first. The call is
+// refused if the callee defers calls, is recursive, or returns other than
+// at the end of its body.
+func inlineCall(fset *token.FileSet, rng span.Range, pgf *ParsedGoFile, pkg Package) (*analysis.SuggestedFix, error) {
+	info := pkg.GetTypesInfo()
+	path, fn := callAt(rng, pgf.File, info)
+	if path == nil {
+		return nil, fmt.Errorf("no call to inline at %s", fset.Position(rng.Start))
+	}
+	calleePGF, decl := findFuncDecl(pkg, fn)
+	if decl == nil || decl.Body == nil {
+		return nil, fmt.Errorf("cannot inline %s: its body is not available", fn.Name())
+	}
+	in := &inliner{
+		fset:   fset,
+		info:   info,
+		pkg:    pkg.GetTypes(),
+		caller: pgf,
+		callee: calleePGF,
+		path:   path,
+		call:   path[0].(*ast.CallExpr),
+		fn:     fn,
+		decl:   decl,
+		subst:  make(map[types.Object]string),
+		taken:  make(map[string]bool),
+	}
+	in.scope = scopeAt(info, path, in.call.Pos())
+	edits, err := in.inline()
+	if err != nil {
+		return nil, fmt.Errorf("cannot inline %s: %v", fn.Name(), err)
+	}
+	return &analysis.SuggestedFix{TextEdits: edits}, nil
+}
+
+// callAt returns the path to the innermost call enclosing rng whose
+// callee is a declared function or method, along with that callee.
+func callAt(rng span.Range, file *ast.File, info *types.Info) ([]ast.Node, *types.Func) {
+	path, _ := astutil.PathEnclosingInterval(file, rng.Start, rng.End)
+	for i, n := range path {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			continue
+		}
+		var id *ast.Ident
+		switch fun := astutil.Unparen(call.Fun).(type) {
+		case *ast.Ident:
+			id = fun
+		case *ast.SelectorExpr:
+			id = fun.Sel
+		}
+		if id == nil {
+			continue
+		}
+		if fn, ok := info.Uses[id].(*types.Func); ok {
+			return path[i:], fn
+		}
+	}
+	return nil, nil
+}
+
+// findFuncDecl returns the declaration of fn among the files of pkg.
+func findFuncDecl(pkg Package, fn *types.Func) (*ParsedGoFile, *ast.FuncDecl) {
+	if fn.Pkg() != pkg.GetTypes() {
+		return nil, nil
+	}
+	for _, pgf := range pkg.CompiledGoFiles() {
+		for _, decl := range pgf.File.Decls {
+			if decl, ok := decl.(*ast.FuncDecl); ok && decl.Name.Pos() == fn.Pos() {
+				return pgf, decl
+			}
+		}
+	}
+	return nil, nil
+}
+
+// An inliner holds the state of the inlining of a single call.
+type inliner struct {
+	fset           *token.FileSet
+	info           *types.Info
+	pkg            *types.Package
+	caller, callee *ParsedGoFile
+	path           []ast.Node // path from the call to the root of the caller
+	call           *ast.CallExpr
+	fn             *types.Func
+	decl           *ast.FuncDecl
+	scope          *types.Scope // innermost scope enclosing the call
+
+	subst map[types.Object]string // replacement text for params and locals
+	taken map[string]bool         // names of the variables introduced so far
+
+	// effects is set if the arguments or the body may have side effects,
+	// which the reads of memory by a substituted argument must not follow.
+	effects bool
+}
+
+func (in *inliner) inline() ([]analysis.TextEdit, error) {
+	sig := in.fn.Type().(*types.Signature)
+	if sig.Variadic() {
+		return nil, fmt.Errorf("it is variadic")
+	}
+	body := in.decl.Body
+	stmts, final, err := in.checkBody(sig)
+	if err != nil {
+		return nil, err
+	}
+	switch in.path[1].(type) {
+	case *ast.GoStmt, *ast.DeferStmt:
+		return nil, fmt.Errorf("the call is in a go or defer statement")
+	}
+
+	// Statements can only be inserted before the call if it is evaluated
+	// first in its statement.
+	stmt, hoistable := in.enclosingStmt()
+
+	args, err := in.arguments(sig)
+	if err != nil {
+		return nil, err
+	}
+
+	// Rename the callee's top-level locals that would collide with names
+	// at the call site.
+	fnScope := in.info.Scopes[in.decl.Type]
+	for _, name := range fnScope.Names() {
+		obj := fnScope.Lookup(name)
+		if !NodeContains(body, obj.Pos()) {
+			continue // a parameter or result
+		}
+		if newName := in.freshName(name); newName != name {
+			in.subst[obj] = newName
+		}
+	}
+
+	in.effects = hasSideEffects(in.info, body, nil)
+	for _, arg := range args {
+		in.effects = in.effects || !arg.pure
+	}
+	var pre []string // statements to insert before the call
+	for _, arg := range args {
+		pre = append(pre, in.bindArgument(arg)...)
+	}
+	if (len(pre) > 0 || len(stmts) > 0) && !hoistable {
+		return nil, fmt.Errorf("statements would have to be inserted before the call, which is not possible here")
+	}
+	if len(stmts) > 0 {
+		text := in.rewrite(stmts[0].Pos(), stmts[len(stmts)-1].End())
+		pre = append(pre, reindent(text, in.indent(in.callee, stmts[0]), in.indent(in.caller, stmt)))
+	}
+
+	var results []string
+	if final != nil {
+		for i, e := range final.Results {
+			text := in.rewrite(e.Pos(), e.End())
+			if len(final.Results) == sig.Results().Len() {
+				text = in.convert(e, text, sig.Results().At(i).Type())
+			}
+			results = append(results, text)
+		}
+	}
+
+	tok := in.fset.File(in.call.Pos())
+	if _, ok := stmt.(*ast.ExprStmt); ok && hoistable {
+		// The results of the call are discarded, but their evaluation may
+		// still have side effects.
+		if final != nil && !in.allPure(final.Results) {
+			switch call, ok := final.Results[0].(*ast.CallExpr); {
+			case len(results) == 1 && ok && !in.info.Types[call.Fun].IsType():
+				pre = append(pre, results[0])
+			default:
+				blanks := strings.Repeat("_, ", len(results)-1) + "_"
+				pre = append(pre, blanks+" = "+strings.Join(results, ", "))
+			}
+		}
+		if len(pre) == 0 {
+			start, end := lineBounds(in.caller.Src, tok, stmt)
+			return []analysis.TextEdit{{Pos: start, End: end}}, nil
+		}
+		indent := in.indent(in.caller, stmt)
+		return []analysis.TextEdit{{
+			Pos:     stmt.Pos(),
+			End:     stmt.End(),
+			NewText: []byte(strings.Join(pre, "\n"+indent)),
+		}}, nil
+	}
+
+	if len(results) == 0 {
+		return nil, fmt.Errorf("it has no results")
+	}
+	text := strings.Join(results, ", ")
+	if len(results) == 1 && needsParens(in.path[1], in.call) && !isPrimary(final.Results[0]) {
+		text = "(" + text + ")"
+	}
+	edits := []analysis.TextEdit{{
+		Pos:     in.call.Pos(),
+		End:     in.call.End(),
+		NewText: []byte(text),
+	}}
+	if len(pre) > 0 {
+		indent := in.indent(in.caller, stmt)
+		edits = append(edits, analysis.TextEdit{
+			Pos:     stmt.Pos(),
+			End:     stmt.Pos(),
+			NewText: []byte(strings.Join(pre, "\n"+indent) + "\n" + indent),
+		})
+	}
+	return edits, nil
+}
+
+// checkBody reports whether the callee's body can be inlined, returning
+// the statements that precede its final return statement, if any.
+func (in *inliner) checkBody(sig *types.Signature) ([]ast.Stmt, *ast.ReturnStmt, error) {
+	body := in.decl.Body
+	stmts := body.List
+	var final *ast.ReturnStmt
+	if len(stmts) > 0 {
+		if ret, ok := stmts[len(stmts)-1].(*ast.ReturnStmt); ok {
+			final = ret
+			stmts = stmts[:len(stmts)-1]
+		}
+	}
+	if sig.Results().Len() > 0 && (final == nil || len(final.Results) == 0) {
+		return nil, nil, fmt.Errorf("it does not end with a return statement with results")
+	}
+
+	results := make(map[types.Object]bool)
+	for i := 0; i < sig.Results().Len(); i++ {
+		results[sig.Results().At(i)] = true
+	}
+	var err error
+	ast.Inspect(body, func(n ast.Node) bool {
+		if err != nil {
+			return false
+		}
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			// Returns and defers in function literals are not our concern,
+			// but recursion is.
+			ast.Inspect(n.Body, func(n ast.Node) bool {
+				if id, ok := n.(*ast.Ident); ok && in.info.Uses[id] == in.fn {
+					err = fmt.Errorf("it is recursive")
+				}
+				return err == nil
+			})
+			return false
+		case *ast.DeferStmt:
+			err = fmt.Errorf("it contains a defer statement")
+		case *ast.LabeledStmt:
+			err = fmt.Errorf("it contains labeled statements")
+		case *ast.ReturnStmt:
+			if n != final {
+				err = fmt.Errorf("it has multiple return statements")
+			}
+		case *ast.Ident:
+			if obj := in.info.Uses[n]; obj == in.fn {
+				err = fmt.Errorf("it is recursive")
+			} else if results[obj] {
+				err = fmt.Errorf("it refers to its named results")
+			}
+		}
+		return err == nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return stmts, final, in.checkReferences()
+}
+
+// checkReferences reports an error if a package-level object referenced by
+// the callee's body would resolve to something else at the call site.
+func (in *inliner) checkReferences() error {
+	var err error
+	ast.Inspect(in.decl.Body, func(n ast.Node) bool {
+		id, ok := n.(*ast.Ident)
+		if !ok || err != nil {
+			return err == nil
+		}
+		obj := in.info.Uses[id]
+		if obj == nil {
+			return true
+		}
+		if pkgName, ok := obj.(*types.PkgName); ok {
+			found, ok := in.lookup(id.Name).(*types.PkgName)
+			if !ok || found.Imported() != pkgName.Imported() {
+				err = fmt.Errorf("package %s is not imported as %s at the call site", pkgName.Imported().Path(), id.Name)
+			}
+			return true
+		}
+		if obj.Parent() != in.pkg.Scope() && obj.Parent() != types.Universe {
+			return true
+		}
+		if in.lookup(id.Name) != obj {
+			err = fmt.Errorf("%s is shadowed at the call site", id.Name)
+		}
+		return true
+	})
+	return err
+}
+
+// enclosingStmt returns the statement containing the call. It reports
+// whether statements can be inserted before that statement without
+// changing the order of evaluation, which is the case when the statement
+// is in a statement list and the call is the first thing it evaluates.
+func (in *inliner) enclosingStmt() (ast.Stmt, bool) {
+	var stmt ast.Stmt
+	var i int
+	for i = 1; i < len(in.path); i++ {
+		if s, ok := in.path[i].(ast.Stmt); ok {
+			stmt = s
+			break
+		}
+	}
+	if stmt == nil || i+1 >= len(in.path) {
+		return nil, false
+	}
+	switch in.path[i+1].(type) {
+	case *ast.BlockStmt, *ast.CaseClause, *ast.CommClause:
+	default:
+		return stmt, false
+	}
+	call := ast.Expr(in.call)
+	switch stmt := stmt.(type) {
+	case *ast.ExprStmt:
+		return stmt, stmt.X == call
+	case *ast.AssignStmt:
+		for _, lhs := range stmt.Lhs {
+			if _, ok := lhs.(*ast.Ident); !ok {
+				return stmt, false
+			}
+		}
+		return stmt, len(stmt.Rhs) == 1 && stmt.Rhs[0] == call
+	case *ast.ReturnStmt:
+		return stmt, len(stmt.Results) == 1 && stmt.Results[0] == call
+	case *ast.DeclStmt:
+		decl := stmt.Decl.(*ast.GenDecl)
+		if len(decl.Specs) != 1 {
+			return stmt, false
+		}
+		spec, ok := decl.Specs[0].(*ast.ValueSpec)
+		return stmt, ok && len(spec.Values) == 1 && spec.Values[0] == call
+	}
+	return stmt, false
+}
+
+// An argument is the value passed for a parameter of the callee.
+type argument struct {
+	param *types.Var
+	expr  ast.Expr // the argument as written
+	text  string   // the text of the argument, converted to the type of param
+	pure  bool
+
+	// implicit is set for a receiver whose address is taken or which is
+	// dereferenced implicitly by the call. It is still valid as is if the
+	// parameter is only used as the operand of selectors.
+	implicit bool
+}
+
+// arguments returns the arguments of the call, starting with the receiver
+// if the callee is a method.
+func (in *inliner) arguments(sig *types.Signature) ([]*argument, error) {
+	var args []*argument
+	if recv := sig.Recv(); recv != nil {
+		sel, ok := astutil.Unparen(in.call.Fun).(*ast.SelectorExpr)
+		if !ok {
+			return nil, fmt.Errorf("the method is not called through a selector")
+		}
+		selection := in.info.Selections[sel]
+		if selection == nil || selection.Kind() != types.MethodVal {
+			return nil, fmt.Errorf("it is called as a method expression")
+		}
+		if len(selection.Index()) > 1 {
+			return nil, fmt.Errorf("it is a promoted method")
+		}
+		arg := &argument{
+			param: recv,
+			expr:  sel.X,
+			text:  in.callerText(sel.X),
+			pure:  in.isPure(sel.X),
+		}
+		// Make explicit the implicit address or dereference of the receiver.
+		_, wantPtr := recv.Type().(*types.Pointer)
+		_, isPtr := in.info.TypeOf(sel.X).(*types.Pointer)
+		switch {
+		case wantPtr && !isPtr:
+			arg.text, arg.implicit = "&"+parenthesize(sel.X, arg.text), true
+		case !wantPtr && isPtr:
+			arg.text, arg.implicit = "*"+parenthesize(sel.X, arg.text), true
+		}
+		args = append(args, arg)
+	}
+	params := sig.Params()
+	if len(in.call.Args) != params.Len() {
+		return nil, fmt.Errorf("its arguments are the results of a call")
+	}
+	for i, e := range in.call.Args {
+		param := params.At(i)
+		args = append(args, &argument{
+			param: param,
+			expr:  e,
+			text:  in.convert(e, in.callerText(e), param.Type()),
+			pure:  in.isPure(e),
+		})
+	}
+	return args, nil
+}
+
+// bindArgument records how the parameter of arg is replaced in the body,
+// returning the statements, if any, that must precede the body.
+func (in *inliner) bindArgument(arg *argument) []string {
+	param := arg.param
+	var uses, selected int
+	ast.Inspect(in.decl.Body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.SelectorExpr:
+			if id, ok := n.X.(*ast.Ident); ok && in.info.Uses[id] == param {
+				selected++
+			}
+		case *ast.Ident:
+			if in.info.Uses[n] == param {
+				uses++
+			}
+		}
+		return true
+	})
+	if uses == 0 {
+		if arg.pure {
+			return nil
+		}
+		return []string{"_ = " + arg.text}
+	}
+
+	substitute := !in.isModified(param) && !in.captures(arg.expr) &&
+		(in.isSimple(arg.expr) || arg.pure && uses == 1 && !(in.effects && in.readsMemory(arg.expr)))
+	if substitute && arg.implicit {
+		// Selectors take the address of, or dereference, their operand
+		// as needed, so the receiver can be used as written.
+		if selected == uses {
+			in.subst[param] = parenthesize(arg.expr, in.callerText(arg.expr))
+			return nil
+		}
+		substitute = false
+	}
+	if substitute {
+		in.subst[param] = parenthesize(arg.expr, arg.text)
+		return nil
+	}
+	name := in.freshName(param.Name())
+	if name != param.Name() {
+		in.subst[param] = name
+	}
+	return []string{name + " := " + arg.text}
+}
+
+// isModified reports whether the callee may modify the variable v, other
+// than through a pointer it holds.
+func (in *inliner) isModified(v *types.Var) bool {
+	modified := false
+	isV := func(e ast.Expr) bool {
+		id, ok := storageIdent(in.info, e)
+		return ok && in.info.Uses[id] == v
+	}
+	ast.Inspect(in.decl.Body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			for _, lhs := range n.Lhs {
+				modified = modified || isV(lhs)
+			}
+		case *ast.IncDecStmt:
+			modified = modified || isV(n.X)
+		case *ast.RangeStmt:
+			if n.Tok == token.ASSIGN {
+				modified = modified || (n.Key != nil && isV(n.Key)) || (n.Value != nil && isV(n.Value))
+			}
+		case *ast.UnaryExpr:
+			modified = modified || (n.Op == token.AND && isV(n.X))
+		case *ast.SelectorExpr:
+			// Calling a pointer method on a variable takes its address.
+			if sel := in.info.Selections[n]; sel != nil && sel.Kind() == types.MethodVal && isV(n.X) {
+				if _, ptrRecv := sel.Obj().Type().(*types.Signature).Recv().Type().(*types.Pointer); ptrRecv {
+					_, isPtr := in.info.TypeOf(n.X).Underlying().(*types.Pointer)
+					modified = modified || !isPtr
+				}
+			}
+		}
+		return !modified
+	})
+	return modified
+}
+
+// storageIdent returns the variable whose own storage is written by an
+// assignment to e, such as x in x.f[i] for a struct x with an array field
+// f. There is none if e writes through a pointer, slice, or map.
+func storageIdent(info *types.Info, e ast.Expr) (*ast.Ident, bool) {
+	for {
+		switch x := e.(type) {
+		case *ast.Ident:
+			return x, true
+		case *ast.ParenExpr:
+			e = x.X
+		case *ast.SelectorExpr:
+			if sel := info.Selections[x]; sel == nil || sel.Indirect() {
+				return nil, false
+			}
+			if _, ok := info.TypeOf(x.X).Underlying().(*types.Pointer); ok {
+				return nil, false
+			}
+			e = x.X
+		case *ast.IndexExpr:
+			if _, ok := info.TypeOf(x.X).Underlying().(*types.Array); !ok {
+				return nil, false
+			}
+			e = x.X
+		default:
+			return nil, false
+		}
+	}
+}
+
+// captures reports whether an identifier in e would refer to a different
+// object if e were moved into the callee's body.
+func (in *inliner) captures(e ast.Expr) bool {
+	declared := make(map[string]bool)
+	for id, obj := range in.info.Defs {
+		if obj != nil && NodeContains(in.decl.Body, id.Pos()) {
+			declared[id.Name] = true
+		}
+	}
+	captured := false
+	ast.Inspect(e, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok && declared[id.Name] {
+			captured = true
+		}
+		return !captured
+	})
+	return captured
+}
+
+// rewrite returns the callee's source between start and end, with its
+// parameters and locals replaced according to in.subst.
+func (in *inliner) rewrite(start, end token.Pos) string {
+	type replacement struct {
+		pos, end token.Pos
+		text     string
+	}
+	var replacements []replacement
+	ast.Inspect(in.decl.Body, func(n ast.Node) bool {
+		id, ok := n.(*ast.Ident)
+		if !ok || id.Pos() < start || id.End() > end {
+			return true
+		}
+		obj := in.info.Uses[id]
+		if obj == nil {
+			obj = in.info.Defs[id]
+		}
+		if text, ok := in.subst[obj]; ok && obj != nil {
+			replacements = append(replacements, replacement{id.Pos(), id.End(), text})
+		}
+		return true
+	})
+	sort.Slice(replacements, func(i, j int) bool {
+		return replacements[i].pos < replacements[j].pos
+	})
+	tok := in.callee.Tok
+	var b strings.Builder
+	offset := tok.Offset(start)
+	for _, r := range replacements {
+		b.Write(in.callee.Src[offset:tok.Offset(r.pos)])
+		b.WriteString(r.text)
+		offset = tok.Offset(r.end)
+	}
+	b.Write(in.callee.Src[offset:tok.Offset(end)])
+	return b.String()
+}
+
+// freshName returns name, or a variant of it, that refers to nothing at
+// the call site and is not used by the callee's body.
+func (in *inliner) freshName(name string) string {
+	inUse := func(name string) bool {
+		if in.taken[name] || in.lookup(name) != nil {
+			return true
+		}
+		// Names declared later in the block of the call would conflict
+		// with the new declaration.
+		if in.scope != nil && in.scope.Lookup(name) != nil {
+			return true
+		}
+		return in.usedByCallee(name)
+	}
+	newName := name
+	for i := 1; inUse(newName); i++ {
+		newName = fmt.Sprintf("%s%d", name, i)
+	}
+	in.taken[newName] = true
+	return newName
+}
+
+// usedByCallee reports whether the callee's body refers to a package-level
+// object named name.
+func (in *inliner) usedByCallee(name string) bool {
+	used := false
+	ast.Inspect(in.decl.Body, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok && id.Name == name {
+			if obj := in.info.Uses[id]; obj != nil && !NodeContains(in.decl, obj.Pos()) {
+				used = true
+			}
+		}
+		return !used
+	})
+	return used
+}
+
+func (in *inliner) lookup(name string) types.Object {
+	if in.scope == nil {
+		return nil
+	}
+	_, obj := in.scope.LookupParent(name, in.call.Pos())
+	return obj
+}
+
+func (in *inliner) callerText(e ast.Expr) string {
+	tok := in.caller.Tok
+	return string(in.caller.Src[tok.Offset(e.Pos()):tok.Offset(e.End())])
+}
+
+func (in *inliner) indent(pgf *ParsedGoFile, n ast.Node) string {
+	return calculateIndentation(pgf.Src, pgf.Tok, n)
+}
+
+// convert returns text, the source of e, converted to typ for use at the
+// call site.
+func (in *inliner) convert(e ast.Expr, text string, typ types.Type) string {
+	return convertExpr(in.info, in.pkg, in.caller.File, e, text, typ)
+}
+
+func (in *inliner) isPure(e ast.Expr) bool   { return isPure(in.info, e) }
+func (in *inliner) isSimple(e ast.Expr) bool { return isSimple(in.info, e) }
+
+// readsMemory reports whether the value of e depends on memory that side
+// effects elsewhere could modify: memory reached through a pointer, slice,
+// map or field, a package-level variable, or a local variable that may be
+// modified through a pointer or a call.
+func (in *inliner) readsMemory(e ast.Expr) bool {
+	reads := false
+	ast.Inspect(e, func(n ast.Node) bool {
+		if reads {
+			return false
+		}
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.StarExpr:
+			reads = !in.info.Types[n].IsType()
+		case *ast.IndexExpr:
+			reads = in.info.Types[n].Value == nil
+		case *ast.SelectorExpr:
+			if sel := in.info.Selections[n]; sel != nil && sel.Kind() == types.FieldVal {
+				reads = true
+			}
+		case *ast.Ident:
+			if v, ok := in.info.Uses[n].(*types.Var); ok && !v.IsField() {
+				reads = v.Pkg() != nil && v.Parent() == v.Pkg().Scope() || isReassigned(in.info, in.caller.File, v)
+			}
+		}
+		return !reads
+	})
+	return reads
+}
+
+func (in *inliner) allPure(exprs []ast.Expr) bool {
+	for _, e := range exprs {
+		if !in.isPure(e) {
+			return false
+		}
+	}
+	return true
+}
+
+// CanInlineVariable reports whether the identifier at rng refers to a
+// local variable that is assigned exactly once, in its declaration.
+func CanInlineVariable(rng span.Range, file *ast.File, info *types.Info) (*types.Var, bool) {
+	v, _, _, err := inlinableVariable(rng, file, info)
+	return v, err == nil
+}
+
+// inlineVariable replaces the references to the variable at rng with its
+// initial value and removes its declaration.
+func inlineVariable(fset *token.FileSet, rng span.Range, src []byte, file *ast.File, pkg *types.Package, info *types.Info) (*analysis.SuggestedFix, error) {
+	v, stmt, value, err := inlinableVariable(rng, file, info)
+	if err != nil {
+		return nil, fmt.Errorf("cannot inline variable: %v", err)
+	}
+	if !isPure(info, value) {
+		return nil, fmt.Errorf("cannot inline %s: its value may have side effects", v.Name())
+	}
+	var uses []*ast.Ident
+	for id, obj := range info.Uses {
+		if obj == v {
+			uses = append(uses, id)
+		}
+	}
+	if len(uses) == 0 {
+		return nil, fmt.Errorf("cannot inline %s: it is never used", v.Name())
+	}
+	// A value that is not evaluated the same way wherever it appears may
+	// only replace a single use, with nothing in between that could change
+	// the memory it reads.
+	if !isDuplicable(info, pkg, value) {
+		if len(uses) > 1 {
+			return nil, fmt.Errorf("cannot inline %s: its value would be evaluated %d times", v.Name(), len(uses))
+		}
+		if err := checkIntervening(info, file, stmt, uses[0]); err != nil {
+			return nil, fmt.Errorf("cannot inline %s: %v", v.Name(), err)
+		}
+	}
+	// The variables used by the value must not change before the uses.
+	var free []*ast.Ident
+	ast.Inspect(value, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok {
+			if obj, ok := info.Uses[id].(*types.Var); ok && obj.Parent() != pkg.Scope() {
+				free = append(free, id)
+			}
+		}
+		return true
+	})
+	for _, id := range free {
+		if isReassigned(info, file, info.Uses[id]) {
+			return nil, fmt.Errorf("cannot inline %s: %s may change before its uses", v.Name(), id.Name)
+		}
+	}
+
+	tok := fset.File(file.Pos())
+	text := string(src[tok.Offset(value.Pos()):tok.Offset(value.End())])
+	text = convertExpr(info, pkg, file, value, text, v.Type())
+
+	var edits []analysis.TextEdit
+	for _, id := range uses {
+		path, _ := astutil.PathEnclosingInterval(file, id.Pos(), id.End())
+		scope := scopeAt(info, path, id.Pos())
+		for _, f := range free {
+			if _, obj := scope.LookupParent(f.Name, id.Pos()); obj != info.Uses[f] {
+				return nil, fmt.Errorf("cannot inline %s: %s is shadowed at %s", v.Name(), f.Name, fset.Position(id.Pos()))
+			}
+		}
+		newText := text
+		if needsParens(path[1], id) {
+			newText = parenthesize(value, text)
+		}
+		edits = append(edits, analysis.TextEdit{Pos: id.Pos(), End: id.End(), NewText: []byte(newText)})
+	}
+	// The edits are applied in order, so start from the end of the file.
+	sort.Slice(edits, func(i, j int) bool {
+		return edits[i].Pos > edits[j].Pos
+	})
+	start, end := lineBounds(src, tok, stmt)
+	return &analysis.SuggestedFix{
+		TextEdits: append(edits, analysis.TextEdit{Pos: start, End: end}),
+	}, nil
+}
+
+// inlinableVariable returns the local variable referred to by the
+// identifier at rng, along with the statement declaring it and its value.
+func inlinableVariable(rng span.Range, file *ast.File, info *types.Info) (*types.Var, ast.Stmt, ast.Expr, error) {
+	path, _ := astutil.PathEnclosingInterval(file, rng.Start, rng.End)
+	if len(path) == 0 {
+		return nil, nil, nil, fmt.Errorf("no identifier")
+	}
+	id, ok := path[0].(*ast.Ident)
+	if !ok {
+		return nil, nil, nil, fmt.Errorf("no identifier")
+	}
+	v, ok := info.ObjectOf(id).(*types.Var)
+	if !ok || v.IsField() || v.Parent() == nil || v.Parent() == v.Pkg().Scope() {
+		return nil, nil, nil, fmt.Errorf("%s is not a local variable", id.Name)
+	}
+	declPath, _ := astutil.PathEnclosingInterval(file, v.Pos(), v.Pos())
+	if len(declPath) < 2 {
+		return nil, nil, nil, fmt.Errorf("%s has no declaration", id.Name)
+	}
+	var stmt ast.Stmt
+	var value ast.Expr
+	switch decl := declPath[1].(type) {
+	case *ast.AssignStmt:
+		if decl.Tok == token.DEFINE && len(decl.Lhs) == 1 && len(decl.Rhs) == 1 {
+			stmt, value = decl, decl.Rhs[0]
+		}
+	case *ast.ValueSpec:
+		if len(decl.Names) == 1 && len(decl.Values) == 1 && len(declPath) > 3 {
+			if gen := declPath[2].(*ast.GenDecl); len(gen.Specs) == 1 {
+				stmt, _ = declPath[3].(*ast.DeclStmt)
+				value = decl.Values[0]
+			}
+		}
+	}
+	if stmt == nil {
+		return nil, nil, nil, fmt.Errorf("%s is not declared alone with a value", id.Name)
+	}
+	if isAssigned(info, file, v) {
+		return nil, nil, nil, fmt.Errorf("%s is assigned more than once", id.Name)
+	}
+	return v, stmt, value, nil
+}
+
+// isAssigned reports whether the variable obj is assigned, incremented, or
+// has its address taken anywhere in file, other than in its declaration.
+func isAssigned(info *types.Info, file *ast.File, obj types.Object) bool {
+	return modifiesVar(info, file, obj, false)
+}
+
+// isReassigned is like isAssigned, but also reports whether obj is passed to
+// a call, or has a method called on it, that could modify what it refers
+// to. Such calls matter to the reads of memory through obj.
+func isReassigned(info *types.Info, file *ast.File, obj types.Object) bool {
+	return modifiesVar(info, file, obj, true)
+}
+
+func modifiesVar(info *types.Info, file *ast.File, obj types.Object, calls bool) bool {
+	isObj := func(e ast.Expr) bool {
+		id, ok := rootIdent(e)
+		return ok && info.Uses[id] == obj
+	}
+	modified := false
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			for _, lhs := range n.Lhs {
+				modified = modified || isObj(lhs)
+			}
+		case *ast.IncDecStmt:
+			modified = modified || isObj(n.X)
+		case *ast.RangeStmt:
+			if n.Tok == token.ASSIGN {
+				modified = modified || (n.Key != nil && isObj(n.Key)) || (n.Value != nil && isObj(n.Value))
+			}
+		case *ast.UnaryExpr:
+			modified = modified || (n.Op == token.AND && isObj(n.X))
+		case *ast.SelectorExpr:
+			if sel := info.Selections[n]; sel != nil && sel.Kind() == types.MethodVal && isObj(n.X) {
+				_, ptrRecv := sel.Obj().Type().(*types.Signature).Recv().Type().(*types.Pointer)
+				_, isPtr := info.TypeOf(n.X).Underlying().(*types.Pointer)
+				modified = modified || ptrRecv && !isPtr || calls && hasReferences(info.TypeOf(n.X))
+			}
+		case *ast.CallExpr:
+			if !calls || info.Types[n.Fun].IsType() || isBuiltin(info, n.Fun, "len", "cap") {
+				break
+			}
+			for _, arg := range n.Args {
+				modified = modified || (isObj(arg) && hasReferences(info.TypeOf(arg)))
+			}
+		}
+		return !modified
+	})
+	return modified
+}
+
+// isBuiltin reports whether fun refers to one of the named builtins.
+func isBuiltin(info *types.Info, fun ast.Expr, names ...string) bool {
+	id, ok := astutil.Unparen(fun).(*ast.Ident)
+	if !ok {
+		return false
+	}
+	b, ok := info.Uses[id].(*types.Builtin)
+	if !ok {
+		return false
+	}
+	for _, name := range names {
+		if b.Name() == name {
+			return true
+		}
+	}
+	return false
+}
+
+// hasReferences reports whether a value of type t refers to memory that a
+// function it is passed to could modify.
+func hasReferences(t types.Type) bool {
+	if t == nil {
+		return true
+	}
+	switch t := t.Underlying().(type) {
+	case *types.Basic, *types.Signature:
+		return false
+	case *types.Array:
+		return hasReferences(t.Elem())
+	case *types.Struct:
+		for i := 0; i < t.NumFields(); i++ {
+			if hasReferences(t.Field(i).Type()) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// rootIdent returns the variable at the root of an addressable expression,
+// such as x in x.f[i].
+func rootIdent(e ast.Expr) (*ast.Ident, bool) {
+	for {
+		switch x := e.(type) {
+		case *ast.Ident:
+			return x, true
+		case *ast.ParenExpr:
+			e = x.X
+		case *ast.SelectorExpr:
+			e = x.X
+		case *ast.IndexExpr:
+			e = x.X
+		case *ast.StarExpr:
+			e = x.X
+		default:
+			return nil, false
+		}
+	}
+}
+
+// isPure reports whether evaluating e has no side effects.
+func isPure(info *types.Info, e ast.Expr) bool {
+	if tv, ok := info.Types[e]; ok && tv.Value != nil {
+		return true
+	}
+	switch e := e.(type) {
+	case *ast.Ident, *ast.BasicLit, *ast.FuncLit:
+		return true
+	case *ast.ParenExpr:
+		return isPure(info, e.X)
+	case *ast.SelectorExpr:
+		return isPure(info, e.X)
+	case *ast.StarExpr:
+		return isPure(info, e.X)
+	case *ast.UnaryExpr:
+		return e.Op != token.ARROW && isPure(info, e.X)
+	case *ast.BinaryExpr:
+		return isPure(info, e.X) && isPure(info, e.Y)
+	case *ast.IndexExpr:
+		return isPure(info, e.X) && isPure(info, e.Index)
+	case *ast.SliceExpr:
+		for _, x := range []ast.Expr{e.Low, e.High, e.Max} {
+			if x != nil && !isPure(info, x) {
+				return false
+			}
+		}
+		return isPure(info, e.X)
+	case *ast.KeyValueExpr:
+		return isPure(info, e.Key) && isPure(info, e.Value)
+	case *ast.CompositeLit:
+		for _, elt := range e.Elts {
+			if !isPure(info, elt) {
+				return false
+			}
+		}
+		return true
+	case *ast.CallExpr:
+		// Only conversions and allocations are free of side effects.
+		if !info.Types[e.Fun].IsType() && !isBuiltin(info, e.Fun, "make", "new") {
+			return false
+		}
+		for _, arg := range e.Args {
+			if !info.Types[arg].IsType() && !isPure(info, arg) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// isSimple reports whether e is cheap enough to be evaluated any number of
+// times.
+func isSimple(info *types.Info, e ast.Expr) bool {
+	if tv, ok := info.Types[e]; ok && tv.Value != nil {
+		return true
+	}
+	switch e := e.(type) {
+	case *ast.Ident, *ast.BasicLit:
+		return true
+	case *ast.SelectorExpr:
+		// A qualified identifier.
+		if id, ok := e.X.(*ast.Ident); ok {
+			_, ok := info.Uses[id].(*types.PkgName)
+			return ok
+		}
+	}
+	return false
+}
+
+// isDuplicable reports whether e, which is pure, evaluates to the same
+// value wherever it is used: it is a constant, nil, a function literal, or
+// a local variable, function, or constant.
+func isDuplicable(info *types.Info, pkg *types.Package, e ast.Expr) bool {
+	if tv, ok := info.Types[e]; ok && (tv.Value != nil || tv.IsNil()) {
+		return true
+	}
+	switch e := astutil.Unparen(e).(type) {
+	case *ast.FuncLit:
+		return true
+	case *ast.Ident:
+		switch obj := info.Uses[e].(type) {
+		case *types.Var:
+			return obj.Parent() != pkg.Scope()
+		case *types.Func, *types.Const:
+			return true
+		}
+	}
+	return false
+}
+
+// checkIntervening reports an error if something that is evaluated after
+// the declaration stmt but before use, the only use of the variable it
+// declares, could change the value of the variable's initializer.
+func checkIntervening(info *types.Info, file *ast.File, stmt ast.Stmt, use *ast.Ident) error {
+	path, _ := astutil.PathEnclosingInterval(file, use.Pos(), use.End())
+	declPath, _ := astutil.PathEnclosingInterval(file, stmt.Pos(), stmt.End())
+	if len(declPath) < 2 {
+		return fmt.Errorf("its declaration is not in a block")
+	}
+	var list []ast.Stmt
+	switch block := declPath[1].(type) {
+	case *ast.BlockStmt:
+		list = block.List
+	case *ast.CaseClause:
+		list = block.Body
+	case *ast.CommClause:
+		list = block.Body
+	default:
+		return fmt.Errorf("its declaration is not in a block")
+	}
+	// Find the statement of the block that contains the use, and the calls
+	// and statements whose operands include the use, whose own effects
+	// follow it.
+	var top ast.Node
+	after := make(map[ast.Node]bool)
+	for i, n := range path {
+		switch n.(type) {
+		case *ast.ForStmt, *ast.RangeStmt:
+			return fmt.Errorf("it is used in a loop")
+		case *ast.FuncLit:
+			return fmt.Errorf("it is used in a function literal")
+		case *ast.CallExpr:
+			after[n] = true
+		}
+		if i+1 < len(path) && path[i+1] == declPath[1] {
+			top = n
+			break
+		}
+	}
+	if top == nil {
+		return fmt.Errorf("it is not used in the block of its declaration")
+	}
+	after[top] = true
+	for _, s := range list {
+		if s.Pos() <= stmt.Pos() {
+			continue
+		}
+		if s == top {
+			break
+		}
+		if hasSideEffects(info, s, nil) {
+			return fmt.Errorf("a statement before its use may change its value")
+		}
+	}
+	if hasSideEffects(info, top, after) {
+		return fmt.Errorf("its use is evaluated along with side effects that may change its value")
+	}
+	return nil
+}
+
+// hasSideEffects reports whether evaluating n may have side effects other
+// than allocation. The effects of the nodes in except itself, but not of
+// their operands, are ignored.
+func hasSideEffects(info *types.Info, n ast.Node, except map[ast.Node]bool) bool {
+	effects := false
+	ast.Inspect(n, func(n ast.Node) bool {
+		if effects || n == nil {
+			return false
+		}
+		if except[n] {
+			return true
+		}
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			return false // declaring a closure does nothing
+		case *ast.AssignStmt:
+			effects = n.Tok != token.DEFINE
+		case *ast.IncDecStmt, *ast.SendStmt, *ast.GoStmt, *ast.DeferStmt:
+			effects = true
+		case *ast.UnaryExpr:
+			effects = n.Op == token.ARROW
+		case *ast.CallExpr:
+			if info.Types[n.Fun].IsType() {
+				break
+			}
+			if isBuiltin(info, n.Fun, "len", "cap") {
+				break
+			}
+			effects = true
+		}
+		return !effects
+	})
+	return effects
+}
+
+// isPrimary reports whether e can be an operand of any operator without
+// being parenthesized.
+func isPrimary(e ast.Expr) bool {
+	switch e.(type) {
+	case *ast.Ident, *ast.BasicLit, *ast.CompositeLit, *ast.ParenExpr,
+		*ast.SelectorExpr, *ast.IndexExpr, *ast.SliceExpr, *ast.TypeAssertExpr,
+		*ast.CallExpr:
+		return true
+	}
+	return false
+}
+
+// needsParens reports whether an expression that is not primary must be
+// parenthesized when it replaces child in parent.
+func needsParens(parent, child ast.Node) bool {
+	switch parent := parent.(type) {
+	case *ast.BinaryExpr, *ast.UnaryExpr, *ast.StarExpr, *ast.SelectorExpr,
+		*ast.SliceExpr, *ast.TypeAssertExpr:
+		return true
+	case *ast.IndexExpr:
+		return parent.X == child
+	case *ast.CallExpr:
+		return parent.Fun == child
+	}
+	return false
+}
+
+// parenthesize returns text, the source of e, in parentheses if e is not
+// primary.
+func parenthesize(e ast.Expr, text string) string {
+	if e == nil || !isPrimary(e) {
+		return "(" + text + ")"
+	}
+	return text
+}
+
+// convertExpr returns text, the source of e, converted to typ if e does
+// not already have that type when it stands alone.
+func convertExpr(info *types.Info, pkg *types.Package, file *ast.File, e ast.Expr, text string, typ types.Type) string {
+	tv := info.Types[e]
+	if isUntyped(info, e) {
+		// The recorded type of an untyped constant is the one it is
+		// converted to, but moved elsewhere it gets its default type.
+		if types.Identical(defaultType(tv.Value), typ) {
+			return text
+		}
+	} else if tv.Type != nil && types.Identical(tv.Type, typ) {
+		return text
+	}
+	name := types.TypeString(typ, Qualifier(file, pkg, info))
+	if strings.HasPrefix(name, "*") || strings.HasPrefix(name, "<-") || strings.HasPrefix(name, "func") {
+		name = "(" + name + ")"
+	}
+	return name + "(" + text + ")"
+}
+
+// isUntyped reports whether e is an untyped constant or nil.
+func isUntyped(info *types.Info, e ast.Expr) bool {
+	if tv := info.Types[e]; tv.IsNil() {
+		return true
+	} else if tv.Value == nil {
+		return false
+	}
+	switch e := e.(type) {
+	case *ast.BasicLit:
+		return true
+	case *ast.ParenExpr:
+		return isUntyped(info, e.X)
+	case *ast.UnaryExpr:
+		return isUntyped(info, e.X)
+	case *ast.BinaryExpr:
+		return isUntyped(info, e.X) && isUntyped(info, e.Y)
+	case *ast.Ident, *ast.SelectorExpr:
+		var id *ast.Ident
+		if sel, ok := e.(*ast.SelectorExpr); ok {
+			id = sel.Sel
+		} else {
+			id = e.(*ast.Ident)
+		}
+		if c, ok := info.Uses[id].(*types.Const); ok {
+			basic, ok := c.Type().(*types.Basic)
+			return ok && basic.Info()&types.IsUntyped != 0
+		}
+	}
+	return false
+}
+
+// defaultType returns the type that an untyped constant with the given
+// value has when it is not converted.
+func defaultType(v constant.Value) types.Type {
+	if v == nil {
+		return types.Typ[types.UntypedNil]
+	}
+	switch v.Kind() {
+	case constant.Bool:
+		return types.Typ[types.Bool]
+	case constant.String:
+		return types.Typ[types.String]
+	case constant.Int:
+		return types.Typ[types.Int]
+	case constant.Float:
+		return types.Typ[types.Float64]
+	case constant.Complex:
+		return types.Typ[types.Complex128]
+	}
+	return nil
+}
+
+// scopeAt returns the innermost scope on path that encloses pos.
+func scopeAt(info *types.Info, path []ast.Node, pos token.Pos) *types.Scope {
+	for _, scope := range CollectScopes(info, path, pos) {
+		if scope != nil {
+			return scope
+		}
+	}
+	return nil
+}
+
+// lineBounds returns the range to delete in order to remove n from the
+// source. It covers the whole lines of n if no other code shares them.
+func lineBounds(src []byte, tok *token.File, n ast.Node) (token.Pos, token.Pos) {
+	start, end := tok.Offset(n.Pos()), tok.Offset(n.End())
+	lstart, lend := lineStart(src, start), lineEnd(src, end)
+	if strings.TrimSpace(string(src[lstart:start])) != "" || strings.TrimSpace(string(src[end:lend])) != "" {
+		return n.Pos(), n.End()
+	}
+	if lend < len(src) {
+		lend++ // include the newline
+	}
+	return tok.Pos(lstart), tok.Pos(lend)
+}
+
+// reindent replaces the indentation from of the lines after the first in
+// text with the indentation to.
+func reindent(text, from, to string) string {
+	lines := strings.Split(text, "\n")
+	for i := 1; i < len(lines); i++ {
+		if lines[i] != "" {
+			lines[i] = to + strings.TrimPrefix(lines[i], from)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,244 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package source
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/tools/internal/event"
+	"golang.org/x/tools/internal/imports"
+	"golang.org/x/tools/internal/lsp/protocol"
+	"golang.org/x/tools/internal/span"
+	errors "golang.org/x/xerrors"
+)
+
+// CanMoveDeclarations reports whether the given range selects top-level
+// declarations, other than imports, that can be moved to a new file.
+func CanMoveDeclarations(file *ast.File, rng span.Range) bool {
+	return len(selectedDecls(file, rng)) > 0
+}
+
+// MoveDeclarations moves the top-level declarations selected by pRng to a
+// new file in the same directory and package. It returns the URI and
+// content of the new file, along with the edits that remove the
+// declarations from their current file. The new file imports what it uses,
+// and the imports that the current file no longer uses are removed from it;
+// the rest of the current file is left as is.
+func MoveDeclarations(ctx context.Context, snapshot Snapshot, fh FileHandle, pRng protocol.Range) (span.URI, []byte, []protocol.TextEdit, error) {
+	ctx, done := event.Start(ctx, "source.MoveDeclarations")
+	defer done()
+
+	pgf, err := snapshot.ParseGo(ctx, fh, ParseFull)
+	if err != nil {
+		return "", nil, nil, errors.Errorf("getting file for MoveDeclarations: %w", err)
+	}
+	rng, err := pgf.Mapper.RangeToSpanRange(pRng)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	decls := selectedDecls(pgf.File, rng)
+	if len(decls) == 0 {
+		return "", nil, nil, fmt.Errorf("no declarations to move")
+	}
+
+	// The declarations are contiguous, so move the lines from the start of
+	// the first one, including its doc comment, to the end of the last.
+	start := lineStart(pgf.Src, pgf.Tok.Offset(regionStart(decls[0])))
+	end := lineEnd(pgf.Src, pgf.Tok.Offset(decls[len(decls)-1].End()))
+	if end < len(pgf.Src) {
+		end++ // include the newline
+	}
+	moved := pgf.Src[start:end]
+
+	newURI, err := newDeclFile(ctx, snapshot, pgf.URI, decls[0])
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	// Give the new file the build constraints, package clause, and imports
+	// of the original file, and let goimports remove the unused imports.
+	var buf bytes.Buffer
+	for _, cg := range pgf.File.Comments {
+		if cg.Pos() >= pgf.File.Package {
+			break
+		}
+		for _, c := range cg.List {
+			if strings.HasPrefix(c.Text, "//go:build") || strings.HasPrefix(c.Text, "// +build") {
+				fmt.Fprintf(&buf, "%s\n", c.Text)
+			}
+		}
+	}
+	if buf.Len() > 0 {
+		buf.WriteString("\n")
+	}
+	fmt.Fprintf(&buf, "package %s\n\n", pgf.File.Name.Name)
+	for _, decl := range pgf.File.Decls {
+		if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.IMPORT {
+			buf.Write(pgf.Src[pgf.Tok.Offset(gen.Pos()):pgf.Tok.Offset(gen.End())])
+			buf.WriteString("\n")
+		}
+	}
+	buf.WriteString("\n")
+	buf.Write(moved)
+
+	// Remove the declarations, along with the blank lines that follow them.
+	for end < len(pgf.Src) && pgf.Src[end] == '\n' {
+		end++
+	}
+	remaining := make([]byte, 0, len(pgf.Src)-(end-start))
+	remaining = append(remaining, pgf.Src[:start]...)
+	remaining = append(remaining, pgf.Src[end:]...)
+
+	// The rest of the original file is left as is, apart from the imports
+	// that it no longer uses.
+	var newContent []byte
+	var edits []protocol.TextEdit
+	if err := snapshot.RunProcessEnvFunc(ctx, func(opts *imports.Options) error {
+		var err error
+		if newContent, err = imports.Process(newURI.Filename(), buf.Bytes(), opts); err != nil {
+			return err
+		}
+		fixes, err := imports.FixImports(pgf.URI.Filename(), remaining, opts)
+		if err != nil {
+			return err
+		}
+		var unused []*imports.ImportFix
+		for _, fix := range fixes {
+			if fix.FixType == imports.DeleteImport {
+				unused = append(unused, fix)
+			}
+		}
+		if len(unused) > 0 {
+			edits, err = computeFixEdits(snapshot, pgf, opts, unused)
+		}
+		return err
+	}); err != nil {
+		return "", nil, nil, errors.Errorf("computing imports: %w", err)
+	}
+	removed, err := NewMappedRange(snapshot.FileSet(), pgf.Mapper, pgf.Tok.Pos(start), pgf.Tok.Pos(end)).Range()
+	if err != nil {
+		return "", nil, nil, err
+	}
+	edits = append(edits, protocol.TextEdit{Range: removed})
+	return newURI, newContent, edits, nil
+}
+
+// selectedDecls returns the top-level declarations, other than imports,
+// whose first token lies within rng. Selecting text inside a declaration
+// does not select the declaration itself.
+func selectedDecls(file *ast.File, rng span.Range) []ast.Decl {
+	var decls []ast.Decl
+	for _, decl := range file.Decls {
+		if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.IMPORT {
+			continue
+		}
+		if decl.Pos() < rng.Start || decl.Pos() >= rng.End {
+			continue
+		}
+		decls = append(decls, decl)
+	}
+	return decls
+}
+
+// newDeclFile returns the URI of a file that does not yet exist, next to
+// the file uri, named after the first name declared by decl.
+func newDeclFile(ctx context.Context, snapshot Snapshot, uri span.URI, decl ast.Decl) (span.URI, error) {
+	name, suffix := declFileName(decl), fileNameSuffix(uri.Filename())
+	dir := filepath.Dir(uri.Filename())
+	for i := 0; ; i++ {
+		base := name
+		if i > 0 {
+			base = fmt.Sprintf("%s%d", name, i)
+		}
+		newURI := span.URIFromPath(filepath.Join(dir, base+suffix))
+		fh, err := snapshot.GetFile(ctx, newURI)
+		if err != nil {
+			return "", err
+		}
+		if _, err := fh.Read(); err != nil {
+			return newURI, nil
+		}
+	}
+}
+
+// declFileName returns the base name, without the .go extension, of a file
+// named after the first name declared by decl.
+func declFileName(decl ast.Decl) string {
+	name := "decls"
+	switch decl := decl.(type) {
+	case *ast.FuncDecl:
+		name = decl.Name.Name
+	case *ast.GenDecl:
+		if len(decl.Specs) > 0 {
+			switch spec := decl.Specs[0].(type) {
+			case *ast.TypeSpec:
+				name = spec.Name.Name
+			case *ast.ValueSpec:
+				name = spec.Names[0].Name
+			}
+		}
+	}
+	// The go command ignores files whose names begin with "_", and gives
+	// files whose names end in _test, _GOOS, or _GOARCH a meaning that the
+	// declarations did not have.
+	name = strings.TrimLeft(strings.ToLower(name), "_")
+	if name == "" {
+		return "decls"
+	}
+	elems := strings.Split(name, "_")
+	if last := elems[len(elems)-1]; last == "test" || knownOS[last] || knownArch[last] {
+		name += "_decl"
+	}
+	return name
+}
+
+// fileNameSuffix returns the _GOOS, _GOARCH, and _test suffixes of the
+// file name, along with its .go extension, so that the declarations moved
+// out of the file are built under the same conditions.
+func fileNameSuffix(filename string) string {
+	suffix := ".go"
+	base := strings.TrimSuffix(filepath.Base(filename), ".go")
+	if strings.HasSuffix(base, "_test") {
+		base = strings.TrimSuffix(base, "_test")
+		suffix = "_test.go"
+	}
+	// As in go/build, the first element of the name is never a suffix.
+	elems := strings.Split(base, "_")[1:]
+	if n := len(elems); n > 0 && knownArch[elems[n-1]] {
+		suffix = "_" + elems[n-1] + suffix
+		elems = elems[:n-1]
+	}
+	if n := len(elems); n > 0 && knownOS[elems[n-1]] {
+		suffix = "_" + elems[n-1] + suffix
+	}
+	return suffix
+}
+
+// knownOS and knownArch are the values of GOOS and GOARCH that the go command
+// recognizes as file name suffixes, as listed in go/build/syslist.go.
+var (
+	knownOS = map[string]bool{
+		"aix": true, "android": true, "darwin": true, "dragonfly": true,
+		"freebsd": true, "hurd": true, "illumos": true, "ios": true,
+		"js": true, "linux": true, "nacl": true, "netbsd": true,
+		"openbsd": true, "plan9": true, "solaris": true, "windows": true,
+		"zos": true,
+	}
+	knownArch = map[string]bool{
+		"386": true, "amd64": true, "amd64p32": true, "arm": true,
+		"armbe": true, "arm64": true, "arm64be": true, "ppc64": true,
+		"ppc64le": true, "loong64": true, "mips": true, "mipsle": true,
+		"mips64": true, "mips64le": true, "mips64p32": true,
+		"mips64p32le": true, "ppc": true, "riscv": true, "riscv64": true,
+		"s390": true, "s390x": true, "sparc": true, "sparc64": true,
+		"wasm": true,
+	}
+)
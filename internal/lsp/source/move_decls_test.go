@@ -0,0 +1,52 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package source
+
+import (
+	"go/parser"
+	"go/token"
+	"testing"
+)
+
+func TestDeclFileName(t *testing.T) {
+	for _, test := range []struct {
+		decl, want string
+	}{
+		{"func Point() {}", "point"},
+		{"type Linux int", "linux_decl"},
+		{"func Test() {}", "test_decl"},
+		{"var foo_test int", "foo_test_decl"},
+		{"const x_amd64 = 1", "x_amd64_decl"},
+		{"var _ int", "decls"},
+		{"var _private int", "private"},
+		{"func linuxThings() {}", "linuxthings"},
+	} {
+		f, err := parser.ParseFile(token.NewFileSet(), "p.go", "package p\n"+test.decl, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := declFileName(f.Decls[0]); got != test.want {
+			t.Errorf("declFileName(%q) = %q, want %q", test.decl, got, test.want)
+		}
+	}
+}
+
+func TestFileNameSuffix(t *testing.T) {
+	for _, test := range []struct {
+		filename, want string
+	}{
+		{"/src/p/main.go", ".go"},
+		{"/src/p/main_test.go", "_test.go"},
+		{"/src/p/linux.go", ".go"},
+		{"/src/p/file_linux.go", "_linux.go"},
+		{"/src/p/file_amd64.go", "_amd64.go"},
+		{"/src/p/file_linux_amd64_test.go", "_linux_amd64_test.go"},
+		{"/src/p/file_other.go", ".go"},
+	} {
+		if got := fileNameSuffix(test.filename); got != test.want {
+			t.Errorf("fileNameSuffix(%q) = %q, want %q", test.filename, got, test.want)
+		}
+	}
+}
@@ -53,6 +53,7 @@ import (
 	"golang.org/x/tools/internal/lsp/analysis/simplifycompositelit"
 	"golang.org/x/tools/internal/lsp/analysis/simplifyrange"
 	"golang.org/x/tools/internal/lsp/analysis/simplifyslice"
+	"golang.org/x/tools/internal/lsp/analysis/stubmethods"
 	"golang.org/x/tools/internal/lsp/analysis/undeclaredname"
 	"golang.org/x/tools/internal/lsp/analysis/unusedparams"
 	"golang.org/x/tools/internal/lsp/command"
@@ -94,6 +95,7 @@ func DefaultOptions() *Options {
 						protocol.QuickFix:              true,
 						protocol.RefactorRewrite:       true,
 						protocol.RefactorExtract:       true,
+						protocol.RefactorInline:        true,
 					},
 					Mod: {
 						protocol.SourceOrganizeImports: true,
@@ -111,6 +113,7 @@ func DefaultOptions() *Options {
 				UIOptions: UIOptions{
 					DiagnosticOptions: DiagnosticOptions{
 						ExperimentalDiagnosticsDelay: 250 * time.Millisecond,
+						DiagnosticsScope:             OpenFilesScope,
 						Annotations: map[Annotation]bool{
 							Bounds: true,
 							Escape: true,
@@ -180,12 +183,15 @@ type ClientOptions struct {
 	ConfigurationSupported            bool
 	DynamicConfigurationSupported     bool
 	DynamicWatchedFilesSupported      bool
+	DynamicRenameFilesSupported       bool
 	PreferredContentFormat            protocol.MarkupKind
 	LineFoldingOnly                   bool
 	HierarchicalDocumentSymbolSupport bool
 	SemanticTypes                     []string
 	SemanticMods                      []string
 	RelatedInformationSupported       bool
+	CodeActionResolveSupported        bool
+	CreateFileSupported               bool
 }
 
 // ServerOptions holds LSP-specific configuration that is provided by the
@@ -204,6 +210,20 @@ type BuildOptions struct {
 	// Env adds environment variables to external commands run by `gopls`, most notably `go list`.
 	Env map[string]string
 
+	// Goroot is the GOROOT of the Go toolchain that runs the go command for
+	// the workspace folder, such as `/usr/local/go1.16`. By default, the go
+	// command on PATH is run.
+	Goroot string `status:"experimental"`
+
+	// ToolchainsDir is a directory of Go toolchains, such as `~/sdk` where
+	// `golang.org/dl` installs them. If it is set, and goroot is not, the go
+	// command is run from the toolchain for the version of the `go`
+	// directive of the go.mod file of the workspace folder: the latest
+	// patch release of that version in the directory, such as `go1.16.5`
+	// for `go 1.16`. The go command on PATH is run if there is no such
+	// toolchain.
+	ToolchainsDir string `status:"experimental"`
+
 	// DirectoryFilters can be used to exclude unwanted directories from the
 	// workspace. By default, all directories are included. Filters are an
 	// operator, `+` to include and `-` to exclude, followed by a path prefix
@@ -238,6 +258,14 @@ type BuildOptions struct {
 	// comprehensively test.
 	ExperimentalPackageCacheKey bool `status:"experimental"`
 
+	// ExperimentalPersistentCache controls whether to store the type
+	// information of dependencies and the results of analyses in a cache on
+	// disk, so that they are reused when gopls restarts. The cache is in the
+	// gopls subdirectory of the user's cache directory, or in the directory
+	// named by $GOPLSCACHE. It may be inspected and cleaned with the
+	// `gopls cache` command.
+	ExperimentalPersistentCache bool `status:"experimental"`
+
 	// AllowModfileModifications disables -mod=readonly, allowing imports from
 	// out-of-scope modules. This option will eventually be removed.
 	AllowModfileModifications bool `status:"experimental"`
@@ -253,6 +281,7 @@ type UIOptions struct {
 	CompletionOptions
 	NavigationOptions
 	DiagnosticOptions
+	InlayHintOptions
 
 	// Codelenses overrides the enabled/disabled state of code lenses. See the
 	// "Code Lenses" section of the
@@ -356,6 +385,18 @@ type DiagnosticOptions struct {
 	//
 	// This option must be set to a valid duration string, for example `"250ms"`.
 	ExperimentalDiagnosticsDelay time.Duration `status:"experimental"`
+
+	// DiagnosticsScope controls which packages are analyzed. Type errors are
+	// always reported for all workspace packages, but by default analyzers
+	// only run on the packages of open files. In the "Workspace" scope, all
+	// workspace packages are analyzed in the background after each change,
+	// and their diagnostics are published even if their files are not open.
+	DiagnosticsScope DiagnosticsScope `status:"experimental"`
+
+	// RequireIgnoreReason reports the `//lint:ignore` and `//lint:file-ignore`
+	// directives that suppress the diagnostics of analyzers without giving a
+	// reason.
+	RequireIgnoreReason bool `status:"experimental"`
 }
 
 type NavigationOptions struct {
@@ -380,6 +421,31 @@ type NavigationOptions struct {
 	SymbolStyle SymbolStyle `status:"advanced"`
 }
 
+// InlayHintOptions controls the inlay hints that gopls returns for the
+// gopls.inlay_hint command.
+type InlayHintOptions struct {
+	// ParameterNameHints enables inlay hints for the names of the parameters
+	// of functions at call sites, as in `NewServer(ctx: ctx, port: 0)`.
+	ParameterNameHints bool `status:"experimental"`
+
+	// AssignVariableTypeHints enables inlay hints for the types of the
+	// variables declared by `:=` assignments, as in `i int := 0`.
+	AssignVariableTypeHints bool `status:"experimental"`
+
+	// RangeVariableTypeHints enables inlay hints for the types of the
+	// variables declared by range statements, as in
+	// `for k int, v string := range s`.
+	RangeVariableTypeHints bool `status:"experimental"`
+
+	// ConstantValueHints enables inlay hints for the values of the constants
+	// of declarations that use iota, as in `KindPrint = 1`.
+	ConstantValueHints bool `status:"experimental"`
+
+	// CompositeLiteralFieldHints enables inlay hints for the names of the
+	// fields of unkeyed struct literals, as in `{in: "abc", want: "cba"}`.
+	CompositeLiteralFieldHints bool `status:"experimental"`
+}
+
 // UserOptions holds custom Gopls configuration (not part of the LSP) that is
 // modified by the client.
 type UserOptions struct {
@@ -526,6 +592,15 @@ const (
 	DynamicSymbols SymbolStyle = "Dynamic"
 )
 
+type DiagnosticsScope string
+
+const (
+	// OpenFilesScope runs analyzers only on the packages of open files.
+	OpenFilesScope DiagnosticsScope = "OpenFiles"
+	// WorkspaceScope runs analyzers on all workspace packages.
+	WorkspaceScope DiagnosticsScope = "Workspace"
+)
+
 type HoverKind string
 
 const (
@@ -604,6 +679,18 @@ func (o *Options) ForClientCapabilities(caps protocol.ClientCapabilities) {
 	o.ConfigurationSupported = caps.Workspace.Configuration
 	o.DynamicConfigurationSupported = caps.Workspace.DidChangeConfiguration.DynamicRegistration
 	o.DynamicWatchedFilesSupported = caps.Workspace.DidChangeWatchedFiles.DynamicRegistration
+	// Check if the client can create files in a workspace edit.
+	if we := caps.Workspace.WorkspaceEdit; we != nil {
+		for _, kind := range we.ResourceOperations {
+			if kind == protocol.Create {
+				o.CreateFileSupported = true
+				break
+			}
+		}
+	}
+	if fo := caps.Workspace.FileOperations; fo != nil {
+		o.DynamicRenameFilesSupported = fo.DynamicRegistration && (fo.WillRename || fo.DidRename)
+	}
 
 	// Check which types of content format are supported by this client.
 	if hover := caps.TextDocument.Hover; len(hover.ContentFormat) > 0 {
@@ -622,6 +709,16 @@ func (o *Options) ForClientCapabilities(caps protocol.ClientCapabilities) {
 
 	// Check if the client supports diagnostic related information.
 	o.RelatedInformationSupported = caps.TextDocument.PublishDiagnostics.RelatedInformation
+
+	// Check if the client can resolve code action edits lazily.
+	if ca := caps.TextDocument.CodeAction; ca.DataSupport {
+		for _, p := range ca.ResolveSupport.Properties {
+			if p == "edit" {
+				o.CodeActionResolveSupported = true
+				break
+			}
+		}
+	}
 }
 
 func (o *Options) Clone() *Options {
@@ -681,6 +778,13 @@ func (o *Options) AddStaticcheckAnalyzer(a *analysis.Analyzer) {
 // should be enabled in enableAllExperimentMaps.
 func (o *Options) enableAllExperiments() {
 	o.SemanticTokens = true
+	o.InlayHintOptions = InlayHintOptions{
+		ParameterNameHints:         true,
+		AssignVariableTypeHints:    true,
+		RangeVariableTypeHints:     true,
+		ConstantValueHints:         true,
+		CompositeLiteralFieldHints: true,
+	}
 }
 
 func (o *Options) enableAllExperimentMaps() {
@@ -717,6 +821,12 @@ func (o *Options) set(name string, value interface{}, seen map[string]struct{})
 			o.Env[k] = fmt.Sprint(v)
 		}
 
+	case "goroot":
+		result.setString(&o.Goroot)
+
+	case "toolchainsDir":
+		result.setString(&o.ToolchainsDir)
+
 	case "buildFlags":
 		iflags, ok := value.([]interface{})
 		if !ok {
@@ -849,6 +959,21 @@ func (o *Options) set(name string, value interface{}, seen map[string]struct{})
 	case "semanticTokens":
 		result.setBool(&o.SemanticTokens)
 
+	case "parameterNameHints":
+		result.setBool(&o.ParameterNameHints)
+
+	case "assignVariableTypeHints":
+		result.setBool(&o.AssignVariableTypeHints)
+
+	case "rangeVariableTypeHints":
+		result.setBool(&o.RangeVariableTypeHints)
+
+	case "constantValueHints":
+		result.setBool(&o.ConstantValueHints)
+
+	case "compositeLiteralFieldHints":
+		result.setBool(&o.CompositeLiteralFieldHints)
+
 	case "expandWorkspaceToModule":
 		result.setBool(&o.ExpandWorkspaceToModule)
 
@@ -858,9 +983,23 @@ func (o *Options) set(name string, value interface{}, seen map[string]struct{})
 	case "experimentalDiagnosticsDelay":
 		result.setDuration(&o.ExperimentalDiagnosticsDelay)
 
+	case "diagnosticsScope":
+		if s, ok := result.asOneOf(
+			string(OpenFilesScope),
+			string(WorkspaceScope),
+		); ok {
+			o.DiagnosticsScope = DiagnosticsScope(s)
+		}
+
+	case "requireIgnoreReason":
+		result.setBool(&o.RequireIgnoreReason)
+
 	case "experimentalPackageCacheKey":
 		result.setBool(&o.ExperimentalPackageCacheKey)
 
+	case "experimentalPersistentCache":
+		result.setBool(&o.ExperimentalPersistentCache)
+
 	case "allowModfileModifications":
 		result.setBool(&o.AllowModfileModifications)
 
@@ -1091,6 +1230,11 @@ func typeErrorAnalyzers() map[string]*Analyzer {
 			Analyzer: noresultvalues.Analyzer,
 			Enabled:  true,
 		},
+		stubmethods.Analyzer.Name: {
+			Analyzer: stubmethods.Analyzer,
+			Fix:      StubMethods,
+			Enabled:  true,
+		},
 		undeclaredname.Analyzer.Name: {
 			Analyzer: undeclaredname.Analyzer,
 			Fix:      UndeclaredName,
@@ -1146,7 +1290,7 @@ func defaultAnalyzers() map[string]*Analyzer {
 		shadow.Analyzer.Name:           {Analyzer: shadow.Analyzer, Enabled: false},
 		sortslice.Analyzer.Name:        {Analyzer: sortslice.Analyzer, Enabled: true},
 		testinggoroutine.Analyzer.Name: {Analyzer: testinggoroutine.Analyzer, Enabled: true},
-		unusedparams.Analyzer.Name:     {Analyzer: unusedparams.Analyzer, Enabled: false},
+		unusedparams.Analyzer.Name:     {Analyzer: unusedparams.Analyzer, Fix: RemoveParameter, Enabled: false},
 		unusedwrite.Analyzer.Name:      {Analyzer: unusedwrite.Analyzer, Enabled: false},
 
 		// gofmt -s suite:
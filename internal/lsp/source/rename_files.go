@@ -0,0 +1,290 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package source
+
+import (
+	"context"
+	"go/ast"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/internal/event"
+	"golang.org/x/tools/internal/lsp/protocol"
+	"golang.org/x/tools/internal/span"
+	errors "golang.org/x/xerrors"
+)
+
+// RenameFiles computes the edits that keep the workspace building when the
+// given files or directories are renamed. It is intended to be called before
+// the rename takes place, so the edits are keyed by the files' current URIs.
+//
+// Renaming a directory rewrites the import paths of every package it
+// contains, in all workspace packages that import them. If the directory
+// name was also the package name, the package clause is updated to match
+// the new directory name, and importers that relied on the implicit name
+// are given an explicit one so that their references remain valid, as
+// gomvpkg does.
+//
+// Renaming a single file updates its package clause when the file moves
+// into or out of a test file, or into a directory of a different package.
+func RenameFiles(ctx context.Context, snapshot Snapshot, renames []protocol.FileRename) (map[span.URI][]protocol.TextEdit, error) {
+	ctx, done := event.Start(ctx, "source.RenameFiles")
+	defer done()
+
+	files, err := workspaceGoFiles(ctx, snapshot)
+	if err != nil {
+		return nil, err
+	}
+	r := &fileRenamer{
+		snapshot: snapshot,
+		files:    files,
+		edits:    make(map[span.URI][]protocol.TextEdit),
+	}
+	for _, rename := range renames {
+		oldURI := span.URIFromURI(rename.OldURI)
+		newURI := span.URIFromURI(rename.NewURI)
+		if !oldURI.IsFile() || !newURI.IsFile() {
+			continue
+		}
+		from, to := oldURI.Filename(), newURI.Filename()
+		if r.isDir(from) {
+			if err := r.renameDir(from, to); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if strings.HasSuffix(from, ".go") && strings.HasSuffix(to, ".go") {
+			if err := r.renameFile(oldURI, to); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return r.edits, nil
+}
+
+// workspaceFile is a Go file belonging to a workspace package.
+type workspaceFile struct {
+	pgf     *ParsedGoFile
+	pkgPath string // import path of the package, empty for test variants
+}
+
+// workspaceGoFiles returns the Go files of all workspace packages, indexed
+// by URI. Files shared by a package and its test variant are reported once,
+// with the import path of the non-test package.
+func workspaceGoFiles(ctx context.Context, snapshot Snapshot) (map[span.URI]*workspaceFile, error) {
+	pkgs, err := snapshot.WorkspacePackages(ctx)
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[span.URI]*workspaceFile)
+	for _, pkg := range pkgs {
+		var pkgPath string
+		if pkg.ForTest() == "" {
+			pkgPath = pkg.PkgPath()
+		}
+		for _, pgf := range pkg.CompiledGoFiles() {
+			if f, ok := files[pgf.URI]; ok {
+				if f.pkgPath == "" {
+					f.pkgPath = pkgPath
+				}
+				continue
+			}
+			files[pgf.URI] = &workspaceFile{pgf: pgf, pkgPath: pkgPath}
+		}
+	}
+	return files, nil
+}
+
+type fileRenamer struct {
+	snapshot Snapshot
+	files    map[span.URI]*workspaceFile
+	edits    map[span.URI][]protocol.TextEdit
+}
+
+// isDir reports whether filename is a directory containing workspace files.
+func (r *fileRenamer) isDir(filename string) bool {
+	for uri := range r.files {
+		if inDirLex(filename, filepath.Dir(uri.Filename())) {
+			return true
+		}
+	}
+	return false
+}
+
+// renameDir computes the edits for moving the directory from to the
+// directory to.
+func (r *fileRenamer) renameDir(from, to string) error {
+	// destinations maps the import path of each package that moves to its
+	// new import path.
+	destinations := make(map[string]string)
+	var dirPkgPath string // import path of the package in from itself
+	for uri, f := range r.files {
+		if f.pkgPath == "" || !inDirLex(from, uri.Filename()) {
+			continue
+		}
+		if modURI := r.snapshot.GoModForFile(uri); modURI != "" && !inDirLex(filepath.Dir(modURI.Filename()), to) {
+			// Moves that leave the module cannot be expressed as a change
+			// of import path.
+			return errors.Errorf("%s is outside the module of %s", to, f.pkgPath)
+		}
+		// Import paths mirror the directory structure within a module, so
+		// the relative path between the package's old and new directories
+		// gives its new import path.
+		dir := filepath.Dir(uri.Filename())
+		sub, err := filepath.Rel(from, dir)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, filepath.Join(to, sub))
+		if err != nil {
+			return err
+		}
+		newPath := path.Join(f.pkgPath, filepath.ToSlash(rel))
+		if newPath == ".." || strings.HasPrefix(newPath, "../") {
+			return errors.Errorf("%s has no import path: it is above the root of %s", filepath.Join(to, sub), f.pkgPath)
+		}
+		destinations[f.pkgPath] = newPath
+		if dir == from {
+			dirPkgPath = f.pkgPath
+		}
+	}
+	if len(destinations) == 0 {
+		return nil
+	}
+
+	// Only rename the package if it was named after its directory.
+	oldName, newName := filepath.Base(from), filepath.Base(to)
+	renamePkg := oldName != newName && isValidIdentifier(newName)
+	if renamePkg {
+		for uri, f := range r.files {
+			if filepath.Dir(uri.Filename()) != from {
+				continue
+			}
+			if name := f.pgf.File.Name.Name; name != oldName && name != oldName+"_test" {
+				renamePkg = false
+				break
+			}
+		}
+	}
+	if renamePkg {
+		for uri, f := range r.files {
+			if filepath.Dir(uri.Filename()) != from {
+				continue
+			}
+			name := newName
+			if strings.HasSuffix(f.pgf.File.Name.Name, "_test") {
+				name += "_test"
+			}
+			if err := r.setPackageName(f.pgf, name); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, f := range r.files {
+		for _, imp := range f.pgf.File.Imports {
+			importPath, err := strconv.Unquote(imp.Path.Value)
+			if err != nil {
+				continue
+			}
+			newPath, ok := destinations[importPath]
+			if !ok {
+				continue
+			}
+			text := strconv.Quote(newPath)
+			if renamePkg && importPath == dirPkgPath && imp.Name == nil {
+				text = oldName + " " + text
+			}
+			if err := r.replace(f.pgf, imp.Path, text); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// renameFile computes the edits for renaming the file uri to the file
+// named to.
+func (r *fileRenamer) renameFile(uri span.URI, to string) error {
+	f, ok := r.files[uri]
+	if !ok {
+		return nil
+	}
+	from := uri.Filename()
+	oldName := f.pgf.File.Name.Name
+
+	base := strings.TrimSuffix(oldName, "_test")
+	toDir := filepath.Dir(to)
+	if toDir != filepath.Dir(from) {
+		if name := r.dirPackageName(toDir); name != "" {
+			base = name
+		}
+	}
+
+	newName := base
+	switch wasTest, isTest := isTestFile(from), isTestFile(to); {
+	case wasTest && isTest:
+		if strings.HasSuffix(oldName, "_test") {
+			newName += "_test"
+		}
+	case isTest:
+		// A file that becomes a test joins the external test package if
+		// that is what the other tests in the directory use.
+		if r.usesExternalTests(toDir) {
+			newName += "_test"
+		}
+	}
+	if newName == oldName {
+		return nil
+	}
+	return r.setPackageName(f.pgf, newName)
+}
+
+// dirPackageName returns the name of the non-test package in dir, or ""
+// if there is none.
+func (r *fileRenamer) dirPackageName(dir string) string {
+	for uri, f := range r.files {
+		if filepath.Dir(uri.Filename()) == dir && !isTestFile(uri.Filename()) {
+			return f.pgf.File.Name.Name
+		}
+	}
+	return ""
+}
+
+// usesExternalTests reports whether the test files in dir all belong to an
+// external test package.
+func (r *fileRenamer) usesExternalTests(dir string) bool {
+	external := false
+	for uri, f := range r.files {
+		if filepath.Dir(uri.Filename()) != dir || !isTestFile(uri.Filename()) {
+			continue
+		}
+		if !strings.HasSuffix(f.pgf.File.Name.Name, "_test") {
+			return false
+		}
+		external = true
+	}
+	return external
+}
+
+func (r *fileRenamer) setPackageName(pgf *ParsedGoFile, name string) error {
+	return r.replace(pgf, pgf.File.Name, name)
+}
+
+// replace replaces the text of node n with text.
+func (r *fileRenamer) replace(pgf *ParsedGoFile, n ast.Node, text string) error {
+	rng, err := NewMappedRange(r.snapshot.FileSet(), pgf.Mapper, n.Pos(), n.End()).Range()
+	if err != nil {
+		return err
+	}
+	r.edits[pgf.URI] = append(r.edits[pgf.URI], protocol.TextEdit{Range: rng, NewText: text})
+	return nil
+}
+
+func isTestFile(filename string) bool {
+	return strings.HasSuffix(filename, "_test.go")
+}
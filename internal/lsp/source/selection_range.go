@@ -0,0 +1,84 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package source
+
+import (
+	"context"
+	"go/ast"
+
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/internal/event"
+	"golang.org/x/tools/internal/lsp/protocol"
+	errors "golang.org/x/xerrors"
+)
+
+// SelectionRange returns, for each of the given positions, the chain of
+// syntactic ranges that enclose it. Each result is the innermost range, and
+// its Parent links lead outward through the enclosing nodes to the file.
+func SelectionRange(ctx context.Context, snapshot Snapshot, fh FileHandle, positions []protocol.Position) ([]protocol.SelectionRange, error) {
+	ctx, done := event.Start(ctx, "source.SelectionRange")
+	defer done()
+
+	pgf, err := snapshot.ParseGo(ctx, fh, ParseFull)
+	if err != nil {
+		return nil, errors.Errorf("getting file for SelectionRange: %w", err)
+	}
+	fset := snapshot.FileSet()
+
+	result := make([]protocol.SelectionRange, 0, len(positions))
+	for _, pos := range positions {
+		spn, err := pgf.Mapper.PointSpan(pos)
+		if err != nil {
+			return nil, err
+		}
+		rng, err := spn.Range(pgf.Mapper.Converter)
+		if err != nil {
+			return nil, err
+		}
+		path, _ := astutil.PathEnclosingInterval(pgf.File, rng.Start, rng.Start)
+		if len(path) == 0 {
+			return nil, errors.Errorf("no enclosing node found for %v:%v", int(pos.Line), int(pos.Character))
+		}
+
+		// Build the chain from the outside in, so that each range can point
+		// to the range that encloses it. Nodes that span exactly the same
+		// range as their parent (for example, an ExprStmt wrapping a
+		// CallExpr) are skipped, as they would not change the selection.
+		var parent *protocol.SelectionRange
+		for i := len(path) - 1; i >= 0; i-- {
+			n := path[i]
+			if !selectable(n) {
+				continue
+			}
+			prng, err := NewMappedRange(fset, pgf.Mapper, n.Pos(), n.End()).Range()
+			if err != nil {
+				return nil, err
+			}
+			if parent != nil && parent.Range == prng {
+				continue
+			}
+			parent = &protocol.SelectionRange{
+				Range:  prng,
+				Parent: parent,
+			}
+		}
+		if parent == nil {
+			return nil, errors.Errorf("no selection range found for %v:%v", int(pos.Line), int(pos.Character))
+		}
+		result = append(result, *parent)
+	}
+	return result, nil
+}
+
+// selectable reports whether n should contribute a range to the selection
+// chain. Bad nodes cover whatever text failed to parse, which is not a
+// meaningful unit to select.
+func selectable(n ast.Node) bool {
+	switch n.(type) {
+	case *ast.BadExpr, *ast.BadStmt, *ast.BadDecl:
+		return false
+	}
+	return n.Pos().IsValid() && n.End().IsValid()
+}
@@ -151,7 +151,7 @@ func (r *runner) CallHierarchy(t *testing.T, spn span.Span, expectedCalls *tests
 }
 
 func (r *runner) Diagnostics(t *testing.T, uri span.URI, want []*source.Diagnostic) {
-	fileID, got, err := source.FileDiagnostics(r.ctx, r.snapshot, uri)
+	fileID, got, err := source.FileDiagnostics(r.ctx, r.snapshot, uri, false)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -503,6 +503,39 @@ func (r *runner) Format(t *testing.T, spn span.Span) {
 	}
 }
 
+func (r *runner) SelectionRanges(t *testing.T, uri span.URI, spans []span.Span) {
+	m, err := r.data.Mapper(uri)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fh, err := r.snapshot.GetFile(r.ctx, uri)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var positions []protocol.Position
+	for _, spn := range spans {
+		rng, err := m.Range(spn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		positions = append(positions, rng.Start)
+	}
+	ranges, err := source.SelectionRange(r.ctx, r.snapshot, fh, positions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := tests.SelectionRangesString(m, ranges)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := string(r.data.Golden("selectionrange", uri.Filename(), func() ([]byte, error) {
+		return []byte(got), nil
+	}))
+	if want != got {
+		t.Errorf("selection ranges failed for %s:\n%s", uri.Filename(), tests.Diff(t, want, got))
+	}
+}
+
 func (r *runner) SemanticTokens(t *testing.T, spn span.Span) {
 	t.Skip("nothing to test in source")
 }
@@ -512,7 +545,11 @@ func (r *runner) Import(t *testing.T, spn span.Span) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	edits, _, err := source.AllImportsFixes(r.ctx, r.snapshot, fh)
+	fixes, err := source.ImportFixes(r.ctx, r.snapshot, fh)
+	if err != nil {
+		t.Error(err)
+	}
+	edits, err := source.ImportFixEdits(r.ctx, r.snapshot, fh, fixes)
 	if err != nil {
 		t.Error(err)
 	}
@@ -933,7 +970,10 @@ func (r *runner) SignatureHelp(t *testing.T, spn span.Span, want *protocol.Signa
 func (r *runner) Link(t *testing.T, uri span.URI, wantLinks []tests.Link) {}
 func (r *runner) SuggestedFix(t *testing.T, spn span.Span, actionKinds []string, expectedActions int) {
 }
+func (r *runner) SuggestedFixError(t *testing.T, spn span.Span, fixErr tests.SuggestedFixError) {
+}
 func (r *runner) FunctionExtraction(t *testing.T, start span.Span, end span.Span) {}
+func (r *runner) MethodExtraction(t *testing.T, start span.Span, end span.Span)   {}
 func (r *runner) CodeLens(t *testing.T, uri span.URI, want []protocol.CodeLens)   {}
 
 func spanToRange(data *tests.Data, spn span.Span) (*protocol.ColumnMapper, protocol.Range, error) {
@@ -0,0 +1,263 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package source
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/types"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/internal/imports"
+	"golang.org/x/tools/internal/lsp/analysis/stubmethods"
+	"golang.org/x/tools/internal/lsp/protocol"
+	"golang.org/x/tools/internal/span"
+	errors "golang.org/x/xerrors"
+)
+
+// stubMethods adds stubs for the methods that the concrete type at rng
+// lacks to implement the interface it is used as.
+func stubMethods(ctx context.Context, snapshot Snapshot, fh VersionedFileHandle, pgf *ParsedGoFile, rng span.Range) (map[span.URI][]protocol.TextEdit, error) {
+	pkg, _, err := GetParsedFile(ctx, snapshot, fh, NarrowestPackage)
+	if err != nil {
+		return nil, err
+	}
+	path, _ := astutil.PathEnclosingInterval(pgf.File, rng.Start, rng.End)
+	si := stubmethods.GetStubInfo(pkg.GetTypes(), pkg.GetTypesInfo(), path, rng.Start)
+	if si == nil {
+		return nil, errors.New("no conversion of a concrete type to an interface at position")
+	}
+	return stubEdits(snapshot, pkg, si)
+}
+
+// ImplementInterface returns the edits that add stubs for the methods that
+// the type at pos lacks to implement the named interface. The interface is
+// named by its package path and name, as in "io.Reader", or only by its
+// name if it is declared in the package of the type or is predeclared.
+func ImplementInterface(ctx context.Context, snapshot Snapshot, fh FileHandle, pos protocol.Position, ifaceName string) (map[span.URI][]protocol.TextEdit, error) {
+	pkg, pgf, err := GetParsedFile(ctx, snapshot, fh, NarrowestPackage)
+	if err != nil {
+		return nil, err
+	}
+	spn, err := pgf.Mapper.PointSpan(pos)
+	if err != nil {
+		return nil, err
+	}
+	rng, err := spn.Range(pgf.Mapper.Converter)
+	if err != nil {
+		return nil, err
+	}
+	path, _ := astutil.PathEnclosingInterval(pgf.File, rng.Start, rng.Start)
+	ident, ok := path[0].(*ast.Ident)
+	if !ok {
+		return nil, errors.New("no type name at position")
+	}
+	tname, ok := pkg.GetTypesInfo().ObjectOf(ident).(*types.TypeName)
+	if !ok || tname.Pkg() != pkg.GetTypes() {
+		return nil, errors.Errorf("%s is not a type declared in package %s", ident.Name, pkg.GetTypes().Name())
+	}
+	named, ok := tname.Type().(*types.Named)
+	if !ok || types.IsInterface(named) {
+		return nil, errors.Errorf("%s is not a concrete type", ident.Name)
+	}
+	iface := lookupInterface(pkg.GetTypes(), ifaceName)
+	if iface == nil {
+		return nil, errors.Errorf("no interface %s in scope of package %s", ifaceName, pkg.GetTypes().Name())
+	}
+	si := &stubmethods.StubInfo{
+		Concrete:  named,
+		Pointer:   hasPointerReceivers(named),
+		Interface: iface,
+	}
+	return stubEdits(snapshot, pkg, si)
+}
+
+// ListInterfaces returns the names of the interfaces that may be passed to
+// ImplementInterface for the package of fh: the interfaces declared at
+// package level in the package and the exported ones of its imports, and
+// the predeclared error interface.
+func ListInterfaces(ctx context.Context, snapshot Snapshot, fh FileHandle) ([]string, error) {
+	pkg, _, err := GetParsedFile(ctx, snapshot, fh, NarrowestPackage)
+	if err != nil {
+		return nil, err
+	}
+	names := []string{"error"}
+	addInterfaces := func(p *types.Package) {
+		for _, name := range p.Scope().Names() {
+			obj, ok := p.Scope().Lookup(name).(*types.TypeName)
+			if !ok || !types.IsInterface(obj.Type()) {
+				continue
+			}
+			if p == pkg.GetTypes() {
+				names = append(names, name)
+			} else if obj.Exported() {
+				names = append(names, p.Path()+"."+name)
+			}
+		}
+	}
+	addInterfaces(pkg.GetTypes())
+	for _, imp := range pkg.GetTypes().Imports() {
+		addInterfaces(imp)
+	}
+	sort.Strings(names[1:])
+	return names, nil
+}
+
+// lookupInterface returns the interface with the given name in the scope
+// of pkg, or in one of its transitive imports if the name is qualified by
+// a package path.
+func lookupInterface(pkg *types.Package, name string) types.Type {
+	var obj types.Object
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		path, name := name[:i], name[i+1:]
+		seen := make(map[*types.Package]bool)
+		queue := []*types.Package{pkg}
+		for len(queue) > 0 && obj == nil {
+			p := queue[0]
+			queue = queue[1:]
+			if p.Path() == path {
+				obj = p.Scope().Lookup(name)
+			}
+			for _, imp := range p.Imports() {
+				if !seen[imp] {
+					seen[imp] = true
+					queue = append(queue, imp)
+				}
+			}
+		}
+	} else {
+		_, obj = pkg.Scope().LookupParent(name, 0)
+	}
+	tname, ok := obj.(*types.TypeName)
+	if !ok || !types.IsInterface(tname.Type()) {
+		return nil
+	}
+	return tname.Type()
+}
+
+// hasPointerReceivers reports whether some method of named has a pointer
+// receiver.
+func hasPointerReceivers(named *types.Named) bool {
+	for i := 0; i < named.NumMethods(); i++ {
+		recv := named.Method(i).Type().(*types.Signature).Recv()
+		if _, ok := recv.Type().(*types.Pointer); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// stubEdits returns the edits that declare the methods that si.Concrete
+// lacks after its type declaration, and that import the packages that
+// their signatures refer to.
+func stubEdits(snapshot Snapshot, pkg Package, si *stubmethods.StubInfo) (map[span.URI][]protocol.TextEdit, error) {
+	missing, err := si.MissingMethods()
+	if err != nil {
+		return nil, err
+	}
+	if len(missing) == 0 {
+		return nil, errors.Errorf("%s already implements %s", si.Concrete.Obj().Name(), si.Interface)
+	}
+	pos := si.Concrete.Obj().Pos()
+	var pgf *ParsedGoFile
+	for _, f := range pkg.CompiledGoFiles() {
+		if f.File.Pos() <= pos && pos < f.File.End() {
+			pgf = f
+			break
+		}
+	}
+	if pgf == nil {
+		return nil, errors.Errorf("no file for the declaration of %s", si.Concrete.Obj().Name())
+	}
+	path, _ := astutil.PathEnclosingInterval(pgf.File, pos, pos)
+	var decl *ast.GenDecl
+	for _, n := range path {
+		if n, ok := n.(*ast.GenDecl); ok {
+			decl = n
+			break
+		}
+	}
+	if decl == nil {
+		return nil, errors.Errorf("%s is not declared at package level", si.Concrete.Obj().Name())
+	}
+
+	// Qualify the types of the stubs by the imports of the file of the
+	// type, adding those that are missing, as Qualifier does.
+	info := pkg.GetTypesInfo()
+	imported := make(map[*types.Package]string)
+	for _, imp := range pgf.File.Imports {
+		var obj types.Object
+		if imp.Name != nil {
+			obj = info.Defs[imp.Name]
+		} else {
+			obj = info.Implicits[imp]
+		}
+		pkgName, ok := obj.(*types.PkgName)
+		if !ok {
+			continue
+		}
+		switch name := pkgName.Name(); name {
+		case "_":
+			// A blank import declares no name to qualify with.
+		case ".":
+			imported[pkgName.Imported()] = ""
+		default:
+			imported[pkgName.Imported()] = name
+		}
+	}
+	// An added import is renamed if its name is already declared in the
+	// file or the package, or by another added import.
+	fileScope := info.Scopes[pgf.File]
+	added := make(map[string]bool)
+	declared := func(name string) bool {
+		return added[name] || (fileScope != nil && fileScope.Lookup(name) != nil) || pkg.GetTypes().Scope().Lookup(name) != nil
+	}
+	var fixes []*imports.ImportFix
+	qf := func(p *types.Package) string {
+		if p == pkg.GetTypes() {
+			return ""
+		}
+		if name, ok := imported[p]; ok {
+			return name
+		}
+		name := p.Name()
+		for i := 2; declared(name); i++ {
+			name = fmt.Sprintf("%s%d", p.Name(), i)
+		}
+		imported[p] = name
+		added[name] = true
+		fix := &imports.ImportFix{
+			StmtInfo:  imports.ImportInfo{ImportPath: p.Path()},
+			IdentName: name,
+			FixType:   imports.AddImport,
+		}
+		if name != imports.ImportPathToAssumedName(p.Path()) {
+			fix.StmtInfo.Name = name
+		}
+		fixes = append(fixes, fix)
+		return name
+	}
+	stubs := si.Stubs(missing, qf)
+
+	var edits []protocol.TextEdit
+	if len(fixes) > 0 {
+		edits, err = computeImportFixEdits(snapshot, pgf, fixes...)
+		if err != nil {
+			return nil, fmt.Errorf("adding imports for %s: %v", si.Concrete.Obj().Name(), err)
+		}
+	}
+	rng, err := NewMappedRange(snapshot.FileSet(), pgf.Mapper, decl.End(), decl.End()).Range()
+	if err != nil {
+		return nil, err
+	}
+	edits = append(edits, protocol.TextEdit{
+		Range:   rng,
+		NewText: "\n" + string(stubs[:len(stubs)-1]),
+	})
+	return map[span.URI][]protocol.TextEdit{pgf.URI: edits}, nil
+}
@@ -0,0 +1,393 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package source
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/types"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/internal/event"
+	"golang.org/x/tools/internal/imports"
+	"golang.org/x/tools/internal/lsp/protocol"
+	"golang.org/x/tools/internal/span"
+	errors "golang.org/x/xerrors"
+)
+
+// CanAddTest returns the declaration of the function or method whose name
+// or signature contains rng, if a test can be generated for it.
+func CanAddTest(pgf *ParsedGoFile, rng span.Range) (*ast.FuncDecl, bool) {
+	if strings.HasSuffix(pgf.URI.Filename(), "_test.go") {
+		return nil, false
+	}
+	for _, decl := range pgf.File.Decls {
+		decl, ok := decl.(*ast.FuncDecl)
+		if !ok || decl.Body == nil {
+			continue
+		}
+		if rng.Start < decl.Pos() || rng.End > decl.Body.Lbrace {
+			continue
+		}
+		switch name := decl.Name.Name; {
+		case name == "_" || name == "init":
+			return nil, false
+		case name == "main" && decl.Recv == nil && pgf.File.Name.Name == "main":
+			return nil, false
+		}
+		return decl, true
+	}
+	return nil, false
+}
+
+// AddTest generates a table-driven test of the function or method declared
+// at pRng, in the _test.go file that matches the file of the declaration.
+// It returns the URI of the test file. If that file does not exist, it
+// also returns its content, which declares the test in the external test
+// package if the other test files of the package do. Otherwise, it returns
+// the edits that append the test to the file and add the imports it needs.
+func AddTest(ctx context.Context, snapshot Snapshot, fh FileHandle, pRng protocol.Range) (span.URI, []byte, []protocol.TextEdit, error) {
+	ctx, done := event.Start(ctx, "source.AddTest")
+	defer done()
+
+	pkg, pgf, err := GetParsedFile(ctx, snapshot, fh, NarrowestPackage)
+	if err != nil {
+		return "", nil, nil, errors.Errorf("getting file for AddTest: %w", err)
+	}
+	rng, err := pgf.Mapper.RangeToSpanRange(pRng)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	decl, ok := CanAddTest(pgf, rng)
+	if !ok {
+		return "", nil, nil, fmt.Errorf("no function declaration to test")
+	}
+	fn, ok := pkg.GetTypesInfo().Defs[decl.Name].(*types.Func)
+	if !ok {
+		return "", nil, nil, fmt.Errorf("no type information for %s", decl.Name.Name)
+	}
+
+	testURI := span.URIFromPath(strings.TrimSuffix(pgf.URI.Filename(), ".go") + "_test.go")
+	testPGF, err := parseIfExists(ctx, snapshot, testURI)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	var external bool
+	if testPGF != nil {
+		external = testPGF.File.Name.Name != pgf.File.Name.Name
+		for _, d := range testPGF.File.Decls {
+			if d, ok := d.(*ast.FuncDecl); ok && d.Recv == nil && d.Name.Name == testName(fn) {
+				return "", nil, nil, fmt.Errorf("%s already declares %s", filepath.Base(testURI.Filename()), d.Name.Name)
+			}
+		}
+	} else {
+		external, err = usesExternalTests(ctx, snapshot, pgf)
+		if err != nil {
+			return "", nil, nil, err
+		}
+	}
+	if external && !accessible(fn) {
+		return "", nil, nil, fmt.Errorf("%s cannot be tested from package %s_test", fn.Name(), pkg.GetTypes().Name())
+	}
+
+	// Qualify the types of the test by the imports of the test file, and
+	// collect the ones it lacks. A blank import declares no name to qualify
+	// with, and a dot import makes the qualifier empty.
+	imported := make(map[string]string)
+	if testPGF != nil {
+		for _, imp := range testPGF.File.Imports {
+			name := ""
+			if imp.Name != nil {
+				name = imp.Name.Name
+			}
+			if name != "_" {
+				imported[ImportPath(imp)] = name
+			}
+		}
+	}
+	missing := make(map[string]bool)
+	importName := func(path, name string) string {
+		n, ok := imported[path]
+		switch {
+		case !ok:
+			missing[path] = true
+		case n == ".":
+			return ""
+		case n != "":
+			return n
+		}
+		return name
+	}
+	qf := func(p *types.Package) string {
+		if p == pkg.GetTypes() && !external {
+			return ""
+		}
+		return importName(p.Path(), p.Name())
+	}
+	g := &testGenerator{
+		fn:        fn,
+		qf:        qf,
+		testing:   importName("testing", "testing"),
+		importPkg: func(path, name string) string { return importName(path, name) },
+	}
+	if external {
+		g.pkgName = qf(pkg.GetTypes())
+	}
+	test := g.generate()
+
+	var fixes []*imports.ImportFix
+	for path := range missing {
+		fixes = append(fixes, &imports.ImportFix{
+			StmtInfo: imports.ImportInfo{ImportPath: path},
+			FixType:  imports.AddImport,
+		})
+	}
+	sort.Slice(fixes, func(i, j int) bool {
+		return fixes[i].StmtInfo.ImportPath < fixes[j].StmtInfo.ImportPath
+	})
+	if testPGF != nil {
+		var edits []protocol.TextEdit
+		if len(fixes) > 0 {
+			edits, err = computeImportFixEdits(snapshot, testPGF, fixes...)
+			if err != nil {
+				return "", nil, nil, err
+			}
+		}
+		eof := testPGF.Tok.Pos(testPGF.Tok.Size())
+		eofRng, err := NewMappedRange(snapshot.FileSet(), testPGF.Mapper, eof, eof).Range()
+		if err != nil {
+			return "", nil, nil, err
+		}
+		prefix := "\n"
+		if len(testPGF.Src) > 0 && testPGF.Src[len(testPGF.Src)-1] != '\n' {
+			prefix = "\n\n"
+		}
+		edits = append(edits, protocol.TextEdit{
+			Range:   eofRng,
+			NewText: prefix + string(test),
+		})
+		return testURI, nil, edits, nil
+	}
+
+	name := pgf.File.Name.Name
+	if external {
+		name += "_test"
+	}
+	src := []byte(fmt.Sprintf("package %s\n\n%s", name, test))
+	content, err := imports.ApplyFixes(fixes, testURI.Filename(), src, importFixOptions(snapshot), 0)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	return testURI, content, nil, nil
+}
+
+// parseIfExists parses the Go file with the given URI, or returns nil if it
+// does not exist.
+func parseIfExists(ctx context.Context, snapshot Snapshot, uri span.URI) (*ParsedGoFile, error) {
+	fh, err := snapshot.GetFile(ctx, uri)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := fh.Read(); err != nil {
+		return nil, nil
+	}
+	return snapshot.ParseGo(ctx, fh, ParseFull)
+}
+
+// usesExternalTests reports whether the test files in the directory of pgf
+// belong to the external test package.
+func usesExternalTests(ctx context.Context, snapshot Snapshot, pgf *ParsedGoFile) (bool, error) {
+	files, err := filepath.Glob(filepath.Join(filepath.Dir(pgf.URI.Filename()), "*_test.go"))
+	if err != nil {
+		return false, err
+	}
+	for _, file := range files {
+		fh, err := snapshot.GetFile(ctx, span.URIFromPath(file))
+		if err != nil {
+			return false, err
+		}
+		testPGF, err := snapshot.ParseGo(ctx, fh, ParseHeader)
+		if err != nil {
+			continue
+		}
+		if testPGF.File.Name.Name == pgf.File.Name.Name+"_test" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// accessible reports whether fn may be called from another package.
+func accessible(fn *types.Func) bool {
+	if !fn.Exported() {
+		return false
+	}
+	if recv := fn.Type().(*types.Signature).Recv(); recv != nil {
+		named, ok := Deref(recv.Type()).(*types.Named)
+		return ok && named.Obj().Exported()
+	}
+	return true
+}
+
+// testName returns the name of the test of fn, following the convention of
+// Example functions: TestF for a function, TestT_M for a method and
+// Test_f for an unexported function.
+func testName(fn *types.Func) string {
+	name := fn.Name()
+	if recv := fn.Type().(*types.Signature).Recv(); recv != nil {
+		if named, ok := Deref(recv.Type()).(*types.Named); ok {
+			name = named.Obj().Name() + "_" + name
+		}
+	}
+	if !ast.IsExported(name) {
+		return "Test_" + name
+	}
+	return "Test" + name
+}
+
+// A testGenerator generates the table-driven test of a function.
+type testGenerator struct {
+	fn      *types.Func
+	qf      types.Qualifier
+	pkgName string // qualifier of fn, if tested from the external package
+	testing string // qualifier of the testing package
+
+	// importPkg returns the name by which the test file refers to the
+	// package with the given path and name, importing it if needed.
+	importPkg func(path, name string) string
+}
+
+func (g *testGenerator) generate() []byte {
+	sig := g.fn.Type().(*types.Signature)
+	var buf bytes.Buffer
+	p := func(format string, args ...interface{}) {
+		fmt.Fprintf(&buf, format, args...)
+	}
+
+	// The fields of the args struct, one per parameter.
+	var args []string
+	params := sig.Params()
+	for i := 0; i < params.Len(); i++ {
+		name := params.At(i).Name()
+		if name == "" || name == "_" {
+			name = fmt.Sprintf("arg%d", i)
+		}
+		args = append(args, name)
+	}
+	// The want fields, one per result, except for a final error result
+	// which is checked by a wantErr field.
+	var wants []string
+	results := sig.Results()
+	nonErr := results.Len()
+	hasErr := nonErr > 0 && isErrorType(results.At(nonErr-1).Type())
+	if hasErr {
+		nonErr--
+	}
+	for i := 0; i < nonErr; i++ {
+		if i == 0 {
+			wants = append(wants, "want")
+		} else {
+			wants = append(wants, fmt.Sprintf("want%d", i))
+		}
+	}
+
+	name := g.fn.Name()
+	p("func %s(t *%s) {\n", testName(g.fn), qualified(g.testing, "T"))
+	if len(args) > 0 {
+		p("type args struct {\n")
+		for i, arg := range args {
+			typ := params.At(i).Type()
+			p("%s %s\n", arg, types.TypeString(typ, g.qf))
+		}
+		p("}\n")
+	}
+	p("tests := []struct {\nname string\n")
+	recv := sig.Recv()
+	if recv != nil {
+		p("recv %s\n", types.TypeString(recv.Type(), g.qf))
+	}
+	if len(args) > 0 {
+		p("args args\n")
+	}
+	for i, want := range wants {
+		p("%s %s\n", want, types.TypeString(results.At(i).Type(), g.qf))
+	}
+	if hasErr {
+		p("wantErr bool\n")
+	}
+	p("}{\n// TODO: Add test cases.\n}\n")
+
+	p("for _, tt := range tests {\n")
+	p("t.Run(tt.name, func(t *%s) {\n", qualified(g.testing, "T"))
+	var got []string
+	for i := range wants {
+		if i == 0 {
+			got = append(got, "got")
+		} else {
+			got = append(got, fmt.Sprintf("got%d", i))
+		}
+	}
+	if hasErr {
+		got = append(got, "err")
+	}
+	if len(got) > 0 {
+		p("%s := ", strings.Join(got, ", "))
+	}
+	switch {
+	case recv != nil:
+		p("tt.recv.")
+	case g.pkgName != "":
+		p("%s.", g.pkgName)
+	}
+	var callArgs []string
+	for _, arg := range args {
+		callArgs = append(callArgs, "tt.args."+arg)
+	}
+	ellipsis := ""
+	if sig.Variadic() {
+		ellipsis = "..."
+	}
+	p("%s(%s%s)\n", name, strings.Join(callArgs, ", "), ellipsis)
+	if hasErr {
+		p("if (err != nil) != tt.wantErr {\n")
+		p("t.Errorf(\"%s() error = %%v, wantErr %%v\", err, tt.wantErr)\n", name)
+		p("return\n}\n")
+	}
+	if len(wants) > 0 {
+		deepEqual := qualified(g.importPkg("reflect", "reflect"), "DeepEqual")
+		for i, want := range wants {
+			p("if !%s(%s, tt.%s) {\n", deepEqual, got[i], want)
+			p("t.Errorf(\"%s() %s = %%v, %s %%v\", %s, tt.%s)\n", name, got[i], want, got[i], want)
+			p("}\n")
+		}
+	}
+	p("})\n}\n}\n")
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		// The generated code is always valid, but keep it as is rather
+		// than losing it.
+		return buf.Bytes()
+	}
+	return src
+}
+
+// qualified returns name qualified by the package name qualifier, which is
+// empty for a dot import.
+func qualified(qualifier, name string) string {
+	if qualifier == "" {
+		return name
+	}
+	return qualifier + "." + name
+}
+
+func isErrorType(t types.Type) bool {
+	return types.Identical(t, types.Universe.Lookup("error").Type())
+}
@@ -0,0 +1,167 @@
+// Copyright 2021 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package source
+
+import (
+	"context"
+	"fmt"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"sort"
+
+	"golang.org/x/tools/internal/event"
+	"golang.org/x/tools/internal/lsp/protocol"
+	errors "golang.org/x/xerrors"
+)
+
+// PrepareTypeHierarchy returns the TypeHierarchyItem of the named type at
+// the position within the file.
+func PrepareTypeHierarchy(ctx context.Context, snapshot Snapshot, fh FileHandle, pp protocol.Position) ([]protocol.TypeHierarchyItem, error) {
+	ctx, done := event.Start(ctx, "source.PrepareTypeHierarchy")
+	defer done()
+
+	qo, err := typeNameAtProtocolPos(ctx, snapshot, fh, pp)
+	if err != nil || qo.obj == nil {
+		return nil, err
+	}
+	item, err := typeHierarchyItem(snapshot, qo.pkg, qo.obj)
+	if err != nil {
+		return nil, err
+	}
+	return []protocol.TypeHierarchyItem{item}, nil
+}
+
+// Supertypes returns the TypeHierarchyItems of the interfaces implemented by
+// the named type at the position within the file.
+func Supertypes(ctx context.Context, snapshot Snapshot, fh FileHandle, pp protocol.Position) ([]protocol.TypeHierarchyItem, error) {
+	ctx, done := event.Start(ctx, "source.Supertypes")
+	defer done()
+
+	return relatedTypes(ctx, snapshot, fh, pp, true)
+}
+
+// Subtypes returns the TypeHierarchyItems of the concrete types and
+// interfaces that implement the interface at the position within the file.
+func Subtypes(ctx context.Context, snapshot Snapshot, fh FileHandle, pp protocol.Position) ([]protocol.TypeHierarchyItem, error) {
+	ctx, done := event.Start(ctx, "source.Subtypes")
+	defer done()
+
+	return relatedTypes(ctx, snapshot, fh, pp, false)
+}
+
+// typeNameAtProtocolPos returns the named type referenced at the given
+// position, or a zero qualifiedObject if there is none.
+func typeNameAtProtocolPos(ctx context.Context, s Snapshot, fh FileHandle, pp protocol.Position) (qualifiedObject, error) {
+	qos, err := qualifiedObjsAtProtocolPos(ctx, s, fh, pp)
+	if err != nil {
+		if errors.Is(err, errNoObjectFound) || errors.Is(err, errBuiltin) {
+			return qualifiedObject{}, nil
+		}
+		return qualifiedObject{}, err
+	}
+	for _, qo := range qos {
+		obj, ok := qo.obj.(*types.TypeName)
+		if !ok {
+			continue
+		}
+		named, ok := obj.Type().(*types.Named)
+		// An alias of a type of another package is not followed, as qo.pkg
+		// does not declare the type.
+		if !ok || named.Obj().Pkg() != obj.Pkg() {
+			continue
+		}
+		qo.obj = named.Obj()
+		return qo, nil
+	}
+	return qualifiedObject{}, nil
+}
+
+// relatedTypes returns the TypeHierarchyItems of the named types of the
+// known packages that are supertypes of the named type at pp, if super is
+// set, or else its subtypes. The supertypes of a type are the non-empty
+// interfaces that it implements, and the subtypes of an interface are the
+// types that implement it, including other interfaces.
+//
+// Like Go, the hierarchy does not distinguish between direct and indirect
+// relations: all the interfaces implemented by a type are its supertypes.
+func relatedTypes(ctx context.Context, s Snapshot, fh FileHandle, pp protocol.Position, super bool) ([]protocol.TypeHierarchyItem, error) {
+	qo, err := typeNameAtProtocolPos(ctx, s, fh, pp)
+	if err != nil || qo.obj == nil {
+		return nil, err
+	}
+	queryType := ensurePointer(qo.obj.Type())
+	if !super && (!IsInterface(queryType) || types.NewMethodSet(queryType).Len() == 0) {
+		// Only interfaces have subtypes, and the empty interface would have
+		// all the types of the workspace.
+		return nil, nil
+	}
+
+	allNamed, pkgs, err := namedTypes(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		items []protocol.TypeHierarchyItem
+		fset  = s.FileSet()
+		seen  = map[token.Position]bool{
+			fset.Position(qo.obj.Pos()): true,
+		}
+	)
+	for _, named := range allNamed {
+		candObj := named.Obj()
+		if super {
+			if !IsInterface(named) || types.NewMethodSet(named).Len() == 0 || !types.AssignableTo(queryType, named) {
+				continue
+			}
+		} else if !types.AssignableTo(ensurePointer(named), queryType) {
+			continue
+		}
+		pos := fset.Position(candObj.Pos())
+		if seen[pos] {
+			continue
+		}
+		seen[pos] = true
+
+		pkg := pkgs[candObj.Pkg()]
+		if pkg == nil || len(pkg.CompiledGoFiles()) == 0 {
+			continue
+		}
+		item, err := typeHierarchyItem(s, pkg, candObj)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		ii, ij := items[i], items[j]
+		if ii.URI != ij.URI {
+			return ii.URI < ij.URI
+		}
+		return protocol.CompareRange(ii.Range, ij.Range) < 0
+	})
+	return items, nil
+}
+
+// typeHierarchyItem returns the TypeHierarchyItem of obj, which is declared
+// in pkg.
+func typeHierarchyItem(snapshot Snapshot, pkg Package, obj types.Object) (protocol.TypeHierarchyItem, error) {
+	rng, err := objToMappedRange(snapshot, pkg, obj)
+	if err != nil {
+		return protocol.TypeHierarchyItem{}, err
+	}
+	pr, err := rng.Range()
+	if err != nil {
+		return protocol.TypeHierarchyItem{}, err
+	}
+	return protocol.TypeHierarchyItem{
+		Name:           obj.Name(),
+		Kind:           typeToKind(obj.Type()),
+		Detail:         fmt.Sprintf("%s • %s", obj.Pkg().Path(), filepath.Base(rng.URI().Filename())),
+		URI:            protocol.URIFromSpanURI(rng.URI()),
+		Range:          pr,
+		SelectionRange: pr,
+	}, nil
+}
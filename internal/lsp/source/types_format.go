@@ -249,7 +249,7 @@ func varType(ctx context.Context, snapshot Snapshot, pgf *ParsedGoFile, obj *typ
 	if err != nil {
 		return nil, err
 	}
-	field := posToField[obj.Pos()]
+	field := posToField[IdentPos(snapshot.FileSet(), pgf, obj.Pos(), obj.Name())]
 	if field == nil {
 		return nil, fmt.Errorf("no declaration for object %s", obj.Name())
 	}
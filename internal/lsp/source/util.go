@@ -138,9 +138,42 @@ func objToMappedRange(snapshot Snapshot, pkg Package, obj types.Object) (MappedR
 }
 
 func nameToMappedRange(snapshot Snapshot, pkg Package, pos token.Pos, name string) (MappedRange, error) {
+	if pgf, _, err := FindPosInPackage(snapshot, pkg, pos); err == nil {
+		pos = IdentPos(snapshot.FileSet(), pgf, pos, name)
+	}
 	return posToMappedRange(snapshot, pkg, pos, pos+token.Pos(len(name)))
 }
 
+// IdentPos returns the position in pgf of the identifier name declared at
+// pos. The types of dependencies may be imported from export data, whose
+// objects have positions in files of their own that record the lines of
+// their declarations but not their columns. The identifier is then looked
+// up on the same line of pgf, or pos is returned if it is not found.
+func IdentPos(fset *token.FileSet, pgf *ParsedGoFile, pos token.Pos, name string) token.Pos {
+	tok := fset.File(pos)
+	if tok == nil || tok == pgf.Tok {
+		return pos
+	}
+	line := tok.Line(pos)
+	var found token.Pos
+	ast.Inspect(pgf.File, func(n ast.Node) bool {
+		if n == nil || found.IsValid() {
+			return false
+		}
+		if pgf.Tok.Line(n.Pos()) > line || pgf.Tok.Line(n.End()) < line {
+			return false
+		}
+		if id, ok := n.(*ast.Ident); ok && id.Name == name && pgf.Tok.Line(id.Pos()) == line {
+			found = id.Pos()
+		}
+		return true
+	})
+	if !found.IsValid() {
+		return pos
+	}
+	return found
+}
+
 func posToMappedRange(snapshot Snapshot, pkg Package, pos, end token.Pos) (MappedRange, error) {
 	logicalFilename := snapshot.FileSet().File(pos).Position(pos).Filename
 	pgf, _, err := findFileInDeps(pkg, span.URIFromPath(logicalFilename))
@@ -20,6 +20,7 @@ import (
 	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/internal/gocommand"
 	"golang.org/x/tools/internal/imports"
+	"golang.org/x/tools/internal/lintignore"
 	"golang.org/x/tools/internal/lsp/protocol"
 	"golang.org/x/tools/internal/span"
 	errors "golang.org/x/xerrors"
@@ -242,6 +243,28 @@ type View interface {
 
 	// RegisterModuleUpgrades registers that upgrades exist for the given modules.
 	RegisterModuleUpgrades(upgrades map[string]string)
+
+	// Toolchain returns the Go toolchain that runs the go command for the
+	// view.
+	Toolchain() Toolchain
+}
+
+// A Toolchain is a Go toolchain that runs the go command for a view.
+type Toolchain struct {
+	// GoCmd is the path of the go command, or "go" for the go command on
+	// PATH.
+	GoCmd string
+
+	// GOROOT is the GOROOT of the toolchain.
+	GOROOT string
+
+	// Version is the version of the toolchain, such as "go1.16.5".
+	Version string
+
+	// Selection is the setting that selected the toolchain, such as the go
+	// directive of a go.mod file for the toolchainsDir setting, or "" for
+	// the go command on PATH.
+	Selection string
 }
 
 // A FileSource maps uris to FileHandles. This abstraction exists both for
@@ -421,6 +444,18 @@ const (
 	ParseFull
 )
 
+func (m ParseMode) String() string {
+	switch m {
+	case ParseHeader:
+		return "header"
+	case ParseExported:
+		return "exported"
+	case ParseFull:
+		return "full"
+	}
+	return fmt.Sprintf("ParseMode(%d)", int(m))
+}
+
 // TypecheckMode controls what kind of parsing should be done (see ParseMode)
 // while type checking a package.
 type TypecheckMode int
@@ -601,6 +636,7 @@ const (
 	ModTidyError             DiagnosticSource = "go mod tidy"
 	OptimizationDetailsError DiagnosticSource = "optimizer details"
 	UpgradeNotification      DiagnosticSource = "upgrade available"
+	IgnoreDirectiveError     DiagnosticSource = lintignore.Name
 )
 
 func AnalyzerErrorKind(name string) DiagnosticSource {
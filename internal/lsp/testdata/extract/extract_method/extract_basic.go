@@ -0,0 +1,26 @@
+package extract
+
+type A struct {
+	x int
+	y int
+}
+
+func (a *A) XLessThanY() bool {
+	x := a.x //@mark(exMethodSt1, "x")
+	y := a.y //@mark(exMethodEn1, ".y")
+	//@extractmethod(exMethodSt1, exMethodEn1)
+	return x < y
+}
+
+func (a *A) AddP(p int) {
+	a.x += p //@mark(exMethodSt2, "a")
+	a.y += p //@mark(exMethodEn2, "p")
+	//@extractmethod(exMethodSt2, exMethodEn2)
+}
+
+func (a A) Sum() int {
+	v := a.x //@mark(exMethodSt3, "v")
+	v += a.y //@mark(exMethodEn3, ".y")
+	//@extractmethod(exMethodSt3, exMethodEn3)
+	return v
+}
@@ -0,0 +1,40 @@
+package inline
+
+import "fmt"
+
+func add(x, y int) int {
+	return x + y
+}
+
+func square(x int) int {
+	return x * x
+}
+
+func greet(name string) {
+	msg := "hello, " + name
+	fmt.Println(msg)
+}
+
+type counter struct{ n int }
+
+func (c *counter) incr() {
+	c.n++
+}
+
+func (c *counter) value() int {
+	return c.n
+}
+
+func next() int { return 0 }
+
+func _() {
+	a := add(1, 2) * 3  //@suggestedfix("add(", "refactor.inline")
+	b := square(next()) //@suggestedfix("square(", "refactor.inline")
+	greet("gopher")     //@suggestedfix("greet(", "refactor.inline")
+	msg := "shadow"
+	greet(msg)            //@suggestedfix("greet(", "refactor.inline")
+	s := sum([]int{a, b}) //@suggestedfix("sum(", "refactor.inline")
+	var c counter
+	c.incr()                       //@suggestedfix("incr", "refactor.inline")
+	fmt.Println(s, c.value(), msg) //@suggestedfix("value", "refactor.inline")
+}
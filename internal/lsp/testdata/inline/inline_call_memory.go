@@ -0,0 +1,15 @@
+package inline
+
+type box struct{ v int }
+
+func (b *box) bump() { b.v++ }
+
+func bumped(v int, b *box) int {
+	b.bump()
+	return v
+}
+
+func _() {
+	b := &box{}
+	_ = bumped(b.v, b) //@suggestedfix("bumped(", "refactor.inline")
+}
@@ -0,0 +1,36 @@
+package inline
+
+import "fmt"
+
+func deferred() {
+	defer fmt.Println("done")
+}
+
+func fact(n int) int {
+	if n == 0 {
+		return 1
+	}
+	return n * fact(n-1)
+}
+
+func sign(n int) int {
+	if n < 0 {
+		return -1
+	}
+	return 1
+}
+
+func _() {
+	deferred()   //@suggestedfixerr("deferred(", "refactor.inline", "cannot inline deferred: it contains a defer statement")
+	_ = fact(3)  //@suggestedfixerr("fact(", "refactor.inline", "cannot inline fact: it has multiple return statements")
+	_ = sign(-2) //@suggestedfixerr("sign(", "refactor.inline", "cannot inline sign: it has multiple return statements")
+}
+
+func countdown(n int) {
+	fmt.Println(n)
+	countdown(n - 1)
+}
+
+func _() {
+	countdown(3) //@suggestedfixerr("countdown(", "refactor.inline", "cannot inline countdown: it is recursive")
+}
@@ -0,0 +1,8 @@
+package inline
+
+func _() {
+	x := 1 + 2
+	y := x * 2 //@suggestedfix("x", "refactor.inline")
+	var s string = "a"
+	println(s+"b", y) //@suggestedfix("s", "refactor.inline")
+}
@@ -0,0 +1,19 @@
+package inline
+
+type point struct{ x, y int }
+
+func move(p *point) { p.x++ }
+
+func _() {
+	p := &point{1, 2}
+	move(p) //@suggestedfixerr("p", "refactor.inline", "cannot inline p: its value would be evaluated 2 times")
+	move(p)
+	s := make([]int, 3)
+	_ = s[0] + len(s) //@suggestedfixerr("s", "refactor.inline", "cannot inline s: its value would be evaluated 2 times")
+	q := &point{3, 4}
+	x := q.x
+	move(q)
+	println(x) //@suggestedfixerr("x", "refactor.inline", "cannot inline x: a statement before its use may change its value")
+	t := &point{5, 6}
+	move(t) //@suggestedfix("t", "refactor.inline")
+}
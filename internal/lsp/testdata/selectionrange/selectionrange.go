@@ -0,0 +1,20 @@
+package selectionrange
+
+import "fmt"
+
+type T struct {
+	name string
+}
+
+func (t *T) Greet(greeting string) {
+	if t != nil {
+		fmt.Println(greeting, t.name) //@selectionrange("Println"),selectionrange("name")
+	}
+}
+
+func sum(values ...int) (total int) {
+	for _, v := range values { //@selectionrange("values")
+		total += v * 2 //@selectionrange("2")
+	}
+	return total
+}
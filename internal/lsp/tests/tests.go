@@ -60,8 +60,11 @@ type FoldingRanges []span.Span
 type Formats []span.Span
 type Imports []span.Span
 type SemanticTokens []span.Span
+type SelectionRanges map[span.URI][]span.Span
 type SuggestedFixes map[span.Span][]string
+type SuggestedFixErrors map[span.Span]SuggestedFixError
 type FunctionExtractions map[span.Span]span.Span
+type MethodExtractions map[span.Span]span.Span
 type Definitions map[span.Span]Definition
 type Implementations map[span.Span][]span.Span
 type Highlights map[span.Span][]span.Span
@@ -93,8 +96,11 @@ type Data struct {
 	Formats                  Formats
 	Imports                  Imports
 	SemanticTokens           SemanticTokens
+	SelectionRanges          SelectionRanges
 	SuggestedFixes           SuggestedFixes
+	SuggestedFixErrors       SuggestedFixErrors
 	FunctionExtractions      FunctionExtractions
+	MethodExtractions        MethodExtractions
 	Definitions              Definitions
 	Implementations          Implementations
 	Highlights               Highlights
@@ -135,8 +141,11 @@ type Tests interface {
 	Format(*testing.T, span.Span)
 	Import(*testing.T, span.Span)
 	SemanticTokens(*testing.T, span.Span)
+	SelectionRanges(*testing.T, span.URI, []span.Span)
 	SuggestedFix(*testing.T, span.Span, []string, int)
+	SuggestedFixError(*testing.T, span.Span, SuggestedFixError)
 	FunctionExtraction(*testing.T, span.Span, span.Span)
+	MethodExtraction(*testing.T, span.Span, span.Span)
 	Definition(*testing.T, span.Span, Definition)
 	Implementation(*testing.T, span.Span, []span.Span)
 	Highlight(*testing.T, span.Span, []span.Span)
@@ -156,6 +165,13 @@ type Definition struct {
 	Src, Def  span.Span
 }
 
+// A SuggestedFixError is a code action, of the given kind, that fails to
+// compute its fix with an error containing Message.
+type SuggestedFixError struct {
+	ActionKind string
+	Message    string
+}
+
 type CompletionTestType int
 
 const (
@@ -228,6 +244,7 @@ func DefaultOptions(o *source.Options) {
 			protocol.QuickFix:              true,
 			protocol.RefactorRewrite:       true,
 			protocol.RefactorExtract:       true,
+			protocol.RefactorInline:        true,
 			protocol.SourceFixAll:          true,
 		},
 		source.Mod: {
@@ -284,8 +301,11 @@ func load(t testing.TB, mode string, dir string) *Data {
 		References:               make(References),
 		Renames:                  make(Renames),
 		PrepareRenames:           make(PrepareRenames),
+		SelectionRanges:          make(SelectionRanges),
 		SuggestedFixes:           make(SuggestedFixes),
+		SuggestedFixErrors:       make(SuggestedFixErrors),
 		FunctionExtractions:      make(FunctionExtractions),
+		MethodExtractions:        make(MethodExtractions),
 		Symbols:                  make(Symbols),
 		symbolsChildren:          make(SymbolsChildren),
 		symbolInformation:        make(SymbolInformation),
@@ -431,6 +451,7 @@ func load(t testing.TB, mode string, dir string) *Data {
 		"format":          datum.collectFormats,
 		"import":          datum.collectImports,
 		"semantic":        datum.collectSemanticTokens,
+		"selectionrange":  datum.collectSelectionRanges,
 		"godef":           datum.collectDefinitions,
 		"implementations": datum.collectImplementations,
 		"typdef":          datum.collectTypeDefinitions,
@@ -443,7 +464,9 @@ func load(t testing.TB, mode string, dir string) *Data {
 		"signature":       datum.collectSignatures,
 		"link":            datum.collectLinks,
 		"suggestedfix":    datum.collectSuggestedFixes,
+		"suggestedfixerr": datum.collectSuggestedFixErrors,
 		"extractfunc":     datum.collectFunctionExtractions,
+		"extractmethod":   datum.collectMethodExtractions,
 		"incomingcalls":   datum.collectIncomingCalls,
 		"outgoingcalls":   datum.collectOutgoingCalls,
 	}); err != nil {
@@ -625,6 +648,16 @@ func Run(t *testing.T, tests Tests, data *Data) {
 		}
 	})
 
+	t.Run("SelectionRange", func(t *testing.T) {
+		t.Helper()
+		for uri, spans := range data.SelectionRanges {
+			t.Run(uriName(uri), func(t *testing.T) {
+				t.Helper()
+				tests.SelectionRanges(t, uri, spans)
+			})
+		}
+	})
+
 	t.Run("SuggestedFix", func(t *testing.T) {
 		t.Helper()
 		for spn, actionKinds := range data.SuggestedFixes {
@@ -639,6 +672,20 @@ func Run(t *testing.T, tests Tests, data *Data) {
 		}
 	})
 
+	t.Run("SuggestedFixError", func(t *testing.T) {
+		t.Helper()
+		for spn, fixErr := range data.SuggestedFixErrors {
+			// Check if we should skip this spn if the -modfile flag is not available.
+			if shouldSkip(data, spn.URI()) {
+				continue
+			}
+			t.Run(SpanName(spn), func(t *testing.T) {
+				t.Helper()
+				tests.SuggestedFixError(t, spn, fixErr)
+			})
+		}
+	})
+
 	t.Run("FunctionExtraction", func(t *testing.T) {
 		t.Helper()
 		for start, end := range data.FunctionExtractions {
@@ -653,6 +700,20 @@ func Run(t *testing.T, tests Tests, data *Data) {
 		}
 	})
 
+	t.Run("MethodExtraction", func(t *testing.T) {
+		t.Helper()
+		for start, end := range data.MethodExtractions {
+			// Check if we should skip this spn if the -modfile flag is not available.
+			if shouldSkip(data, start.URI()) {
+				continue
+			}
+			t.Run(SpanName(start), func(t *testing.T) {
+				t.Helper()
+				tests.MethodExtraction(t, start, end)
+			})
+		}
+	})
+
 	t.Run("Definition", func(t *testing.T) {
 		t.Helper()
 		for spn, d := range data.Definitions {
@@ -839,6 +900,11 @@ func checkData(t *testing.T, data *Data) {
 		return count
 	}
 
+	selectionRangesCount := 0
+	for _, spans := range data.SelectionRanges {
+		selectionRangesCount += len(spans)
+	}
+
 	countWorkspaceSymbols := func(c map[WorkspaceSymbolsTestType]map[span.URI][]string) (count int) {
 		for _, typs := range c {
 			for _, queries := range typs {
@@ -862,8 +928,11 @@ func checkData(t *testing.T, data *Data) {
 	fmt.Fprintf(buf, "FormatCount = %v\n", len(data.Formats))
 	fmt.Fprintf(buf, "ImportCount = %v\n", len(data.Imports))
 	fmt.Fprintf(buf, "SemanticTokenCount = %v\n", len(data.SemanticTokens))
+	fmt.Fprintf(buf, "SelectionRangesCount = %v\n", selectionRangesCount)
 	fmt.Fprintf(buf, "SuggestedFixCount = %v\n", len(data.SuggestedFixes))
+	fmt.Fprintf(buf, "SuggestedFixErrorCount = %v\n", len(data.SuggestedFixErrors))
 	fmt.Fprintf(buf, "FunctionExtractionCount = %v\n", len(data.FunctionExtractions))
+	fmt.Fprintf(buf, "MethodExtractionCount = %v\n", len(data.MethodExtractions))
 	fmt.Fprintf(buf, "DefinitionsCount = %v\n", definitionCount)
 	fmt.Fprintf(buf, "TypeDefinitionsCount = %v\n", typeDefinitionCount)
 	fmt.Fprintf(buf, "HighlightsCount = %v\n", len(data.Highlights))
@@ -1080,6 +1149,10 @@ func (data *Data) collectSemanticTokens(spn span.Span) {
 	data.SemanticTokens = append(data.SemanticTokens, spn)
 }
 
+func (data *Data) collectSelectionRanges(spn span.Span) {
+	data.SelectionRanges[spn.URI()] = append(data.SelectionRanges[spn.URI()], spn)
+}
+
 func (data *Data) collectSuggestedFixes(spn span.Span, actionKind string) {
 	if _, ok := data.SuggestedFixes[spn]; !ok {
 		data.SuggestedFixes[spn] = []string{}
@@ -1087,12 +1160,25 @@ func (data *Data) collectSuggestedFixes(spn span.Span, actionKind string) {
 	data.SuggestedFixes[spn] = append(data.SuggestedFixes[spn], actionKind)
 }
 
+func (data *Data) collectSuggestedFixErrors(spn span.Span, actionKind, message string) {
+	data.SuggestedFixErrors[spn] = SuggestedFixError{
+		ActionKind: actionKind,
+		Message:    message,
+	}
+}
+
 func (data *Data) collectFunctionExtractions(start span.Span, end span.Span) {
 	if _, ok := data.FunctionExtractions[start]; !ok {
 		data.FunctionExtractions[start] = end
 	}
 }
 
+func (data *Data) collectMethodExtractions(start span.Span, end span.Span) {
+	if _, ok := data.MethodExtractions[start]; !ok {
+		data.MethodExtractions[start] = end
+	}
+}
+
 func (data *Data) collectDefinitions(src, target span.Span) {
 	data.Definitions[src] = Definition{
 		Src: src,
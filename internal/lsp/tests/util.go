@@ -527,6 +527,25 @@ func WorkspaceSymbolsString(ctx context.Context, data *Data, queryURI span.URI,
 	return strings.Join(filtered, "\n") + "\n", nil
 }
 
+// SelectionRangesString formats the selection range chains in ranges, one
+// range per line, innermost first. Chains are separated by a blank line.
+func SelectionRangesString(m *protocol.ColumnMapper, ranges []protocol.SelectionRange) (string, error) {
+	var buf bytes.Buffer
+	for i, sr := range ranges {
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+		for cur := &sr; cur != nil; cur = cur.Parent {
+			spn, err := m.RangeSpan(cur.Range)
+			if err != nil {
+				return "", err
+			}
+			fmt.Fprintf(&buf, "%v:%v-%v:%v\n", spn.Start().Line(), spn.Start().Column(), spn.End().Line(), spn.End().Column())
+		}
+	}
+	return buf.String(), nil
+}
+
 func WorkspaceSymbolsTestTypeToMatcher(typ WorkspaceSymbolsTestType) source.SymbolMatcher {
 	switch typ {
 	case WorkspaceSymbolsFuzzy:
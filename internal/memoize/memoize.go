@@ -18,6 +18,8 @@ import (
 	"sync"
 	"sync/atomic"
 
+	"golang.org/x/tools/internal/event"
+	"golang.org/x/tools/internal/event/keys"
 	"golang.org/x/tools/internal/xcontext"
 )
 
@@ -28,6 +30,14 @@ var (
 			"used with GOTRACEBACK=crash to see all running goroutines.")
 )
 
+// The metrics recorded by RecordHits, from which the hit ratio of stores can
+// be computed. Each is labeled with the type of the key of the handles.
+var (
+	KeyHit  = keys.NewInt64("memoize_hit", "Count of gets of values that were computed or being computed.")
+	KeyMiss = keys.NewInt64("memoize_miss", "Count of gets of values that had to be computed.")
+	KeyType = keys.NewString("memoize_key_type", "The type of the key of a memoized value.")
+)
+
 // Store binds keys to functions, returning handles that can be used to access
 // the functions results.
 type Store struct {
@@ -37,6 +47,15 @@ type Store struct {
 
 	// generations is the set of generations live in this store.
 	generations map[*Generation]struct{}
+
+	// hits holds the counts of gets of the handles of each key type.
+	hits map[reflect.Type]*hitCounts
+}
+
+// hitCounts are the counts of gets of handles, which Get increments
+// atomically, and RecordHits resets.
+type hitCounts struct {
+	hits, misses int64
 }
 
 // Generation creates a new Generation associated with s. Destroy must be
@@ -48,6 +67,7 @@ func (s *Store) Generation(name string) *Generation {
 	if s.handles == nil {
 		s.handles = map[interface{}]*Handle{}
 		s.generations = map[*Generation]struct{}{}
+		s.hits = map[reflect.Type]*hitCounts{}
 	}
 	g := &Generation{store: s, name: name}
 	s.generations[g] = struct{}{}
@@ -138,6 +158,8 @@ const (
 type Handle struct {
 	key interface{}
 	mu  sync.Mutex
+	// counts are the counts of gets of the handles of the type of key.
+	counts *hitCounts
 
 	// generations is the set of generations in which this handle is valid.
 	generations map[*Generation]struct{}
@@ -181,8 +203,15 @@ func (g *Generation) Bind(key interface{}, function Function, cleanup func(inter
 	defer g.store.mu.Unlock()
 	h, ok := g.store.handles[key]
 	if !ok {
+		t := reflect.TypeOf(key)
+		counts := g.store.hits[t]
+		if counts == nil {
+			counts = &hitCounts{}
+			g.store.hits[t] = counts
+		}
 		h := &Handle{
 			key:         key,
+			counts:      counts,
 			function:    function,
 			generations: map[*Generation]struct{}{g: {}},
 			cleanup:     cleanup,
@@ -210,6 +239,24 @@ func (s *Store) Stats() map[reflect.Type]int {
 	return result
 }
 
+// RecordHits records the metrics of the gets of the handles of s since the
+// last call, for each key type. Get only counts them, so that it does not
+// pay for an event per call.
+func (s *Store) RecordHits(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for t, counts := range s.hits {
+		hits := atomic.SwapInt64(&counts.hits, 0)
+		misses := atomic.SwapInt64(&counts.misses, 0)
+		if hits > 0 {
+			event.Metric(ctx, KeyHit.Of(hits), KeyType.Of(t.String()))
+		}
+		if misses > 0 {
+			event.Metric(ctx, KeyMiss.Of(misses), KeyType.Of(t.String()))
+		}
+	}
+}
+
 // DebugOnlyIterate iterates through all live cache entries and calls f on them.
 // It should only be used for debugging purposes.
 func (s *Store) DebugOnlyIterate(f func(k, v interface{})) {
@@ -284,11 +331,14 @@ func (h *Handle) Get(ctx context.Context, g *Generation, arg Arg) (interface{},
 	}
 	switch h.state {
 	case stateIdle:
+		atomic.AddInt64(&h.counts.misses, 1)
 		return h.run(ctx, g, arg)
 	case stateRunning:
+		atomic.AddInt64(&h.counts.hits, 1)
 		return h.wait(ctx)
 	case stateCompleted:
 		defer h.mu.Unlock()
+		atomic.AddInt64(&h.counts.hits, 1)
 		return h.value, nil
 	case stateDestroyed:
 		h.mu.Unlock()
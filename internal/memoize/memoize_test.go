@@ -9,6 +9,9 @@ import (
 	"strings"
 	"testing"
 
+	"golang.org/x/tools/internal/event"
+	"golang.org/x/tools/internal/event/core"
+	"golang.org/x/tools/internal/event/label"
 	"golang.org/x/tools/internal/memoize"
 )
 
@@ -105,3 +108,37 @@ func TestCleanup(t *testing.T) {
 		t.Error("after destroying g2, v2 is not cleaned up")
 	}
 }
+
+func TestRecordHits(t *testing.T) {
+	type counts struct{ hits, misses int64 }
+	got := make(map[string]counts)
+	event.SetExporter(func(ctx context.Context, ev core.Event, lm label.Map) context.Context {
+		if event.IsMetric(ev) {
+			c := got[memoize.KeyType.Get(lm)]
+			c.hits += memoize.KeyHit.Get(lm)
+			c.misses += memoize.KeyMiss.Get(lm)
+			got[memoize.KeyType.Get(lm)] = c
+		}
+		return ctx
+	})
+	defer event.SetExporter(nil)
+
+	s := &memoize.Store{}
+	g := s.Generation("g")
+	defer g.Destroy()
+	h := g.Bind("key", func(context.Context, memoize.Arg) interface{} { return "res" }, nil)
+	expectGet(t, h, g, "res")
+	expectGet(t, h, g, "res")
+	expectGet(t, h, g, "res")
+
+	ctx := context.Background()
+	s.RecordHits(ctx)
+	if want := (counts{hits: 2, misses: 1}); got["string"] != want {
+		t.Errorf("recorded %+v, want %+v", got["string"], want)
+	}
+	// The counts were reset.
+	s.RecordHits(ctx)
+	if want := (counts{hits: 2, misses: 1}); got["string"] != want {
+		t.Errorf("recorded %+v after a second call, want %+v", got["string"], want)
+	}
+}
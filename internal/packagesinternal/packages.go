@@ -26,3 +26,4 @@ var TypecheckCgo int
 
 var SetModFlag = func(config interface{}, value string) {}
 var SetModFile = func(config interface{}, value string) {}
+var SetGoCmd = func(config interface{}, value string) {}
@@ -15,6 +15,7 @@ import (
 	"runtime"
 	"runtime/pprof"
 	"runtime/trace"
+	"strings"
 	"time"
 )
 
@@ -29,7 +30,8 @@ import (
 //     }
 // It recursively scans the application object for fields with a tag containing
 //     `flag:"flagname" help:"short help text"``
-// uses all those fields to build command line flags.
+// uses all those fields to build command line flags. The flag name may be a
+// comma-separated list of names for the same flag, such as "d,diff".
 // It expects the Application type to have a method
 //     Run(context.Context, args...string) error
 // which it invokes only after all command line flag processing has been finished.
@@ -184,27 +186,29 @@ func addFlags(f *flag.FlagSet, field reflect.StructField, value reflect.Value) *
 		}
 		return p
 	}
-	switch v := value.Interface().(type) {
-	case flag.Value:
-		f.Var(v, flagName, help)
-	case *bool:
-		f.BoolVar(v, flagName, *v, help)
-	case *time.Duration:
-		f.DurationVar(v, flagName, *v, help)
-	case *float64:
-		f.Float64Var(v, flagName, *v, help)
-	case *int64:
-		f.Int64Var(v, flagName, *v, help)
-	case *int:
-		f.IntVar(v, flagName, *v, help)
-	case *string:
-		f.StringVar(v, flagName, *v, help)
-	case *uint:
-		f.UintVar(v, flagName, *v, help)
-	case *uint64:
-		f.Uint64Var(v, flagName, *v, help)
-	default:
-		log.Fatalf("Cannot understand flag of type %T", v)
+	for _, name := range strings.Split(flagName, ",") {
+		switch v := value.Interface().(type) {
+		case flag.Value:
+			f.Var(v, name, help)
+		case *bool:
+			f.BoolVar(v, name, *v, help)
+		case *time.Duration:
+			f.DurationVar(v, name, *v, help)
+		case *float64:
+			f.Float64Var(v, name, *v, help)
+		case *int64:
+			f.Int64Var(v, name, *v, help)
+		case *int:
+			f.IntVar(v, name, *v, help)
+		case *string:
+			f.StringVar(v, name, *v, help)
+		case *uint:
+			f.UintVar(v, name, *v, help)
+		case *uint64:
+			f.Uint64Var(v, name, *v, help)
+		default:
+			log.Fatalf("Cannot understand flag of type %T", v)
+		}
 	}
 	return nil
 }